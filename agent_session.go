@@ -11,12 +11,24 @@ type AgentSession = sessions.AgentSession
 type HTTPSession = sessions.HTTPSession
 type WebSocketWriter = sessions.WebSocketWriter
 type WebSocketToolResultMessage = sessions.WebSocketToolResultMessage
+type WebSocketFeedbackMessage = sessions.WebSocketFeedbackMessage
+type WebSocketMemoryMessage = sessions.WebSocketMemoryMessage
+type WebSocketResumeMessage = sessions.WebSocketResumeMessage
+type WebSocketHelloMessage = sessions.WebSocketHelloMessage
+type WebSocketChatRequest = sessions.WebSocketChatRequest
+type SessionHub = sessions.SessionHub
+type Envelope = sessions.Envelope
 type AgentError = sessions.AgentError
 type SSEWriter = sessions.SSEWriter
 type ResponseWaiter = sessions.ResponseWaiter
 type AgentInterface = sessions.AgentInterface
 type ToolExecutorFunc = sessions.ToolExecutorFunc
 type MemoryManager = sessions.MemoryManager
+type Interceptor = sessions.Interceptor
+type Interceptors = sessions.Interceptors
+type ModelCall = sessions.ModelCall
+type ToolCall = sessions.ToolCall
+type ToolResult = sessions.ToolResult
 
 // Re-export constructor functions
 func NewAgentSession(sessionID string, userID string, conn *websocket.Conn, agent *Agent, store stores.MessageStore, memory MemoryManager) *AgentSession {
@@ -26,3 +38,7 @@ func NewAgentSession(sessionID string, userID string, conn *websocket.Conn, agen
 func NewHTTPSession(conversationID string, agent *Agent, store stores.MessageStore) *HTTPSession {
 	return sessions.NewHTTPSession(conversationID, agent, store)
 }
+
+func NewSessionHub() *SessionHub {
+	return sessions.NewSessionHub()
+}
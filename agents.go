@@ -1,6 +1,7 @@
 package godantic
 
 import (
+	"context"
 	"embed"
 	"encoding/json"
 	"errors"
@@ -93,6 +94,8 @@ type Agent struct {
 	Model  Model
 	Tools  []models.FunctionDeclaration
 	Memory MemoryManager
+
+	interceptors Interceptors
 }
 
 // Create_Agent creates an agent with the given model and tools
@@ -120,6 +123,8 @@ func Create_Agent_From_Config(config *WSConfig, tools []models.FunctionDeclarati
 			MaxTokens:   config.MaxTokens,
 			SiteURL:     config.SiteURL,
 			SiteName:    config.SiteName,
+			Logger:      config.Logger,
+			Client:      config.Client,
 		}
 	case ProviderGroq:
 		model = &groq.Groq_Model{
@@ -127,6 +132,8 @@ func Create_Agent_From_Config(config *WSConfig, tools []models.FunctionDeclarati
 			Temperature:  config.Temperature,
 			MaxTokens:    config.MaxTokens,
 			SystemPrompt: config.SystemPrompt,
+			Logger:       config.Logger,
+			Client:       config.Client,
 		}
 	case ProviderCerebras:
 		model = &cerebras.Cerebras_Model{
@@ -134,31 +141,49 @@ func Create_Agent_From_Config(config *WSConfig, tools []models.FunctionDeclarati
 			Temperature:  config.Temperature,
 			MaxTokens:    config.MaxTokens,
 			SystemPrompt: config.SystemPrompt,
+			Logger:       config.Logger,
+			Client:       config.Client,
 		}
 	case ProviderAnthropic:
-		model = &anthropicModel.Anthropic_Model{
+		anthropic := &anthropicModel.Anthropic_Model{
 			Model:        config.ModelName,
 			Temperature:  config.Temperature,
 			MaxTokens:    config.MaxTokens,
 			SystemPrompt: config.SystemPrompt,
+			Logger:       config.Logger,
+			Client:       config.Client,
+		}
+		if config.ThinkingBudget != nil {
+			anthropic.ThinkingBudget = *config.ThinkingBudget
 		}
+		model = anthropic
 	case ProviderGemini:
 		fallthrough
 	default:
-		model = &gemini.Gemini_Model{
-			Model: config.ModelName,
+		geminiModel := &gemini.Gemini_Model{
+			Model:  config.ModelName,
+			Logger: config.Logger,
+			Client: config.Client,
 		}
+		if config.ThinkingBudget != nil {
+			budget := *config.ThinkingBudget
+			geminiModel.ThinkingBudget = &budget
+			geminiModel.IncludeThoughts = budget != 0
+		}
+		model = geminiModel
 	}
 
 	var mem MemoryManager
 	if len(memory) > 0 {
 		mem = memory[0]
 	}
-	return Agent{
+	agent := Agent{
 		Model:  model,
 		Tools:  tools,
 		Memory: mem,
 	}
+	agent.Use(config.Interceptors...)
+	return agent
 }
 
 // NewAnthropicModel creates a new Anthropic model instance
@@ -336,26 +361,69 @@ func Create_Tools(fns []interface{}) ([]models.FunctionDeclaration, error) {
 	return tools, nil
 }
 
+// Use adds interceptors around the agent's model calls and tool executions (see Interceptor)
+func (agent *Agent) Use(interceptors ...Interceptor) {
+	agent.interceptors = append(agent.interceptors, interceptors...)
+}
+
+// Interceptors returns the interceptors added with Use
+func (agent *Agent) Interceptors() Interceptors {
+	return agent.interceptors
+}
+
 func (agent *Agent) Run(request models.Model_Request, conversationHistory []stores.Message) (models.Model_Response, error) {
-	return agent.Model.Model_Request(request, agent.Tools, conversationHistory)
+	call := &ModelCall{Request: request, Tools: agent.Tools, History: conversationHistory}
+	return agent.interceptors.RunModel(call, func(call *ModelCall) (models.Model_Response, error) {
+		return agent.Model.Model_Request(call.Request, call.Tools, call.History)
+	})
 }
 
 func (agent *Agent) Run_Stream(request models.Model_Request, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
-	return agent.Model.Stream_Model_Request(request, agent.Tools, conversationHistory)
+	return agent.RunStreamContext(context.Background(), request, conversationHistory)
+}
+
+// RunStreamContext is Run_Stream for a consumer that may stop reading; the stream is
+// abandoned when ctx is done
+func (agent *Agent) RunStreamContext(ctx context.Context, request models.Model_Request, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
+	call := &ModelCall{Request: request, Tools: agent.Tools, History: conversationHistory}
+	return agent.interceptors.StreamModel(ctx, call, func(call *ModelCall) (<-chan models.Model_Response, <-chan error) {
+		return agent.Model.Stream_Model_Request(call.Request, call.Tools, call.History)
+	})
 }
 
-// ExecuteTool executes a tool dynamically by name and arguments
+// ExecuteTool executes a tool dynamically by name and arguments, through the agent's interceptors.
+// The output is wrapped as {"result": ...}, or {"error": ...} when the tool fails.
 func (agent *Agent) ExecuteTool(functionName string, functionCallArgs map[string]interface{}, sessionID string) (string, error) {
-	var toolResultJSON string
+	// Trim whitespace from function name (some models output with leading/trailing spaces)
+	call := &ToolCall{Name: strings.TrimSpace(functionName), Args: functionCallArgs, SessionID: sessionID}
+	output, err := agent.interceptors.RunTool(call, func(call *ToolCall) (string, error) {
+		return agent.callTool(call.Name, call.Args)
+	})
+
+	if err != nil {
+		errorBytes, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return string(errorBytes), err
+	}
+	resultBytes, _ := json.Marshal(map[string]string{"result": output})
+	return string(resultBytes), nil
+}
+
+// callTool finds a tool by name and calls it with the model's arguments, returning its own output
+func (agent *Agent) callTool(functionName string, functionCallArgs map[string]interface{}) (string, error) {
+	var toolOutput string
 	var toolExecErr error
 	toolFound := false
 
-	// Trim whitespace from function name (some models output with leading/trailing spaces)
-	functionName = strings.TrimSpace(functionName)
-
 	for _, tool := range agent.Tools {
 		if tool.Name == functionName {
 			toolFound = true
+
+			// Callables taking the raw arguments skip the reflection-based mapping below
+			if callable, ok := tool.Callable.(models.ArgsCallable); ok {
+				toolOutput, toolExecErr = callable(functionCallArgs)
+				break
+			}
+
 			callableFunc := reflect.ValueOf(tool.Callable)
 
 			// Basic Validation
@@ -460,14 +528,7 @@ func (agent *Agent) ExecuteTool(functionName string, functionCallArgs map[string
 			} else {
 				// Success: Extract the string result
 				if successResultString, ok := results[0].Interface().(string); ok {
-					// Wrap the string result in a standard JSON object for the FunctionResponse part
-					resultMap := map[string]string{"result": successResultString}
-					resultBytes, marshalErr := json.Marshal(resultMap)
-					if marshalErr != nil {
-						toolExecErr = fmt.Errorf("failed marshal result for '%s': %v", functionName, marshalErr)
-					} else {
-						toolResultJSON = string(resultBytes) // Store the JSON string of the result map
-					}
+					toolOutput = successResultString
 				} else {
 					toolExecErr = fmt.Errorf("internal error: tool '%s' returned non-string result", functionName)
 				}
@@ -480,14 +541,7 @@ func (agent *Agent) ExecuteTool(functionName string, functionCallArgs map[string
 		toolExecErr = fmt.Errorf("unknown or unavailable tool: %s", functionName)
 	}
 
-	// If execution resulted in an error (any stage), ensure toolResultJSON reflects it
-	if toolExecErr != nil {
-		errorMap := map[string]string{"error": toolExecErr.Error()}
-		errorBytes, _ := json.Marshal(errorMap) // Marshal the error map
-		toolResultJSON = string(errorBytes)     // This becomes the result
-	}
-
-	return toolResultJSON, toolExecErr // Return the JSON string and the Go error
+	return toolOutput, toolExecErr
 }
 
 // ApproveTool checks if a tool should be auto-approved
@@ -510,3 +564,24 @@ func (agent *Agent) SetHistoryWarningCallback(callback func(warnings []models.Hi
 	}
 	return false
 }
+
+// ModelInfo reports the provider and model name of the agent's model.
+// Used by sessions to record per-message metadata; unknown model types return empty strings.
+func (agent *Agent) ModelInfo() (provider string, model string) {
+	switch m := agent.Model.(type) {
+	case *gemini.Gemini_Model:
+		if m.Model == "" {
+			return string(ProviderGemini), "gemini-2.0-flash"
+		}
+		return string(ProviderGemini), m.Model
+	case *openrouter.OpenRouter_Model:
+		return string(ProviderOpenRouter), m.Model
+	case *groq.Groq_Model:
+		return string(ProviderGroq), m.Model
+	case *cerebras.Cerebras_Model:
+		return string(ProviderCerebras), m.Model
+	case *anthropicModel.Anthropic_Model:
+		return string(ProviderAnthropic), m.Model
+	}
+	return "", ""
+}
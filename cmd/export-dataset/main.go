@@ -0,0 +1,139 @@
+// Command export-dataset writes stored conversations as a fine-tuning dataset.
+//
+//	go run ./cmd/export-dataset -sqlite chat.sqlite -format openai -min-rating 1 -redact-pii -out train.jsonl
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Desarso/godantic/datasets"
+	"github.com/Desarso/godantic/stores"
+)
+
+func main() {
+	sqlitePath := flag.String("sqlite", "", "SQLite database path")
+	postgresDSN := flag.String("postgres", "", "PostgreSQL connection string")
+	tenant := flag.String("tenant", "", "Tenant to export (default tenant when empty)")
+	formatName := flag.String("format", "openai", "Output format: openai, anthropic or sharegpt")
+	outPath := flag.String("out", "", "Output file (stdout when empty)")
+	user := flag.String("user", "", "Only conversations owned by this user")
+	since := flag.String("since", "", "Only conversations active on or after this date (YYYY-MM-DD)")
+	until := flag.String("until", "", "Only conversations active before this date (YYYY-MM-DD)")
+	minRating := flag.String("min-rating", "", "Minimum conversation rating (lowest feedback rating)")
+	maxRating := flag.String("max-rating", "", "Maximum conversation rating (lowest feedback rating)")
+	toolUsage := flag.String("tool-usage", "", "with_tools or without_tools")
+	tools := flag.String("tools", "", "Comma-separated tool names; only conversations calling one of them")
+	modelNames := flag.String("models", "", "Comma-separated model names; only conversations generated by them")
+	limit := flag.Int("limit", 0, "Maximum number of conversations")
+	systemPrompt := flag.String("system", "", "System prompt added to every example")
+	redactPII := flag.Bool("redact-pii", false, "Redact emails, phone numbers, card numbers, SSNs and IP addresses")
+	redactTerms := flag.String("redact-terms", "", "Comma-separated literal terms to redact")
+	flag.Parse()
+
+	format, err := datasets.ParseFormat(*formatName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	filter := datasets.Filter{
+		UserID:    *user,
+		ToolUsage: datasets.ToolUsage(*toolUsage),
+		Tools:     splitList(*tools),
+		Models:    splitList(*modelNames),
+		Limit:     *limit,
+	}
+	if filter.Since, err = parseDate(*since); err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+	if filter.Until, err = parseDate(*until); err != nil {
+		log.Fatalf("Invalid -until: %v", err)
+	}
+	if filter.MinRating, err = parseRating(*minRating); err != nil {
+		log.Fatalf("Invalid -min-rating: %v", err)
+	}
+	if filter.MaxRating, err = parseRating(*maxRating); err != nil {
+		log.Fatalf("Invalid -max-rating: %v", err)
+	}
+
+	store, err := openStore(*sqlitePath, *postgresDSN)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+	scoped, err := stores.ScopeStoreToTenant(store, *tenant)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exporter := datasets.NewExporter(scoped).
+		WithSystemPrompt(*systemPrompt).
+		WithLogger(log.Printf)
+	if *redactPII || *redactTerms != "" {
+		redactor := datasets.NewRedactor()
+		if *redactPII {
+			redactor = datasets.NewPIIRedactor()
+		}
+		exporter.WithRedactor(redactor.WithTerms("[REDACTED]", splitList(*redactTerms)...))
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	stats, err := exporter.Export(out, format, filter)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	log.Printf("Exported %d of %d conversations (%d turns dropped, skipped: %v)", stats.Exported, stats.Scanned, stats.DroppedTurns, stats.Skipped)
+}
+
+func openStore(sqlitePath, postgresDSN string) (stores.MessageStore, error) {
+	switch {
+	case sqlitePath != "":
+		return stores.NewSQLiteStoreSimple(sqlitePath)
+	case postgresDSN != "":
+		return stores.NewPostgresStoreSimple(postgresDSN)
+	}
+	return nil, fmt.Errorf("one of -sqlite or -postgres is required")
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func parseRating(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	rating, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
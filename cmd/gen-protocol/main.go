@@ -0,0 +1,38 @@
+// Command gen-protocol writes TypeScript definitions and a JSON Schema for the WebSocket protocol.
+//
+//	go run ./cmd/gen-protocol -out schemas/protocol
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Desarso/godantic/sessions"
+)
+
+func main() {
+	outDir := flag.String("out", "schemas/protocol", "Output directory")
+	flag.Parse()
+
+	schema, err := sessions.GenerateJSONSchema()
+	if err != nil {
+		log.Fatalf("Failed to generate JSON Schema: %v", err)
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatalf("Failed to create %s: %v", *outDir, err)
+	}
+
+	files := map[string][]byte{
+		"websocket.d.ts":        []byte(sessions.GenerateTypeScript()),
+		"websocket.schema.json": append(schema, '\n'),
+	}
+	for name, data := range files {
+		path := filepath.Join(*outDir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		log.Printf("Wrote %s", path)
+	}
+}
@@ -0,0 +1,185 @@
+// Command mcp-server publishes the built-in common_tools as an MCP server, over stdio
+// (the default) or streamable HTTP.
+//
+// Over HTTP a bare port listens on 127.0.0.1, other hosts need a bearer token (-token or
+// MCP_SERVER_TOKEN), and the default tools can only read.
+//
+//	go run ./cmd/mcp-server -tools files,web,skills
+//	go run ./cmd/mcp-server -http :8090 -tools files-read,workflows -trace-sqlite traces.sqlite
+//	MCP_SERVER_TOKEN=... go run ./cmd/mcp-server -http 0.0.0.0:8090
+//	go run ./cmd/mcp-server -telemetry otel.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/common_tools"
+	"github.com/Desarso/godantic/mcp"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+	"github.com/Desarso/godantic/telemetry"
+)
+
+// Default -tools for each transport. Over HTTP they exclude tools that write or execute.
+const (
+	defaultStdioTools = "files,web,skills,workflows"
+	defaultHTTPTools  = "files-read,web"
+)
+
+// toolGroups are the tool sets selectable with -tools
+var toolGroups = map[string]func() ([]models.FunctionDeclaration, error){
+	"files": func() ([]models.FunctionDeclaration, error) {
+		return []models.FunctionDeclaration{
+			common_tools.ReadFileTool(),
+			common_tools.WriteFileTool(),
+			common_tools.EditFileTool(),
+			common_tools.ListDirectoryTool(),
+		}, nil
+	},
+	"files-read": func() ([]models.FunctionDeclaration, error) {
+		return []models.FunctionDeclaration{common_tools.ReadFileTool(), common_tools.ListDirectoryTool()}, nil
+	},
+	"web": func() ([]models.FunctionDeclaration, error) {
+		return []models.FunctionDeclaration{common_tools.WebSearchTool(), common_tools.WebFetchTool()}, nil
+	},
+	"shell": func() ([]models.FunctionDeclaration, error) {
+		return []models.FunctionDeclaration{common_tools.ShellExecTool()}, nil
+	},
+	"skills": func() ([]models.FunctionDeclaration, error) {
+		return godantic.Create_Tools([]interface{}{
+			common_tools.List_Skill_Files,
+			common_tools.Read_Skill_File,
+			common_tools.Create_Skill_File,
+			common_tools.Edit_Skill_File,
+			common_tools.Delete_Skill_File,
+		})
+	},
+	"workflows": func() ([]models.FunctionDeclaration, error) {
+		return godantic.Create_Tools([]interface{}{
+			common_tools.Create_Workflow,
+			common_tools.Edit_Workflow,
+			common_tools.Patch_Workflow,
+			common_tools.Run_Workflow,
+			common_tools.Stop_Workflow,
+			common_tools.Delete_Workflow,
+			common_tools.List_Workflows,
+			common_tools.Get_Workflow_Status,
+			common_tools.Get_Workflow_Code,
+			common_tools.Get_Workflow_Logs,
+			common_tools.Schedule_Workflow,
+			common_tools.Unschedule_Workflow,
+		})
+	},
+}
+
+func main() {
+	addr := flag.String("http", "", "Serve streamable HTTP on this address instead of stdio; a bare port listens on 127.0.0.1")
+	path := flag.String("path", "/mcp", "HTTP endpoint path")
+	token := flag.String("token", os.Getenv("MCP_SERVER_TOKEN"), "Bearer token HTTP clients must send (default $MCP_SERVER_TOKEN); required off loopback")
+	groups := flag.String("tools", "", "Comma-separated tool groups: files, files-read, web, shell, skills, workflows (default "+defaultStdioTools+" over stdio, "+defaultHTTPTools+" over HTTP)")
+	traceSQLite := flag.String("trace-sqlite", "", "SQLite database to save tool call traces to")
+	telemetryFile := flag.String("telemetry", "", "File to write OpenTelemetry spans and metrics to, as JSON lines")
+	flag.Parse()
+
+	if *telemetryFile != "" {
+		shutdown, err := telemetry.SetupFile("godantic-mcp-server", *telemetryFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer shutdown(context.Background())
+	}
+
+	if *groups == "" {
+		*groups = defaultStdioTools
+		if *addr != "" {
+			*groups = defaultHTTPTools
+		}
+	}
+
+	var tools []models.FunctionDeclaration
+	for _, group := range strings.Split(*groups, ",") {
+		load, ok := toolGroups[strings.TrimSpace(group)]
+		if !ok {
+			log.Fatalf("Unknown tool group %q", group)
+		}
+		groupTools, err := load()
+		if err != nil {
+			log.Fatalf("Failed to load %s tools: %v", group, err)
+		}
+		tools = append(tools, groupTools...)
+	}
+
+	agent := godantic.Create_Agent(nil, tools)
+	server := mcp.NewServer("godantic", "1.0.0", &agent)
+	if *traceSQLite != "" {
+		traceStore, err := openTraceStore(*traceSQLite)
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.WithTraceStore(traceStore)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *addr == "" {
+		// stdout carries the protocol, so logs go to stderr
+		log.SetOutput(os.Stderr)
+		if err := server.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && err != context.Canceled {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	listenAddr, err := listenAddress(*addr, *token)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *token != "" {
+		server.WithAuthenticator(mcp.BearerToken(*token))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, server)
+	httpServer := &http.Server{Addr: listenAddr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+	log.Printf("Serving %d tools on http://%s%s", len(tools), listenAddr, *path)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// listenAddress binds a bare port to loopback and refuses other hosts without a token
+func listenAddress(addr, token string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid -http address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if ip := net.ParseIP(host); token == "" && host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", fmt.Errorf("serving on %s needs a bearer token: set -token or MCP_SERVER_TOKEN", host)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func openTraceStore(path string) (stores.TraceStore, error) {
+	store, err := stores.NewSQLiteStoreSimple(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace database: %w", err)
+	}
+	return stores.NewGORMTraceStore(store.DB())
+}
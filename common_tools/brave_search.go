@@ -57,15 +57,7 @@ func Brave_Search(query string) (string, error) {
 		return "", fmt.Errorf("error unmarshalling Brave Search API response: %w", err)
 	}
 
-	resultString := FormatResultsAsText(result)
-
-	// Save the result to file
-	err = os.WriteFile("brave_search_result.txt", []byte(resultString), 0644)
-	if err != nil {
-		fmt.Printf("Error writing brave search result to file: %v\n", err)
-	}
-
-	return resultString, nil
+	return FormatResultsAsText(result), nil
 }
 
 // stripStrongTags removes specific known HTML tags from strings
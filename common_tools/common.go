@@ -10,6 +10,7 @@
 //   - Edit_Skill_File: Replace a skill markdown file's contents
 //   - Browser_Alert: Trigger an alert in the user's browser with a custom message
 //   - Browser_Prompt: Show a prompt dialog in the user's browser to collect input
+//   - Remember, Recall, Forget: Manage the session user's long-term memory (see MemoryToolDeclarations)
 //
 // Each tool is defined in its own file for better organization and maintainability.
 package common_tools
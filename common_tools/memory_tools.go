@@ -0,0 +1,312 @@
+package common_tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// Memory tool names, as seen by the model
+const (
+	RememberToolName = "Remember"
+	RecallToolName   = "Recall"
+	ForgetToolName   = "Forget"
+)
+
+// MemoryBackend is the memory a MemoryTools instance reads and writes (satisfied by sessions.MemoryManager)
+type MemoryBackend interface {
+	AddMemory(content string, metadata map[string]interface{}) error
+	RetrieveMemories(queryText string, limit int) ([]string, error)
+}
+
+// MemoryHit is a recalled memory with its ID, so the model can forget it
+type MemoryHit struct {
+	ID      string  `json:"id"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score,omitempty"`
+}
+
+// MemorySearcher is implemented by memories that can return IDs and scores with recalled memories
+type MemorySearcher interface {
+	SearchMemories(queryText string, limit int) ([]MemoryHit, error)
+}
+
+// MemoryForgetter is implemented by memories that can delete a memory by ID
+type MemoryForgetter interface {
+	ForgetMemory(id string) error
+}
+
+// MemoryTools binds the Remember, Recall and Forget tools to one user's memory.
+// Sessions create one per tool call; the declarations registered on the agent
+// (MemoryToolDeclarations) are stubs that must be routed here.
+type MemoryTools struct {
+	memory    MemoryBackend
+	userID    string
+	sessionID string
+	tracer    TraceEmitter
+}
+
+// NewMemoryTools creates memory tools for userID backed by memory
+func NewMemoryTools(memory MemoryBackend, userID string) *MemoryTools {
+	return &MemoryTools{memory: memory, userID: userID}
+}
+
+// WithSessionID records sessionID in the metadata of remembered memories
+func (t *MemoryTools) WithSessionID(sessionID string) *MemoryTools {
+	t.sessionID = sessionID
+	return t
+}
+
+// WithTraceEmitter reports memory operations as trace events (tool "memory")
+func (t *MemoryTools) WithTraceEmitter(tracer TraceEmitter) *MemoryTools {
+	t.tracer = tracer
+	return t
+}
+
+// Execute runs the memory tool name with the model's arguments
+func (t *MemoryTools) Execute(name string, args map[string]interface{}) (string, error) {
+	switch name {
+	case RememberToolName:
+		content, _ := args["content"].(string)
+		category, _ := args["category"].(string)
+		return t.Remember(content, category)
+	case RecallToolName:
+		query, _ := args["query"].(string)
+		limit := 0
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		return t.Recall(query, limit)
+	case ForgetToolName:
+		id, _ := args["id"].(string)
+		return t.Forget(id)
+	default:
+		return "", fmt.Errorf("unknown memory tool %q", name)
+	}
+}
+
+// Remember stores content in the user's long-term memory
+func (t *MemoryTools) Remember(content, category string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("content cannot be empty")
+	}
+	if category == "" {
+		category = "fact"
+	}
+
+	var result string
+	err := t.trace("remember", memoryTraceLabel("Remembering: "+content), func() (map[string]interface{}, error) {
+		metadata := map[string]interface{}{
+			"source":    "tool",
+			"kind":      category,
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+		if t.userID != "" {
+			metadata["user_id"] = t.userID
+		}
+		if t.sessionID != "" {
+			metadata["session_id"] = t.sessionID
+		}
+		if err := t.memory.AddMemory(content, metadata); err != nil {
+			return nil, fmt.Errorf("failed to save memory: %w", err)
+		}
+		result = memoryToolJSON(map[string]interface{}{"saved": true, "content": content, "category": category})
+		return map[string]interface{}{"category": category}, nil
+	})
+	return result, err
+}
+
+// Recall searches the user's long-term memory for query
+func (t *MemoryTools) Recall(query string, limit int) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("query cannot be empty")
+	}
+	if limit <= 0 || limit > 20 {
+		limit = 5
+	}
+
+	var result string
+	err := t.trace("recall", memoryTraceLabel(fmt.Sprintf("Recalling: \"%s\"", query)), func() (map[string]interface{}, error) {
+		var hits []MemoryHit
+		if searcher, ok := t.memory.(MemorySearcher); ok {
+			found, err := searcher.SearchMemories(query, limit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search memories: %w", err)
+			}
+			hits = found
+		} else {
+			contents, err := t.memory.RetrieveMemories(query, limit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search memories: %w", err)
+			}
+			for _, content := range contents {
+				hits = append(hits, MemoryHit{Content: content})
+			}
+		}
+		if hits == nil {
+			hits = []MemoryHit{}
+		}
+		result = memoryToolJSON(map[string]interface{}{"query": query, "memories": hits})
+		return map[string]interface{}{"results": len(hits)}, nil
+	})
+	return result, err
+}
+
+// Forget deletes a memory by the ID returned from Recall
+func (t *MemoryTools) Forget(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "", fmt.Errorf("id cannot be empty")
+	}
+	forgetter, ok := t.memory.(MemoryForgetter)
+	if !ok {
+		return "", fmt.Errorf("this memory does not support forgetting")
+	}
+
+	var result string
+	err := t.trace("forget", "Forgetting a memory", func() (map[string]interface{}, error) {
+		if err := forgetter.ForgetMemory(id); err != nil {
+			return nil, fmt.Errorf("failed to forget memory %s: %w", id, err)
+		}
+		result = memoryToolJSON(map[string]interface{}{"forgotten": true, "id": id})
+		return map[string]interface{}{"id": id}, nil
+	})
+	return result, err
+}
+
+// trace runs op between start and end (or error) trace events
+func (t *MemoryTools) trace(operation, label string, op func() (map[string]interface{}, error)) error {
+	if t.tracer == nil {
+		_, err := op()
+		return err
+	}
+
+	start := time.Now()
+	traceID := fmt.Sprintf("memory_%s_%d", operation, start.UnixNano())
+	_ = t.tracer.EmitTrace(TraceEvent{
+		TraceID:   traceID,
+		Tool:      "memory",
+		Operation: operation,
+		Status:    "start",
+		Label:     label,
+		Timestamp: start.UnixMilli(),
+	})
+
+	details, err := op()
+	end := TraceEvent{
+		TraceID:    traceID,
+		Tool:       "memory",
+		Operation:  operation,
+		Status:     "end",
+		Label:      label,
+		Details:    details,
+		Timestamp:  time.Now().UnixMilli(),
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		end.Status = "error"
+		end.Details = map[string]interface{}{"error": err.Error()}
+	}
+	_ = t.tracer.EmitTrace(end)
+	return err
+}
+
+func memoryTraceLabel(label string) string {
+	if len(label) > 60 {
+		return label[:60] + "..."
+	}
+	return label
+}
+
+func memoryToolJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(data)
+}
+
+// IsMemoryTool reports whether name is one of the memory tools
+func IsMemoryTool(name string) bool {
+	return name == RememberToolName || name == RecallToolName || name == ForgetToolName
+}
+
+// MemoryToolDeclarations returns the Remember, Recall and Forget declarations to register on an agent.
+// Their Callables are stubs: AgentSession routes these tools to its own memory and user.
+func MemoryToolDeclarations() []models.FunctionDeclaration {
+	return []models.FunctionDeclaration{
+		{
+			Name:        RememberToolName,
+			Description: "Save a durable fact or preference about the user to long-term memory so it can be recalled in future conversations. Write it as one short sentence.",
+			Parameters: models.Parameters{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The fact to remember, e.g. \"The user prefers metric units\"",
+					},
+					"category": map[string]interface{}{
+						"type":        "string",
+						"description": "Kind of memory. Default: fact",
+						"enum":        []string{"fact", "preference", "project", "instruction"},
+					},
+				},
+				Required: []string{"content"},
+			},
+			Callable: Remember,
+		},
+		{
+			Name:        RecallToolName,
+			Description: "Search the user's long-term memory. Returns the most relevant memories with their ids.",
+			Parameters: models.Parameters{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "What to look for",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum memories to return (1-20). Default: 5",
+					},
+				},
+				Required: []string{"query"},
+			},
+			Callable: Recall,
+		},
+		{
+			Name:        ForgetToolName,
+			Description: "Delete a memory from the user's long-term memory, e.g. when the user asks you to forget something or it is no longer true. Use Recall first to find its id.",
+			Parameters: models.Parameters{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "The id of the memory, as returned by Recall",
+					},
+				},
+				Required: []string{"id"},
+			},
+			Callable: Forget,
+		},
+	}
+}
+
+// Remember, Recall and Forget are stubs for the agent's tool list. The session routes these
+// tools to its MemoryTools before they reach here (like Consult_Model).
+func Remember(content, category string) (string, error) {
+	return "", fmt.Errorf("Remember must be executed through a session with memory")
+}
+
+func Recall(query string, limit int) (string, error) {
+	return "", fmt.Errorf("Recall must be executed through a session with memory")
+}
+
+func Forget(id string) (string, error) {
+	return "", fmt.Errorf("Forget must be executed through a session with memory")
+}
@@ -0,0 +1,121 @@
+package common_tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeMemory is an in-memory MemoryBackend with search and forget support
+type fakeMemory struct {
+	contents map[string]string
+	metadata map[string]map[string]interface{}
+	nextID   int
+}
+
+func newFakeMemory() *fakeMemory {
+	return &fakeMemory{contents: map[string]string{}, metadata: map[string]map[string]interface{}{}}
+}
+
+func (m *fakeMemory) AddMemory(content string, metadata map[string]interface{}) error {
+	m.nextID++
+	id := fmt.Sprintf("mem-%d", m.nextID)
+	m.contents[id] = content
+	m.metadata[id] = metadata
+	return nil
+}
+
+func (m *fakeMemory) RetrieveMemories(query string, limit int) ([]string, error) {
+	hits, _ := m.SearchMemories(query, limit)
+	contents := make([]string, len(hits))
+	for i, hit := range hits {
+		contents[i] = hit.Content
+	}
+	return contents, nil
+}
+
+func (m *fakeMemory) SearchMemories(query string, limit int) ([]MemoryHit, error) {
+	var hits []MemoryHit
+	for id, content := range m.contents {
+		if strings.Contains(strings.ToLower(content), strings.ToLower(query)) {
+			hits = append(hits, MemoryHit{ID: id, Content: content, Score: 1})
+		}
+	}
+	return hits, nil
+}
+
+func (m *fakeMemory) ForgetMemory(id string) error {
+	if _, ok := m.contents[id]; !ok {
+		return fmt.Errorf("memory not found")
+	}
+	delete(m.contents, id)
+	return nil
+}
+
+type recordingTracer struct {
+	events []TraceEvent
+}
+
+func (r *recordingTracer) EmitTrace(trace TraceEvent) error {
+	r.events = append(r.events, trace)
+	return nil
+}
+
+func TestMemoryTools_RememberRecallForget(t *testing.T) {
+	mem := newFakeMemory()
+	tracer := &recordingTracer{}
+	tools := NewMemoryTools(mem, "alice").WithSessionID("conv-1").WithTraceEmitter(tracer)
+
+	if _, err := tools.Execute(RememberToolName, map[string]interface{}{"content": "The user prefers tea", "category": "preference"}); err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+	meta := mem.metadata["mem-1"]
+	if meta["user_id"] != "alice" || meta["session_id"] != "conv-1" || meta["kind"] != "preference" {
+		t.Errorf("Unexpected metadata: %v", meta)
+	}
+
+	out, err := tools.Execute(RecallToolName, map[string]interface{}{"query": "tea", "limit": float64(3)})
+	if err != nil {
+		t.Fatalf("Recall: %v", err)
+	}
+	var recalled struct {
+		Memories []MemoryHit `json:"memories"`
+	}
+	if err := json.Unmarshal([]byte(out), &recalled); err != nil {
+		t.Fatalf("Recall returned invalid JSON %q: %v", out, err)
+	}
+	if len(recalled.Memories) != 1 || recalled.Memories[0].ID != "mem-1" {
+		t.Fatalf("Expected the tea memory with its id, got %s", out)
+	}
+
+	if _, err := tools.Execute(ForgetToolName, map[string]interface{}{"id": "mem-1"}); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	if len(mem.contents) != 0 {
+		t.Errorf("Expected the memory to be forgotten")
+	}
+	if _, err := tools.Forget("mem-1"); err == nil {
+		t.Error("Expected an error forgetting a missing memory")
+	}
+
+	if len(tracer.events) != 8 {
+		t.Fatalf("Expected start/end traces for 4 operations, got %d", len(tracer.events))
+	}
+	last := tracer.events[len(tracer.events)-1]
+	if last.Tool != "memory" || last.Operation != "forget" || last.Status != "error" {
+		t.Errorf("Expected a memory forget error trace, got %+v", last)
+	}
+}
+
+func TestMemoryToolDeclarations(t *testing.T) {
+	tools := MemoryToolDeclarations()
+	if len(tools) != 3 {
+		t.Fatalf("Expected 3 memory tools, got %d", len(tools))
+	}
+	for _, tool := range tools {
+		if !IsMemoryTool(tool.Name) || tool.Callable == nil || len(tool.Parameters.Required) == 0 {
+			t.Errorf("Invalid memory tool declaration %+v", tool)
+		}
+	}
+}
@@ -88,6 +88,11 @@ func htmlToText(html string) string {
 	return strings.TrimSpace(text)
 }
 
+// HTMLToMarkdown converts HTML to markdown the same way Web_Fetch does (used by document ingestion).
+func HTMLToMarkdown(html string) string {
+	return htmlToMarkdown(html)
+}
+
 // htmlToMarkdown does a basic HTML-to-markdown conversion.
 func htmlToMarkdown(html string) string {
 	// Remove script and style blocks
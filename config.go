@@ -1,6 +1,11 @@
 package godantic
 
 import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
 )
 
@@ -17,16 +22,39 @@ const (
 
 // WSConfig holds configuration for WebSocket controllers
 type WSConfig struct {
-	ModelName    string
-	Tools        []interface{}
-	Store        stores.MessageStore
-	TraceStore   stores.TraceStore // Optional: Store for execution traces
-	Provider     ModelProvider     // AI model provider (gemini, openrouter, groq)
-	SiteURL      string            // Optional: Site URL for OpenRouter rankings
-	SiteName     string            // Optional: Site name for OpenRouter rankings
-	Temperature  *float64          // Optional: Temperature for model generation
-	MaxTokens    *int              // Optional: Max tokens for model generation
-	SystemPrompt string            // Optional: System prompt for the AI
+	ModelName      string
+	Tools          []interface{}
+	Store          stores.MessageStore
+	TraceStore     stores.TraceStore      // Optional: Store for execution traces
+	Provider       ModelProvider          // AI model provider (gemini, openrouter, groq)
+	SiteURL        string                 // Optional: Site URL for OpenRouter rankings
+	SiteName       string                 // Optional: Site name for OpenRouter rankings
+	Temperature    *float64               // Optional: Temperature for model generation
+	MaxTokens      *int                   // Optional: Max tokens for model generation
+	ThinkingBudget *int                   // Optional: extended thinking tokens (Anthropic and Gemini); nil leaves the model's default, 0 turns thinking off
+	SystemPrompt   string                 // Optional: System prompt for the AI
+	Encryptor      *stores.FieldEncryptor // Optional: field-level encryption applied to Store and TraceStore
+	Interceptors   []Interceptor          // Optional: added to agents built by Create_Agent_From_Config
+	Logger         *slog.Logger           // Optional: defaults to logging.Default(); secrets are redacted
+	Client         *models.ProviderClient // Optional: API key, endpoint and HTTP client for the provider; defaults to its environment variable
+
+	// Multi-tenancy
+	TenantID       string                                       // Set on configs returned by ForTenant
+	Tenants        map[string]TenantConfig                      // Optional: static per-tenant overrides
+	TenantResolver func(tenantID string) (*TenantConfig, error) // Optional: dynamic overrides, consulted when Tenants has no entry
+}
+
+// TenantConfig holds per-tenant overrides applied by WSConfig.ForTenant.
+// Zero values inherit from the base configuration.
+type TenantConfig struct {
+	ModelName      string
+	Provider       ModelProvider
+	Tools          []interface{} // Replaces the base tools when non-nil
+	SystemPrompt   string
+	Temperature    *float64
+	MaxTokens      *int
+	ThinkingBudget *int                   // 0 turns thinking off for the tenant
+	Client         *models.ProviderClient // Merged over the base Client, e.g. a tenant's own API key
 }
 
 // NewWSConfig creates a new WebSocket configuration with default values
@@ -88,6 +116,8 @@ func (c *WSConfig) WithSystemPrompt(systemPrompt string) *WSConfig {
 // WithStore sets the message store for the configuration
 func (c *WSConfig) WithStore(store stores.MessageStore) *WSConfig {
 	c.Store = store
+	c.applyEncryption()
+	c.applyLogger()
 	return c
 }
 
@@ -98,6 +128,8 @@ func (c *WSConfig) WithSQLiteStore(dbPath string) *WSConfig {
 		panic("Failed to create SQLite store: " + err.Error())
 	}
 	c.Store = store
+	c.applyEncryption()
+	c.applyLogger()
 	return c
 }
 
@@ -108,6 +140,8 @@ func (c *WSConfig) WithPostgresStore(host, user, password, dbname string, port i
 		panic("Failed to create PostgreSQL store: " + err.Error())
 	}
 	c.Store = store
+	c.applyEncryption()
+	c.applyLogger()
 	return c
 }
 
@@ -231,8 +265,155 @@ func (c *WSConfig) WithAnthropic(model string) *WSConfig {
 	return c
 }
 
+// WithInterceptors adds interceptors to the agents built from this configuration
+func (c *WSConfig) WithInterceptors(interceptors ...Interceptor) *WSConfig {
+	c.Interceptors = append(c.Interceptors, interceptors...)
+	return c
+}
+
 // WithTraceStore sets the trace store for execution trace persistence
 func (c *WSConfig) WithTraceStore(traceStore stores.TraceStore) *WSConfig {
 	c.TraceStore = traceStore
+	c.applyEncryption()
+	c.applyLogger()
+	return c
+}
+
+// WithEncryption enables envelope encryption of message parts and trace details at rest.
+// It applies to the current stores and to any store set afterwards.
+func (c *WSConfig) WithEncryption(provider stores.KeyProvider) *WSConfig {
+	c.Encryptor = stores.NewFieldEncryptor(provider)
+	c.applyEncryption()
+	return c
+}
+
+// WithThinkingBudget enables extended thinking with the given token budget on Anthropic and Gemini models;
+// 0 turns it off. Their reasoning streams as Reasoning parts and is saved so it can be replayed during tool use.
+func (c *WSConfig) WithThinkingBudget(tokens int) *WSConfig {
+	c.ThinkingBudget = &tokens
+	return c
+}
+
+// WithProviderClient sets the API key, endpoint and HTTP client used by models built from this configuration
+func (c *WSConfig) WithProviderClient(client models.ProviderClient) *WSConfig {
+	c.Client = &client
+	return c
+}
+
+// WithLogger sets the logger used by sessions, models and stores built from this configuration.
+// Session loggers add session, user and tenant IDs; secrets are redacted from every record.
+func (c *WSConfig) WithLogger(logger *slog.Logger) *WSConfig {
+	c.Logger = logging.Redact(logger)
+	c.applyLogger()
+	return c
+}
+
+// applyLogger hands the configured logger to stores that support it
+func (c *WSConfig) applyLogger() {
+	if c.Logger == nil {
+		return
+	}
+	if s, ok := c.Store.(stores.LoggerConfigurable); ok {
+		s.SetLogger(c.Logger)
+	}
+	if s, ok := c.TraceStore.(stores.LoggerConfigurable); ok {
+		s.SetLogger(c.Logger)
+	}
+}
+
+// applyEncryption hands the configured encryptor to stores that support it
+func (c *WSConfig) applyEncryption() {
+	if c.Encryptor == nil {
+		return
+	}
+	if s, ok := c.Store.(stores.EncryptionConfigurable); ok {
+		s.SetEncryptor(c.Encryptor)
+	}
+	if s, ok := c.TraceStore.(stores.EncryptionConfigurable); ok {
+		s.SetEncryptor(c.Encryptor)
+	}
+}
+
+// WithTenantConfig registers overrides for a tenant
+func (c *WSConfig) WithTenantConfig(tenantID string, tenant TenantConfig) *WSConfig {
+	if c.Tenants == nil {
+		c.Tenants = make(map[string]TenantConfig)
+	}
+	c.Tenants[tenantID] = tenant
+	return c
+}
+
+// WithTenantResolver sets a function used to look up tenant overrides that are not registered statically
+func (c *WSConfig) WithTenantResolver(resolver func(tenantID string) (*TenantConfig, error)) *WSConfig {
+	c.TenantResolver = resolver
 	return c
 }
+
+// ForTenant resolves the configuration for a tenant.
+// The returned copy has the tenant's overrides applied and its Store and TraceStore
+// scoped so that they only see the tenant's conversations and traces.
+func (c *WSConfig) ForTenant(tenantID string) (*WSConfig, error) {
+	resolved := *c
+	resolved.TenantID = tenantID
+	if tenantID == "" {
+		return &resolved, nil
+	}
+
+	var override *TenantConfig
+	if tenant, ok := c.Tenants[tenantID]; ok {
+		override = &tenant
+	} else if c.TenantResolver != nil {
+		tenant, err := c.TenantResolver(tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve config for tenant %s: %w", tenantID, err)
+		}
+		override = tenant
+	}
+	if override != nil {
+		resolved.applyTenantConfig(override)
+	}
+
+	store, err := stores.ScopeStoreToTenant(c.Store, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	traceStore, err := stores.ScopeTraceStoreToTenant(c.TraceStore, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	resolved.Store = store
+	resolved.TraceStore = traceStore
+	return &resolved, nil
+}
+
+func (c *WSConfig) applyTenantConfig(tenant *TenantConfig) {
+	if tenant.ModelName != "" {
+		c.ModelName = tenant.ModelName
+	}
+	if tenant.Provider != "" {
+		c.Provider = tenant.Provider
+	}
+	if tenant.Tools != nil {
+		c.Tools = tenant.Tools
+	}
+	if tenant.SystemPrompt != "" {
+		c.SystemPrompt = tenant.SystemPrompt
+	}
+	if tenant.Temperature != nil {
+		c.Temperature = tenant.Temperature
+	}
+	if tenant.MaxTokens != nil {
+		c.MaxTokens = tenant.MaxTokens
+	}
+	if tenant.ThinkingBudget != nil {
+		c.ThinkingBudget = tenant.ThinkingBudget
+	}
+	if tenant.Client != nil {
+		var base models.ProviderClient
+		if c.Client != nil {
+			base = *c.Client
+		}
+		merged := base.Merge(tenant.Client)
+		c.Client = &merged
+	}
+}
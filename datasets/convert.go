@@ -0,0 +1,191 @@
+package datasets
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+// exampleMessage is a format-neutral training message built from stored history
+type exampleMessage struct {
+	Role        string // "user", "assistant" or "tool"
+	Text        string
+	ToolCalls   []toolCall
+	ToolResults []toolResult // Only for Role "tool"
+}
+
+type toolCall struct {
+	ID   string
+	Name string
+	Args map[string]interface{}
+}
+
+type toolResult struct {
+	CallID  string
+	Name    string
+	Content string // JSON-encoded tool response
+}
+
+// splitTurns groups history into turns that each start with a user_message.
+// Messages before the first user_message form their own (invalid) turn.
+func splitTurns(msgs []stores.Message) [][]stores.Message {
+	var turns [][]stores.Message
+	var current []stores.Message
+	for _, msg := range msgs {
+		if msg.Type == "user_message" && len(current) > 0 {
+			turns = append(turns, current)
+			current = nil
+		}
+		current = append(current, msg)
+	}
+	if len(current) > 0 {
+		turns = append(turns, current)
+	}
+	return turns
+}
+
+// turnIssues reports why a turn cannot be used for training, combining
+// DetectCorruptedHistory with the requirement that a turn ends in a model reply
+func turnIssues(turn []stores.Message) []string {
+	issues := stores.DetectCorruptedHistory(turn)
+	if turn[0].Type != "user_message" {
+		issues = append(issues, "turn does not start with a user_message")
+	}
+	if turn[len(turn)-1].Type != "model_message" {
+		issues = append(issues, "turn does not end with a model reply")
+	}
+	return issues
+}
+
+// converter turns stored messages into example messages, pairing tool calls with their responses
+type converter struct {
+	redactor *Redactor
+	nextID   int
+}
+
+// convertTurn converts one clean turn. It fails when the turn has no usable text
+// or a tool response cannot be matched to a call.
+func (c *converter) convertTurn(turn []stores.Message) ([]exampleMessage, error) {
+	var out []exampleMessage
+	var pending []toolCall
+
+	for _, msg := range turn {
+		switch msg.Type {
+		case "user_message":
+			var parts []models.User_Part
+			if err := json.Unmarshal([]byte(msg.PartsJSON), &parts); err != nil {
+				return nil, fmt.Errorf("failed to parse user message %d: %w", msg.Sequence, err)
+			}
+			texts := make([]string, 0, len(parts))
+			for _, part := range parts {
+				if part.Text != "" {
+					texts = append(texts, part.Text)
+				}
+			}
+			if len(texts) == 0 {
+				return nil, fmt.Errorf("user message %d has no text", msg.Sequence)
+			}
+			out = append(out, exampleMessage{Role: "user", Text: c.redactor.Redact(strings.Join(texts, "\n"))})
+
+		case "model_message", "function_call":
+			var parts []models.Model_Part
+			if err := json.Unmarshal([]byte(msg.PartsJSON), &parts); err != nil {
+				return nil, fmt.Errorf("failed to parse model message %d: %w", msg.Sequence, err)
+			}
+			assistant := exampleMessage{Role: "assistant"}
+			for _, part := range parts {
+				if part.Text != nil {
+					assistant.Text += *part.Text
+				}
+				if part.FunctionCall != nil {
+					call := toolCall{
+						ID:   part.FunctionCall.ID,
+						Name: part.FunctionCall.Name,
+						Args: c.redactor.redactMap(part.FunctionCall.Args),
+					}
+					if call.ID == "" {
+						c.nextID++
+						call.ID = fmt.Sprintf("call_%d", c.nextID)
+					}
+					if call.Args == nil {
+						call.Args = map[string]interface{}{}
+					}
+					assistant.ToolCalls = append(assistant.ToolCalls, call)
+					pending = append(pending, call)
+				}
+			}
+			assistant.Text = c.redactor.Redact(assistant.Text)
+			if assistant.Text == "" && len(assistant.ToolCalls) == 0 {
+				continue
+			}
+			// A reply split across messages (text, then calls) becomes one assistant message
+			if n := len(out); n > 0 && out[n-1].Role == "assistant" && len(out[n-1].ToolCalls) == 0 {
+				out[n-1].Text += assistant.Text
+				out[n-1].ToolCalls = assistant.ToolCalls
+				continue
+			}
+			out = append(out, assistant)
+
+		case "function_response":
+			var parts []models.User_Part
+			if err := json.Unmarshal([]byte(msg.PartsJSON), &parts); err != nil {
+				return nil, fmt.Errorf("failed to parse function response %d: %w", msg.Sequence, err)
+			}
+			tool := exampleMessage{Role: "tool"}
+			for _, part := range parts {
+				if part.FunctionResponse == nil {
+					continue
+				}
+				call, ok := takePendingCall(&pending, part.FunctionResponse.ID, part.FunctionResponse.Name)
+				if !ok {
+					return nil, fmt.Errorf("function response %q in message %d has no matching call", part.FunctionResponse.Name, msg.Sequence)
+				}
+				content, err := json.Marshal(c.redactor.redactMap(part.FunctionResponse.Response))
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode function response: %w", err)
+				}
+				tool.ToolResults = append(tool.ToolResults, toolResult{CallID: call.ID, Name: call.Name, Content: string(content)})
+			}
+			if len(tool.ToolResults) > 0 {
+				out = append(out, tool)
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("%d tool call(s) without responses", len(pending))
+	}
+	return out, nil
+}
+
+// takePendingCall removes and returns the pending call a response belongs to,
+// matching by ID first and falling back to the oldest call with the same name
+func takePendingCall(pending *[]toolCall, id, name string) (toolCall, bool) {
+	calls := *pending
+	index := -1
+	if id != "" {
+		for i, call := range calls {
+			if call.ID == id {
+				index = i
+				break
+			}
+		}
+	}
+	if index == -1 {
+		for i, call := range calls {
+			if call.Name == name {
+				index = i
+				break
+			}
+		}
+	}
+	if index == -1 {
+		return toolCall{}, false
+	}
+	call := calls[index]
+	*pending = append(calls[:index], calls[index+1:]...)
+	return call, true
+}
@@ -0,0 +1,344 @@
+// Package datasets exports stored conversations as fine-tuning datasets.
+//
+// Conversations are read from a stores.MessageStore, filtered by date, feedback rating,
+// tool usage and model, cleaned of corrupted tool cycles, optionally redacted, and written
+// as JSONL in OpenAI, Anthropic or ShareGPT format (one conversation per line).
+package datasets
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+// ToolUsage filters conversations by whether the model called tools
+type ToolUsage string
+
+const (
+	ToolUsageAny     ToolUsage = ""              // No filtering
+	ToolUsageWith    ToolUsage = "with_tools"    // Only conversations with at least one tool call
+	ToolUsageWithout ToolUsage = "without_tools" // Only conversations without tool calls
+)
+
+// Filter selects which conversations are exported. Zero values are ignored.
+type Filter struct {
+	ConversationIDs []string  // Export only these conversations (default: all conversations in the store)
+	UserID          string    // Only conversations owned by this user
+	Since           time.Time // Only conversations whose last message is at or after Since
+	Until           time.Time // Only conversations whose last message is before Until
+
+	// A conversation's rating is the lowest rating given to any of its messages.
+	// Setting either bound excludes unrated conversations and requires a FeedbackStore.
+	MinRating *int
+	MaxRating *int
+
+	ToolUsage ToolUsage
+	Tools     []string // Only conversations that call at least one of these tools
+	Models    []string // Only conversations whose recorded model messages all come from these models
+	Limit     int      // Maximum number of conversations to export
+}
+
+// Stats summarizes an export
+type Stats struct {
+	Scanned      int            `json:"scanned"`       // Conversations read from the store
+	Exported     int            `json:"exported"`      // Conversations written
+	DroppedTurns int            `json:"dropped_turns"` // Corrupted or incomplete turns removed from exported conversations
+	Skipped      map[string]int `json:"skipped"`       // Conversations not written, by reason
+}
+
+func (s *Stats) skip(reason string) {
+	s.Skipped[reason]++
+}
+
+// Exporter writes stored conversations as fine-tuning datasets
+type Exporter struct {
+	store         stores.MessageStore
+	feedbackStore stores.FeedbackStore
+	redactor      *Redactor
+	systemPrompt  string
+	tools         []models.FunctionDeclaration
+	logger        func(format string, args ...interface{})
+}
+
+// NewExporter creates an exporter for store. The store is also used for feedback
+// when it implements stores.FeedbackStore.
+func NewExporter(store stores.MessageStore) *Exporter {
+	e := &Exporter{store: store}
+	if fs, ok := store.(stores.FeedbackStore); ok {
+		e.feedbackStore = fs
+	}
+	return e
+}
+
+// WithFeedbackStore sets the store used for rating filters
+func (e *Exporter) WithFeedbackStore(feedbackStore stores.FeedbackStore) *Exporter {
+	e.feedbackStore = feedbackStore
+	return e
+}
+
+// WithRedactor redacts text, tool arguments and tool results before they are written
+func (e *Exporter) WithRedactor(redactor *Redactor) *Exporter {
+	e.redactor = redactor
+	return e
+}
+
+// WithSystemPrompt adds a system prompt to every example
+func (e *Exporter) WithSystemPrompt(systemPrompt string) *Exporter {
+	e.systemPrompt = systemPrompt
+	return e
+}
+
+// WithTools adds tool declarations to every example
+func (e *Exporter) WithTools(tools []models.FunctionDeclaration) *Exporter {
+	e.tools = tools
+	return e
+}
+
+// WithLogger receives a line for every dropped turn and skipped conversation
+func (e *Exporter) WithLogger(logf func(format string, args ...interface{})) *Exporter {
+	e.logger = logf
+	return e
+}
+
+func (e *Exporter) logf(format string, args ...interface{}) {
+	if e.logger != nil {
+		e.logger(format, args...)
+	}
+}
+
+// Export writes one JSONL record per matching conversation to w
+func (e *Exporter) Export(w io.Writer, format Format, filter Filter) (*Stats, error) {
+	if e.store == nil {
+		return nil, fmt.Errorf("message store is nil")
+	}
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+	if (filter.MinRating != nil || filter.MaxRating != nil) && e.feedbackStore == nil {
+		return nil, fmt.Errorf("rating filters require a feedback store")
+	}
+
+	ids, err := e.conversationIDs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{Skipped: make(map[string]int)}
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+
+	for _, id := range ids {
+		if filter.Limit > 0 && stats.Exported >= filter.Limit {
+			break
+		}
+		stats.Scanned++
+
+		messages, reason, err := e.exportConversation(id, filter, stats)
+		if err != nil {
+			return stats, err
+		}
+		if reason != "" {
+			e.logf("Skipping conversation %s: %s", id, reason)
+			stats.skip(reason)
+			continue
+		}
+
+		record, err := encodeExample(format, e.systemPrompt, e.tools, messages)
+		if err != nil {
+			return stats, err
+		}
+		if err := encoder.Encode(record); err != nil {
+			return stats, fmt.Errorf("failed to write example: %w", err)
+		}
+		stats.Exported++
+	}
+
+	if err := out.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to write dataset: %w", err)
+	}
+	return stats, nil
+}
+
+// conversationIDs lists the conversations to consider
+func (e *Exporter) conversationIDs(filter Filter) ([]string, error) {
+	if len(filter.ConversationIDs) > 0 {
+		return filter.ConversationIDs, nil
+	}
+	if filter.UserID != "" {
+		infos, err := e.store.ListConversationsForUser(filter.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		ids := make([]string, len(infos))
+		for i, info := range infos {
+			ids[i] = info.ConversationID
+		}
+		return ids, nil
+	}
+	ids, err := e.store.ListConversations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	return ids, nil
+}
+
+// exportConversation loads, filters and converts one conversation.
+// A non-empty reason means the conversation is skipped.
+func (e *Exporter) exportConversation(id string, filter Filter, stats *Stats) ([]exampleMessage, string, error) {
+	history, err := e.fetchHistory(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(history) == 0 {
+		return nil, "empty", nil
+	}
+
+	last := history[len(history)-1].CreatedAt
+	if !filter.Since.IsZero() && last.Before(filter.Since) {
+		return nil, "outside date range", nil
+	}
+	if !filter.Until.IsZero() && !last.Before(filter.Until) {
+		return nil, "outside date range", nil
+	}
+
+	if filter.MinRating != nil || filter.MaxRating != nil {
+		reason, err := e.checkRating(id, filter)
+		if err != nil || reason != "" {
+			return nil, reason, err
+		}
+	}
+
+	// Drop turns reported as corrupted, keeping the rest of the conversation
+	var clean []stores.Message
+	converter := &converter{redactor: e.redactor}
+	var messages []exampleMessage
+	for _, turn := range splitTurns(history) {
+		if issues := turnIssues(turn); len(issues) > 0 {
+			e.logf("Dropping turn at sequence %d of %s: %v", turn[0].Sequence, id, issues)
+			stats.DroppedTurns++
+			continue
+		}
+		converted, err := converter.convertTurn(turn)
+		if err != nil {
+			e.logf("Dropping turn at sequence %d of %s: %v", turn[0].Sequence, id, err)
+			stats.DroppedTurns++
+			continue
+		}
+		clean = append(clean, turn...)
+		messages = append(messages, converted...)
+	}
+	if len(messages) == 0 {
+		return nil, "no usable turns", nil
+	}
+
+	if reason := matchToolUsage(messages, filter); reason != "" {
+		return nil, reason, nil
+	}
+	if len(filter.Models) > 0 && !matchModels(clean, filter.Models) {
+		return nil, "model mismatch", nil
+	}
+	return messages, "", nil
+}
+
+// fetchHistory prefers unsanitized history so corrupted cycles can be detected and dropped
+func (e *Exporter) fetchHistory(id string) ([]stores.Message, error) {
+	var history []stores.Message
+	var err error
+	if raw, ok := e.store.(stores.RawHistoryStore); ok {
+		history, err = raw.FetchRawHistory(id)
+	} else {
+		history, err = e.store.FetchHistory(id, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history for %s: %w", id, err)
+	}
+	return history, nil
+}
+
+// checkRating applies the rating bounds to the conversation's lowest rating
+func (e *Exporter) checkRating(id string, filter Filter) (string, error) {
+	feedback, err := e.feedbackStore.GetFeedbackForConversation(id)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch feedback for %s: %w", id, err)
+	}
+	if len(feedback) == 0 {
+		return "unrated", nil
+	}
+	lowest := feedback[0].Rating
+	for _, f := range feedback[1:] {
+		if f.Rating < lowest {
+			lowest = f.Rating
+		}
+	}
+	if filter.MinRating != nil && lowest < *filter.MinRating {
+		return "rating out of range", nil
+	}
+	if filter.MaxRating != nil && lowest > *filter.MaxRating {
+		return "rating out of range", nil
+	}
+	return "", nil
+}
+
+// matchToolUsage applies the ToolUsage and Tools filters
+func matchToolUsage(messages []exampleMessage, filter Filter) string {
+	called := make(map[string]bool)
+	for _, msg := range messages {
+		for _, call := range msg.ToolCalls {
+			called[call.Name] = true
+		}
+	}
+	switch filter.ToolUsage {
+	case ToolUsageWith:
+		if len(called) == 0 {
+			return "no tool calls"
+		}
+	case ToolUsageWithout:
+		if len(called) > 0 {
+			return "has tool calls"
+		}
+	}
+	if len(filter.Tools) > 0 {
+		for _, name := range filter.Tools {
+			if called[name] {
+				return ""
+			}
+		}
+		return "tools not used"
+	}
+	return ""
+}
+
+// matchModels reports whether every model message with recorded metadata came from one of
+// the allowed models, and at least one did
+func matchModels(history []stores.Message, allowed []string) bool {
+	matched := false
+	for _, msg := range history {
+		if msg.Role != "model" || msg.MetadataJSON == "" {
+			continue
+		}
+		var metadata models.MessageMetadata
+		if err := json.Unmarshal([]byte(msg.MetadataJSON), &metadata); err != nil || metadata.Model == "" {
+			continue
+		}
+		if !containsString(allowed, metadata.Model) {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package datasets
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+func newTestStore(t *testing.T) *stores.SQLiteStore {
+	t.Helper()
+	store, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func text(s string) *string { return &s }
+
+func save(t *testing.T, store *stores.SQLiteStore, conv, role, msgType string, parts interface{}, metadata *models.MessageMetadata) {
+	t.Helper()
+	if err := store.SaveMessageWithMetadata(conv, "alice", role, msgType, parts, "", metadata); err != nil {
+		t.Fatalf("SaveMessageWithMetadata: %v", err)
+	}
+}
+
+// seedToolConversation stores a clean tool cycle followed by a turn whose function_call never got a response
+func seedToolConversation(t *testing.T, store *stores.SQLiteStore, conv, model string) {
+	t.Helper()
+	md := &models.MessageMetadata{Provider: "test", Model: model}
+	save(t, store, conv, "user", "user_message", []models.User_Part{{Text: "Weather for bob@example.com?"}}, nil)
+	save(t, store, conv, "model", "function_call", []models.Model_Part{{FunctionCall: &models.FunctionCall{ID: "call-1", Name: "Get_Weather", Args: map[string]interface{}{"city": "Paris"}}}}, md)
+	save(t, store, conv, "user", "function_response", []models.User_Part{{FunctionResponse: &models.FunctionResponse{ID: "call-1", Name: "Get_Weather", Response: map[string]interface{}{"temp": 21.0}}}}, nil)
+	save(t, store, conv, "model", "model_message", []models.Model_Part{{Text: text("It is 21C in Paris.")}}, md)
+	save(t, store, conv, "user", "user_message", []models.User_Part{{Text: "And London?"}}, nil)
+	save(t, store, conv, "model", "function_call", []models.Model_Part{{FunctionCall: &models.FunctionCall{ID: "call-2", Name: "Get_Weather", Args: map[string]interface{}{"city": "London"}}}}, md)
+	save(t, store, conv, "user", "user_message", []models.User_Part{{Text: "Thanks"}}, nil)
+	save(t, store, conv, "model", "model_message", []models.Model_Part{{Text: text("You're welcome.")}}, md)
+}
+
+func exportLines(t *testing.T, exporter *Exporter, format Format, filter Filter) ([]map[string]interface{}, *Stats) {
+	t.Helper()
+	var buf bytes.Buffer
+	stats, err := exporter.Export(&buf, format, filter)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid JSONL line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, stats
+}
+
+func TestExport_OpenAIDropsCorruptedTurnsAndRedacts(t *testing.T) {
+	store := newTestStore(t)
+	seedToolConversation(t, store, "conv-1", "gpt-test")
+
+	exporter := NewExporter(store).WithRedactor(NewPIIRedactor()).WithSystemPrompt("Be brief.")
+	records, stats := exportLines(t, exporter, FormatOpenAI, Filter{})
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 example, got %d", len(records))
+	}
+	if stats.DroppedTurns != 1 {
+		t.Errorf("Expected the turn with the unanswered call to be dropped, got %d", stats.DroppedTurns)
+	}
+
+	messages := records[0]["messages"].([]interface{})
+	roles := []string{}
+	for _, m := range messages {
+		roles = append(roles, m.(map[string]interface{})["role"].(string))
+	}
+	want := "system,user,assistant,tool,assistant,user,assistant"
+	if strings.Join(roles, ",") != want {
+		t.Errorf("Expected roles %s, got %s", want, strings.Join(roles, ","))
+	}
+
+	user := messages[1].(map[string]interface{})
+	if user["content"] != "Weather for [EMAIL]?" {
+		t.Errorf("Expected redacted email, got %v", user["content"])
+	}
+	call := messages[2].(map[string]interface{})["tool_calls"].([]interface{})[0].(map[string]interface{})
+	if call["id"] != "call-1" || call["function"].(map[string]interface{})["arguments"] != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %v", call)
+	}
+	tool := messages[3].(map[string]interface{})
+	if tool["tool_call_id"] != "call-1" || tool["content"] != `{"temp":21}` {
+		t.Errorf("Unexpected tool message: %v", tool)
+	}
+}
+
+func TestExport_AnthropicAndShareGPT(t *testing.T) {
+	store := newTestStore(t)
+	seedToolConversation(t, store, "conv-1", "gpt-test")
+	exporter := NewExporter(store)
+
+	records, _ := exportLines(t, exporter, FormatAnthropic, Filter{})
+	messages := records[0]["messages"].([]interface{})
+	if len(messages) != 6 {
+		t.Fatalf("Expected 6 alternating messages, got %d", len(messages))
+	}
+	toolUse := messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if toolUse["type"] != "tool_use" || toolUse["name"] != "Get_Weather" {
+		t.Errorf("Expected tool_use block, got %v", toolUse)
+	}
+	toolResult := messages[2].(map[string]interface{})
+	block := toolResult["content"].([]interface{})[0].(map[string]interface{})
+	if toolResult["role"] != "user" || block["type"] != "tool_result" || block["tool_use_id"] != "call-1" {
+		t.Errorf("Expected tool_result in a user message, got %v", toolResult)
+	}
+
+	records, _ = exportLines(t, exporter, FormatShareGPT, Filter{})
+	froms := []string{}
+	for _, turn := range records[0]["conversations"].([]interface{}) {
+		froms = append(froms, turn.(map[string]interface{})["from"].(string))
+	}
+	if strings.Join(froms, ",") != "human,function_call,observation,gpt,human,gpt" {
+		t.Errorf("Unexpected ShareGPT turns: %v", froms)
+	}
+}
+
+func TestExport_Filters(t *testing.T) {
+	store := newTestStore(t)
+	seedToolConversation(t, store, "conv-tools", "model-a")
+	md := &models.MessageMetadata{Model: "model-b"}
+	save(t, store, "conv-chat", "user", "user_message", []models.User_Part{{Text: "Hi"}}, nil)
+	save(t, store, "conv-chat", "model", "model_message", []models.Model_Part{{Text: text("Hello!")}}, md)
+
+	exporter := NewExporter(store)
+
+	records, _ := exportLines(t, exporter, FormatOpenAI, Filter{ToolUsage: ToolUsageWithout})
+	if len(records) != 1 {
+		t.Errorf("Expected only the conversation without tools, got %d", len(records))
+	}
+	records, _ = exportLines(t, exporter, FormatOpenAI, Filter{Tools: []string{"Get_Weather"}})
+	if len(records) != 1 {
+		t.Errorf("Expected only the conversation calling Get_Weather, got %d", len(records))
+	}
+	records, _ = exportLines(t, exporter, FormatOpenAI, Filter{Models: []string{"model-b"}})
+	if len(records) != 1 {
+		t.Errorf("Expected only the model-b conversation, got %d", len(records))
+	}
+
+	if err := store.SaveFeedback(&stores.MessageFeedback{ConversationID: "conv-chat", Sequence: 2, UserID: "alice", Rating: stores.RatingPositive}); err != nil {
+		t.Fatalf("SaveFeedback: %v", err)
+	}
+	positive := stores.RatingPositive
+	records, stats := exportLines(t, exporter, FormatOpenAI, Filter{MinRating: &positive})
+	if len(records) != 1 || stats.Skipped["unrated"] != 1 {
+		t.Errorf("Expected only the positively rated conversation, got %d records, stats %+v", len(records), stats)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewPIIRedactor().WithTerms("[NAME]", "Alice Smith")
+	got := r.Redact("Call alice smith at (555) 123-4567, SSN 123-45-6789, card 4111 1111 1111 1111, ip 10.0.0.1")
+	want := "Call [NAME] at [PHONE], SSN [SSN], card [CARD], ip [IP]"
+	if got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,243 @@
+package datasets
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// Format selects the JSONL record layout written by the exporter
+type Format string
+
+const (
+	FormatOpenAI    Format = "openai"    // OpenAI chat fine-tuning: {"messages": [...], "tools": [...]}
+	FormatAnthropic Format = "anthropic" // Anthropic Messages API: {"system": ..., "messages": [...], "tools": [...]}
+	FormatShareGPT  Format = "sharegpt"  // ShareGPT: {"conversations": [{"from": "human", "value": ...}], ...}
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatOpenAI, FormatAnthropic, FormatShareGPT:
+		return Format(name), nil
+	}
+	return "", fmt.Errorf("unknown dataset format %q (expected openai, anthropic or sharegpt)", name)
+}
+
+// encodeExample builds the record for one conversation in the given format
+func encodeExample(format Format, systemPrompt string, tools []models.FunctionDeclaration, messages []exampleMessage) (interface{}, error) {
+	switch format {
+	case FormatOpenAI:
+		return toOpenAI(systemPrompt, tools, messages), nil
+	case FormatAnthropic:
+		return toAnthropic(systemPrompt, tools, messages), nil
+	case FormatShareGPT:
+		return toShareGPT(systemPrompt, tools, messages)
+	}
+	return nil, fmt.Errorf("unknown dataset format %q", format)
+}
+
+// OpenAI chat fine-tuning format
+
+type openAIExample struct {
+	Messages []openAIMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON-encoded, as returned by the API
+}
+
+type openAITool struct {
+	Type     string                     `json:"type"`
+	Function models.FunctionDeclaration `json:"function"`
+}
+
+func toOpenAI(systemPrompt string, tools []models.FunctionDeclaration, messages []exampleMessage) openAIExample {
+	example := openAIExample{}
+	if systemPrompt != "" {
+		example.Messages = append(example.Messages, openAIMessage{Role: "system", Content: systemPrompt})
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			example.Messages = append(example.Messages, openAIMessage{Role: "user", Content: msg.Text})
+		case "assistant":
+			out := openAIMessage{Role: "assistant", Content: msg.Text}
+			for _, call := range msg.ToolCalls {
+				args, _ := json.Marshal(call.Args)
+				out.ToolCalls = append(out.ToolCalls, openAIToolCall{
+					ID:       call.ID,
+					Type:     "function",
+					Function: openAIFunctionCall{Name: call.Name, Arguments: string(args)},
+				})
+			}
+			example.Messages = append(example.Messages, out)
+		case "tool":
+			for _, result := range msg.ToolResults {
+				example.Messages = append(example.Messages, openAIMessage{Role: "tool", ToolCallID: result.CallID, Content: result.Content})
+			}
+		}
+	}
+	for _, tool := range tools {
+		example.Tools = append(example.Tools, openAITool{Type: "function", Function: tool})
+	}
+	return example
+}
+
+// Anthropic Messages format
+
+type anthropicExample struct {
+	System   string             `json:"system,omitempty"`
+	Tools    []anthropicTool    `json:"tools,omitempty"`
+	Messages []anthropicMessage `json:"messages"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type      string      `json:"type"` // "text", "tool_use" or "tool_result"
+	Text      string      `json:"text,omitempty"`
+	ID        string      `json:"id,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Input     interface{} `json:"input,omitempty"`
+	ToolUseID string      `json:"tool_use_id,omitempty"`
+	Content   string      `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	InputSchema models.Parameters `json:"input_schema"`
+}
+
+func toAnthropic(systemPrompt string, tools []models.FunctionDeclaration, messages []exampleMessage) anthropicExample {
+	example := anthropicExample{System: systemPrompt}
+	for _, msg := range messages {
+		role := "user"
+		var blocks []anthropicBlock
+		switch msg.Role {
+		case "user":
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Text})
+		case "assistant":
+			role = "assistant"
+			if msg.Text != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Text})
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: call.Args})
+			}
+		case "tool":
+			// Tool results are sent back as user content
+			for _, result := range msg.ToolResults {
+				blocks = append(blocks, anthropicBlock{Type: "tool_result", ToolUseID: result.CallID, Content: result.Content})
+			}
+		}
+		// Roles must alternate, so consecutive messages of the same role are merged
+		if n := len(example.Messages); n > 0 && example.Messages[n-1].Role == role {
+			example.Messages[n-1].Content = append(example.Messages[n-1].Content, blocks...)
+			continue
+		}
+		example.Messages = append(example.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	for _, tool := range tools {
+		example.Tools = append(example.Tools, anthropicTool{Name: tool.Name, Description: tool.Description, InputSchema: tool.Parameters})
+	}
+	return example
+}
+
+// ShareGPT format, using the function_call/observation roles understood by common fine-tuning tools
+
+type shareGPTExample struct {
+	Conversations []shareGPTTurn `json:"conversations"`
+	System        string         `json:"system,omitempty"`
+	Tools         string         `json:"tools,omitempty"` // JSON-encoded tool declarations
+}
+
+type shareGPTTurn struct {
+	From  string `json:"from"` // "human", "gpt", "function_call" or "observation"
+	Value string `json:"value"`
+}
+
+type shareGPTCall struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// toShareGPT encodes tool calls as a function_call turn. Parallel calls (and their
+// observations) are encoded as JSON arrays. ShareGPT has no place for text that
+// accompanies a tool call, so that text is dropped.
+func toShareGPT(systemPrompt string, tools []models.FunctionDeclaration, messages []exampleMessage) (shareGPTExample, error) {
+	example := shareGPTExample{System: systemPrompt}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			example.Conversations = append(example.Conversations, shareGPTTurn{From: "human", Value: msg.Text})
+		case "assistant":
+			if len(msg.ToolCalls) == 0 {
+				example.Conversations = append(example.Conversations, shareGPTTurn{From: "gpt", Value: msg.Text})
+				continue
+			}
+			calls := make([]shareGPTCall, len(msg.ToolCalls))
+			for i, call := range msg.ToolCalls {
+				calls[i] = shareGPTCall{Name: call.Name, Arguments: call.Args}
+			}
+			value, err := marshalOneOrMany(calls, len(calls) == 1)
+			if err != nil {
+				return example, err
+			}
+			example.Conversations = append(example.Conversations, shareGPTTurn{From: "function_call", Value: value})
+		case "tool":
+			results := make([]json.RawMessage, len(msg.ToolResults))
+			for i, result := range msg.ToolResults {
+				results[i] = json.RawMessage(result.Content)
+			}
+			value, err := marshalOneOrMany(results, len(results) == 1)
+			if err != nil {
+				return example, err
+			}
+			example.Conversations = append(example.Conversations, shareGPTTurn{From: "observation", Value: value})
+		}
+	}
+	if len(tools) > 0 {
+		data, err := json.Marshal(tools)
+		if err != nil {
+			return example, fmt.Errorf("failed to encode tools: %w", err)
+		}
+		example.Tools = string(data)
+	}
+	return example, nil
+}
+
+// marshalOneOrMany encodes the only element when single is true, otherwise the whole slice
+func marshalOneOrMany[T any](items []T, single bool) (string, error) {
+	var data []byte
+	var err error
+	if single {
+		data, err = json.Marshal(items[0])
+	} else {
+		data, err = json.Marshal(items)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tool calls: %w", err)
+	}
+	return string(data), nil
+}
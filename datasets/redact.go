@@ -0,0 +1,110 @@
+package datasets
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactionRule replaces every match of Pattern with Replacement
+type RedactionRule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Redactor removes personally identifiable information from exported text,
+// tool call arguments and tool results. A nil Redactor leaves content unchanged.
+type Redactor struct {
+	rules []RedactionRule
+}
+
+// NewRedactor creates a redactor that applies rules in order
+func NewRedactor(rules ...RedactionRule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// NewPIIRedactor creates a redactor with DefaultPIIRules
+func NewPIIRedactor() *Redactor {
+	return NewRedactor(DefaultPIIRules()...)
+}
+
+// DefaultPIIRules covers emails, US social security numbers, card numbers, phone numbers and IPv4 addresses.
+// SSNs and card numbers run before phone numbers so they are not partially matched as phones.
+func DefaultPIIRules() []RedactionRule {
+	return []RedactionRule{
+		{Name: "email", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), Replacement: "[EMAIL]"},
+		{Name: "ssn", Pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), Replacement: "[SSN]"},
+		{Name: "card", Pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), Replacement: "[CARD]"},
+		{Name: "phone", Pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?\(?\b\d{3}\)?[ .-]?\d{3}[ .-]?\d{4}\b`), Replacement: "[PHONE]"},
+		{Name: "ipv4", Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), Replacement: "[IP]"},
+	}
+}
+
+// WithRule appends a rule
+func (r *Redactor) WithRule(name, pattern, replacement string) (*Redactor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	r.rules = append(r.rules, RedactionRule{Name: name, Pattern: re, Replacement: replacement})
+	return r, nil
+}
+
+// WithTerms redacts literal terms (names, account numbers, ...) case-insensitively
+func (r *Redactor) WithTerms(replacement string, terms ...string) *Redactor {
+	for _, term := range terms {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+		r.rules = append(r.rules, RedactionRule{
+			Name:        "term",
+			Pattern:     regexp.MustCompile(`(?i)` + regexp.QuoteMeta(term)),
+			Replacement: replacement,
+		})
+	}
+	return r
+}
+
+// Redact applies all rules to text
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = rule.Pattern.ReplaceAllString(text, rule.Replacement)
+	}
+	return text
+}
+
+// redactValue redacts every string inside decoded JSON (maps, slices and scalars)
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return r.Redact(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = r.redactValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = r.redactValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// redactMap is redactValue for JSON objects
+func (r *Redactor) redactMap(m map[string]interface{}) map[string]interface{} {
+	if r == nil || m == nil {
+		return m
+	}
+	return r.redactValue(m).(map[string]interface{})
+}
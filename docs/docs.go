@@ -17,6 +17,11 @@ const docTemplate = `{
     "paths": {
         "/chat/history/{conversationID}": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Retrieves the entire chat history for a specific conversation.",
                 "produces": [
                     "application/json"
@@ -36,7 +41,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved chat history",
+                        "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -44,24 +49,97 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request - Missing or invalid Conversation ID",
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error - Failed to retrieve history",
+                        "description": "Failed to retrieve history",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     }
                 }
             }
         },
         "/chat/stream/{conversationID}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replays the conversation's buffered events after the Last-Event-ID header (or last_event_id query parameter) and follows the live stream until the turn finishes. Returns 204 when nothing is buffered, which stops EventSource from reconnecting.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Resume a chat stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Alternative to the Last-Event-ID header",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Server-Sent Events stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "No stream buffered for the conversation"
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "streams responses from the Gemini model via Server-Sent Events for a specific conversation",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams a turn as numbered Server-Sent Events named delta, tool_call, tool_result, trace, done and error. The turn keeps running if the client disconnects; reconnect with GET and Last-Event-ID.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "text/event-stream"
                 ],
@@ -78,12 +156,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "User Message Data",
-                        "name": "userRequest",
+                        "description": "User message or tool results",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User_Message"
+                            "$ref": "#/definitions/models.Model_Request"
                         }
                     }
                 ],
@@ -95,15 +173,33 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid input or Conversation ID",
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "A turn is already streaming on the conversation",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     },
                     "500": {
-                        "description": "Error processing request or server error",
+                        "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     }
                 }
@@ -111,10 +207,12 @@ const docTemplate = `{
         },
         "/chat/ws/{session_id}": {
             "get": {
-                "description": "Upgrade to WebSocket for interactive AI chat with streaming and tool confirmation.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Upgrade to WebSocket for interactive AI chat with streaming and tool confirmation. The message types are described by schemas/protocol/websocket.schema.json. Connections to the same session ID share its events and turns.",
                 "tags": [
                     "Chat"
                 ],
@@ -122,7 +220,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
+                        "description": "Session (conversation) ID",
                         "name": "session_id",
                         "in": "path",
                         "required": true
@@ -134,13 +232,33 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
                     }
                 }
             }
         },
         "/chat/{conversationID}": {
             "post": {
-                "description": "Sends a prompt to the AI agent and receives a response for a specific conversation.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sends a user message (or tool results) to the agent and returns the final response once all tool calls have run.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
@@ -157,12 +275,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "User Message Data",
-                        "name": "userRequest",
+                        "description": "User message or tool results",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User_Message"
+                            "$ref": "#/definitions/models.Model_Request"
                         }
                     }
                 ],
@@ -174,210 +292,1188 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid input or Conversation ID",
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     },
                     "404": {
-                        "description": "Not Found - Conversation not found (optional)",
+                        "description": "Conversation belongs to another user",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error - Error processing request",
+                        "description": "Error processing request",
                         "schema": {
-                            "$ref": "#/definitions/controllers.HTTPError"
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.HTTPError": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "message": {
-                    "type": "string",
-                    "example": "status bad request"
-                }
-            }
-        },
-        "models.ChatMessageResponse": {
-            "type": "object",
-            "properties": {
-                "conversation_id": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "description": "Time the message was created",
-                    "type": "string"
-                },
-                "function_id": {
-                    "description": "Associated function call ID (potentially linking bundles)",
-                    "type": "string"
-                },
-                "id": {
-                    "description": "Message primary key ID",
-                    "type": "integer"
-                },
-                "parts": {
-                    "description": "Unmarshalled parts array (e.g., []User_Part, []Model_Part)"
-                },
-                "role": {
-                    "description": "\"user\", \"model\"",
-                    "type": "string"
-                },
-                "sequence": {
-                    "type": "integer"
-                },
-                "text": {
-                    "description": "Primary text content, if applicable (extracted from parts)",
-                    "type": "string"
-                },
-                "type": {
-                    "description": "\"user_message\", \"model_message\", \"function_call\", \"function_response\"",
-                    "type": "string"
-                },
-                "updated_at": {
-                    "description": "Time the message was last updated",
-                    "type": "string"
-                }
-            }
         },
-        "models.Content": {
-            "type": "object",
-            "properties": {
-                "parts": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.User_Part"
+        "/conversations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the caller's conversations, most recently updated first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversations"
+                ],
+                "summary": "List conversations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/stores.ConversationInfo"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to list conversations",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
                     }
                 }
-            }
-        },
-        "models.FileData": {
-            "type": "object",
-            "properties": {
-                "fileUrl": {
-                    "type": "string"
-                },
-                "googleUri": {
-                    "type": "string"
-                },
-                "mimeType": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.FunctionCall": {
-            "type": "object",
-            "properties": {
-                "args": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "id": {
-                    "description": "Unique ID for this specific call instance",
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.FunctionResponse": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "response": {
-                    "type": "object",
-                    "additionalProperties": true
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an empty conversation owned by the caller. Conversations are also created by the first message sent to a new ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversations"
+                ],
+                "summary": "Create a conversation",
+                "parameters": [
+                    {
+                        "description": "Optional ID and title",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateConversationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/stores.ConversationInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conversation already exists",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to create conversation",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "501": {
+                        "description": "Store does not support conversation management",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
                 }
             }
         },
-        "models.ImageData": {
-            "type": "object",
+        "/conversations/{conversationID}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversations"
+                ],
+                "summary": "Get a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stores.ConversationInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "501": {
+                        "description": "Store does not support conversation management",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently deletes a conversation with its messages, feedback and execution traces.",
+                "tags": [
+                    "Conversations"
+                ],
+                "summary": "Delete a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete conversation",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "501": {
+                        "description": "Store does not support conversation management",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversations"
+                ],
+                "summary": "Rename a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New title",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RenameConversationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stores.ConversationInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "501": {
+                        "description": "Store does not support conversation management",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/conversations/{conversationID}/traces": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the tool execution traces recorded for a conversation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversations"
+                ],
+                "summary": "List execution traces",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/stores.ExecutionTrace"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch traces",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "501": {
+                        "description": "No trace store configured",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/chat/completions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Runs the agent registered as \"model\" on the conversation in \"messages\". With \"stream\" the completion is sent as chat.completion.chunk Server-Sent Events ending with [DONE]. Without \"tools\" the agent's tools run server-side; with \"tools\" tool calls are returned to the client. Set the X-Conversation-ID header to save the exchange to the message store.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "OpenAI"
+                ],
+                "summary": "Create a chat completion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation to save the exchange to",
+                        "name": "X-Conversation-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Chat completion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ChatCompletionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ChatCompletion"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.OpenAIErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.OpenAIErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown model or conversation",
+                        "schema": {
+                            "$ref": "#/definitions/server.OpenAIErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Model or tool error",
+                        "schema": {
+                            "$ref": "#/definitions/server.OpenAIErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/models": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the agents exposed through the OpenAI-compatible API.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenAI"
+                ],
+                "summary": "List models",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ModelList"
+                        }
+                    },
+                    "401": {
+                        "description": "Not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.OpenAIErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.ChatMessageResponse": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Time the message was created",
+                    "type": "string"
+                },
+                "function_id": {
+                    "description": "Associated function call ID (potentially linking bundles)",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Message primary key ID",
+                    "type": "integer"
+                },
+                "metadata": {
+                    "description": "Generation metadata for model messages",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MessageMetadata"
+                        }
+                    ]
+                },
+                "parts": {
+                    "description": "Unmarshalled parts array (e.g., []User_Part, []Model_Part)"
+                },
+                "role": {
+                    "description": "\"user\", \"model\"",
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "text": {
+                    "description": "Primary text content, if applicable (extracted from parts)",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"user_message\", \"model_message\", \"function_call\", \"function_response\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Time the message was last updated",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Content": {
+            "type": "object",
+            "properties": {
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User_Part"
+                    }
+                }
+            }
+        },
+        "models.FileData": {
+            "type": "object",
+            "properties": {
+                "fileUrl": {
+                    "type": "string"
+                },
+                "googleUri": {
+                    "type": "string"
+                },
+                "mimeType": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FunctionCall": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "id": {
+                    "description": "Unique ID for this specific call instance",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FunctionResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "The tool call ID this response is for",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "response": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "models.HistoryWarning": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "description": "Additional details (e.g., which message, what content)",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Human-readable description",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"unsupported_content\", \"conversion_error\", etc.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImageData": {
+            "type": "object",
+            "properties": {
+                "fileUrl": {
+                    "type": "string"
+                },
+                "googleUri": {
+                    "type": "string"
+                },
+                "mimeType": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.InlineData": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "string"
+                },
+                "mimeType": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MessageMetadata": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Set when the response was cut short by an error",
+                    "type": "string"
+                },
+                "finish_reason": {
+                    "description": "Provider stop reason, or \"cancelled\"/\"error\"",
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "description": "Milliseconds from request to end of response",
+                    "type": "integer"
+                },
+                "model": {
+                    "description": "Model name used for the call",
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "e.g. \"gemini\", \"anthropic\", \"openrouter\"",
+                    "type": "string"
+                },
+                "time_to_first_token_ms": {
+                    "description": "Milliseconds from request to first streamed chunk",
+                    "type": "integer"
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.Usage"
+                }
+            }
+        },
+        "models.Model_Part": {
+            "type": "object",
+            "properties": {
+                "functionCall": {
+                    "$ref": "#/definitions/models.FunctionCall"
+                },
+                "reasoning": {
+                    "description": "Chain-of-thought reasoning content",
+                    "type": "string"
+                },
+                "redacted_reasoning": {
+                    "description": "Encrypted thinking the provider withheld; replayed as-is",
+                    "type": "string"
+                },
+                "signature": {
+                    "description": "Signature is an opaque provider token that must be sent back with this part: it closes an\nAnthropic thinking block (following its Reasoning) or is a Gemini thought signature.",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Model_Request": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "Client_ID optionally identifies the calling client for prompt selection.",
+                    "type": "string"
+                },
+                "input_mode": {
+                    "description": "Input_Mode optionally indicates how the user provided the message.\nSupported values: \"text\" (default), \"voice\".",
+                    "type": "string"
+                },
+                "language_code": {
+                    "description": "Language_Code optionally indicates the user's preferred language.\nSupported values: \"en\" (English, default), \"es\" (Spanish), etc.",
+                    "type": "string"
+                },
+                "message": {
+                    "$ref": "#/definitions/models.User_Message"
+                },
+                "tool_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tool_Result"
+                    }
+                }
+            }
+        },
+        "models.Model_Response": {
+            "type": "object",
+            "properties": {
+                "finish_reason": {
+                    "description": "FinishReason and Usage are reported by the provider, typically on the final chunk of a stream.\nA chunk may carry only these fields and no parts.",
+                    "type": "string"
+                },
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Model_Part"
+                    }
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.Usage"
+                },
+                "warnings": {
+                    "description": "Warnings about history adaptation (only sent in first chunk)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HistoryWarning"
+                    }
+                }
+            }
+        },
+        "models.Tool_Result": {
+            "type": "object",
+            "properties": {
+                "tool_id": {
+                    "description": "The tool call ID to match with the tool call",
+                    "type": "string"
+                },
+                "tool_name": {
+                    "type": "string"
+                },
+                "tool_output": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Usage": {
+            "type": "object",
+            "properties": {
+                "cache_read_tokens": {
+                    "description": "Input tokens served from the prompt cache (included in InputTokens)",
+                    "type": "integer"
+                },
+                "cache_write_tokens": {
+                    "description": "Input tokens written to the prompt cache (included in InputTokens)",
+                    "type": "integer"
+                },
+                "input_tokens": {
+                    "type": "integer"
+                },
+                "output_tokens": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.User_Message": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "$ref": "#/definitions/models.Content"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User_Part": {
+            "type": "object",
+            "properties": {
+                "file_data": {
+                    "$ref": "#/definitions/models.FileData"
+                },
+                "function_response": {
+                    "$ref": "#/definitions/models.FunctionResponse"
+                },
+                "image_data": {
+                    "$ref": "#/definitions/models.ImageData"
+                },
+                "inline_data": {
+                    "$ref": "#/definitions/models.InlineData"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ChatCompletion": {
+            "type": "object",
             "properties": {
-                "fileUrl": {
+                "choices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ChatCompletionChoice"
+                    }
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "id": {
                     "type": "string"
                 },
-                "googleUri": {
+                "model": {
                     "type": "string"
                 },
-                "mimeType": {
+                "object": {
+                    "description": "\"chat.completion\" or \"chat.completion.chunk\"",
                     "type": "string"
+                },
+                "usage": {
+                    "$ref": "#/definitions/server.ChatCompletionUsage"
                 }
             }
         },
-        "models.InlineData": {
+        "server.ChatCompletionChoice": {
             "type": "object",
             "properties": {
-                "data": {
+                "delta": {
+                    "$ref": "#/definitions/server.ChatCompletionDelta"
+                },
+                "finish_reason": {
+                    "description": "stop, length or tool_calls; null in chunks before the last",
                     "type": "string"
                 },
-                "mimeType": {
+                "index": {
+                    "type": "integer"
+                },
+                "message": {
+                    "$ref": "#/definitions/server.ChatCompletionResponseMessage"
+                }
+            }
+        },
+        "server.ChatCompletionDelta": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ChatCompletionToolCall"
+                    }
                 }
             }
         },
-        "models.Model_Part": {
+        "server.ChatCompletionFunctionCall": {
             "type": "object",
             "properties": {
-                "functionCall": {
-                    "$ref": "#/definitions/models.FunctionCall"
+                "arguments": {
+                    "type": "string"
                 },
-                "text": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "models.Model_Response": {
+        "server.ChatCompletionMessage": {
             "type": "object",
             "properties": {
-                "parts": {
+                "content": {
+                    "description": "A string or an array of text and image_url parts",
+                    "type": "object"
+                },
+                "role": {
+                    "description": "system, developer, user, assistant or tool",
+                    "type": "string"
+                },
+                "tool_call_id": {
+                    "type": "string"
+                },
+                "tool_calls": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Model_Part"
+                        "$ref": "#/definitions/server.ChatCompletionToolCall"
                     }
                 }
             }
         },
-        "models.User_Message": {
+        "server.ChatCompletionRequest": {
+            "type": "object",
+            "required": [
+                "messages",
+                "model"
+            ],
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ChatCompletionMessage"
+                    }
+                },
+                "model": {
+                    "type": "string"
+                },
+                "stream": {
+                    "type": "boolean"
+                },
+                "stream_options": {
+                    "$ref": "#/definitions/server.ChatCompletionStreamOptions"
+                },
+                "tools": {
+                    "description": "Client-executed tools; when set, the agent's own tools are not used",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ChatCompletionTool"
+                    }
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ChatCompletionResponseMessage": {
             "type": "object",
             "properties": {
                 "content": {
-                    "$ref": "#/definitions/models.Content"
+                    "type": "string"
                 },
                 "role": {
                     "type": "string"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ChatCompletionToolCall"
+                    }
                 }
             }
         },
-        "models.User_Part": {
+        "server.ChatCompletionStreamOptions": {
             "type": "object",
             "properties": {
-                "file_data": {
-                    "$ref": "#/definitions/models.FileData"
+                "include_usage": {
+                    "description": "Send a final chunk with the usage of the whole completion",
+                    "type": "boolean"
+                }
+            }
+        },
+        "server.ChatCompletionTool": {
+            "type": "object",
+            "properties": {
+                "function": {
+                    "$ref": "#/definitions/server.ChatCompletionToolFunction"
                 },
-                "function_response": {
-                    "$ref": "#/definitions/models.FunctionResponse"
+                "type": {
+                    "description": "\"function\"",
+                    "type": "string"
+                }
+            }
+        },
+        "server.ChatCompletionToolCall": {
+            "type": "object",
+            "properties": {
+                "function": {
+                    "$ref": "#/definitions/server.ChatCompletionFunctionCall"
                 },
-                "image_data": {
-                    "$ref": "#/definitions/models.ImageData"
+                "id": {
+                    "type": "string"
                 },
-                "inline_data": {
-                    "$ref": "#/definitions/models.InlineData"
+                "index": {
+                    "description": "Only in streamed deltas",
+                    "type": "integer"
                 },
-                "text": {
+                "type": {
+                    "description": "\"function\"",
+                    "type": "string"
+                }
+            }
+        },
+        "server.ChatCompletionToolFunction": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parameters": {
+                    "description": "JSON Schema of the arguments",
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "server.ChatCompletionUsage": {
+            "type": "object",
+            "properties": {
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "prompt_tokens_details": {
+                    "$ref": "#/definitions/server.PromptTokensDetails"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.CreateConversationRequest": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "description": "Generated when empty",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.HTTPError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "type": "string",
+                    "example": "status bad request"
+                }
+            }
+        },
+        "server.ModelCard": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "object": {
+                    "description": "\"model\"",
+                    "type": "string"
+                },
+                "owned_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ModelList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ModelCard"
+                    }
+                },
+                "object": {
+                    "description": "\"list\"",
+                    "type": "string"
+                }
+            }
+        },
+        "server.OpenAIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "param": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.OpenAIErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/server.OpenAIError"
+                }
+            }
+        },
+        "server.PromptTokensDetails": {
+            "type": "object",
+            "properties": {
+                "cached_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.RenameConversationRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "stores.ConversationInfo": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "message_count": {
+                    "type": "integer"
+                },
+                "tenant_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "stores.ExecutionTrace": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "details": {
+                    "description": "Not stored, computed from DetailsJSON",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "span_id": {
+                    "description": "OpenTelemetry span ID of the tool call span",
+                    "type": "string"
+                },
+                "span_trace_id": {
+                    "description": "OpenTelemetry trace of the tool call span, if one was recording",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "start, progress, end, error",
+                    "type": "string"
+                },
+                "tenant_id": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "tool": {
+                    "type": "string"
+                },
+                "tool_call_id": {
+                    "type": "string"
+                },
+                "trace_id": {
                     "type": "string"
                 }
             }
@@ -395,12 +1491,12 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
+	Version:          "1.0",
 	Host:             "",
 	BasePath:         "",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "Godantic Chat API",
+	Description:      "Chat, streaming, WebSocket, conversation and trace endpoints mounted by the godantic server package.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/Desarso/godantic/common_tools"
 	models "github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/models/gemini"
+	"github.com/Desarso/godantic/sessions"
 	"github.com/Desarso/godantic/stores"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -159,11 +161,27 @@ func basicHTTPChatExample() {
 		// Create custom SSE writer for gin context
 		writer := &GinSSEWriter{Context: c}
 
-		// Run streaming interaction
-		ctx := context.Background()
-		err := session.RunSSEInteraction(*req.User_Message, writer, ctx)
-		if err != nil {
-			writer.WriteSSEError(err)
+		// Run streaming interaction. Named events (delta, tool_call, tool_result, trace, done, error)
+		// are numbered; the interaction keeps running if the client disconnects.
+		if err := session.RunSSEInteractionWithRequest(req, writer, c.Request.Context()); err != nil {
+			log.Printf("SSE interaction ended: %v", err)
+		}
+	})
+
+	// Resume a stream after a reconnect: replays the events after Last-Event-ID and follows the live stream
+	r.GET("/chat/stream/:conversationID", func(c *gin.Context) {
+		session := godantic.NewHTTPSession(c.Param("conversationID"), &agent, store)
+		writer := &GinSSEWriter{Context: c}
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+		err := session.ResumeSSE(lastEventID, writer, c.Request.Context())
+		if errors.Is(err, sessions.ErrSSEStreamNotFound) {
+			c.Status(http.StatusNoContent) // Tells EventSource to stop reconnecting
 		}
 	})
 
@@ -205,6 +223,12 @@ func (w *GinSSEWriter) WriteSSEError(err error) error {
 	return nil
 }
 
+// WriteSSEEvent writes a named event with an ID, so EventSource can send Last-Event-ID on reconnect
+func (w *GinSSEWriter) WriteSSEEvent(id, event, data string) error {
+	_, err := fmt.Fprintf(w.Context.Writer, "id: %s\nevent: %s\ndata: %s\n\n", id, event, data)
+	return err
+}
+
 func (w *GinSSEWriter) Flush() {
 	w.Context.Writer.Flush()
 }
@@ -296,6 +320,9 @@ func streamingChatExample() {
 func websocketSessionExample() {
 	fmt.Println("=== WebSocket Session Example ===")
 
+	// Connections to the same conversation (e.g. phone and desktop) share its stream
+	hub := godantic.NewSessionHub()
+
 	// This would typically be in a WebSocket handler
 	handleWebSocketConnection := func(conn *websocket.Conn, sessionID string) {
 		defer conn.Close()
@@ -311,25 +338,66 @@ func websocketSessionExample() {
 
 		// Create agent session (sessionID, userID, conn, agent, store, memory)
 		session := godantic.NewAgentSession(sessionID, "", conn, &agent, store, nil)
+		detach, err := hub.Attach(session)
+		if err != nil {
+			log.Printf("Attach failed: %v", err)
+			return
+		}
+		defer detach()
 
 		// Message loop
 		for {
-			var req models.Model_Request
-			if err := conn.ReadJSON(&req); err != nil {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
 					log.Printf("WebSocket error: %v", err)
 				}
 				break
 			}
 
-			// Run interaction - handles everything
-			if err := session.RunInteraction(req); err != nil {
+			// Clients negotiate the protocol version with {"type":"hello","versions":[1,2]}
+			if handled, _ := session.HandleHelloMessage(data); handled {
+				continue
+			}
+			// Reconnecting clients send {"type":"resume","session_id":"...","last_event_id":42}
+			// to replay missed events and reattach to a turn that is still running
+			if handled, _ := session.HandleResumeMessage(data); handled {
+				continue
+			}
+			// Feedback messages ({"type":"feedback","sequence":3,"rating":1}) are saved directly
+			if handled, _ := session.HandleFeedbackMessage(data); handled {
+				continue
+			}
+			// Memory management ({"type":"list_memories"} / {"type":"forget_memory","id":"..."})
+			if handled, _ := session.HandleMemoryMessage(data); handled {
+				continue
+			}
+
+			// User messages sent while a turn is running are queued for after it, or steer it
+			// at the next tool-loop boundary with {"message":{...},"delivery":"steer"}
+			if handled, _ := session.HandleUserInputMessage(data); handled {
+				continue
+			}
+
+			var req models.Model_Request
+			if err := json.Unmarshal(data, &req); err != nil {
+				log.Printf("Invalid request: %v", err)
+				continue
+			}
+
+			// Run interaction - handles everything. Interactions run one at a time in the
+			// background, in order, so this loop keeps reading messages during the turn.
+			session.EnqueueInteraction(req, func(err error) {
+				if err == nil {
+					return
+				}
 				if agentErr, ok := err.(*godantic.AgentError); ok && agentErr.Fatal {
 					log.Printf("Fatal error: %v", err)
-					break
+					conn.Close()
+					return
 				}
 				log.Printf("Non-fatal error: %v", err)
-			}
+			})
 		}
 
 		log.Printf("WebSocket session %s ended", sessionID)
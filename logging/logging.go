@@ -0,0 +1,184 @@
+// Package logging provides the structured loggers used across godantic.
+//
+// Components take a *slog.Logger (see WSConfig.WithLogger) and fall back to Default.
+// Loggers passed through New or Default redact secrets: attributes with credential-like
+// keys (api_key, authorization, password, ...) and API keys or bearer tokens embedded in
+// messages, error strings and URLs.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Attribute keys shared by godantic's log records
+const (
+	KeySessionID = "session_id"
+	KeyUserID    = "user_id"
+	KeyTenantID  = "tenant_id"
+	KeyTool      = "tool"
+	KeyModel     = "model"
+	KeyProvider  = "provider"
+	KeyComponent = "component"
+	KeyError     = "error"
+)
+
+// Redacted replaces redacted values
+const Redacted = "[REDACTED]"
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *slog.Logger
+)
+
+// Default returns the logger used when none is configured: the one set with SetDefault,
+// or slog.Default() with redaction
+func Default() *slog.Logger {
+	defaultMu.RLock()
+	logger := defaultLogger
+	defaultMu.RUnlock()
+	if logger != nil {
+		return logger
+	}
+	return New(slog.Default().Handler())
+}
+
+// SetDefault replaces the fallback logger; nil restores slog.Default(). Secrets are redacted either way.
+func SetDefault(logger *slog.Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if logger == nil {
+		defaultLogger = nil
+		return
+	}
+	defaultLogger = Redact(logger)
+}
+
+// Or returns logger, or Default when it is nil
+func Or(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return Default()
+	}
+	return logger
+}
+
+// New returns a logger writing to handler with secrets redacted
+func New(handler slog.Handler) *slog.Logger {
+	if _, ok := handler.(*redactingHandler); ok {
+		return slog.New(handler)
+	}
+	return slog.New(&redactingHandler{next: handler})
+}
+
+// Redact returns logger with secrets redacted. Loggers that already redact are returned as is.
+func Redact(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return nil
+	}
+	if _, ok := logger.Handler().(*redactingHandler); ok {
+		return logger
+	}
+	return New(logger.Handler())
+}
+
+// sensitiveKeys are attribute keys whose values are always redacted
+var sensitiveKeys = map[string]bool{
+	"api_key":       true,
+	"apikey":        true,
+	"x-api-key":     true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range []string{"_api_key", "_secret", "_password", "_token"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretPatterns match credentials embedded in free text; the first group, if any, is kept
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:bearer|basic)\s+)[a-z0-9._~+/=-]{8,}`),
+	regexp.MustCompile(`(?i)([?&](?:key|api_key|apikey|token|access_token)=)[^&\s"']+`),
+	regexp.MustCompile(`(?i)((?:x-api-key|api[_-]?key|password|secret)["']?\s*[:=]\s*["']?)[^\s"',}]{6,}`),
+	regexp.MustCompile(`\b()(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\b()AIza[0-9A-Za-z_-]{30,}`),
+	regexp.MustCompile(`\b()gsk_[A-Za-z0-9]{20,}`),
+}
+
+// RedactString replaces credentials found in s
+func RedactString(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+// redactingHandler removes secrets from records before passing them on
+type redactingHandler struct {
+	next slog.Handler
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, RedactString(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	if isSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactString(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, RedactString(err.Error()))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
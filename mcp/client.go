@@ -0,0 +1,474 @@
+// Package mcp connects godantic agents to Model Context Protocol servers. A Client speaks
+// MCP over a Transport (a stdio subprocess or streamable HTTP), discovers the server's tools
+// and exposes them as models.FunctionDeclarations whose Callables proxy tools/call:
+//
+//	github := mcp.NewClient("github", mcp.NewStdioTransport("github-mcp-server", "stdio"))
+//	defer github.Close()
+//	tools, err := github.Tools(ctx) // github__create_issue, github__search_code, ...
+//	agent := godantic.Create_Agent(model, append(localTools, tools...))
+//
+// Resources and prompts are available through ListResources, ReadResource, ListPrompts
+// and GetPrompt. A lost connection is re-established, and the request retried, on the
+// next request.
+//
+// Server does the reverse: it publishes an agent's tools to MCP clients over stdio or
+// streamable HTTP.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Desarso/godantic/logging"
+)
+
+// DefaultTimeout bounds requests whose context has no deadline
+const DefaultTimeout = 60 * time.Second
+
+// NotificationHandler receives notifications sent by the server, e.g. notifications/tools/list_changed
+type NotificationHandler func(method string, params json.RawMessage)
+
+// Client is a connection to one MCP server. It is safe for concurrent use.
+type Client struct {
+	name          string
+	transport     Transport
+	clientInfo    Implementation
+	timeout       time.Duration
+	maxReconnects int
+	resourceTool  bool
+	onNotify      NotificationHandler
+
+	mu   sync.Mutex
+	conn *connection
+	info InitializeResult
+
+	progressMu    sync.Mutex
+	progress      map[string]func(Progress)
+	progressToken int64
+}
+
+// connection is one initialized session; a reconnect replaces it
+type connection struct {
+	mu      sync.Mutex
+	pending map[string]chan message
+	nextID  int64
+	closed  chan struct{}
+	lost    bool
+}
+
+// NewClient creates a client for the server reached through transport. name namespaces the
+// server's tools (name__tool) so several servers can share an agent.
+func NewClient(name string, transport Transport) *Client {
+	return &Client{
+		name:          name,
+		transport:     transport,
+		clientInfo:    Implementation{Name: "godantic", Version: "1.0.0"},
+		timeout:       DefaultTimeout,
+		maxReconnects: 1,
+	}
+}
+
+// WithClientInfo sets the client name and version sent to the server
+func (c *Client) WithClientInfo(name, version string) *Client {
+	c.clientInfo = Implementation{Name: name, Version: version}
+	return c
+}
+
+// WithTimeout sets the timeout of requests whose context has no deadline, including tool calls
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// WithMaxReconnects sets how many times a request reconnects and retries after the
+// connection is lost (default 1, 0 disables reconnection)
+func (c *Client) WithMaxReconnects(n int) *Client {
+	c.maxReconnects = n
+	return c
+}
+
+// WithResourceTool makes Tools include a name__read_resource tool that lets the model read
+// the server's resources
+func (c *Client) WithResourceTool() *Client {
+	c.resourceTool = true
+	return c
+}
+
+// WithNotificationHandler sets the handler for server notifications
+func (c *Client) WithNotificationHandler(handler NotificationHandler) *Client {
+	c.onNotify = handler
+	return c
+}
+
+// Name returns the namespace of the client's tools
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Connect starts the transport and performs the initialize handshake. Requests connect
+// on demand, so calling it is only needed to surface connection errors early.
+func (c *Client) Connect(ctx context.Context) error {
+	_, err := c.connection(ctx)
+	return err
+}
+
+// ServerInfo returns the initialize result of the current connection
+func (c *Client) ServerInfo() InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.info
+}
+
+// Close ends the connection to the server
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.disconnect()
+}
+
+// disconnect closes the current connection; c.mu must be held
+func (c *Client) disconnect() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.transport.Close()
+	close(c.conn.closed)
+	c.conn.fail()
+	c.conn = nil
+	return err
+}
+
+// connection returns the live connection, connecting (again) if needed
+func (c *Client) connection(ctx context.Context) (*connection, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && !c.conn.isLost() {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		logging.Default().Info("Reconnecting to MCP server", logging.KeyComponent, "mcp", "server", c.name)
+		c.disconnect()
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	messages, err := c.transport.Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MCP server %s: %w", c.name, err)
+	}
+	conn := &connection{pending: map[string]chan message{}, closed: make(chan struct{})}
+	c.conn = conn
+	go c.read(conn, messages)
+
+	params := initializeParams{ProtocolVersion: ProtocolVersion, Capabilities: map[string]interface{}{}, ClientInfo: c.clientInfo}
+	var info InitializeResult
+	if err := c.send(ctx, conn, "initialize", params, &info); err != nil {
+		c.disconnect()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", c.name, err)
+	}
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		c.disconnect()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", c.name, err)
+	}
+	c.info = info
+	return conn, nil
+}
+
+// read dispatches the messages of one connection until it ends
+func (c *Client) read(conn *connection, messages <-chan json.RawMessage) {
+	for {
+		select {
+		case raw, ok := <-messages:
+			if !ok {
+				conn.fail()
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				logging.Default().Warn("Dropping invalid MCP message", logging.KeyComponent, "mcp", "server", c.name, logging.KeyError, err)
+				continue
+			}
+			c.dispatch(conn, msg)
+		case <-conn.closed:
+			return
+		}
+	}
+}
+
+func (c *Client) dispatch(conn *connection, msg message) {
+	switch {
+	case msg.Method != "" && len(msg.ID) > 0:
+		// Requests from the server: answer pings, decline everything else (sampling, roots, ...)
+		reply := message{JSONRPC: "2.0", ID: msg.ID}
+		if msg.Method == "ping" {
+			reply.Result = json.RawMessage("{}")
+		} else {
+			reply.Error = &RPCError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method}
+		}
+		go func() {
+			ctx, cancel := c.withTimeout(context.Background())
+			defer cancel()
+			if raw, err := json.Marshal(reply); err == nil {
+				c.transport.Send(ctx, raw)
+			}
+		}()
+	case msg.Method == "notifications/progress" && c.reportProgress(msg.Params):
+	case msg.Method != "":
+		if c.onNotify != nil {
+			c.onNotify(msg.Method, msg.Params)
+		}
+	default:
+		conn.mu.Lock()
+		waiter, ok := conn.pending[string(msg.ID)]
+		delete(conn.pending, string(msg.ID))
+		conn.mu.Unlock()
+		if ok {
+			waiter <- msg
+		}
+	}
+}
+
+// fail marks the connection lost and fails its pending requests
+func (conn *connection) fail() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.lost = true
+	for id, waiter := range conn.pending {
+		close(waiter)
+		delete(conn.pending, id)
+	}
+}
+
+func (conn *connection) isLost() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.lost
+}
+
+// call sends a request, reconnecting and retrying when the connection is lost
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		conn, err := c.connection(ctx)
+		if err != nil {
+			return err
+		}
+		err = c.send(ctx, conn, method, params, result)
+		if !errors.Is(err, ErrConnectionClosed) || attempt >= c.maxReconnects {
+			return err
+		}
+		conn.fail()
+		logging.Default().Info("Retrying MCP request", logging.KeyComponent, "mcp", "server", c.name, "method", method, logging.KeyError, err)
+	}
+}
+
+// send sends one request on conn and decodes its result
+func (c *Client) send(ctx context.Context, conn *connection, method string, params, result interface{}) error {
+	conn.mu.Lock()
+	if conn.lost {
+		conn.mu.Unlock()
+		return ErrConnectionClosed
+	}
+	conn.nextID++
+	id := strconv.FormatInt(conn.nextID, 10)
+	waiter := make(chan message, 1)
+	conn.pending[id] = waiter
+	conn.mu.Unlock()
+
+	raw, err := encode(json.RawMessage(id), method, params)
+	if err == nil {
+		err = c.transport.Send(ctx, raw)
+	}
+	if err != nil {
+		conn.mu.Lock()
+		delete(conn.pending, id)
+		conn.mu.Unlock()
+		return err
+	}
+
+	select {
+	case msg, ok := <-waiter:
+		if !ok {
+			return ErrConnectionClosed
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		conn.mu.Lock()
+		delete(conn.pending, id)
+		conn.mu.Unlock()
+		c.notify(context.Background(), "notifications/cancelled", map[string]interface{}{"requestId": json.RawMessage(id), "reason": ctx.Err().Error()})
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+// notify sends a notification
+func (c *Client) notify(ctx context.Context, method string, params interface{}) error {
+	raw, err := encode(nil, method, params)
+	if err != nil {
+		return err
+	}
+	return c.transport.Send(ctx, raw)
+}
+
+func encode(id json.RawMessage, method string, params interface{}) (json.RawMessage, error) {
+	msg := message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	return json.Marshal(msg)
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// ListTools returns every tool the server offers
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var page listToolsResult
+		if err := c.call(ctx, "tools/list", cursorParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list tools of %s: %w", c.name, err)
+		}
+		tools = append(tools, page.Tools...)
+		if cursor = page.NextCursor; cursor == "" {
+			return tools, nil
+		}
+	}
+}
+
+// CallTool calls a tool by its name on the server (without namespace). A tool that
+// reports an error returns a result with IsError set, not an error.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, fmt.Errorf("failed to call %s on %s: %w", name, c.name, err)
+	}
+	return &result, nil
+}
+
+// CallToolWithProgress calls a tool like CallTool and passes the server's progress
+// notifications for the call to onProgress
+func (c *Client) CallToolWithProgress(ctx context.Context, name string, args map[string]interface{}, onProgress func(Progress)) (*CallToolResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	c.progressMu.Lock()
+	if c.progress == nil {
+		c.progress = map[string]func(Progress){}
+	}
+	c.progressToken++
+	token := strconv.FormatInt(c.progressToken, 10)
+	c.progress[token] = onProgress
+	c.progressMu.Unlock()
+	defer func() {
+		c.progressMu.Lock()
+		delete(c.progress, token)
+		c.progressMu.Unlock()
+	}()
+
+	params := callToolParams{Name: name, Arguments: args, Meta: &requestMeta{ProgressToken: json.RawMessage(token)}}
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to call %s on %s: %w", name, c.name, err)
+	}
+	return &result, nil
+}
+
+// reportProgress passes a progress notification to the handler of its call, reporting
+// whether one was registered
+func (c *Client) reportProgress(params json.RawMessage) bool {
+	var progress Progress
+	if err := json.Unmarshal(params, &progress); err != nil {
+		return false
+	}
+	c.progressMu.Lock()
+	onProgress, ok := c.progress[string(progress.ProgressToken)]
+	c.progressMu.Unlock()
+	if ok {
+		onProgress(progress)
+	}
+	return ok
+}
+
+// ListResources returns every resource the server offers
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	cursor := ""
+	for {
+		var page listResourcesResult
+		if err := c.call(ctx, "resources/list", cursorParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list resources of %s: %w", c.name, err)
+		}
+		resources = append(resources, page.Resources...)
+		if cursor = page.NextCursor; cursor == "" {
+			return resources, nil
+		}
+	}
+}
+
+// ReadResource returns the contents of the resource at uri
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	var result readResourceResult
+	if err := c.call(ctx, "resources/read", map[string]string{"uri": uri}, &result); err != nil {
+		return nil, fmt.Errorf("failed to read %s from %s: %w", uri, c.name, err)
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts returns every prompt template the server offers
+func (c *Client) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var prompts []Prompt
+	cursor := ""
+	for {
+		var page listPromptsResult
+		if err := c.call(ctx, "prompts/list", cursorParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list prompts of %s: %w", c.name, err)
+		}
+		prompts = append(prompts, page.Prompts...)
+		if cursor = page.NextCursor; cursor == "" {
+			return prompts, nil
+		}
+	}
+}
+
+// GetPrompt expands the prompt template name with args
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error) {
+	params := map[string]interface{}{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+	var result GetPromptResult
+	if err := c.call(ctx, "prompts/get", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s from %s: %w", name, c.name, err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,197 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Desarso/godantic/logging"
+)
+
+// sessionHeader carries the session ID assigned by a streamable HTTP server
+const sessionHeader = "Mcp-Session-Id"
+
+// HTTPTransport talks to an MCP server over the streamable HTTP transport: every message is
+// POSTed to the endpoint and the server answers with JSON or an SSE stream. The session ID
+// the server assigns during initialization is sent with every later request; when the server
+// forgets it (404) the connection is reported closed so the client re-initializes.
+type HTTPTransport struct {
+	url     string
+	headers http.Header
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+	messages  chan json.RawMessage
+	closed    chan struct{}
+}
+
+// NewHTTPTransport creates a transport for the MCP endpoint at url
+func NewHTTPTransport(url string) *HTTPTransport {
+	return &HTTPTransport{url: url, headers: http.Header{}, client: http.DefaultClient}
+}
+
+// WithHeader adds a header to every request, e.g. Authorization
+func (t *HTTPTransport) WithHeader(key, value string) *HTTPTransport {
+	t.headers.Add(key, value)
+	return t
+}
+
+// WithHTTPClient sets the HTTP client used for requests
+func (t *HTTPTransport) WithHTTPClient(client *http.Client) *HTTPTransport {
+	t.client = client
+	return t
+}
+
+// Start begins a new session. No request is made until the first message is sent.
+func (t *HTTPTransport) Start(ctx context.Context) (<-chan json.RawMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionID = ""
+	t.messages = make(chan json.RawMessage, 16)
+	t.closed = make(chan struct{})
+	return t.messages, nil
+}
+
+// Send POSTs msg and delivers the server's reply, if any, on the message channel
+func (t *HTTPTransport) Send(ctx context.Context, msg json.RawMessage) error {
+	t.mu.Lock()
+	sessionID, messages, closed := t.sessionID, t.messages, t.closed
+	t.mu.Unlock()
+	if messages == nil {
+		return ErrConnectionClosed
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(msg))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	t.setHeaders(req, sessionID)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && sessionID != "":
+		resp.Body.Close()
+		return fmt.Errorf("%w: session expired", ErrConnectionClosed)
+	case resp.StatusCode >= 400:
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("MCP server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	case resp.StatusCode == http.StatusAccepted:
+		resp.Body.Close()
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		go t.readStream(resp.Body, messages, closed)
+		return nil
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+	deliver(bytes.TrimSpace(body), messages, closed)
+	return nil
+}
+
+// readStream delivers the data of every SSE event in body
+func (t *HTTPTransport) readStream(body io.ReadCloser, messages chan json.RawMessage, closed chan struct{}) {
+	defer body.Close()
+	var data []string
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				deliver([]byte(strings.Join(data, "\n")), messages, closed)
+				data = nil
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logging.Default().Info("MCP event stream ended", logging.KeyComponent, "mcp", "url", t.url, logging.KeyError, err)
+	}
+}
+
+// deliver queues a message, or each message of a JSON-RPC batch, unless the transport was closed
+func deliver(body []byte, messages chan json.RawMessage, closed chan struct{}) {
+	if len(body) == 0 {
+		return
+	}
+	batch := []json.RawMessage{body}
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &batch); err != nil {
+			logging.Default().Warn("Dropping invalid MCP message batch", logging.KeyComponent, "mcp", logging.KeyError, err)
+			return
+		}
+	}
+	for _, msg := range batch {
+		select {
+		case messages <- msg:
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Close ends the session on the server
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	sessionID, closed := t.sessionID, t.closed
+	t.sessionID, t.messages, t.closed = "", nil, nil
+	t.mu.Unlock()
+	if closed == nil {
+		return nil
+	}
+	close(closed)
+
+	if sessionID == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	t.setHeaders(req, sessionID)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to end MCP session: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *HTTPTransport) setHeaders(req *http.Request, sessionID string) {
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+	if sessionID != "" {
+		req.Header.Set(sessionHeader, sessionID)
+	}
+}
@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Desarso/godantic"
+)
+
+// fixtureEnv makes the test binary act as a stdio MCP server
+const fixtureEnv = "GODANTIC_MCP_FIXTURE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fixtureEnv) == "stdio" {
+		serveStdio()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveStdio answers newline-delimited requests on stdin. The "crash" tool exits after answering.
+func serveStdio() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || len(msg.ID) == 0 {
+			continue
+		}
+		reply, _ := json.Marshal(fixtureReply(msg))
+		fmt.Println(string(reply))
+		if strings.Contains(string(msg.Params), `"crash"`) {
+			os.Exit(1)
+		}
+	}
+}
+
+// fixtureReply answers one request as a small MCP server with tools, resources and prompts
+func fixtureReply(msg message) message {
+	var params map[string]interface{}
+	json.Unmarshal(msg.Params, &params)
+	result := func(v interface{}) message {
+		raw, _ := json.Marshal(v)
+		return message{JSONRPC: "2.0", ID: msg.ID, Result: raw}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return result(map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "resources": map[string]interface{}{}, "prompts": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "fixture", "version": "0.1.0"},
+		})
+	case "tools/list":
+		// Two pages, to exercise pagination
+		if params["cursor"] == nil {
+			return result(map[string]interface{}{
+				"tools": []map[string]interface{}{{
+					"name":        "echo",
+					"description": "Echo a message",
+					"inputSchema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"message": map[string]string{"type": "string"}, "times": map[string]string{"type": "integer"}}, "required": []string{"message"}},
+				}},
+				"nextCursor": "page-2",
+			})
+		}
+		return result(map[string]interface{}{"tools": []map[string]interface{}{
+			{"name": "fail", "inputSchema": map[string]string{"type": "object"}},
+			{"name": "crash", "inputSchema": map[string]string{"type": "object"}},
+		}})
+	case "tools/call":
+		args, _ := params["arguments"].(map[string]interface{})
+		switch params["name"] {
+		case "echo":
+			text := strings.Repeat(fmt.Sprint(args["message"]), int(args["times"].(float64)))
+			return result(map[string]interface{}{"content": []map[string]string{{"type": "text", "text": text}}})
+		case "fail":
+			return result(map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "it broke"}}, "isError": true})
+		default:
+			return result(map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "bye"}}})
+		}
+	case "resources/list":
+		return result(map[string]interface{}{"resources": []map[string]string{{"uri": "file:///readme.md", "name": "readme", "mimeType": "text/markdown"}}})
+	case "resources/read":
+		return result(map[string]interface{}{"contents": []map[string]string{{"uri": fmt.Sprint(params["uri"]), "text": "# Fixture"}}})
+	case "prompts/list":
+		return result(map[string]interface{}{"prompts": []map[string]interface{}{{"name": "greet", "arguments": []map[string]interface{}{{"name": "who", "required": true}}}}})
+	case "prompts/get":
+		args, _ := params["arguments"].(map[string]interface{})
+		return result(map[string]interface{}{"messages": []map[string]interface{}{{"role": "user", "content": map[string]string{"type": "text", "text": fmt.Sprintf("Say hello to %v", args["who"])}}}})
+	}
+	return message{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: codeMethodNotFound, Message: "unknown method " + msg.Method}}
+}
+
+func newStdioClient(t *testing.T) *Client {
+	t.Helper()
+	client := NewClient("fixture", NewStdioTransport(os.Args[0]).WithEnv(fixtureEnv+"=stdio"))
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestStdioClient_ToolsRunThroughAgent(t *testing.T) {
+	client := newStdioClient(t)
+	tools, err := client.Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools: %v", err)
+	}
+	if len(tools) != 3 || tools[0].Name != "fixture__echo" || tools[2].Name != "fixture__crash" {
+		t.Fatalf("Expected namespaced tools from both pages, got %+v", tools)
+	}
+	if tools[0].Parameters.Required[0] != "message" || tools[1].Parameters.Properties == nil {
+		t.Errorf("Unexpected parameters %+v / %+v", tools[0].Parameters, tools[1].Parameters)
+	}
+	if info := client.ServerInfo(); info.ServerInfo.Name != "fixture" {
+		t.Errorf("Unexpected server info %+v", info)
+	}
+
+	agent := godantic.Create_Agent(nil, tools)
+	result, err := agent.ExecuteTool("fixture__echo", map[string]interface{}{"message": "ab", "times": 2}, "s1")
+	if err != nil || result != `{"result":"abab"}` {
+		t.Errorf("Expected the echoed text, got %s (%v)", result, err)
+	}
+	result, err = agent.ExecuteTool("fixture__fail", nil, "s1")
+	if err == nil || result != `{"error":"it broke"}` {
+		t.Errorf("Expected the tool error, got %s (%v)", result, err)
+	}
+}
+
+func TestStdioClient_ReconnectsAfterServerExit(t *testing.T) {
+	client := newStdioClient(t)
+	ctx := context.Background()
+	if _, err := client.CallTool(ctx, "crash", nil); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	result, err := client.CallTool(ctx, "echo", map[string]interface{}{"message": "x", "times": 3})
+	if err != nil || result.Text() != "xxx" {
+		t.Errorf("Expected the call to succeed on a new server process, got %+v (%v)", result, err)
+	}
+}
+
+func TestStdioClient_ResourcesAndPrompts(t *testing.T) {
+	client := newStdioClient(t).WithResourceTool()
+	ctx := context.Background()
+
+	resources, err := client.ListResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "file:///readme.md" {
+		t.Fatalf("Unexpected resources %+v (%v)", resources, err)
+	}
+	tools, err := client.Tools(ctx)
+	if err != nil {
+		t.Fatalf("Tools: %v", err)
+	}
+	agent := godantic.Create_Agent(nil, tools)
+	if result, err := agent.ExecuteTool("fixture__read_resource", map[string]interface{}{"uri": "file:///readme.md"}, "s1"); err != nil || result != `{"result":"# Fixture"}` {
+		t.Errorf("Expected the resource text, got %s (%v)", result, err)
+	}
+
+	prompts, err := client.ListPrompts(ctx)
+	if err != nil || len(prompts) != 1 || !prompts[0].Arguments[0].Required {
+		t.Fatalf("Unexpected prompts %+v (%v)", prompts, err)
+	}
+	prompt, err := client.GetPrompt(ctx, "greet", map[string]string{"who": "Ada"})
+	if err != nil || prompt.Messages[0].Content.Text != "Say hello to Ada" {
+		t.Errorf("Unexpected prompt %+v (%v)", prompt, err)
+	}
+}
+
+// httpFixture serves the fixture over streamable HTTP, answering tool calls as SSE.
+// Sessions can be dropped to simulate a server restart.
+type httpFixture struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	next     int
+}
+
+func (f *httpFixture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var msg message
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&msg) != nil {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if msg.Method == "initialize" {
+		f.next++
+		id := fmt.Sprintf("session-%d", f.next)
+		f.sessions[id] = true
+		w.Header().Set(sessionHeader, id)
+	} else if !f.sessions[r.Header.Get(sessionHeader)] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(msg.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	reply, _ := json.Marshal(fixtureReply(msg))
+	if msg.Method == "tools/call" {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", reply)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(reply)
+}
+
+func TestHTTPClient_StreamsResultsAndRenewsExpiredSessions(t *testing.T) {
+	fixture := &httpFixture{sessions: map[string]bool{}}
+	server := httptest.NewServer(fixture)
+	defer server.Close()
+
+	client := NewClient("remote", NewHTTPTransport(server.URL))
+	defer client.Close()
+	ctx := context.Background()
+
+	result, err := client.CallTool(ctx, "echo", map[string]interface{}{"message": "hi", "times": 1})
+	if err != nil || result.Text() != "hi" {
+		t.Fatalf("Expected the SSE result, got %+v (%v)", result, err)
+	}
+
+	fixture.mu.Lock()
+	fixture.sessions = map[string]bool{}
+	fixture.mu.Unlock()
+
+	tools, err := client.Tools(ctx)
+	if err != nil || len(tools) != 3 || tools[0].Name != "remote__echo" {
+		t.Fatalf("Expected the tools after re-initializing, got %+v (%v)", tools, err)
+	}
+	if fixture.next != 2 {
+		t.Errorf("Expected a second session, got %d", fixture.next)
+	}
+}
+
+func TestClient_ToolNameIsSanitized(t *testing.T) {
+	client := NewClient("my server", nil)
+	if name := client.ToolName("get.file"); name != "my_server__get_file" {
+		t.Errorf("Unexpected tool name %q", name)
+	}
+	long := client.ToolName(strings.Repeat("x", 100) + "_read")
+	if len(long) != maxToolNameLength {
+		t.Errorf("Expected names truncated to %d characters, got %d", maxToolNameLength, len(long))
+	}
+	if other := client.ToolName(strings.Repeat("x", 100) + "_write"); other == long || len(other) != maxToolNameLength {
+		t.Errorf("Expected tools sharing a long prefix to get distinct names, got %q and %q", long, other)
+	}
+	if again := client.ToolName(strings.Repeat("x", 100) + "_read"); again != long {
+		t.Errorf("Expected truncated names to be stable, got %q and %q", long, again)
+	}
+}
+
+func TestTools_RejectsDuplicateNames(t *testing.T) {
+	first := NewClient("fixture.a", NewStdioTransport(os.Args[0]).WithEnv(fixtureEnv+"=stdio"))
+	second := NewClient("fixture_a", NewStdioTransport(os.Args[0]).WithEnv(fixtureEnv+"=stdio"))
+	t.Cleanup(func() {
+		first.Close()
+		second.Close()
+	})
+
+	if _, err := Tools(context.Background(), first); err != nil {
+		t.Fatalf("Tools: %v", err)
+	}
+	_, err := Tools(context.Background(), first, second)
+	if err == nil || !strings.Contains(err.Error(), "fixture_a__echo") {
+		t.Errorf("Expected a duplicate name error, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the MCP revision requested during initialization
+const ProtocolVersion = "2025-03-26"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// message is a JSON-RPC 2.0 request, notification or response
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error returned by an MCP server
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("MCP error %d: %s", e.Code, e.Message)
+}
+
+// Implementation names an MCP client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ServerCapabilities lists the features an MCP server supports. A nil field means unsupported.
+type ServerCapabilities struct {
+	Tools     json.RawMessage `json:"tools,omitempty"`
+	Resources json.RawMessage `json:"resources,omitempty"`
+	Prompts   json.RawMessage `json:"prompts,omitempty"`
+	Logging   json.RawMessage `json:"logging,omitempty"`
+}
+
+// InitializeResult is the server's answer to the initialize handshake
+type InitializeResult struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ServerCapabilities `json:"capabilities"`
+	ServerInfo      Implementation     `json:"serverInfo"`
+	Instructions    string             `json:"instructions,omitempty"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      Implementation         `json:"clientInfo"`
+}
+
+// Tool is a tool advertised by an MCP server
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+type listToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Meta      *requestMeta           `json:"_meta,omitempty"`
+}
+
+// requestMeta carries the token under which a request's progress is reported
+type requestMeta struct {
+	ProgressToken json.RawMessage `json:"progressToken,omitempty"`
+}
+
+// Progress is a progress notification for a running request
+type Progress struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// Content is one item of a tool result or prompt message: text, image, audio, an embedded
+// resource or a resource link
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	Name     string            `json:"name,omitempty"`
+}
+
+// CallToolResult is the result of a tools/call request
+type CallToolResult struct {
+	Content           []Content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Text renders the result for a model: text items are joined by newlines and binary
+// items are replaced by a short placeholder. Results with only structured content
+// return its JSON.
+func (r *CallToolResult) Text() string {
+	var parts []string
+	for _, content := range r.Content {
+		switch content.Type {
+		case "text":
+			parts = append(parts, content.Text)
+		case "resource":
+			if content.Resource != nil && content.Resource.Text != "" {
+				parts = append(parts, content.Resource.Text)
+			} else if content.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[resource %s]", content.Resource.URI))
+			}
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("[resource %s]", content.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s %s]", content.Type, content.MimeType))
+		}
+	}
+	if len(parts) == 0 && len(r.StructuredContent) > 0 {
+		return string(r.StructuredContent)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Resource is a resource advertised by an MCP server
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type listResourcesResult struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// ResourceContents is the content of a resource: Text for text resources, base64 Blob otherwise
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+type readResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// Prompt is a prompt template advertised by an MCP server
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument of a prompt template
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type listPromptsResult struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// PromptMessage is one message of an expanded prompt
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// GetPromptResult is an expanded prompt template
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+type cursorParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
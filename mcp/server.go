@@ -0,0 +1,372 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/common_tools"
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/stores"
+	"github.com/Desarso/godantic/telemetry"
+	"github.com/google/uuid"
+)
+
+// supportedVersions are the protocol revisions the server accepts from clients
+var supportedVersions = map[string]bool{"2024-11-05": true, "2025-03-26": true, "2025-06-18": true}
+
+// Default HTTP session limits (see WithSessionLimits)
+const (
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	DefaultMaxSessions        = 1000
+)
+
+// ApprovalHandler decides on tool calls the agent's approval policy did not auto-approve
+type ApprovalHandler func(ctx context.Context, name string, args map[string]interface{}) (bool, error)
+
+// HTTPAuthenticator rejects unauthenticated HTTP requests by returning an error (sent as 401)
+type HTTPAuthenticator func(r *http.Request) error
+
+// Server publishes an agent's tools to MCP clients over stdio (ServeStdio) or streamable
+// HTTP (ServeHTTP). Calls run through Agent.ApproveTool and Agent.ExecuteTool; their start,
+// end and error traces are saved to the trace store and sent as progress notifications
+// to clients that ask for progress.
+type Server struct {
+	info         Implementation
+	instructions string
+	agent        *godantic.Agent
+	approve      ApprovalHandler
+	traceStore   stores.TraceStore
+	authenticate HTTPAuthenticator
+	sessionIdle  time.Duration
+	maxSessions  int
+
+	mu       sync.Mutex
+	sessions map[string]time.Time // Open HTTP sessions and when they were last used
+}
+
+// NewServer creates a server publishing agent.Tools. The agent's model is not used, so
+// godantic.Create_Agent(nil, tools) is enough.
+func NewServer(name, version string, agent *godantic.Agent) *Server {
+	return &Server{
+		info:        Implementation{Name: name, Version: version},
+		agent:       agent,
+		sessionIdle: DefaultSessionIdleTimeout,
+		maxSessions: DefaultMaxSessions,
+		sessions:    map[string]time.Time{},
+	}
+}
+
+// WithInstructions sets the usage instructions sent to clients during initialization
+func (s *Server) WithInstructions(instructions string) *Server {
+	s.instructions = instructions
+	return s
+}
+
+// WithApprovalHandler sets the handler asked about calls the agent does not auto-approve.
+// Without one such calls are rejected.
+func (s *Server) WithApprovalHandler(approve ApprovalHandler) *Server {
+	s.approve = approve
+	return s
+}
+
+// WithTraceStore saves the traces of every tool call, keyed by MCP session and request ID
+func (s *Server) WithTraceStore(store stores.TraceStore) *Server {
+	s.traceStore = store
+	return s
+}
+
+// WithAuthenticator sets the hook that rejects unauthenticated HTTP requests, e.g. BearerToken.
+// Without one ServeHTTP accepts every request, so only expose it behind your own auth.
+func (s *Server) WithAuthenticator(authenticate HTTPAuthenticator) *Server {
+	s.authenticate = authenticate
+	return s
+}
+
+// WithSessionLimits changes how long an unused HTTP session stays open and how many are
+// kept; past max the least recently used one is closed. Zero disables either limit.
+// Clients of a closed session get 404 and initialize a new one.
+func (s *Server) WithSessionLimits(idle time.Duration, max int) *Server {
+	s.sessionIdle = idle
+	s.maxSessions = max
+	return s
+}
+
+// notifyFunc sends a notification to the client that made a request
+type notifyFunc func(method string, params interface{}) error
+
+// ServeStdio serves one client over newline-delimited messages on in and out (usually
+// os.Stdin and os.Stdout) until in is closed or ctx is done. Requests run concurrently.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	sessionID := uuid.NewString()
+	var writeMu sync.Mutex
+	send := func(raw []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := out.Write(append(raw, '\n'))
+		return err
+	}
+	write := func(msg message) error {
+		raw, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return send(raw)
+	}
+	notify := func(method string, params interface{}) error {
+		raw, err := encode(nil, method, params)
+		if err != nil {
+			return err
+		}
+		return send(raw)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read from client: %w", err)
+		case line := <-lines:
+			var msg message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				write(message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: codeParseError, Message: err.Error()}})
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if reply := s.handle(ctx, sessionID, msg, notify); reply != nil {
+					if err := write(*reply); err != nil {
+						logging.Default().Warn("Failed to write MCP response", logging.KeyComponent, "mcp_server", logging.KeyError, err)
+					}
+				}
+			}()
+		}
+	}
+}
+
+// handle answers one message. Notifications and responses get no reply.
+func (s *Server) handle(ctx context.Context, sessionID string, msg message, notify notifyFunc) *message {
+	if msg.Method == "" || len(msg.ID) == 0 {
+		return nil
+	}
+
+	reply := &message{JSONRPC: "2.0", ID: msg.ID}
+	var result interface{}
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		json.Unmarshal(msg.Params, &params)
+		version := ProtocolVersion
+		if supportedVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		result = InitializeResult{
+			ProtocolVersion: version,
+			Capabilities:    ServerCapabilities{Tools: json.RawMessage("{}")},
+			ServerInfo:      s.info,
+			Instructions:    s.instructions,
+		}
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = s.listTools()
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			reply.Error = &RPCError{Code: codeInvalidParams, Message: err.Error()}
+			return reply
+		}
+		callResult, rpcErr := s.callTool(ctx, sessionID, strings.Trim(string(msg.ID), `"`), params, notify)
+		if rpcErr != nil {
+			reply.Error = rpcErr
+			return reply
+		}
+		result = callResult
+	default:
+		reply.Error = &RPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+		return reply
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		reply.Error = &RPCError{Code: codeInternalError, Message: err.Error()}
+		return reply
+	}
+	reply.Result = raw
+	return reply
+}
+
+// listTools describes the agent's tools with their JSON Schemas
+func (s *Server) listTools() listToolsResult {
+	tools := make([]Tool, 0, len(s.agent.Tools))
+	for _, tool := range s.agent.Tools {
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		if tool.Parameters.Properties != nil {
+			schema["properties"] = tool.Parameters.Properties
+		}
+		if len(tool.Parameters.Required) > 0 {
+			schema["required"] = tool.Parameters.Required
+		}
+		raw, _ := json.Marshal(schema)
+		tools = append(tools, Tool{Name: tool.Name, Description: tool.Description, InputSchema: raw})
+	}
+	return listToolsResult{Tools: tools}
+}
+
+// callTool approves and executes a tool call. Tool failures and rejections are reported
+// in the result, as MCP expects; only unknown tools are protocol errors.
+func (s *Server) callTool(ctx context.Context, sessionID, requestID string, params callToolParams, notify notifyFunc) (*CallToolResult, *RPCError) {
+	if !s.hasTool(params.Name) {
+		return nil, &RPCError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+	if params.Arguments == nil {
+		params.Arguments = map[string]interface{}{}
+	}
+
+	approved, err := s.agent.ApproveTool(params.Name, params.Arguments)
+	if err == nil && !approved && s.approve != nil {
+		approved, err = s.approve(ctx, params.Name, params.Arguments)
+	}
+	if err != nil {
+		return errorResult(fmt.Sprintf("approval failed: %v", err)), nil
+	}
+	if !approved {
+		return errorResult(fmt.Sprintf("tool %s was not approved", params.Name)), nil
+	}
+
+	ctx, span := telemetry.StartToolCall(ctx, params.Name, requestID)
+	tracer := &callTracer{traceStore: s.traceStore, sessionID: sessionID, toolCallID: requestID}
+	tracer.spanTraceID, tracer.spanID = telemetry.SpanIDs(ctx)
+	if params.Meta != nil && len(params.Meta.ProgressToken) > 0 {
+		tracer.progressToken, tracer.notify = params.Meta.ProgressToken, notify
+	}
+	start := time.Now()
+	trace := common_tools.TraceEvent{
+		TraceID:   fmt.Sprintf("tool_%s_%d", requestID, start.UnixMilli()),
+		Tool:      "tool",
+		Operation: params.Name,
+		Status:    "start",
+		Label:     "Running " + params.Name,
+		Timestamp: start.UnixMilli(),
+	}
+	tracer.EmitTrace(trace)
+
+	output, err := s.agent.ExecuteTool(params.Name, params.Arguments, sessionID)
+	span.End(err)
+
+	trace.Timestamp = time.Now().UnixMilli()
+	trace.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		trace.Status, trace.Label = "error", fmt.Sprintf("Failed: %v", err)
+		tracer.EmitTrace(trace)
+		return errorResult(err.Error()), nil
+	}
+	trace.Status, trace.Label = "end", "Ran "+params.Name
+	tracer.EmitTrace(trace)
+
+	// ExecuteTool wraps outputs as {"result": ...}; clients get the output itself
+	var wrapped map[string]string
+	if json.Unmarshal([]byte(output), &wrapped) == nil {
+		if result, ok := wrapped["result"]; ok {
+			output = result
+		}
+	}
+	return &CallToolResult{Content: []Content{{Type: "text", Text: output}}}, nil
+}
+
+func (s *Server) hasTool(name string) bool {
+	for _, tool := range s.agent.Tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func errorResult(text string) *CallToolResult {
+	return &CallToolResult{Content: []Content{{Type: "text", Text: text}}, IsError: true}
+}
+
+// callTracer is the TraceEmitter of one tool call: it saves traces and reports them
+// as progress notifications when the client sent a progress token
+type callTracer struct {
+	traceStore    stores.TraceStore
+	sessionID     string
+	toolCallID    string
+	spanTraceID   string // OpenTelemetry IDs of the tool call span
+	spanID        string
+	progressToken json.RawMessage
+	notify        notifyFunc
+
+	mu       sync.Mutex
+	progress int
+}
+
+// EmitTrace implements common_tools.TraceEmitter
+func (t *callTracer) EmitTrace(trace common_tools.TraceEvent) error {
+	if t.traceStore != nil {
+		err := t.traceStore.SaveTrace(&stores.ExecutionTrace{
+			ConversationID: t.sessionID,
+			ToolCallID:     t.toolCallID,
+			TraceID:        trace.TraceID,
+			ParentID:       trace.ParentID,
+			Tool:           trace.Tool,
+			Operation:      trace.Operation,
+			Status:         trace.Status,
+			Label:          trace.Label,
+			Details:        trace.Details,
+			Timestamp:      trace.Timestamp,
+			DurationMS:     trace.DurationMS,
+			SpanTraceID:    t.spanTraceID,
+			SpanID:         t.spanID,
+		})
+		if err != nil {
+			logging.Default().Warn("Failed to save MCP tool trace", logging.KeyComponent, "mcp_server", logging.KeyError, err)
+		}
+	}
+
+	if len(t.progressToken) == 0 || t.notify == nil {
+		return nil
+	}
+	t.mu.Lock()
+	t.progress++
+	progress := t.progress
+	t.mu.Unlock()
+	return t.notify("notifications/progress", Progress{ProgressToken: t.progressToken, Progress: float64(progress), Message: trace.Label})
+}
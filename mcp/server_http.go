@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BearerToken returns an HTTPAuthenticator accepting requests with "Authorization: Bearer <token>"
+func BearerToken(token string) HTTPAuthenticator {
+	return func(r *http.Request) error {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return errors.New("invalid bearer token")
+		}
+		return nil
+	}
+}
+
+// ServeHTTP implements the streamable HTTP transport on a single endpoint: clients POST
+// messages, initialize opens a session whose ID they send back in Mcp-Session-Id, and
+// DELETE ends it. Tool calls that ask for progress are answered with an SSE stream carrying
+// the progress notifications and then the response; everything else is answered with JSON.
+// The server does not push messages outside a request, so GET is not supported.
+// Requests the authenticator (see WithAuthenticator) rejects get 401.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.authenticate != nil {
+		if err := s.authenticate(r); err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.servePost(w, r)
+	case http.MethodDelete:
+		s.mu.Lock()
+		_, ok := s.sessions[r.Header.Get(sessionHeader)]
+		delete(s.sessions, r.Header.Get(sessionHeader))
+		s.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) servePost(w http.ResponseWriter, r *http.Request) {
+	var msg message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: codeParseError, Message: err.Error()}})
+		return
+	}
+
+	sessionID := r.Header.Get(sessionHeader)
+	if msg.Method == "initialize" {
+		sessionID = s.openSession()
+		w.Header().Set(sessionHeader, sessionID)
+	} else {
+		switch {
+		case sessionID == "":
+			http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+			return
+		case !s.useSession(sessionID):
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+
+	if msg.Method == "" || len(msg.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var params callToolParams
+	json.Unmarshal(msg.Params, &params)
+	flusher, canStream := w.(http.Flusher)
+	if msg.Method != "tools/call" || params.Meta == nil || len(params.Meta.ProgressToken) == 0 || !canStream {
+		writeJSON(w, http.StatusOK, s.handle(r.Context(), sessionID, msg, nil))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	var writeMu sync.Mutex
+	writeEvent := func(raw []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	notify := func(method string, params interface{}) error {
+		raw, err := encode(nil, method, params)
+		if err != nil {
+			return err
+		}
+		return writeEvent(raw)
+	}
+
+	reply := s.handle(r.Context(), sessionID, msg, notify)
+	if raw, err := json.Marshal(reply); err == nil {
+		writeEvent(raw)
+	}
+}
+
+// openSession starts an HTTP session, closing idle ones and, at the limit, the least recently used
+func (s *Server) openSession() string {
+	id := uuid.NewString()
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var oldestID string
+	var oldest time.Time
+	for sessionID, used := range s.sessions {
+		if s.sessionIdle > 0 && now.Sub(used) > s.sessionIdle {
+			delete(s.sessions, sessionID)
+			continue
+		}
+		if oldestID == "" || used.Before(oldest) {
+			oldestID, oldest = sessionID, used
+		}
+	}
+	if s.maxSessions > 0 && len(s.sessions) >= s.maxSessions {
+		delete(s.sessions, oldestID)
+	}
+	s.sessions[id] = now
+	return id
+}
+
+// useSession reports whether an HTTP session is open and marks it as used
+func (s *Server) useSession(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	used, ok := s.sessions[id]
+	if !ok {
+		return false
+	}
+	if s.sessionIdle > 0 && time.Since(used) > s.sessionIdle {
+		delete(s.sessions, id)
+		return false
+	}
+	s.sessions[id] = time.Now()
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, msg *message) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(msg)
+}
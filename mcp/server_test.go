@@ -0,0 +1,195 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+// pipeTransport connects a client to Server.ServeStdio in the same process
+type pipeTransport struct {
+	server *Server
+	in     *io.PipeWriter
+}
+
+func (t *pipeTransport) Start(ctx context.Context) (<-chan json.RawMessage, error) {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	t.in = clientOut
+	go func() {
+		t.server.ServeStdio(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	messages := make(chan json.RawMessage, 16)
+	go func() {
+		defer close(messages)
+		scanner := bufio.NewScanner(clientIn)
+		for scanner.Scan() {
+			messages <- json.RawMessage(append([]byte{}, scanner.Bytes()...))
+		}
+	}()
+	return messages, nil
+}
+
+func (t *pipeTransport) Send(ctx context.Context, msg json.RawMessage) error {
+	_, err := t.in.Write(append(append([]byte{}, msg...), '\n'))
+	return err
+}
+
+func (t *pipeTransport) Close() error {
+	return t.in.Close()
+}
+
+func newTestServer() *Server {
+	weather := models.FunctionDeclaration{
+		Name:        "Get_Weather",
+		Description: "Current weather for a city",
+		Parameters: models.Parameters{
+			Type:       "object",
+			Properties: map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+			Required:   []string{"city"},
+		},
+		Callable: func(city string) (string, error) { return "sunny in " + city, nil },
+	}
+	broken := models.FunctionDeclaration{
+		Name:     "Broken",
+		Callable: func() (string, error) { return "", errors.New("disk on fire") },
+	}
+	agent := godantic.Create_Agent(nil, []models.FunctionDeclaration{weather, broken})
+	return NewServer("test-tools", "0.1.0", &agent)
+}
+
+func TestServer_StdioListsAndCallsAgentTools(t *testing.T) {
+	client := NewClient("local", &pipeTransport{server: newTestServer()})
+	defer client.Close()
+	ctx := context.Background()
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "Get_Weather" || !strings.Contains(string(tools[0].InputSchema), `"required":["city"]`) {
+		t.Fatalf("Unexpected tools %+v", tools)
+	}
+	if info := client.ServerInfo(); info.ServerInfo.Name != "test-tools" {
+		t.Errorf("Unexpected server info %+v", info)
+	}
+
+	result, err := client.CallTool(ctx, "Get_Weather", map[string]interface{}{"city": "Paris"})
+	if err != nil || result.IsError || result.Text() != "sunny in Paris" {
+		t.Errorf("Expected the unwrapped tool output, got %+v (%v)", result, err)
+	}
+	result, err = client.CallTool(ctx, "Broken", nil)
+	if err != nil || !result.IsError || result.Text() != "disk on fire" {
+		t.Errorf("Expected the tool error in the result, got %+v (%v)", result, err)
+	}
+
+	var rpcErr *RPCError
+	if _, err := client.CallTool(ctx, "Missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != codeInvalidParams {
+		t.Errorf("Expected an invalid params error for an unknown tool, got %v", err)
+	}
+}
+
+func TestServer_HTTPReportsTracesAsProgress(t *testing.T) {
+	db, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "traces.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	defer db.Close()
+	traceStore, err := stores.NewGORMTraceStore(db.DB())
+	if err != nil {
+		t.Fatalf("NewGORMTraceStore: %v", err)
+	}
+
+	server := httptest.NewServer(newTestServer().WithTraceStore(traceStore))
+	defer server.Close()
+	transport := NewHTTPTransport(server.URL)
+	client := NewClient("remote", transport)
+	defer client.Close()
+
+	var progress []Progress
+	result, err := client.CallToolWithProgress(context.Background(), "Get_Weather", map[string]interface{}{"city": "Oslo"}, func(p Progress) {
+		progress = append(progress, p)
+	})
+	if err != nil || result.Text() != "sunny in Oslo" {
+		t.Fatalf("Expected the tool output, got %+v (%v)", result, err)
+	}
+	if len(progress) != 2 || progress[0].Message != "Running Get_Weather" || progress[1].Progress != 2 {
+		t.Errorf("Expected start and end progress notifications, got %+v", progress)
+	}
+
+	traces, err := traceStore.GetTracesByConversation(transport.sessionID)
+	if err != nil {
+		t.Fatalf("GetTracesByConversation: %v", err)
+	}
+	if len(traces) != 2 || traces[0].Status != "start" || traces[1].Status != "end" || traces[1].Operation != "Get_Weather" {
+		t.Errorf("Expected the call's traces under the MCP session, got %+v", traces)
+	}
+}
+
+func TestServer_HTTPAuthenticator(t *testing.T) {
+	server := httptest.NewServer(newTestServer().WithAuthenticator(BearerToken("secret")))
+	defer server.Close()
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		transport := NewHTTPTransport(server.URL)
+		if header != "" {
+			transport.WithHeader("Authorization", header)
+		}
+		client := NewClient("remote", transport)
+		if _, err := client.ListTools(context.Background()); err == nil {
+			t.Errorf("Expected Authorization %q to be rejected", header)
+		}
+		client.Close()
+	}
+
+	client := NewClient("remote", NewHTTPTransport(server.URL).WithHeader("Authorization", "Bearer secret"))
+	defer client.Close()
+	if tools, err := client.ListTools(context.Background()); err != nil || len(tools) != 2 {
+		t.Errorf("Expected the tools with the token, got %+v (%v)", tools, err)
+	}
+}
+
+func TestServer_HTTPSessionLimits(t *testing.T) {
+	mcpServer := newTestServer().WithSessionLimits(time.Hour, 2)
+	server := httptest.NewServer(mcpServer)
+	defer server.Close()
+
+	var transports []*HTTPTransport
+	for i := 0; i < 3; i++ {
+		transport := NewHTTPTransport(server.URL)
+		client := NewClient("remote", transport)
+		defer client.Close()
+		if _, err := client.ListTools(context.Background()); err != nil {
+			t.Fatalf("ListTools: %v", err)
+		}
+		transports = append(transports, transport)
+	}
+	if len(mcpServer.sessions) != 2 {
+		t.Errorf("Expected 2 open sessions, got %d", len(mcpServer.sessions))
+	}
+	if mcpServer.useSession(transports[0].sessionID) {
+		t.Errorf("Expected the least recently used session to be closed")
+	}
+	if !mcpServer.useSession(transports[2].sessionID) {
+		t.Errorf("Expected the newest session to stay open")
+	}
+
+	mcpServer.WithSessionLimits(time.Millisecond, 0)
+	time.Sleep(5 * time.Millisecond)
+	if mcpServer.useSession(transports[2].sessionID) {
+		t.Errorf("Expected an idle session to expire")
+	}
+}
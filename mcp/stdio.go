@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/Desarso/godantic/logging"
+)
+
+// ErrConnectionClosed is returned when the connection to an MCP server is lost.
+// The client reconnects and retries when it sees it.
+var ErrConnectionClosed = errors.New("MCP connection closed")
+
+// Transport carries JSON-RPC messages between the client and an MCP server
+type Transport interface {
+	// Start opens a connection and returns the messages received from the server. The channel
+	// is closed if the connection ends on its own. Start is called again after Close to reconnect.
+	Start(ctx context.Context) (<-chan json.RawMessage, error)
+	// Send delivers one message to the server
+	Send(ctx context.Context, msg json.RawMessage) error
+	// Close ends the connection
+	Close() error
+}
+
+// StdioTransport runs an MCP server as a subprocess and exchanges newline-delimited
+// JSON-RPC messages over its stdin and stdout. The server's stderr is logged.
+type StdioTransport struct {
+	command string
+	args    []string
+	env     []string
+	dir     string
+
+	mu     sync.Mutex
+	stdin  io.WriteCloser
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+// NewStdioTransport creates a transport that launches command with args
+func NewStdioTransport(command string, args ...string) *StdioTransport {
+	return &StdioTransport{command: command, args: args}
+}
+
+// WithEnv adds KEY=value entries to the server's environment (which inherits the current one)
+func (t *StdioTransport) WithEnv(env ...string) *StdioTransport {
+	t.env = append(t.env, env...)
+	return t
+}
+
+// WithDir sets the server's working directory
+func (t *StdioTransport) WithDir(dir string) *StdioTransport {
+	t.dir = dir
+	return t
+}
+
+// Start launches the server process
+func (t *StdioTransport) Start(ctx context.Context) (<-chan json.RawMessage, error) {
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = append(os.Environ(), t.env...)
+	cmd.Dir = t.dir
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stderr: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", t.command, err)
+	}
+
+	messages := make(chan json.RawMessage, 16)
+	exited := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logging.Default().Info(scanner.Text(), logging.KeyComponent, "mcp", "command", t.command, "stream", "stderr")
+		}
+	}()
+	go func() {
+		defer close(exited)
+		defer close(messages)
+		reader := bufio.NewReader(stdout)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				messages <- json.RawMessage(line)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err := cmd.Wait(); err != nil {
+			logging.Default().Info("MCP server exited", logging.KeyComponent, "mcp", "command", t.command, logging.KeyError, err)
+		}
+	}()
+
+	t.mu.Lock()
+	t.cmd, t.stdin, t.exited = cmd, stdin, exited
+	t.mu.Unlock()
+	return messages, nil
+}
+
+// Send writes msg as one line on the server's stdin
+func (t *StdioTransport) Send(ctx context.Context, msg json.RawMessage) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stdin == nil {
+		return ErrConnectionClosed
+	}
+	if _, err := t.stdin.Write(append(append([]byte{}, msg...), '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+	return nil
+}
+
+// Close closes the server's stdin and kills it if it has not exited after five seconds
+func (t *StdioTransport) Close() error {
+	t.mu.Lock()
+	cmd, stdin, exited := t.cmd, t.stdin, t.exited
+	t.cmd, t.stdin, t.exited = nil, nil, nil
+	t.mu.Unlock()
+	if cmd == nil {
+		return nil
+	}
+
+	stdin.Close()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		<-exited
+	}
+	return nil
+}
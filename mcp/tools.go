@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// NamespaceSeparator joins a client's name and a tool name
+const NamespaceSeparator = "__"
+
+// maxToolNameLength is the longest function name providers accept
+const maxToolNameLength = 64
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// toolNameHashLength is the number of hex digits that replace the tail of a name too long to declare
+const toolNameHashLength = 8
+
+// ToolName returns the namespaced name under which a server tool is declared to the model.
+// Characters providers reject are replaced by underscores. Names longer than providers accept
+// are cut short and end with a hash of the full name, so tools sharing a long prefix stay apart.
+func (c *Client) ToolName(tool string) string {
+	name := tool
+	if c.name != "" {
+		name = c.name + NamespaceSeparator + tool
+	}
+	sanitized := invalidNameChars.ReplaceAllString(name, "_")
+	if len(sanitized) > maxToolNameLength {
+		sum := sha256.Sum256([]byte(name))
+		sanitized = sanitized[:maxToolNameLength-toolNameHashLength-1] + "_" + hex.EncodeToString(sum[:])[:toolNameHashLength]
+	}
+	return sanitized
+}
+
+// checkUniqueNames fails when two tools are declared under the same name, e.g. because
+// sanitizing made "get.file" and "get_file" alike; the model could only reach one of them
+func checkUniqueNames(declarations []models.FunctionDeclaration) error {
+	seen := make(map[string]bool, len(declarations))
+	for _, declaration := range declarations {
+		if seen[declaration.Name] {
+			return fmt.Errorf("more than one MCP tool is declared as %q", declaration.Name)
+		}
+		seen[declaration.Name] = true
+	}
+	return nil
+}
+
+// Tools lists the server's tools as function declarations for an agent. Each Callable
+// calls the tool on the server and returns its text output; tool errors become Go errors.
+func (c *Client) Tools(ctx context.Context) ([]models.FunctionDeclaration, error) {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	declarations := make([]models.FunctionDeclaration, 0, len(tools)+1)
+	for _, tool := range tools {
+		declarations = append(declarations, models.FunctionDeclaration{
+			Name:        c.ToolName(tool.Name),
+			Description: tool.Description,
+			Parameters:  parameters(tool.InputSchema),
+			Callable:    c.proxy(tool.Name),
+		})
+	}
+	if c.resourceTool {
+		declarations = append(declarations, c.readResourceTool())
+	}
+	if err := checkUniqueNames(declarations); err != nil {
+		return nil, err
+	}
+	return declarations, nil
+}
+
+// Tools lists the tools of several servers, each namespaced by its client's name.
+// It fails if two tools end up with the same name.
+func Tools(ctx context.Context, clients ...*Client) ([]models.FunctionDeclaration, error) {
+	var declarations []models.FunctionDeclaration
+	for _, client := range clients {
+		tools, err := client.Tools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		declarations = append(declarations, tools...)
+	}
+	if err := checkUniqueNames(declarations); err != nil {
+		return nil, err
+	}
+	return declarations, nil
+}
+
+// proxy returns a Callable that calls tool on the server
+func (c *Client) proxy(tool string) models.ArgsCallable {
+	return func(args map[string]interface{}) (string, error) {
+		result, err := c.CallTool(context.Background(), tool, args)
+		if err != nil {
+			return "", err
+		}
+		if result.IsError {
+			return "", errors.New(result.Text())
+		}
+		return result.Text(), nil
+	}
+}
+
+// readResourceTool declares a tool reading the server's resources by URI
+func (c *Client) readResourceTool() models.FunctionDeclaration {
+	read := func(args map[string]interface{}) (string, error) {
+		uri, _ := args["uri"].(string)
+		if uri == "" {
+			return "", errors.New("uri is required")
+		}
+		contents, err := c.ReadResource(context.Background(), uri)
+		if err != nil {
+			return "", err
+		}
+		var parts []string
+		for _, content := range contents {
+			if content.Text != "" {
+				parts = append(parts, content.Text)
+			} else {
+				parts = append(parts, fmt.Sprintf("[binary %s %s]", content.MimeType, content.URI))
+			}
+		}
+		return strings.Join(parts, "\n"), nil
+	}
+
+	return models.FunctionDeclaration{
+		Name:        c.ToolName("read_resource"),
+		Description: fmt.Sprintf("Read a resource of the %s server by URI.", c.name),
+		Parameters: models.Parameters{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"uri": map[string]interface{}{
+					"type":        "string",
+					"description": "URI of the resource to read",
+				},
+			},
+			Required: []string{"uri"},
+		},
+		Callable: models.ArgsCallable(read),
+	}
+}
+
+// parameters converts a tool's JSON Schema into declaration parameters
+func parameters(schema json.RawMessage) models.Parameters {
+	var params models.Parameters
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &params); err != nil {
+			params = models.Parameters{}
+		}
+	}
+	if params.Type == "" {
+		params.Type = "object"
+	}
+	if params.Properties == nil {
+		params.Properties = map[string]interface{}{}
+	}
+	return params
+}
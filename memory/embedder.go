@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// Embedder turns text into vectors for similarity search. It is models.Embedder, so
+// any implementation from models/embeddings can be passed to NewVectorMemory.
+type Embedder = models.Embedder
+
+// HashingEmbedder is a deterministic, offline embedder based on feature hashing of
+// lowercased word unigrams and bigrams. It needs no API key, which makes it suitable
+// for tests and development; retrieval quality is lexical, not semantic.
+type HashingEmbedder struct {
+	Dims int
+}
+
+// NewHashingEmbedder creates a hashing embedder with the given dimensions (256 when dims <= 0)
+func NewHashingEmbedder(dims int) *HashingEmbedder {
+	if dims <= 0 {
+		dims = 256
+	}
+	return &HashingEmbedder{Dims: dims}
+}
+
+// Dimensions returns the vector length
+func (h *HashingEmbedder) Dimensions() int {
+	return h.Dims
+}
+
+// Embed hashes each text into an L2-normalized vector
+func (h *HashingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = h.embedOne(text)
+	}
+	return vectors, nil
+}
+
+func (h *HashingEmbedder) embedOne(text string) []float32 {
+	vec := make([]float32, h.Dims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	add := func(feature string, weight float32) {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(feature))
+		sum := hasher.Sum64()
+		index := int(sum % uint64(h.Dims))
+		// The top bit picks the sign so unrelated features tend to cancel out
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vec[index] += weight
+	}
+	for i, word := range words {
+		add(word, 1)
+		if i > 0 {
+			add(words[i-1]+" "+word, 0.5)
+		}
+	}
+
+	normalize(vec)
+	return vec
+}
+
+// normalize scales vec to unit length in place (zero vectors are left unchanged)
+func normalize(vec []float32) {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b (0 for mismatched or zero vectors)
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
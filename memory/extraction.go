@@ -0,0 +1,350 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Desarso/godantic/common_tools"
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/sessions"
+	"github.com/Desarso/godantic/stores"
+)
+
+// CompletionModel is the subset of a godantic model used for fact extraction.
+// Every provider model (gemini.Gemini_Model, anthropic.Anthropic_Model, ...) satisfies it.
+type CompletionModel interface {
+	Model_Request(request models.Model_Request, tools []models.FunctionDeclaration, conversationHistory []stores.Message) (models.Model_Response, error)
+}
+
+// DefaultExtractionInstructions tell the model what is worth remembering
+const DefaultExtractionInstructions = `You maintain long-term memory about a user.
+Extract durable facts and preferences about the user that will matter in future conversations:
+identity, preferences, ongoing projects, goals, constraints and relationships.
+Ignore small talk, one-off requests, and anything the assistant said that the user did not confirm.
+Write each memory as one short third-person sentence, e.g. "The user prefers metric units."`
+
+const extractionFormat = `Compare what you extract with the existing memories:
+- "add" a memory that is not already known
+- "update" an existing memory (by id) when the user refines or changes it
+- "delete" an existing memory (by id) when the user contradicts or retracts it
+- do nothing for information that is already known
+
+Reply with JSON only, no prose:
+{"operations":[{"op":"add","content":"...","kind":"fact"},{"op":"update","id":"m1","content":"...","kind":"preference"},{"op":"delete","id":"m2"}]}
+Reply {"operations":[]} when there is nothing to remember.`
+
+// ExtractionResult counts the changes an extraction made
+type ExtractionResult struct {
+	Added      int `json:"added"`
+	Updated    int `json:"updated"`
+	Deleted    int `json:"deleted"`
+	Duplicates int `json:"duplicates"` // Additions skipped because a near-identical memory exists
+}
+
+// ExtractingMemory wraps a VectorMemory with an LLM pipeline that turns each completed turn
+// into durable facts, reconciled against existing memories instead of stored as raw text.
+// Extracted memories carry provenance metadata: source, kind, conversation_id and sequence.
+type ExtractingMemory struct {
+	memory          *VectorMemory
+	model           CompletionModel
+	instructions    string
+	candidateLimit  int
+	dedupeThreshold float64
+}
+
+// NewExtractingMemory creates an extraction pipeline on top of memory using model
+func NewExtractingMemory(memory *VectorMemory, model CompletionModel) *ExtractingMemory {
+	return &ExtractingMemory{
+		memory:          memory,
+		model:           model,
+		instructions:    DefaultExtractionInstructions,
+		candidateLimit:  10,
+		dedupeThreshold: 0.92,
+	}
+}
+
+// WithInstructions replaces DefaultExtractionInstructions
+func (e *ExtractingMemory) WithInstructions(instructions string) *ExtractingMemory {
+	e.instructions = instructions
+	return e
+}
+
+// WithCandidateLimit sets how many related memories the model sees when reconciling (default 10)
+func (e *ExtractingMemory) WithCandidateLimit(limit int) *ExtractingMemory {
+	e.candidateLimit = limit
+	return e
+}
+
+// WithDedupeThreshold sets the similarity above which an added memory counts as a duplicate (default 0.92)
+func (e *ExtractingMemory) WithDedupeThreshold(threshold float64) *ExtractingMemory {
+	e.dedupeThreshold = threshold
+	return e
+}
+
+// Memory returns the underlying vector memory
+func (e *ExtractingMemory) Memory() *VectorMemory {
+	return e.memory
+}
+
+// ForTenant returns a copy scoped to tenantID
+func (e *ExtractingMemory) ForTenant(tenantID string) sessions.MemoryManager {
+	scoped := *e
+	scoped.memory = e.memory.ForTenant(tenantID).(*VectorMemory)
+	return &scoped
+}
+
+// ForUser returns a copy scoped to userID
+func (e *ExtractingMemory) ForUser(userID string) sessions.MemoryManager {
+	scoped := *e
+	scoped.memory = e.memory.ForUser(userID).(*VectorMemory)
+	return &scoped
+}
+
+// AddMemory stores content directly (no extraction), skipping near-duplicates
+func (e *ExtractingMemory) AddMemory(content string, metadata map[string]interface{}) error {
+	ctx, cancel := e.memory.context()
+	defer cancel()
+	duplicate, err := e.isDuplicate(ctx, e.memory, content)
+	if err != nil || duplicate {
+		return err
+	}
+	_, err = e.memory.Add(ctx, content, metadata)
+	return err
+}
+
+// RetrieveMemories delegates to the underlying vector memory
+func (e *ExtractingMemory) RetrieveMemories(queryText string, limit int) ([]string, error) {
+	return e.memory.RetrieveMemories(queryText, limit)
+}
+
+// SearchMemories delegates to the underlying vector memory
+func (e *ExtractingMemory) SearchMemories(queryText string, limit int) ([]common_tools.MemoryHit, error) {
+	return e.memory.SearchMemories(queryText, limit)
+}
+
+// ListMemories delegates to the underlying vector memory
+func (e *ExtractingMemory) ListMemories(limit int) ([]sessions.MemoryEntry, error) {
+	return e.memory.ListMemories(limit)
+}
+
+// ForgetMemory delegates to the underlying vector memory
+func (e *ExtractingMemory) ForgetMemory(id string) error {
+	return e.memory.ForgetMemory(id)
+}
+
+// ProcessTurn extracts facts from a completed turn (implements sessions.TurnMemory)
+func (e *ExtractingMemory) ProcessTurn(turn sessions.MemoryTurn) error {
+	ctx, cancel := e.memory.context()
+	defer cancel()
+	_, err := e.ExtractFromTurn(ctx, turn)
+	return err
+}
+
+// ExtractFromTurn asks the model for memory operations on a turn and applies them
+func (e *ExtractingMemory) ExtractFromTurn(ctx context.Context, turn sessions.MemoryTurn) (*ExtractionResult, error) {
+	transcript, sequence := turnTranscript(turn.Messages)
+	if transcript == "" {
+		return &ExtractionResult{}, nil
+	}
+
+	// Memories without an explicit scope belong to the turn's tenant and user
+	memory := e.memory
+	if memory.scope.TenantID == "" && turn.TenantID != "" {
+		memory = memory.ForTenant(turn.TenantID).(*VectorMemory)
+	}
+	if memory.scope.UserID == "" && turn.UserID != "" {
+		memory = memory.ForUser(turn.UserID).(*VectorMemory)
+	}
+
+	candidates, err := memory.Search(ctx, transcript, e.candidateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load related memories: %w", err)
+	}
+	aliases := make(map[string]ScoredRecord, len(candidates))
+
+	var prompt strings.Builder
+	prompt.WriteString(e.instructions)
+	prompt.WriteString("\n\n")
+	prompt.WriteString(extractionFormat)
+	prompt.WriteString("\n\nExisting memories:\n")
+	if len(candidates) == 0 {
+		prompt.WriteString("(none)\n")
+	}
+	for i, candidate := range candidates {
+		alias := fmt.Sprintf("m%d", i+1)
+		aliases[alias] = candidate
+		fmt.Fprintf(&prompt, "[%s] %s\n", alias, candidate.Content)
+	}
+	prompt.WriteString("\nConversation turn:\n")
+	prompt.WriteString(transcript)
+
+	response, err := e.model.Model_Request(models.Model_Request{
+		User_Message: &models.User_Message{
+			Role:    "user",
+			Content: models.Content{Parts: []models.User_Part{{Text: prompt.String()}}},
+		},
+	}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fact extraction request failed: %w", err)
+	}
+	operations, err := parseOperations(responseText(response))
+	if err != nil {
+		return nil, err
+	}
+
+	provenance := func(kind string) map[string]interface{} {
+		if kind == "" {
+			kind = "fact"
+		}
+		return map[string]interface{}{
+			"source":          "extraction",
+			"kind":            kind,
+			"conversation_id": turn.SessionID,
+			"sequence":        sequence,
+		}
+	}
+
+	result := &ExtractionResult{}
+	for _, op := range operations {
+		switch op.Op {
+		case "add":
+			content := strings.TrimSpace(op.Content)
+			if content == "" {
+				continue
+			}
+			duplicate, err := e.isDuplicate(ctx, memory, content)
+			if err != nil {
+				return result, err
+			}
+			if duplicate {
+				result.Duplicates++
+				continue
+			}
+			if _, err := memory.Add(ctx, content, provenance(op.Kind)); err != nil {
+				return result, err
+			}
+			result.Added++
+
+		case "update":
+			candidate, ok := aliases[op.ID]
+			content := strings.TrimSpace(op.Content)
+			if !ok || content == "" {
+				logging.Default().Debug("Ignoring update of unknown memory", logging.KeyComponent, "memory", "memory_id", op.ID)
+				continue
+			}
+			vectors, err := memory.embedder.Embed(ctx, []string{content})
+			if err != nil {
+				return result, fmt.Errorf("failed to embed memory: %w", err)
+			}
+			record := candidate.Record
+			record.Content = content
+			record.Embedding = vectors[0]
+			record.Metadata = provenance(op.Kind)
+			if previous, ok := candidate.Metadata["conversation_id"]; ok {
+				record.Metadata["first_conversation_id"] = previous
+			}
+			if err := memory.store.Update(ctx, memory.scope, &record); err != nil {
+				return result, err
+			}
+			result.Updated++
+
+		case "delete":
+			candidate, ok := aliases[op.ID]
+			if !ok {
+				logging.Default().Debug("Ignoring deletion of unknown memory", logging.KeyComponent, "memory", "memory_id", op.ID)
+				continue
+			}
+			if err := memory.store.Delete(ctx, memory.scope, candidate.ID); err != nil && err != ErrRecordNotFound {
+				return result, err
+			}
+			result.Deleted++
+		}
+	}
+	return result, nil
+}
+
+// isDuplicate reports whether memory already holds a near-identical memory
+func (e *ExtractingMemory) isDuplicate(ctx context.Context, memory *VectorMemory, content string) (bool, error) {
+	hits, err := memory.Search(ctx, content, 1)
+	if err != nil {
+		return false, err
+	}
+	return len(hits) > 0 && hits[0].Score >= e.dedupeThreshold, nil
+}
+
+// memoryOperation is one change proposed by the extraction model
+type memoryOperation struct {
+	Op      string `json:"op"`
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+}
+
+// parseOperations reads the model's JSON reply, tolerating code fences and surrounding prose
+func parseOperations(text string) ([]memoryOperation, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("fact extraction returned no JSON: %.200s", text)
+	}
+	var reply struct {
+		Operations []memoryOperation `json:"operations"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse fact extraction reply: %w", err)
+	}
+	return reply.Operations, nil
+}
+
+// turnTranscript renders the text of a turn and returns the sequence of its user message
+func turnTranscript(messages []stores.Message) (string, int) {
+	var b strings.Builder
+	sequence := 0
+	for _, msg := range messages {
+		var text strings.Builder
+		switch msg.Type {
+		case "user_message":
+			var parts []models.User_Part
+			if err := json.Unmarshal([]byte(msg.PartsJSON), &parts); err != nil {
+				continue
+			}
+			for _, part := range parts {
+				text.WriteString(part.Text)
+			}
+			if sequence == 0 {
+				sequence = msg.Sequence
+			}
+			b.WriteString("User: ")
+		case "model_message":
+			var parts []models.Model_Part
+			if err := json.Unmarshal([]byte(msg.PartsJSON), &parts); err != nil {
+				continue
+			}
+			for _, part := range parts {
+				if part.Text != nil {
+					text.WriteString(*part.Text)
+				}
+			}
+			b.WriteString("Assistant: ")
+		default:
+			continue
+		}
+		b.WriteString(strings.TrimSpace(text.String()))
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String()), sequence
+}
+
+// responseText concatenates the text parts of a model response
+func responseText(response models.Model_Response) string {
+	var b strings.Builder
+	for _, part := range response.Parts {
+		if part.Text != nil {
+			b.WriteString(*part.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic/common_tools"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/sessions"
+	"github.com/Desarso/godantic/stores"
+)
+
+var (
+	_ sessions.MemoryManager    = (*ExtractingMemory)(nil)
+	_ sessions.TurnMemory       = (*ExtractingMemory)(nil)
+	_ sessions.ManageableMemory = (*ExtractingMemory)(nil)
+	_ sessions.ManageableMemory = (*VectorMemory)(nil)
+
+	_ common_tools.MemorySearcher  = (*ExtractingMemory)(nil)
+	_ common_tools.MemoryForgetter = (*ExtractingMemory)(nil)
+	_ common_tools.MemorySearcher  = (*VectorMemory)(nil)
+)
+
+// scriptedModel replies with canned text and records the prompts it receives
+type scriptedModel struct {
+	reply   string
+	prompts []string
+}
+
+func (m *scriptedModel) Model_Request(request models.Model_Request, tools []models.FunctionDeclaration, history []stores.Message) (models.Model_Response, error) {
+	m.prompts = append(m.prompts, request.User_Message.Content.Parts[0].Text)
+	return models.Model_Response{Parts: []models.Model_Part{{Text: &m.reply}}}, nil
+}
+
+func testTurn(t *testing.T, user, reply string) sessions.MemoryTurn {
+	t.Helper()
+	userParts, _ := json.Marshal([]models.User_Part{{Text: user}})
+	modelParts, _ := json.Marshal([]models.Model_Part{{Text: &reply}})
+	return sessions.MemoryTurn{
+		SessionID: "conv-1",
+		UserID:    "alice",
+		Messages: []stores.Message{
+			{Sequence: 7, Type: "user_message", Role: "user", PartsJSON: string(userParts)},
+			{Sequence: 8, Type: "model_message", Role: "model", PartsJSON: string(modelParts)},
+		},
+	}
+}
+
+func TestExtractingMemory_AddsWithProvenance(t *testing.T) {
+	mem, _ := newTestMemory(t)
+	model := &scriptedModel{reply: "```json\n{\"operations\":[{\"op\":\"add\",\"content\":\"The user is vegetarian\",\"kind\":\"preference\"}]}\n```"}
+	extractor := NewExtractingMemory(mem, model)
+
+	result, err := extractor.ExtractFromTurn(context.Background(), testTurn(t, "I'm vegetarian, suggest a dinner", "How about a mushroom risotto?"))
+	if err != nil {
+		t.Fatalf("ExtractFromTurn: %v", err)
+	}
+	if result.Added != 1 {
+		t.Fatalf("Expected 1 added memory, got %+v", result)
+	}
+	if !strings.Contains(model.prompts[0], "User: I'm vegetarian") {
+		t.Errorf("Expected the prompt to contain the turn, got %q", model.prompts[0])
+	}
+
+	entries, err := mem.ForUser("alice").(*VectorMemory).ListMemories(0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 memory for alice, got %v (err %v)", entries, err)
+	}
+	meta := entries[0].Metadata
+	if meta["source"] != "extraction" || meta["kind"] != "preference" || meta["conversation_id"] != "conv-1" || meta["sequence"] != float64(7) {
+		t.Errorf("Unexpected provenance: %v", meta)
+	}
+}
+
+func TestExtractingMemory_SkipsDuplicates(t *testing.T) {
+	mem, _ := newTestMemory(t)
+	alice := mem.ForUser("alice").(*VectorMemory)
+	if err := alice.AddMemory("The user is vegetarian", nil); err != nil {
+		t.Fatalf("AddMemory: %v", err)
+	}
+
+	model := &scriptedModel{reply: `{"operations":[{"op":"add","content":"The user is vegetarian"}]}`}
+	result, err := NewExtractingMemory(mem, model).ExtractFromTurn(context.Background(), testTurn(t, "Remember I'm vegetarian", "Noted."))
+	if err != nil {
+		t.Fatalf("ExtractFromTurn: %v", err)
+	}
+	if result.Added != 0 || result.Duplicates != 1 {
+		t.Errorf("Expected the duplicate to be skipped, got %+v", result)
+	}
+	if !strings.Contains(model.prompts[0], "[m1] The user is vegetarian") {
+		t.Errorf("Expected existing memories in the prompt, got %q", model.prompts[0])
+	}
+}
+
+func TestExtractingMemory_UpdatesAndDeletes(t *testing.T) {
+	mem, _ := newTestMemory(t)
+	alice := mem.ForUser("alice").(*VectorMemory)
+	ctx := context.Background()
+	if _, err := alice.Add(ctx, "The user lives in Lisbon", nil); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	model := &scriptedModel{reply: `{"operations":[{"op":"update","id":"m1","content":"The user lives in Porto"},{"op":"delete","id":"m9"}]}`}
+	extractor := NewExtractingMemory(mem, model)
+	result, err := extractor.ExtractFromTurn(ctx, testTurn(t, "I moved from Lisbon to Porto", "Enjoy Porto!"))
+	if err != nil {
+		t.Fatalf("ExtractFromTurn: %v", err)
+	}
+	if result.Updated != 1 || result.Deleted != 0 {
+		t.Fatalf("Expected 1 update and no deletes of unknown ids, got %+v", result)
+	}
+	entries, _ := alice.ListMemories(0)
+	if len(entries) != 1 || entries[0].Content != "The user lives in Porto" {
+		t.Fatalf("Expected the memory to be updated, got %v", entries)
+	}
+
+	model.reply = `{"operations":[{"op":"delete","id":"m1"}]}`
+	if err := extractor.ForUser("alice").(sessions.TurnMemory).ProcessTurn(testTurn(t, "Forget where I live", "Done.")); err != nil {
+		t.Fatalf("ProcessTurn: %v", err)
+	}
+	if entries, _ := alice.ListMemories(0); len(entries) != 0 {
+		t.Errorf("Expected the memory to be deleted, got %v", entries)
+	}
+}
+
+func TestExtractingMemory_ForgetMemory(t *testing.T) {
+	mem, _ := newTestMemory(t)
+	extractor := NewExtractingMemory(mem, &scriptedModel{}).ForUser("alice").(*ExtractingMemory)
+	if err := extractor.AddMemory("The user has a cat", nil); err != nil {
+		t.Fatalf("AddMemory: %v", err)
+	}
+	entries, err := extractor.ListMemories(10)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 memory, got %v (err %v)", entries, err)
+	}
+	if err := extractor.ForUser("bob").(*ExtractingMemory).ForgetMemory(entries[0].ID); err != ErrRecordNotFound {
+		t.Errorf("Expected other users to be unable to forget the memory, got %v", err)
+	}
+	if err := extractor.ForgetMemory(entries[0].ID); err != nil {
+		t.Fatalf("ForgetMemory: %v", err)
+	}
+	if entries, _ := extractor.ListMemories(10); len(entries) != 0 {
+		t.Errorf("Expected no memories after forgetting, got %v", entries)
+	}
+}
+
+func TestParseOperations_RejectsProse(t *testing.T) {
+	if _, err := parseOperations("Nothing to remember here."); err == nil {
+		t.Error("Expected an error for a reply without JSON")
+	}
+	ops, err := parseOperations(`Sure! {"operations":[]}`)
+	if err != nil || len(ops) != 0 {
+		t.Errorf("Expected no operations, got %v (err %v)", ops, err)
+	}
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Desarso/godantic/sessions"
+	"github.com/Desarso/godantic/stores"
+)
+
+var (
+	_ sessions.MemoryManager      = (*VectorMemory)(nil)
+	_ sessions.TenantScopedMemory = (*VectorMemory)(nil)
+	_ sessions.UserScopedMemory   = (*VectorMemory)(nil)
+)
+
+func newTestMemory(t *testing.T) (*VectorMemory, *SQLiteVectorStore) {
+	t.Helper()
+	base, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { base.Close() })
+
+	store, err := NewSQLiteVectorStore(base.DB())
+	if err != nil {
+		t.Fatalf("NewSQLiteVectorStore: %v", err)
+	}
+	return NewVectorMemory(NewHashingEmbedder(256), store), store
+}
+
+func TestHashingEmbedder_Deterministic(t *testing.T) {
+	embedder := NewHashingEmbedder(64)
+	vectors, err := embedder.Embed(context.Background(), []string{"I like green tea", "I like green tea", "The deploy failed"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vectors[0]) != 64 {
+		t.Fatalf("Expected 64 dimensions, got %d", len(vectors[0]))
+	}
+	if score := cosineSimilarity(vectors[0], vectors[1]); score < 0.9999 {
+		t.Errorf("Expected identical texts to have similarity 1, got %f", score)
+	}
+	if cosineSimilarity(vectors[0], vectors[2]) >= cosineSimilarity(vectors[0], vectors[1]) {
+		t.Errorf("Expected unrelated text to score lower than identical text")
+	}
+}
+
+func TestVectorMemory_RetrievesMostSimilar(t *testing.T) {
+	mem, _ := newTestMemory(t)
+	alice := mem.ForUser("alice")
+
+	for _, content := range []string{
+		"Alice prefers green tea in the morning",
+		"Alice's production deploys run on Kubernetes",
+		"Alice has a dog named Biscuit",
+	} {
+		if err := alice.AddMemory(content, map[string]interface{}{"role": "user"}); err != nil {
+			t.Fatalf("AddMemory: %v", err)
+		}
+	}
+
+	got, err := alice.RetrieveMemories("what tea does alice drink in the morning", 1)
+	if err != nil {
+		t.Fatalf("RetrieveMemories: %v", err)
+	}
+	if len(got) != 1 || got[0] != "Alice prefers green tea in the morning" {
+		t.Errorf("Expected the tea memory, got %v", got)
+	}
+}
+
+func TestVectorMemory_ScopesAndFilters(t *testing.T) {
+	mem, store := newTestMemory(t)
+	ctx := context.Background()
+
+	// Unscoped memory takes ownership from session metadata
+	if err := mem.AddMemory("Bob lives in Lisbon", map[string]interface{}{"user_id": "bob", "tenant_id": "acme", "role": "user"}); err != nil {
+		t.Fatalf("AddMemory: %v", err)
+	}
+	if err := mem.ForTenant("acme").(*VectorMemory).ForUser("carol").AddMemory("Carol lives in Lisbon", map[string]interface{}{"role": "model"}); err != nil {
+		t.Fatalf("AddMemory: %v", err)
+	}
+
+	bob := mem.ForTenant("acme").(*VectorMemory).ForUser("bob")
+	if got, _ := bob.RetrieveMemories("who lives in Lisbon", 10); len(got) != 1 || got[0] != "Bob lives in Lisbon" {
+		t.Errorf("Expected bob to see only his memory, got %v", got)
+	}
+	if got, _ := mem.ForUser("bob").RetrieveMemories("Lisbon", 10); len(got) != 0 {
+		t.Errorf("Expected the default tenant to see no acme memories, got %v", got)
+	}
+
+	tenantWide := mem.ForTenant("acme").(*VectorMemory).WithFilter(map[string]interface{}{"role": "model"})
+	if got, _ := tenantWide.RetrieveMemories("Lisbon", 10); len(got) != 1 || got[0] != "Carol lives in Lisbon" {
+		t.Errorf("Expected metadata filter to match carol's memory, got %v", got)
+	}
+
+	records, err := store.List(ctx, SearchOptions{Scope: Scope{TenantID: "acme", UserID: "bob"}})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("List: %v %v", records, err)
+	}
+	if err := store.Delete(ctx, Scope{TenantID: "acme", UserID: "carol"}, records[0].ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound deleting another user's memory, got %v", err)
+	}
+	if err := store.Delete(ctx, Scope{TenantID: "acme", UserID: "bob"}, records[0].ID); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+}
+
+func TestVectorLiteralRoundTrip(t *testing.T) {
+	vec := []float32{0.25, -1, 3.5e-7}
+	got, err := parseVectorLiteral(vectorLiteral(vec))
+	if err != nil {
+		t.Fatalf("parseVectorLiteral: %v", err)
+	}
+	for i := range vec {
+		if got[i] != vec[i] {
+			t.Errorf("Element %d: expected %v, got %v", i, vec[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostgresVectorStore keeps memories in PostgreSQL using the pgvector extension.
+// Searches use the cosine distance operator (<=>) backed by an HNSW index.
+type PostgresVectorStore struct {
+	db   *gorm.DB
+	dims int
+}
+
+// NewPostgresVectorStore enables pgvector and creates the memories table on db
+// (e.g. stores.PostgresStore.DB()). dims must match the embedder's Dimensions().
+func NewPostgresVectorStore(db *gorm.DB, dims int) (*PostgresVectorStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if dims <= 0 {
+		return nil, fmt.Errorf("vector dimensions must be positive, got %d", dims)
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS memories (
+			id TEXT PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			namespace TEXT NOT NULL DEFAULT '',
+			tenant_id TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+			embedding vector(%d) NOT NULL
+		)`, dims),
+		`ALTER TABLE memories ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_scope ON memories (tenant_id, user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_namespace ON memories (namespace)`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_embedding ON memories USING hnsw (embedding vector_cosine_ops)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return nil, fmt.Errorf("failed to set up memories table: %w", err)
+		}
+	}
+	return &PostgresVectorStore{db: db, dims: dims}, nil
+}
+
+// postgresMemoryRow is what searches and listings scan into
+type postgresMemoryRow struct {
+	ID            string
+	Namespace     string
+	TenantID      string
+	UserID        string
+	Content       string
+	MetadataJSON  string
+	EmbeddingText string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Score         float64
+}
+
+const postgresMemoryColumns = `id, namespace, tenant_id, user_id, content, metadata::text AS metadata_json,
+	embedding::text AS embedding_text, created_at, updated_at`
+
+func (r *postgresMemoryRow) toRecord() (*Record, error) {
+	embedding, err := parseVectorLiteral(r.EmbeddingText)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		ID:        r.ID,
+		Namespace: r.Namespace,
+		TenantID:  r.TenantID,
+		UserID:    r.UserID,
+		Content:   r.Content,
+		Metadata:  decodeMetadata(r.MetadataJSON),
+		Embedding: embedding,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}, nil
+}
+
+// Add inserts a record, assigning an ID when it has none
+func (s *PostgresVectorStore) Add(ctx context.Context, record *Record) error {
+	if len(record.Embedding) != s.dims {
+		return fmt.Errorf("embedding has %d dimensions, store expects %d", len(record.Embedding), s.dims)
+	}
+	if record.ID == "" {
+		record.ID = uuid.New().String()
+	}
+	metadata, err := encodeMetadata(record.Metadata)
+	if err != nil {
+		return err
+	}
+	if metadata == "" {
+		metadata = "{}"
+	}
+
+	now := time.Now()
+	err = s.db.WithContext(ctx).Exec(
+		`INSERT INTO memories (id, namespace, tenant_id, user_id, content, metadata, embedding, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?::jsonb, ?::vector, ?, ?)`,
+		record.ID, record.Namespace, record.TenantID, record.UserID, record.Content, metadata, vectorLiteral(record.Embedding), now, now,
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	record.CreatedAt, record.UpdatedAt = now, now
+	return nil
+}
+
+// Search returns the records most similar to embedding, best first
+func (s *PostgresVectorStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]ScoredRecord, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	vector := vectorLiteral(embedding)
+	where, args, err := scopeClause(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MinScore > 0 {
+		where += ` AND 1 - (embedding <=> ?::vector) >= ?`
+		args = append(args, vector, opts.MinScore)
+	}
+
+	query := fmt.Sprintf(`SELECT %s, 1 - (embedding <=> ?::vector) AS score FROM memories WHERE %s
+		ORDER BY embedding <=> ?::vector LIMIT ?`, postgresMemoryColumns, where)
+	args = append([]interface{}{vector}, args...)
+	args = append(args, vector, limit)
+
+	var rows []postgresMemoryRow
+	if err := s.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+
+	hits := make([]ScoredRecord, 0, len(rows))
+	for i := range rows {
+		record, err := rows[i].toRecord()
+		if err != nil {
+			return nil, fmt.Errorf("memory %s: %w", rows[i].ID, err)
+		}
+		hits = append(hits, ScoredRecord{Record: *record, Score: rows[i].Score})
+	}
+	return hits, nil
+}
+
+// List returns records in the scope matching the filter, newest first
+func (s *PostgresVectorStore) List(ctx context.Context, opts SearchOptions) ([]*Record, error) {
+	where, args, err := scopeClause(opts)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`SELECT %s FROM memories WHERE %s ORDER BY created_at DESC`, postgresMemoryColumns, where)
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	}
+
+	var rows []postgresMemoryRow
+	if err := s.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch memories: %w", err)
+	}
+
+	records := make([]*Record, 0, len(rows))
+	for i := range rows {
+		record, err := rows[i].toRecord()
+		if err != nil {
+			return nil, fmt.Errorf("memory %s: %w", rows[i].ID, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Update replaces the content, metadata and embedding of a record in the scope
+func (s *PostgresVectorStore) Update(ctx context.Context, scope Scope, record *Record) error {
+	if len(record.Embedding) != s.dims {
+		return fmt.Errorf("embedding has %d dimensions, store expects %d", len(record.Embedding), s.dims)
+	}
+	metadata, err := encodeMetadata(record.Metadata)
+	if err != nil {
+		return err
+	}
+	if metadata == "" {
+		metadata = "{}"
+	}
+	where, args, err := scopeClause(SearchOptions{Scope: scope})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	args = append([]interface{}{record.Content, metadata, vectorLiteral(record.Embedding), now, record.ID}, args...)
+	result := s.db.WithContext(ctx).Exec(
+		`UPDATE memories SET content = ?, metadata = ?::jsonb, embedding = ?::vector, updated_at = ? WHERE id = ? AND `+where,
+		args...,
+	)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update memory: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	record.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a record in the scope, returning ErrRecordNotFound when there is none
+func (s *PostgresVectorStore) Delete(ctx context.Context, scope Scope, id string) error {
+	where, args, err := scopeClause(SearchOptions{Scope: scope})
+	if err != nil {
+		return err
+	}
+	result := s.db.WithContext(ctx).Exec(`DELETE FROM memories WHERE id = ? AND `+where, append([]interface{}{id}, args...)...)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete memory: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteMatching removes every record in the scope whose metadata matches the filter
+func (s *PostgresVectorStore) DeleteMatching(ctx context.Context, opts SearchOptions) (int64, error) {
+	where, args, err := scopeClause(opts)
+	if err != nil {
+		return 0, err
+	}
+	result := s.db.WithContext(ctx).Exec(`DELETE FROM memories WHERE `+where, args...)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete memories: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// scopeClause builds the WHERE clause for namespace, tenant, user and metadata containment filters
+func scopeClause(opts SearchOptions) (string, []interface{}, error) {
+	where := `namespace = ? AND tenant_id = ?`
+	args := []interface{}{opts.Namespace, opts.TenantID}
+	if opts.UserID != "" {
+		where += ` AND user_id = ?`
+		args = append(args, opts.UserID)
+	}
+	if len(opts.Filter) > 0 {
+		filter, err := encodeMetadata(opts.Filter)
+		if err != nil {
+			return "", nil, err
+		}
+		where += ` AND metadata @> ?::jsonb`
+		args = append(args, filter)
+	}
+	return where, args, nil
+}
+
+// vectorLiteral formats a vector as a pgvector text literal, e.g. [0.1,0.2]
+func vectorLiteral(vec []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vec {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseVectorLiteral parses pgvector's text output
+func parseVectorLiteral(text string) ([]float32, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+	if text == "" {
+		return nil, nil
+	}
+	fields := strings.Split(text, ",")
+	vec := make([]float32, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector literal: %w", err)
+		}
+		vec[i] = float32(v)
+	}
+	return vec, nil
+}
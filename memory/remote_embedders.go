@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"os"
+
+	"github.com/Desarso/godantic/models/embeddings"
+)
+
+// OpenAIEmbedder calls an OpenAI-compatible /embeddings endpoint
+type OpenAIEmbedder = embeddings.OpenAI
+
+// GeminiEmbedder calls the Gemini batchEmbedContents API
+type GeminiEmbedder = embeddings.Gemini
+
+// NewOpenAIEmbedder creates an embedder using OPENAI_API_KEY.
+// Known models get their default dimensions; for others use WithDimensions.
+func NewOpenAIEmbedder(model string) *OpenAIEmbedder {
+	return embeddings.NewOpenAI(os.Getenv("OPENAI_API_KEY"), model)
+}
+
+// NewGeminiEmbedder creates an embedder using GEMINI_API_KEY
+func NewGeminiEmbedder(model string) *GeminiEmbedder {
+	return embeddings.NewGemini(os.Getenv("GEMINI_API_KEY"), model)
+}
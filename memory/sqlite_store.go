@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sqliteMemory is the SQLite row for a memory; embeddings are stored as float32 blobs
+type sqliteMemory struct {
+	ID           string    `gorm:"primaryKey;size:36"`
+	CreatedAt    time.Time `gorm:"index"`
+	UpdatedAt    time.Time
+	Namespace    string `gorm:"index;not null;default:''"`
+	TenantID     string `gorm:"index:idx_memories_scope;not null;default:''"`
+	UserID       string `gorm:"index:idx_memories_scope;not null;default:''"`
+	Content      string `gorm:"type:text;not null"`
+	MetadataJSON string `gorm:"type:text"`
+	Embedding    []byte `gorm:"type:blob"`
+}
+
+func (sqliteMemory) TableName() string {
+	return "memories"
+}
+
+func (m *sqliteMemory) toRecord() (*Record, error) {
+	embedding, err := decodeVector(m.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		ID:        m.ID,
+		Namespace: m.Namespace,
+		TenantID:  m.TenantID,
+		UserID:    m.UserID,
+		Content:   m.Content,
+		Metadata:  decodeMetadata(m.MetadataJSON),
+		Embedding: embedding,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}, nil
+}
+
+// SQLiteVectorStore keeps memories in SQLite and searches them by brute-force cosine similarity.
+// Every search scans the scope's rows, which is fine for per-user memories up to tens of thousands of rows.
+type SQLiteVectorStore struct {
+	db *gorm.DB
+}
+
+// NewSQLiteVectorStore creates the memories table on db (e.g. stores.SQLiteStore.DB())
+func NewSQLiteVectorStore(db *gorm.DB) (*SQLiteVectorStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := db.AutoMigrate(&sqliteMemory{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate memories table: %w", err)
+	}
+	return &SQLiteVectorStore{db: db}, nil
+}
+
+// Add inserts a record, assigning an ID when it has none
+func (s *SQLiteVectorStore) Add(ctx context.Context, record *Record) error {
+	if record.ID == "" {
+		record.ID = uuid.New().String()
+	}
+	metadata, err := encodeMetadata(record.Metadata)
+	if err != nil {
+		return err
+	}
+	row := sqliteMemory{
+		ID:           record.ID,
+		Namespace:    record.Namespace,
+		TenantID:     record.TenantID,
+		UserID:       record.UserID,
+		Content:      record.Content,
+		MetadataJSON: metadata,
+		Embedding:    encodeVector(record.Embedding),
+	}
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	record.CreatedAt, record.UpdatedAt = row.CreatedAt, row.UpdatedAt
+	return nil
+}
+
+// Search returns the records most similar to embedding, best first
+func (s *SQLiteVectorStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]ScoredRecord, error) {
+	records, err := s.List(ctx, SearchOptions{Scope: opts.Scope, Filter: opts.Filter})
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	hits := make([]ScoredRecord, 0, len(records))
+	for _, record := range records {
+		score := cosineSimilarity(embedding, record.Embedding)
+		if score < opts.MinScore {
+			continue
+		}
+		hits = append(hits, ScoredRecord{Record: *record, Score: score})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// List returns records in the scope matching the filter, newest first
+func (s *SQLiteVectorStore) List(ctx context.Context, opts SearchOptions) ([]*Record, error) {
+	query := s.scoped(ctx, opts.Scope).Order("created_at DESC")
+	// Metadata filters are applied in Go, so the limit can only be pushed down without them
+	if opts.Limit > 0 && len(opts.Filter) == 0 {
+		query = query.Limit(opts.Limit)
+	}
+
+	var rows []sqliteMemory
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch memories: %w", err)
+	}
+
+	records := make([]*Record, 0, len(rows))
+	for i := range rows {
+		record, err := rows[i].toRecord()
+		if err != nil {
+			return nil, fmt.Errorf("memory %s: %w", rows[i].ID, err)
+		}
+		if !matchesFilter(record.Metadata, opts.Filter) {
+			continue
+		}
+		records = append(records, record)
+		if opts.Limit > 0 && len(records) == opts.Limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+// Update replaces the content, metadata and embedding of a record in the scope
+func (s *SQLiteVectorStore) Update(ctx context.Context, scope Scope, record *Record) error {
+	metadata, err := encodeMetadata(record.Metadata)
+	if err != nil {
+		return err
+	}
+	query := s.scoped(ctx, scope).Model(&sqliteMemory{}).Where("id = ?", record.ID)
+	now := time.Now()
+	result := query.Updates(map[string]interface{}{
+		"content":       record.Content,
+		"metadata_json": metadata,
+		"embedding":     encodeVector(record.Embedding),
+		"updated_at":    now,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update memory: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	record.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a record in the scope, returning ErrRecordNotFound when there is none
+func (s *SQLiteVectorStore) Delete(ctx context.Context, scope Scope, id string) error {
+	result := s.scoped(ctx, scope).Where("id = ?", id).Delete(&sqliteMemory{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete memory: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteMatching removes every record in the scope whose metadata matches the filter
+func (s *SQLiteVectorStore) DeleteMatching(ctx context.Context, opts SearchOptions) (int64, error) {
+	query := s.scoped(ctx, opts.Scope)
+	if len(opts.Filter) > 0 {
+		// Metadata filters are applied in Go, so resolve the matching IDs first
+		records, err := s.List(ctx, SearchOptions{Scope: opts.Scope, Filter: opts.Filter})
+		if err != nil {
+			return 0, err
+		}
+		if len(records) == 0 {
+			return 0, nil
+		}
+		ids := make([]string, len(records))
+		for i, record := range records {
+			ids[i] = record.ID
+		}
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Delete(&sqliteMemory{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete memories: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// scoped restricts a query to the scope's namespace, tenant and (optional) user
+func (s *SQLiteVectorStore) scoped(ctx context.Context, scope Scope) *gorm.DB {
+	query := s.db.WithContext(ctx).Where("namespace = ? AND tenant_id = ?", scope.Namespace, scope.TenantID)
+	if scope.UserID != "" {
+		query = query.Where("user_id = ?", scope.UserID)
+	}
+	return query
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrRecordNotFound is returned when a memory does not exist in the requested scope
+var ErrRecordNotFound = errors.New("memory not found")
+
+// Record is a stored memory with its embedding
+type Record struct {
+	ID        string                 `json:"id"`
+	Namespace string                 `json:"namespace,omitempty"`
+	TenantID  string                 `json:"tenant_id,omitempty"`
+	UserID    string                 `json:"user_id,omitempty"`
+	Content   string                 `json:"content"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Embedding []float32              `json:"-"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// ScoredRecord is a search hit with its cosine similarity to the query
+type ScoredRecord struct {
+	Record
+	Score float64 `json:"score"`
+}
+
+// Scope restricts store operations to a tenant and, optionally, a single user.
+// An empty UserID covers every user of the tenant. Namespace separates independent
+// users of the same table: memories use "", document collections use their own.
+type Scope struct {
+	Namespace string
+	TenantID  string
+	UserID    string
+}
+
+// SearchOptions narrows searches and listings
+type SearchOptions struct {
+	Scope
+	Filter   map[string]interface{} // Metadata keys that must equal these values
+	Limit    int                    // Maximum results (10 for searches when <= 0, unlimited for listings)
+	MinScore float64                // Minimum cosine similarity for search hits
+}
+
+// VectorStore persists memory records and finds the nearest ones to a query embedding
+type VectorStore interface {
+	Add(ctx context.Context, record *Record) error
+	Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]ScoredRecord, error)
+	List(ctx context.Context, opts SearchOptions) ([]*Record, error)
+	// Update replaces the content, metadata and embedding of an existing record in the scope
+	Update(ctx context.Context, scope Scope, record *Record) error
+	Delete(ctx context.Context, scope Scope, id string) error
+	// DeleteMatching removes every record in the scope whose metadata matches the filter
+	DeleteMatching(ctx context.Context, opts SearchOptions) (int64, error)
+}
+
+// matchesFilter reports whether metadata contains every key/value in filter.
+// Values are compared after a JSON round trip so numbers match regardless of Go type.
+func matchesFilter(metadata, filter map[string]interface{}) bool {
+	for key, want := range filter {
+		have, ok := metadata[key]
+		if !ok || !jsonEqual(have, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonEqual(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// encodeVector packs a vector as little-endian float32s
+func encodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeVector unpacks a vector written by encodeVector
+func decodeVector(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("invalid vector encoding of %d bytes", len(buf))
+	}
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vec, nil
+}
+
+func encodeMetadata(metadata map[string]interface{}) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal memory metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeMetadata(data string) map[string]interface{} {
+	if data == "" {
+		return nil
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return nil
+	}
+	return metadata
+}
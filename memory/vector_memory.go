@@ -0,0 +1,199 @@
+// Package memory provides a vector-backed implementation of sessions.MemoryManager.
+//
+// Memories are embedded with a pluggable Embedder and stored in a VectorStore
+// (SQLite with brute-force cosine similarity, or PostgreSQL with pgvector).
+// A VectorMemory can be scoped to a tenant and a user so sessions only see their own memories.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Desarso/godantic/common_tools"
+	"github.com/Desarso/godantic/sessions"
+)
+
+// VectorMemory stores and retrieves memories by embedding similarity
+type VectorMemory struct {
+	embedder Embedder
+	store    VectorStore
+	scope    Scope
+	filter   map[string]interface{}
+	minScore float64
+	timeout  time.Duration
+}
+
+// NewVectorMemory creates a memory manager from an embedder and a vector store
+func NewVectorMemory(embedder Embedder, store VectorStore) *VectorMemory {
+	return &VectorMemory{
+		embedder: embedder,
+		store:    store,
+		timeout:  30 * time.Second,
+	}
+}
+
+// WithMinScore drops retrieved memories whose cosine similarity is below score
+func (m *VectorMemory) WithMinScore(score float64) *VectorMemory {
+	m.minScore = score
+	return m
+}
+
+// WithFilter restricts retrieval to memories whose metadata matches filter
+func (m *VectorMemory) WithFilter(filter map[string]interface{}) *VectorMemory {
+	m.filter = filter
+	return m
+}
+
+// WithTimeout bounds each embedding and store call made through the MemoryManager methods
+func (m *VectorMemory) WithTimeout(timeout time.Duration) *VectorMemory {
+	m.timeout = timeout
+	return m
+}
+
+// ForTenant returns a copy scoped to tenantID (implements sessions.TenantScopedMemory)
+func (m *VectorMemory) ForTenant(tenantID string) sessions.MemoryManager {
+	scoped := *m
+	scoped.scope.TenantID = tenantID
+	return &scoped
+}
+
+// ForUser returns a copy scoped to userID (implements sessions.UserScopedMemory)
+func (m *VectorMemory) ForUser(userID string) sessions.MemoryManager {
+	scoped := *m
+	scoped.scope.UserID = userID
+	return &scoped
+}
+
+// Scope returns the tenant and user this memory is restricted to
+func (m *VectorMemory) Scope() Scope {
+	return m.scope
+}
+
+func (m *VectorMemory) context() (context.Context, context.CancelFunc) {
+	if m.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), m.timeout)
+}
+
+// AddMemory embeds and stores content. When the memory is not scoped, "tenant_id" and
+// "user_id" metadata (as set by AgentSession) decide who the memory belongs to.
+func (m *VectorMemory) AddMemory(content string, metadata map[string]interface{}) error {
+	ctx, cancel := m.context()
+	defer cancel()
+	_, err := m.Add(ctx, content, metadata)
+	return err
+}
+
+// Add embeds and stores content, returning the stored record
+func (m *VectorMemory) Add(ctx context.Context, content string, metadata map[string]interface{}) (*Record, error) {
+	if content == "" {
+		return nil, fmt.Errorf("memory content is empty")
+	}
+	vectors, err := m.embedder.Embed(ctx, []string{content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed memory: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 input", len(vectors))
+	}
+
+	record := &Record{
+		TenantID:  m.scope.TenantID,
+		UserID:    m.scope.UserID,
+		Content:   content,
+		Metadata:  metadata,
+		Embedding: vectors[0],
+	}
+	if record.TenantID == "" {
+		record.TenantID, _ = metadata["tenant_id"].(string)
+	}
+	if record.UserID == "" {
+		record.UserID, _ = metadata["user_id"].(string)
+	}
+
+	if err := m.store.Add(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RetrieveMemories returns the content of the memories most similar to queryText
+func (m *VectorMemory) RetrieveMemories(queryText string, limit int) ([]string, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+	hits, err := m.Search(ctx, queryText, limit)
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]string, len(hits))
+	for i, hit := range hits {
+		contents[i] = hit.Content
+	}
+	return contents, nil
+}
+
+// Search returns the memories most similar to queryText with their scores, best first
+func (m *VectorMemory) Search(ctx context.Context, queryText string, limit int) ([]ScoredRecord, error) {
+	if queryText == "" {
+		return nil, nil
+	}
+	vectors, err := m.embedder.Embed(ctx, []string{queryText})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 input", len(vectors))
+	}
+	return m.store.Search(ctx, vectors[0], SearchOptions{
+		Scope:    m.scope,
+		Filter:   m.filter,
+		Limit:    limit,
+		MinScore: m.minScore,
+	})
+}
+
+// SearchMemories returns the memories most similar to queryText with their IDs
+// (implements common_tools.MemorySearcher for the Recall tool)
+func (m *VectorMemory) SearchMemories(queryText string, limit int) ([]common_tools.MemoryHit, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+	hits, err := m.Search(ctx, queryText, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]common_tools.MemoryHit, len(hits))
+	for i, hit := range hits {
+		results[i] = common_tools.MemoryHit{ID: hit.ID, Content: hit.Content, Score: hit.Score}
+	}
+	return results, nil
+}
+
+// ListMemories returns the scope's memories, newest first (implements sessions.ManageableMemory)
+func (m *VectorMemory) ListMemories(limit int) ([]sessions.MemoryEntry, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+	records, err := m.store.List(ctx, SearchOptions{Scope: m.scope, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]sessions.MemoryEntry, len(records))
+	for i, record := range records {
+		entries[i] = sessions.MemoryEntry{
+			ID:        record.ID,
+			Content:   record.Content,
+			Metadata:  record.Metadata,
+			CreatedAt: record.CreatedAt,
+			UpdatedAt: record.UpdatedAt,
+		}
+	}
+	return entries, nil
+}
+
+// ForgetMemory deletes a memory in the scope, returning ErrRecordNotFound when it does not exist
+func (m *VectorMemory) ForgetMemory(id string) error {
+	ctx, cancel := m.context()
+	defer cancel()
+	return m.store.Delete(ctx, m.scope, id)
+}
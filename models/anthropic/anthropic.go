@@ -6,14 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/Desarso/godantic/logging"
 	models "github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
-	"github.com/joho/godotenv"
 )
 
 const (
@@ -21,14 +20,9 @@ const (
 	DefaultAPIVersion = "2023-06-01"
 	DefaultModel      = "claude-sonnet-4-20250514"
 	DefaultMaxTokens  = 4096
+	MinThinkingBudget = 1024
 )
 
-func init() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-}
-
 // Anthropic_Model implements the godantic Model interface for the Anthropic Messages API.
 type Anthropic_Model struct {
 	Model        string
@@ -37,9 +31,18 @@ type Anthropic_Model struct {
 	SystemPrompt string
 	BaseURL      string   // Optional: custom API endpoint
 	APIKeyEnv    string   // Optional: env var name for API key (defaults to ANTHROPIC_API_KEY)
+	Client       *models.ProviderClient `json:"-"` // Optional: API key, endpoint, HTTP client and headers; takes precedence over BaseURL and APIKeyEnv
 	SupportsVision bool
+	Caching        PromptCaching // Prompt cache breakpoints; on by default
+	ThinkingBudget int           // Optional: enables extended thinking with this many tokens (at least MinThinkingBudget)
 
 	WarningCallback func(warnings []models.HistoryWarning) `json:"-"`
+	Logger          *slog.Logger                           `json:"-"` // Optional: defaults to logging.Default()
+}
+
+// logger returns the model's logger tagged with the provider and model
+func (a *Anthropic_Model) logger() *slog.Logger {
+	return logging.Or(a.Logger).With(logging.KeyProvider, "anthropic", logging.KeyModel, a.Model)
 }
 
 // SetHistoryWarningCallback implements the HistoryWarner interface.
@@ -73,18 +76,14 @@ func (a *Anthropic_Model) Model_Request(request models.Model_Request, tools []mo
 		return models.Model_Response{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	baseURL := a.BaseURL
-	if baseURL == "" {
-		baseURL = DefaultBaseURL
-	}
-
-	req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+	client := a.client(request.Client)
+	req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 	if err != nil {
 		return models.Model_Response{}, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
-	a.setHeaders(req)
+	a.setHeaders(req, client)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return models.Model_Response{}, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -145,19 +144,15 @@ func (a *Anthropic_Model) Stream_Model_Request(request models.Model_Request, too
 			return
 		}
 
-		baseURL := a.BaseURL
-		if baseURL == "" {
-			baseURL = DefaultBaseURL
-		}
-
-		req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+		client := a.client(request.Client)
+		req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 		if err != nil {
 			errChan <- fmt.Errorf("failed to create HTTP request: %w", err)
 			return
 		}
-		a.setHeaders(req)
+		a.setHeaders(req, client)
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			errChan <- fmt.Errorf("HTTP request failed: %w", err)
 			return
@@ -189,6 +184,9 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 	}
 	toolBlocks := make(map[int]*toolBlock)
 
+	// Usage arrives split across message_start (input) and message_delta (output)
+	var usage Usage
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -203,6 +201,7 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 			Message      json.RawMessage `json:"message"`
 			ContentBlock json.RawMessage `json:"content_block"`
 			Delta        json.RawMessage `json:"delta"`
+			Usage        *Usage          `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &raw); err != nil {
 			continue
@@ -218,6 +217,11 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 						id:   block.ID,
 						name: block.Name,
 					}
+				} else if block.Type == "redacted_thinking" && block.Data != "" {
+					data := block.Data
+					respChan <- models.Model_Response{
+						Parts: []models.Model_Part{{RedactedReasoning: &data}},
+					}
 				}
 			}
 
@@ -227,6 +231,8 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 					Type        string `json:"type"`
 					Text        string `json:"text"`
 					PartialJSON string `json:"partial_json"`
+					Thinking    string `json:"thinking"`
+					Signature   string `json:"signature"`
 				}
 				json.Unmarshal(raw.Delta, &delta)
 
@@ -235,6 +241,17 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 					respChan <- models.Model_Response{
 						Parts: []models.Model_Part{{Text: &text}},
 					}
+				} else if delta.Type == "thinking_delta" && delta.Thinking != "" {
+					thinking := delta.Thinking
+					respChan <- models.Model_Response{
+						Parts: []models.Model_Part{{Reasoning: &thinking}},
+					}
+				} else if delta.Type == "signature_delta" && delta.Signature != "" {
+					// Closes the thinking block streamed before it
+					signature := delta.Signature
+					respChan <- models.Model_Response{
+						Parts: []models.Model_Part{{Signature: &signature}},
+					}
 				} else if delta.Type == "input_json_delta" {
 					if tb, ok := toolBlocks[raw.Index]; ok {
 						tb.json.WriteString(delta.PartialJSON)
@@ -263,6 +280,31 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 				delete(toolBlocks, raw.Index)
 			}
 
+		case EventMessageStart:
+			if raw.Message != nil {
+				var msg struct {
+					Usage Usage `json:"usage"`
+				}
+				if err := json.Unmarshal(raw.Message, &msg); err == nil {
+					usage = msg.Usage
+				}
+			}
+
+		case EventMessageDelta:
+			var delta struct {
+				StopReason string `json:"stop_reason"`
+			}
+			if raw.Delta != nil {
+				json.Unmarshal(raw.Delta, &delta)
+			}
+			if raw.Usage != nil {
+				usage.OutputTokens = raw.Usage.OutputTokens
+			}
+			respChan <- models.Model_Response{
+				FinishReason: delta.StopReason,
+				Usage:        usage.toModelUsage(),
+			}
+
 		case EventMessageStop:
 			return
 		}
@@ -275,10 +317,22 @@ func (a *Anthropic_Model) parseSSEStream(r io.Reader, respChan chan<- models.Mod
 
 // toModelResponse converts an Anthropic response to godantic's Model_Response.
 func (a *Anthropic_Model) toModelResponse(resp AnthropicResponse) models.Model_Response {
-	modelResp := models.Model_Response{}
+	modelResp := models.Model_Response{
+		FinishReason: resp.StopReason,
+		Usage:        resp.Usage.toModelUsage(),
+	}
 
 	for _, block := range resp.Content {
 		switch block.Type {
+		case "thinking":
+			thinking, signature := "", block.Signature
+			if block.Thinking != nil {
+				thinking = *block.Thinking
+			}
+			modelResp.Parts = append(modelResp.Parts, models.Model_Part{Reasoning: &thinking, Signature: &signature})
+		case "redacted_thinking":
+			data := block.Data
+			modelResp.Parts = append(modelResp.Parts, models.Model_Part{RedactedReasoning: &data})
 		case "text":
 			if block.Text != "" {
 				text := block.Text
@@ -317,7 +371,7 @@ func (a *Anthropic_Model) buildRequest(model string, message models.User_Message
 	for _, histMsg := range conversationHistory {
 		msg, err := a.convertHistoryMessage(histMsg)
 		if err != nil {
-			log.Printf("Warning: Failed to convert history message %d: %v", histMsg.ID, err)
+			a.logger().Warn("Failed to convert history message", "message_id", histMsg.ID, logging.KeyError, err)
 			continue
 		}
 		if msg != nil {
@@ -359,24 +413,44 @@ func (a *Anthropic_Model) buildRequest(model string, message models.User_Message
 		maxTokens = *a.MaxTokens
 	}
 
-	systemPrompt := a.SystemPrompt
-
 	req := AnthropicRequest{
 		Model:     model,
 		MaxTokens: maxTokens,
 		Messages:  messages,
-		System:    systemPrompt,
 		Stream:    stream,
 	}
 
+	if a.SystemPrompt != "" {
+		req.System = []ContentBlock{{Type: "text", Text: a.SystemPrompt}}
+	}
+
 	if len(tools) > 0 {
 		req.Tools = ConvertToAnthropicTools(tools)
 	}
 
+	if a.ThinkingBudget > 0 {
+		budget := a.ThinkingBudget
+		if budget < MinThinkingBudget {
+			budget = MinThinkingBudget
+		}
+		req.Thinking = &ThinkingConfig{Type: "enabled", BudgetTokens: budget}
+		// The budget is part of max_tokens, so leave room for the answer
+		if req.MaxTokens <= budget {
+			req.MaxTokens = budget + DefaultMaxTokens
+		}
+	}
+
+	// Thinking only runs at the default temperature
 	if a.Temperature != nil {
-		req.Temperature = a.Temperature
+		if req.Thinking == nil {
+			req.Temperature = a.Temperature
+		} else {
+			a.logger().Warn("Temperature is not supported with extended thinking, using the default", "temperature", *a.Temperature)
+		}
 	}
 
+	a.Caching.applyCacheControl(&req)
+
 	return req, nil
 }
 
@@ -471,7 +545,22 @@ func (a *Anthropic_Model) convertHistoryMessage(histMsg stores.Message) (*Anthro
 		}
 
 		var blocks []ContentBlock
+		// Streamed thinking arrives as Reasoning deltas closed by a Signature part. Only signed
+		// blocks can be replayed; unsigned reasoning (e.g. from another provider) is dropped, and
+		// signatures on text or function call parts are Gemini's.
+		var thinking strings.Builder
 		for _, part := range modelParts {
+			if part.RedactedReasoning != nil {
+				blocks = append(blocks, ContentBlock{Type: "redacted_thinking", Data: *part.RedactedReasoning})
+			}
+			if part.Reasoning != nil {
+				thinking.WriteString(*part.Reasoning)
+			}
+			if part.Signature != nil && *part.Signature != "" && part.Text == nil && part.FunctionCall == nil {
+				text := thinking.String()
+				blocks = append(blocks, ContentBlock{Type: "thinking", Thinking: &text, Signature: *part.Signature})
+				thinking.Reset()
+			}
 			if part.Text != nil && *part.Text != "" {
 				blocks = append(blocks, ContentBlock{Type: "text", Text: *part.Text})
 			}
@@ -521,14 +610,19 @@ func (a *Anthropic_Model) convertUserMessage(message models.User_Message) *Anthr
 	}
 }
 
-// setHeaders sets required headers for Anthropic API requests.
-func (a *Anthropic_Model) setHeaders(req *http.Request) {
-	apiKeyEnv := a.APIKeyEnv
-	if apiKeyEnv == "" {
-		apiKeyEnv = "ANTHROPIC_API_KEY"
+// client returns the connection settings for a request: override, then Client, then BaseURL,
+// DefaultBaseURL and the API key environment variable
+func (a *Anthropic_Model) client(override *models.ProviderClient) models.ProviderClient {
+	defaults := models.ProviderClient{BaseURL: a.BaseURL}
+	if defaults.BaseURL == "" {
+		defaults.BaseURL = DefaultBaseURL
 	}
-	apiKey := os.Getenv(apiKeyEnv)
+	return defaults.Merge(a.Client).Merge(override).APIKeyFromEnv(a.APIKeyEnv, "ANTHROPIC_API_KEY")
+}
+
+// setHeaders sets required headers for Anthropic API requests.
+func (a *Anthropic_Model) setHeaders(req *http.Request, client models.ProviderClient) {
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", apiKey)
+	req.Header.Set("X-API-Key", client.APIKey)
 	req.Header.Set("anthropic-version", DefaultAPIVersion)
 }
@@ -0,0 +1,78 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+)
+
+func userMessage(text string) *models.User_Message {
+	return &models.User_Message{Role: "user", Content: models.Content{Parts: []models.User_Part{{Text: text}}}}
+}
+
+// parseStream runs an SSE body through parseSSEStream and returns the responses it produced
+func parseStream(t *testing.T, model *Anthropic_Model, events ...string) []models.Model_Response {
+	t.Helper()
+	var body strings.Builder
+	for _, event := range events {
+		body.WriteString("data: " + event + "\n\n")
+	}
+	respChan := make(chan models.Model_Response, 100)
+	errChan := make(chan error, 1)
+	model.parseSSEStream(strings.NewReader(body.String()), respChan, errChan)
+	close(respChan)
+	close(errChan)
+	if err := <-errChan; err != nil {
+		t.Fatalf("parseSSEStream: %v", err)
+	}
+	var responses []models.Model_Response
+	for response := range respChan {
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func TestModelRequest_ProviderClient(t *testing.T) {
+	var gotKey, gotHeader, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotHeader, gotPath = r.Header.Get("X-API-Key"), r.Header.Get("X-Team"), r.URL.Path
+		json.NewEncoder(w).Encode(AnthropicResponse{
+			Type:       "message",
+			Role:       "assistant",
+			Content:    []ContentBlock{{Type: "text", Text: "hi from the proxy"}},
+			StopReason: "end_turn",
+			Usage:      Usage{InputTokens: 5, OutputTokens: 3},
+		})
+	}))
+	defer srv.Close()
+
+	model := &Anthropic_Model{Client: &models.ProviderClient{
+		APIKey:  "model-key",
+		BaseURL: srv.URL + "/v1/messages",
+		Headers: map[string]string{"X-Team": "search"},
+	}}
+
+	response, err := model.Model_Request(models.Model_Request{User_Message: userMessage("hello")}, nil, nil)
+	if err != nil {
+		t.Fatalf("Model_Request: %v", err)
+	}
+	if gotPath != "/v1/messages" || gotKey != "model-key" || gotHeader != "search" {
+		t.Errorf("Unexpected request: path %q, key %q, X-Team %q", gotPath, gotKey, gotHeader)
+	}
+	if len(response.Parts) != 1 || response.Parts[0].Text == nil || *response.Parts[0].Text != "hi from the proxy" {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	// A request's own client overrides the model's
+	request := models.Model_Request{User_Message: userMessage("hello"), Client: &models.ProviderClient{APIKey: "user-key"}}
+	if _, err := model.Model_Request(request, nil, nil); err != nil {
+		t.Fatalf("Model_Request with override: %v", err)
+	}
+	if gotKey != "user-key" || gotHeader != "search" {
+		t.Errorf("Expected the request's key and the model's headers, got key %q, X-Team %q", gotKey, gotHeader)
+	}
+}
@@ -0,0 +1,50 @@
+package anthropic
+
+// PromptCaching configures the cache_control breakpoints added to each request.
+// The zero value caches the system prompt, the tool definitions and the conversation
+// prefix with the default five minute TTL. Prefixes below the model's minimum cacheable
+// length are not cached by the API and cost nothing extra.
+type PromptCaching struct {
+	Disabled     bool   // Send no breakpoints
+	SkipSystem   bool   // Don't cache the system prompt
+	SkipTools    bool   // Don't cache the tool definitions
+	SkipMessages bool   // Don't cache the conversation prefix
+	TTL          string // "5m" (default) or "1h"
+}
+
+// applyCacheControl marks the cacheable prefixes of req. It uses at most four breakpoints,
+// the API's limit: system prompt, tools, and the last two user turns of the conversation.
+// The breakpoint on the previous user turn is where the prior request of a tool loop wrote
+// its cache entry, so each iteration reads that prefix instead of paying for it again.
+func (c PromptCaching) applyCacheControl(req *AnthropicRequest) {
+	if c.Disabled {
+		return
+	}
+	control := &CacheControl{Type: "ephemeral", TTL: c.TTL}
+
+	if !c.SkipSystem && len(req.System) > 0 {
+		req.System[len(req.System)-1].CacheControl = control
+	}
+	if !c.SkipTools && len(req.Tools) > 0 {
+		req.Tools[len(req.Tools)-1].CacheControl = control
+	}
+	if c.SkipMessages {
+		return
+	}
+
+	marked := 0
+	for i := len(req.Messages) - 1; i >= 0 && marked < 2; i-- {
+		if req.Messages[i].Role != "user" {
+			continue
+		}
+		blocks := toContentBlocks(req.Messages[i].Content)
+		if len(blocks) == 0 {
+			continue
+		}
+		// Copy so breakpoints never leak into blocks shared with the caller
+		blocks = append([]ContentBlock(nil), blocks...)
+		blocks[len(blocks)-1].CacheControl = control
+		req.Messages[i].Content = blocks
+		marked++
+	}
+}
@@ -0,0 +1,189 @@
+package anthropic
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// cacheTestRequest has two system blocks, two tools and a tool loop in progress
+func cacheTestRequest(sharedBlocks []ContentBlock) AnthropicRequest {
+	return AnthropicRequest{
+		System: []ContentBlock{{Type: "text", Text: "You are helpful."}, {Type: "text", Text: "Be brief."}},
+		Tools:  []AnthropicTool{{Name: "search"}, {Name: "fetch"}},
+		Messages: []AnthropicMsg{
+			{Role: "user", Content: "Find the docs"},
+			{Role: "assistant", Content: []ContentBlock{{Type: "tool_use", ID: "t1", Name: "search"}}},
+			{Role: "user", Content: sharedBlocks},
+			{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "Found them."}}},
+			{Role: "user", Content: "Summarize"},
+		},
+	}
+}
+
+// breakpoints lists where req carries cache_control, with the TTL of each
+func breakpoints(req AnthropicRequest) []string {
+	var marks []string
+	mark := func(where string, control *CacheControl) {
+		if control != nil {
+			marks = append(marks, fmt.Sprintf("%s/%s", where, control.TTL))
+		}
+	}
+	for i, block := range req.System {
+		mark(fmt.Sprintf("system[%d]", i), block.CacheControl)
+	}
+	for _, tool := range req.Tools {
+		mark("tool:"+tool.Name, tool.CacheControl)
+	}
+	for i, msg := range req.Messages {
+		for j, block := range toContentBlocks(msg.Content) {
+			mark(fmt.Sprintf("messages[%d][%d]", i, j), block.CacheControl)
+		}
+	}
+	return marks
+}
+
+func TestApplyCacheControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		caching PromptCaching
+		build   func([]ContentBlock) AnthropicRequest
+		want    []string
+	}{
+		{
+			name:    "default",
+			caching: PromptCaching{},
+			want:    []string{"system[1]/", "tool:fetch/", "messages[2][1]/", "messages[4][0]/"},
+		},
+		{
+			name:    "one hour TTL",
+			caching: PromptCaching{TTL: "1h"},
+			want:    []string{"system[1]/1h", "tool:fetch/1h", "messages[2][1]/1h", "messages[4][0]/1h"},
+		},
+		{
+			name:    "disabled",
+			caching: PromptCaching{Disabled: true},
+		},
+		{
+			name:    "skip system",
+			caching: PromptCaching{SkipSystem: true},
+			want:    []string{"tool:fetch/", "messages[2][1]/", "messages[4][0]/"},
+		},
+		{
+			name:    "skip tools",
+			caching: PromptCaching{SkipTools: true},
+			want:    []string{"system[1]/", "messages[2][1]/", "messages[4][0]/"},
+		},
+		{
+			name:    "skip messages",
+			caching: PromptCaching{SkipMessages: true},
+			want:    []string{"system[1]/", "tool:fetch/"},
+		},
+		{
+			name:    "first turn",
+			caching: PromptCaching{},
+			build: func([]ContentBlock) AnthropicRequest {
+				return AnthropicRequest{
+					System:   []ContentBlock{{Type: "text", Text: "You are helpful."}},
+					Messages: []AnthropicMsg{{Role: "user", Content: "Hi"}},
+				}
+			},
+			want: []string{"system[0]/", "messages[0][0]/"},
+		},
+		{
+			name:    "no system or tools",
+			caching: PromptCaching{},
+			build: func(shared []ContentBlock) AnthropicRequest {
+				req := cacheTestRequest(shared)
+				req.System, req.Tools = nil, nil
+				return req
+			},
+			want: []string{"messages[2][1]/", "messages[4][0]/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared := []ContentBlock{
+				{Type: "tool_result", ToolUseID: "t1", Content: "docs"},
+				{Type: "text", Text: "Also check the FAQ"},
+			}
+			build := tt.build
+			if build == nil {
+				build = cacheTestRequest
+			}
+			req := build(shared)
+			tt.caching.applyCacheControl(&req)
+
+			got := breakpoints(req)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected breakpoints %v, got %v", tt.want, got)
+			}
+			if len(got) > 4 {
+				t.Errorf("Expected at most 4 breakpoints, got %d", len(got))
+			}
+			for _, block := range shared {
+				if block.CacheControl != nil {
+					t.Errorf("Expected the caller's blocks to stay unmarked, got %+v", block)
+				}
+			}
+		})
+	}
+}
+
+func TestUsage_ToModelUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  models.Usage
+	}{
+		{
+			name:  "no cache",
+			usage: Usage{InputTokens: 100, OutputTokens: 20},
+			want:  models.Usage{InputTokens: 100, OutputTokens: 20, TotalTokens: 120},
+		},
+		{
+			name:  "cache write",
+			usage: Usage{InputTokens: 10, OutputTokens: 20, CacheCreationInputTokens: 2000},
+			want:  models.Usage{InputTokens: 2010, OutputTokens: 20, TotalTokens: 2030, CacheWriteTokens: 2000},
+		},
+		{
+			name:  "cache read",
+			usage: Usage{InputTokens: 10, OutputTokens: 20, CacheReadInputTokens: 2000},
+			want:  models.Usage{InputTokens: 2010, OutputTokens: 20, TotalTokens: 2030, CacheReadTokens: 2000},
+		},
+		{
+			name:  "read and write",
+			usage: Usage{InputTokens: 5, OutputTokens: 1, CacheReadInputTokens: 2000, CacheCreationInputTokens: 300},
+			want:  models.Usage{InputTokens: 2305, OutputTokens: 1, TotalTokens: 2306, CacheReadTokens: 2000, CacheWriteTokens: 300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.usage.toModelUsage(); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestParseSSEStream_CacheUsage(t *testing.T) {
+	responses := parseStream(t, &Anthropic_Model{},
+		`{"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1,"cache_read_input_tokens":1800,"cache_creation_input_tokens":250}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":9}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	last := responses[len(responses)-1]
+	want := models.Usage{InputTokens: 2062, OutputTokens: 9, TotalTokens: 2071, CacheReadTokens: 1800, CacheWriteTokens: 250}
+	if last.Usage == nil || *last.Usage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, last.Usage)
+	}
+	if last.FinishReason != "end_turn" {
+		t.Errorf("Expected finish reason end_turn, got %q", last.FinishReason)
+	}
+}
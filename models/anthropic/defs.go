@@ -9,11 +9,18 @@ type AnthropicRequest struct {
 	Model       string           `json:"model"`
 	MaxTokens   int              `json:"max_tokens"`
 	Messages    []AnthropicMsg   `json:"messages"`
-	System      string           `json:"system,omitempty"`
+	System      []ContentBlock   `json:"system,omitempty"` // text blocks, so they can carry cache_control
 	Tools       []AnthropicTool  `json:"tools,omitempty"`
 	Stream      bool             `json:"stream,omitempty"`
 	Temperature *float64         `json:"temperature,omitempty"`
 	TopP        *float64         `json:"top_p,omitempty"`
+	Thinking    *ThinkingConfig  `json:"thinking,omitempty"`
+}
+
+// ThinkingConfig enables extended thinking.
+type ThinkingConfig struct {
+	Type         string `json:"type"`          // "enabled"
+	BudgetTokens int    `json:"budget_tokens"` // at least MinThinkingBudget, below max_tokens
 }
 
 // AnthropicMsg is a message in the Anthropic format.
@@ -33,6 +40,16 @@ type ContentBlock struct {
 	Content   interface{} `json:"content,omitempty"`     // for tool_result (string or nested blocks)
 	IsError   bool        `json:"is_error,omitempty"`    // for tool_result
 	Source    *ImageSource `json:"source,omitempty"`     // for image
+	CacheControl *CacheControl `json:"cache_control,omitempty"` // prompt cache breakpoint
+	Thinking  *string `json:"thinking,omitempty"` // for thinking; may be empty but must be present
+	Signature string `json:"signature,omitempty"` // for thinking; required when replayed
+	Data      string `json:"data,omitempty"`      // for redacted_thinking
+}
+
+// CacheControl marks the end of a cacheable prompt prefix.
+type CacheControl struct {
+	Type string `json:"type"`          // "ephemeral"
+	TTL  string `json:"ttl,omitempty"` // "5m" (default) or "1h"
 }
 
 // ImageSource for base64-encoded images.
@@ -47,6 +64,7 @@ type AnthropicTool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description,omitempty"`
 	InputSchema interface{} `json:"input_schema"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // AnthropicResponse is the non-streaming response.
@@ -60,10 +78,24 @@ type AnthropicResponse struct {
 	Usage      Usage          `json:"usage"`
 }
 
-// Usage tracks token consumption.
+// Usage tracks token consumption. InputTokens excludes the tokens read from or written to the cache.
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// toModelUsage converts Anthropic token counts to godantic's Usage, whose InputTokens include cached tokens.
+func (u Usage) toModelUsage() *models.Usage {
+	input := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &models.Usage{
+		InputTokens:      input,
+		OutputTokens:     u.OutputTokens,
+		TotalTokens:      input + u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}
 }
 
 // ErrorResponse from the API.
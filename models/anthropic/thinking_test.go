@@ -0,0 +1,142 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+func TestParseSSEStream_Thinking(t *testing.T) {
+	responses := parseStream(t, &Anthropic_Model{},
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"think."}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-1"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"opaque"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"Answer"}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	var got []string
+	for _, response := range responses {
+		for _, part := range response.Parts {
+			switch {
+			case part.Reasoning != nil:
+				got = append(got, "reasoning:"+*part.Reasoning)
+			case part.Signature != nil:
+				got = append(got, "signature:"+*part.Signature)
+			case part.RedactedReasoning != nil:
+				got = append(got, "redacted:"+*part.RedactedReasoning)
+			case part.Text != nil:
+				got = append(got, "text:"+*part.Text)
+			}
+		}
+	}
+	want := []string{"reasoning:Let me ", "reasoning:think.", "signature:sig-1", "redacted:opaque", "text:Answer"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected parts %v, got %v", want, got)
+	}
+}
+
+// modelHistory stores parts as a model message, the way sessions save streamed responses
+func modelHistory(t *testing.T, parts ...models.Model_Part) stores.Message {
+	t.Helper()
+	data, err := json.Marshal(parts)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return stores.Message{Role: "model", Type: "model_message", PartsJSON: string(data)}
+}
+
+func TestConvertHistoryMessage_Thinking(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		parts []models.Model_Part
+		want  []ContentBlock
+	}{
+		{
+			name: "signed thinking",
+			parts: []models.Model_Part{
+				{Reasoning: str("Let me ")},
+				{Reasoning: str("think.")},
+				{Signature: str("sig-1")},
+				{Text: str("Answer")},
+			},
+			want: []ContentBlock{
+				{Type: "thinking", Thinking: str("Let me think."), Signature: "sig-1"},
+				{Type: "text", Text: "Answer"},
+			},
+		},
+		{
+			name: "redacted thinking",
+			parts: []models.Model_Part{
+				{RedactedReasoning: str("opaque")},
+				{FunctionCall: &models.FunctionCall{ID: "t1", Name: "search", Args: map[string]interface{}{}}},
+			},
+			want: []ContentBlock{
+				{Type: "redacted_thinking", Data: "opaque"},
+				{Type: "tool_use", ID: "t1", Name: "search", Input: map[string]interface{}{}},
+			},
+		},
+		{
+			name: "unsigned reasoning",
+			parts: []models.Model_Part{
+				{Reasoning: str("From another provider")},
+				{Text: str("Answer")},
+			},
+			want: []ContentBlock{{Type: "text", Text: "Answer"}},
+		},
+		{
+			name: "gemini signature",
+			parts: []models.Model_Part{
+				{Text: str("Answer"), Signature: str("gemini-sig")},
+			},
+			want: []ContentBlock{{Type: "text", Text: "Answer"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := (&Anthropic_Model{}).convertHistoryMessage(modelHistory(t, tt.parts...))
+			if err != nil {
+				t.Fatalf("convertHistoryMessage: %v", err)
+			}
+			if msg == nil || msg.Role != "assistant" {
+				t.Fatalf("Expected an assistant message, got %+v", msg)
+			}
+			if got := toContentBlocks(msg.Content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected blocks %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBuildRequest_Thinking(t *testing.T) {
+	temperature, maxTokens := 0.2, 1024
+	model := &Anthropic_Model{Temperature: &temperature, MaxTokens: &maxTokens, ThinkingBudget: 500}
+	req, err := model.buildRequest("claude", *userMessage("Hi"), nil, nil, nil, false)
+	if err != nil {
+		t.Fatalf("buildRequest: %v", err)
+	}
+	if req.Thinking == nil || req.Thinking.BudgetTokens != MinThinkingBudget {
+		t.Errorf("Expected thinking raised to the minimum budget, got %+v", req.Thinking)
+	}
+	if req.MaxTokens <= MinThinkingBudget {
+		t.Errorf("Expected max_tokens above the thinking budget, got %d", req.MaxTokens)
+	}
+	if req.Temperature != nil {
+		t.Errorf("Expected temperature left unset with thinking on, got %v", *req.Temperature)
+	}
+
+	model.ThinkingBudget = 0
+	if req, _ = model.buildRequest("claude", *userMessage("Hi"), nil, nil, nil, false); req.Thinking != nil || req.Temperature == nil {
+		t.Errorf("Expected temperature and no thinking, got thinking=%+v, temperature=%v", req.Thinking, req.Temperature)
+	}
+}
@@ -5,14 +5,27 @@ import "time"
 // ChatMessageResponse defines the structure for messages returned by the chat history API endpoint.
 // It excludes internal DB fields like gorm.Model but includes necessary identifiers and timestamps.
 type ChatMessageResponse struct {
-	ID             uint        `json:"id"`         // Message primary key ID
-	CreatedAt      time.Time   `json:"created_at"` // Time the message was created
-	UpdatedAt      time.Time   `json:"updated_at"` // Time the message was last updated
-	ConversationID string      `json:"conversation_id"`
-	Sequence       int         `json:"sequence"`
-	Role           string      `json:"role"`                  // "user", "model"
-	Type           string      `json:"type"`                  // "user_message", "model_message", "function_call", "function_response"
-	FunctionID     string      `json:"function_id,omitempty"` // Associated function call ID (potentially linking bundles)
-	Text           string      `json:"text,omitempty"`        // Primary text content, if applicable (extracted from parts)
-	Parts          interface{} `json:"parts,omitempty"`       // Unmarshalled parts array (e.g., []User_Part, []Model_Part)
+	ID             uint             `json:"id"`         // Message primary key ID
+	CreatedAt      time.Time        `json:"created_at"` // Time the message was created
+	UpdatedAt      time.Time        `json:"updated_at"` // Time the message was last updated
+	ConversationID string           `json:"conversation_id"`
+	Sequence       int              `json:"sequence"`
+	Role           string           `json:"role"`                  // "user", "model"
+	Type           string           `json:"type"`                  // "user_message", "model_message", "function_call", "function_response"
+	FunctionID     string           `json:"function_id,omitempty"` // Associated function call ID (potentially linking bundles)
+	Text           string           `json:"text,omitempty"`        // Primary text content, if applicable (extracted from parts)
+	Parts          interface{}      `json:"parts,omitempty"`       // Unmarshalled parts array (e.g., []User_Part, []Model_Part)
+	Metadata       *MessageMetadata `json:"metadata,omitempty"`    // Generation metadata for model messages
+}
+
+// MessageMetadata describes how a model message was produced.
+// It is recorded by the sessions for every model message and stored alongside the message parts.
+type MessageMetadata struct {
+	Provider         string `json:"provider,omitempty"`               // e.g. "gemini", "anthropic", "openrouter"
+	Model            string `json:"model,omitempty"`                  // Model name used for the call
+	TimeToFirstToken int64  `json:"time_to_first_token_ms,omitempty"` // Milliseconds from request to first streamed chunk
+	LatencyMS        int64  `json:"latency_ms,omitempty"`             // Milliseconds from request to end of response
+	FinishReason     string `json:"finish_reason,omitempty"`          // Provider stop reason, or "cancelled"/"error"
+	Usage            *Usage `json:"usage,omitempty"`
+	Error            string `json:"error,omitempty"` // Set when the response was cut short by an error
 }
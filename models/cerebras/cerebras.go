@@ -6,14 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/Desarso/godantic/logging"
 	models "github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
-	"github.com/joho/godotenv"
 )
 
 const (
@@ -21,22 +20,6 @@ const (
 	DefaultModel    = "llama-3.3-70b"
 )
 
-var (
-	logFile *os.File
-)
-
-func init() {
-	// Load .env file if it exists (not present in production)
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-	var err error
-	logFile, err = os.OpenFile("debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalln("Failed to open log file")
-	}
-}
-
 // Cerebras_Model implements the Model interface for Cerebras API
 // Cerebras uses OpenAI-compatible API format
 type Cerebras_Model struct {
@@ -48,6 +31,13 @@ type Cerebras_Model struct {
 	APIKeyEnv    string // Optional: Environment variable name for API key (defaults to CEREBRAS_API_KEY)
 	TopP         *float64
 	Seed         *int
+	Client       *models.ProviderClient `json:"-"` // Optional: API key, endpoint, HTTP client and headers; takes precedence over BaseURL and APIKeyEnv
+	Logger       *slog.Logger           `json:"-"` // Optional: defaults to logging.Default()
+}
+
+// logger returns the model's logger tagged with the provider and model
+func (c *Cerebras_Model) logger() *slog.Logger {
+	return logging.Or(c.Logger).With(logging.KeyProvider, "cerebras", logging.KeyModel, c.Model)
 }
 
 // Model_Request implements the Model interface
@@ -68,7 +58,7 @@ func (c *Cerebras_Model) Model_Request(request models.Model_Request, tools []mod
 		modelToUse = DefaultModel
 	}
 
-	cerebrasResponse, err := c.makeRequest(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	cerebrasResponse, err := c.makeRequest(modelToUse, c.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 	if err != nil {
 		return models.Model_Response{}, err
 	}
@@ -99,7 +89,7 @@ func (c *Cerebras_Model) Stream_Model_Request(request models.Model_Request, tool
 		modelToUse = DefaultModel
 	}
 
-	return c.makeStreamRequest(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	return c.makeStreamRequest(modelToUse, c.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 }
 
 // cerebrasResponseToModelResponse converts Cerebras response to the standard Model_Response
@@ -107,6 +97,10 @@ func (c *Cerebras_Model) cerebrasResponseToModelResponse(response CerebrasRespon
 	modelResponse := models.Model_Response{}
 
 	for _, choice := range response.Choices {
+		if choice.FinishReason != nil {
+			modelResponse.FinishReason = *choice.FinishReason
+		}
+
 		// Handle text content
 		if choice.Message.Content != nil {
 			switch content := choice.Message.Content.(type) {
@@ -125,7 +119,7 @@ func (c *Cerebras_Model) cerebrasResponseToModelResponse(response CerebrasRespon
 			if toolCall.Type == "function" {
 				var args map[string]interface{}
 				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					log.Printf("Warning: Failed to unmarshal tool call arguments: %v", err)
+					c.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 					args = map[string]interface{}{}
 				}
 
@@ -140,11 +134,12 @@ func (c *Cerebras_Model) cerebrasResponseToModelResponse(response CerebrasRespon
 		}
 	}
 
+	modelResponse.Usage = response.Usage.toModelUsage()
 	return modelResponse, nil
 }
 
 // makeRequest sends a non-streaming request to Cerebras
-func (c *Cerebras_Model) makeRequest(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (CerebrasResponse, error) {
+func (c *Cerebras_Model) makeRequest(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (CerebrasResponse, error) {
 	requestBody, err := c.createCerebrasRequest(model, message, tools, toolResults, conversationHistory, false)
 	if err != nil {
 		return CerebrasResponse{}, fmt.Errorf("failed to create Cerebras request: %w", err)
@@ -155,25 +150,15 @@ func (c *Cerebras_Model) makeRequest(model string, message models.User_Message,
 		return CerebrasResponse{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Debug: save request body
-	if err := os.WriteFile("cerebras_request_body.json", jsonBytes, 0644); err != nil {
-		log.Printf("Warning: failed to write request body to file: %v", err)
-	}
-
-	// Use custom base URL if provided, otherwise use Cerebras
-	baseURL := c.BaseURL
-	if baseURL == "" {
-		baseURL = CerebrasBaseURL
-	}
+	c.logger().Debug("Sending request", "body_bytes", len(jsonBytes))
 
-	req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+	req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 	if err != nil {
 		return CerebrasResponse{}, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	c.setHeaders(req)
+	c.setHeaders(req, client)
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return CerebrasResponse{}, fmt.Errorf("HTTP request failed: %w", err)
@@ -189,7 +174,7 @@ func (c *Cerebras_Model) makeRequest(model string, message models.User_Message,
 			bodyStr := string(body)
 			
 			// Log the raw response for debugging
-			log.Printf("Cerebras API error response (status %d): %s", resp.StatusCode, bodyStr)
+			c.logger().Warn("API error response", "status", resp.StatusCode, "body", bodyStr)
 			
 			// Try to parse as structured error response
 			var errResp ErrorResponse
@@ -220,12 +205,10 @@ func (c *Cerebras_Model) makeRequest(model string, message models.User_Message,
 					errorMsg += fmt.Sprintf(" - Raw response: %s", bodyStr)
 				}
 				
-				log.Printf("Parsed Cerebras error: %+v", errResp)
 				return CerebrasResponse{}, fmt.Errorf(errorMsg)
 			}
 			
 			// Failed to parse as JSON - show raw response
-			log.Printf("Failed to parse Cerebras error response as JSON: %v, body: %s", err, bodyStr)
 			if bodyStr == "" {
 				bodyStr = "(empty response body)"
 			}
@@ -241,7 +224,7 @@ func (c *Cerebras_Model) makeRequest(model string, message models.User_Message,
 }
 
 // makeStreamRequest sends a streaming request to Cerebras
-func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
+func (c *Cerebras_Model) makeStreamRequest(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
 	respChan := make(chan models.Model_Response)
 	errChan := make(chan error, 1)
 
@@ -261,24 +244,16 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 			return
 		}
 
-		// Request body logging disabled - enable for debugging if needed
-		// log.Printf("Cerebras Stream Request Body:\n%s", string(jsonBytes))
-
-		// Use custom base URL if provided, otherwise use Cerebras
-		baseURL := c.BaseURL
-		if baseURL == "" {
-			baseURL = CerebrasBaseURL
-		}
+		c.logger().Debug("Sending stream request", "body_bytes", len(jsonBytes))
 
-		req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+		req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 		if err != nil {
 			errChan <- fmt.Errorf("failed to create HTTP request: %w", err)
 			return
 		}
 
-		c.setHeaders(req)
+		c.setHeaders(req, client)
 
-		client := &http.Client{}
 		resp, err := client.Do(req)
 		if err != nil {
 			errChan <- fmt.Errorf("HTTP request failed: %w", err)
@@ -291,7 +266,7 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 			bodyStr := string(body)
 			
 			// Log the raw response for debugging
-			log.Printf("Cerebras API error response (status %d): %s", resp.StatusCode, bodyStr)
+			c.logger().Warn("API error response", "status", resp.StatusCode, "body", bodyStr)
 			
 			// Try to parse as structured error response
 			var errResp ErrorResponse
@@ -322,11 +297,9 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 					errorMsg += fmt.Sprintf(" - Raw response: %s", bodyStr)
 				}
 				
-				log.Printf("Parsed Cerebras error: %+v", errResp)
 				errChan <- fmt.Errorf(errorMsg)
 			} else {
 				// Failed to parse as JSON - show raw response
-				log.Printf("Failed to parse Cerebras error response as JSON: %v, body: %s", err, bodyStr)
 				if readErr != nil {
 					errChan <- fmt.Errorf("Cerebras API error (status %d): failed to read response body: %w", resp.StatusCode, readErr)
 				} else if bodyStr == "" {
@@ -340,6 +313,8 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 
 		// Track accumulated tool calls across stream chunks
 		toolCallAccumulator := make(map[int]*ToolCall)
+		var finishReason string
+		var usage *Usage
 
 		reader := bufio.NewReader(resp.Body)
 		for {
@@ -352,7 +327,7 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 						for _, tc := range toolCallAccumulator {
 							var args map[string]interface{}
 							if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-								log.Printf("Warning: Failed to unmarshal final tool call arguments: %v", err)
+								c.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 								args = map[string]interface{}{}
 							}
 							modelResp.Parts = append(modelResp.Parts, models.Model_Part{
@@ -365,6 +340,9 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 						}
 						respChan <- modelResp
 					}
+					if summary, ok := streamSummary(finishReason, usage); ok {
+						respChan <- summary
+					}
 					return
 				}
 				errChan <- fmt.Errorf("error reading stream: %w", err)
@@ -389,7 +367,7 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 					for _, tc := range toolCallAccumulator {
 						var args map[string]interface{}
 						if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-							log.Printf("Warning: Failed to unmarshal final tool call arguments: %v", err)
+							c.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 							args = map[string]interface{}{}
 						}
 						modelResp.Parts = append(modelResp.Parts, models.Model_Part{
@@ -402,16 +380,26 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 					}
 					respChan <- modelResp
 				}
+				if summary, ok := streamSummary(finishReason, usage); ok {
+					respChan <- summary
+				}
 				return
 			}
 
 			var streamResp StreamResponse
 			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
-				log.Printf("Warning: Failed to unmarshal stream chunk: %v, data: %s", err, data)
+				c.logger().Warn("Failed to unmarshal stream chunk", "data", data, logging.KeyError, err)
 				continue
 			}
 
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage
+			}
+
 			for _, choice := range streamResp.Choices {
+				if choice.FinishReason != nil && *choice.FinishReason != "" {
+					finishReason = *choice.FinishReason
+				}
 				if choice.Delta == nil {
 					continue
 				}
@@ -461,15 +449,19 @@ func (c *Cerebras_Model) makeStreamRequest(model string, message models.User_Mes
 	return respChan, errChan
 }
 
+// client returns the connection settings for a request: override, then Client, then BaseURL,
+// CerebrasBaseURL and the API key environment variable
+func (c *Cerebras_Model) client(override *models.ProviderClient) models.ProviderClient {
+	defaults := models.ProviderClient{BaseURL: c.BaseURL}
+	if defaults.BaseURL == "" {
+		defaults.BaseURL = CerebrasBaseURL
+	}
+	return defaults.Merge(c.Client).Merge(override).APIKeyFromEnv(c.APIKeyEnv, "CEREBRAS_API_KEY")
+}
+
 // setHeaders sets the required headers for Cerebras API requests
-func (c *Cerebras_Model) setHeaders(req *http.Request) {
-	// Use custom API key environment variable if provided, otherwise use CEREBRAS_API_KEY
-	apiKeyEnv := c.APIKeyEnv
-	if apiKeyEnv == "" {
-		apiKeyEnv = "CEREBRAS_API_KEY"
-	}
-	apiKey := os.Getenv(apiKeyEnv)
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+func (c *Cerebras_Model) setHeaders(req *http.Request, client models.ProviderClient) {
+	req.Header.Set("Authorization", "Bearer "+client.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 }
 
@@ -489,7 +481,7 @@ func (c *Cerebras_Model) createCerebrasRequest(model string, message models.User
 	for _, histMsg := range conversationHistory {
 		msg, err := c.convertHistoryMessage(histMsg)
 		if err != nil {
-			log.Printf("Warning: Failed to convert history message %d: %v", histMsg.ID, err)
+			c.logger().Warn("Failed to convert history message", "message_id", histMsg.ID, logging.KeyError, err)
 			continue
 		}
 		if msg != nil {
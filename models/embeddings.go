@@ -0,0 +1,25 @@
+package models
+
+import "context"
+
+// Embedder turns text into vectors for similarity search (memory, RAG).
+// Embed returns one vector per input text, each of length Dimensions().
+// Implementations live in models/embeddings.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Dimensions() int
+}
+
+// EmbeddingInfo describes the model behind an Embedder
+type EmbeddingInfo struct {
+	Provider     string `json:"provider"`   // e.g. "gemini", "openai", "ollama"
+	Model        string `json:"model"`      // e.g. "text-embedding-004"
+	Dimensions   int    `json:"dimensions"` // Vector length (0 until known for models discovered at runtime)
+	MaxBatchSize int    `json:"max_batch_size"`
+}
+
+// EmbeddingDescriber is implemented by embedders that can describe their model.
+// Use it to record which model produced stored vectors.
+type EmbeddingDescriber interface {
+	EmbeddingInfo() EmbeddingInfo
+}
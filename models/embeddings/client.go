@@ -0,0 +1,207 @@
+// Package embeddings implements models.Embedder for Gemini, OpenAI-compatible endpoints
+// (OpenAI, OpenRouter, Groq-style base URLs, vLLM, ...) and a local Ollama server.
+//
+// Every embedder splits large inputs into provider-sized batches, retries rate limits and
+// server errors with exponential backoff, and checks that vectors match Dimensions().
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retry defaults shared by all embedders
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// APIError is a non-2xx response from an embedding endpoint
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("embedding API returned %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Config holds the transport settings every embedder shares
+type Config struct {
+	HTTPClient   *http.Client      // Defaults to http.DefaultClient
+	Headers      map[string]string // Extra headers sent with every request
+	BatchSize    int               // Texts per request (provider default when <= 0)
+	MaxRetries   int               // Retries after the first attempt (DefaultMaxRetries when 0, none when negative)
+	RetryBackoff time.Duration     // First retry delay, doubled on each attempt (DefaultRetryBackoff when 0)
+}
+
+// embedBatches calls embed on consecutive batches of at most size texts and concatenates the results
+func embedBatches(ctx context.Context, texts []string, size int, embed func(ctx context.Context, batch []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		batch, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batch))
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// dimensions tracks an embedder's vector length, learning it from the first response when unknown
+type dimensions struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (d *dimensions) get() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.n
+}
+
+func (d *dimensions) set(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.n = n
+}
+
+// check verifies that every vector has the expected length
+func (d *dimensions) check(vectors [][]float32) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, vec := range vectors {
+		if len(vec) == 0 {
+			return fmt.Errorf("embedding %d is empty", i)
+		}
+		if d.n == 0 {
+			d.n = len(vec)
+		}
+		if len(vec) != d.n {
+			return fmt.Errorf("embedding %d has %d dimensions, expected %d", i, len(vec), d.n)
+		}
+	}
+	return nil
+}
+
+// postJSON sends body as JSON and decodes the response into out, retrying retryable failures
+func (c *Config) postJSON(ctx context.Context, url string, headers map[string]string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	retries := c.MaxRetries
+	if retries == 0 {
+		retries = DefaultMaxRetries
+	}
+	backoff := c.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = c.post(ctx, url, headers, data, out)
+		if err == nil || attempt >= retries || !retryable(err) {
+			return err
+		}
+
+		delay := backoff << attempt
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Config) post(ctx context.Context, url string, headers map[string]string, data []byte, out interface{}) error {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &networkError{err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &networkError{err: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	return nil
+}
+
+// networkError wraps transport failures, which are always retried
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string {
+	return fmt.Sprintf("failed to call embedding API: %v", e.err)
+}
+
+func (e *networkError) Unwrap() error {
+	return e.err
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var netErr *networkError
+	return errors.As(err, &netErr)
+}
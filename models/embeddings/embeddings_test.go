@@ -0,0 +1,269 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+)
+
+var (
+	_ models.Embedder           = (*OpenAI)(nil)
+	_ models.Embedder           = (*Gemini)(nil)
+	_ models.Embedder           = (*Ollama)(nil)
+	_ models.EmbeddingDescriber = (*OpenAI)(nil)
+	_ models.EmbeddingDescriber = (*Gemini)(nil)
+	_ models.EmbeddingDescriber = (*Ollama)(nil)
+)
+
+// vectorFor encodes the input text's length so tests can check ordering
+func vectorFor(text string) []float32 {
+	return []float32{float32(len(text)), 1, 0}
+}
+
+func openAIServer(t *testing.T, calls *int32, fail func(call int32) int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(calls, 1)
+		if fail != nil {
+			if status := fail(call); status != 0 {
+				w.WriteHeader(status)
+				w.Write([]byte(`{"error":"nope"}`))
+				return
+			}
+		}
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s auth=%q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req openAIEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad request body: %v", err)
+		}
+		var resp openAIEmbeddingResponse
+		resp.Data = make([]struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}, len(req.Input))
+		// Return items in reverse to check that Index is honored
+		for i := range req.Input {
+			j := len(req.Input) - 1 - i
+			resp.Data[i].Index = j
+			resp.Data[i].Embedding = vectorFor(req.Input[j])
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestOpenAI_BatchesAndPreservesOrder(t *testing.T) {
+	var calls int32
+	server := openAIServer(t, &calls, nil)
+	defer server.Close()
+
+	e := NewOpenAICompatible(server.URL, "key", "custom-model", 0)
+	e.BatchSize = 2
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vectors, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 batched requests, got %d", calls)
+	}
+	for i, text := range texts {
+		if vectors[i][0] != float32(len(text)) {
+			t.Errorf("vector %d is out of order: %v", i, vectors[i])
+		}
+	}
+	if e.Dimensions() != 3 {
+		t.Errorf("expected dimensions learned from response, got %d", e.Dimensions())
+	}
+	if info := e.EmbeddingInfo(); info.Provider != "openai-compatible" || info.MaxBatchSize != 2 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+}
+
+func TestOpenAI_RetriesRateLimitsAndServerErrors(t *testing.T) {
+	var calls int32
+	server := openAIServer(t, &calls, func(call int32) int {
+		switch call {
+		case 1:
+			return http.StatusTooManyRequests
+		case 2:
+			return http.StatusBadGateway
+		}
+		return 0
+	})
+	defer server.Close()
+
+	e := NewOpenAICompatible(server.URL, "key", "custom-model", 3)
+	e.RetryBackoff = time.Millisecond
+	if _, err := e.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("Embed failed after retries: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestOpenAI_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := openAIServer(t, &calls, func(int32) int { return http.StatusBadRequest })
+	defer server.Close()
+
+	e := NewOpenAICompatible(server.URL, "key", "custom-model", 3)
+	e.RetryBackoff = time.Millisecond
+	_, err := e.Embed(context.Background(), []string{"hello"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a 400 APIError, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestOpenAI_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := openAIServer(t, &calls, func(int32) int { return http.StatusServiceUnavailable })
+	defer server.Close()
+
+	e := NewOpenAICompatible(server.URL, "key", "custom-model", 3)
+	e.MaxRetries = 2
+	e.RetryBackoff = time.Millisecond
+	if _, err := e.Embed(context.Background(), []string{"hello"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 3 {
+		t.Errorf("expected 1 attempt + 2 retries, got %d", calls)
+	}
+}
+
+func TestOpenAI_SendsDimensionsOnlyForShortenableModels(t *testing.T) {
+	var got []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIEmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		got = append(got, req.Dimensions)
+		vec := make([]float32, 256)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"index": 0, "embedding": vec}},
+		})
+	}))
+	defer server.Close()
+
+	small := NewOpenAI("key", "text-embedding-3-small").WithDimensions(256)
+	small.BaseURL = server.URL
+	other := NewOpenAICompatible(server.URL, "key", "bge-small", 256)
+	for _, e := range []*OpenAI{small, other} {
+		if _, err := e.Embed(context.Background(), []string{"x"}); err != nil {
+			t.Fatalf("Embed failed: %v", err)
+		}
+	}
+	if len(got) != 2 || got[0] != 256 || got[1] != 0 {
+		t.Errorf("unexpected dimensions parameters: %v", got)
+	}
+}
+
+func TestGemini_RequestShape(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/models/text-embedding-004:batchEmbedContents" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("missing API key header")
+		}
+		var body struct {
+			Requests []geminiEmbedRequest `json:"requests"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var resp geminiBatchEmbedResponse
+		resp.Embeddings = make([]struct {
+			Values []float32 `json:"values"`
+		}, len(body.Requests))
+		for i, req := range body.Requests {
+			if req.Model != "models/text-embedding-004" || req.TaskType != GeminiTaskRetrievalDocument || req.OutputDimensionality != 3 {
+				t.Errorf("unexpected request %+v", req)
+			}
+			resp.Embeddings[i].Values = vectorFor(req.Content.Parts[0].Text)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	e := NewGemini("key", "text-embedding-004").WithDimensions(3)
+	e.BaseURL = server.URL
+	e.TaskType = GeminiTaskRetrievalDocument
+	e.BatchSize = 500 // capped at the API limit of 100
+
+	texts := make([]string, 150)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+	vectors, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+	if len(vectors) != 150 || vectors[149][0] != 150 {
+		t.Errorf("unexpected vectors: %d", len(vectors))
+	}
+}
+
+func TestOllama_LearnsDimensionsAndRejectsMismatch(t *testing.T) {
+	dims := 4
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		embeddings := make([][]float32, len(req.Input))
+		for i := range embeddings {
+			embeddings[i] = make([]float32, dims)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}))
+	defer server.Close()
+
+	e := NewOllama(server.URL, "custom-embed")
+	if e.Dimensions() != 0 {
+		t.Fatalf("expected unknown dimensions, got %d", e.Dimensions())
+	}
+	if _, err := e.Embed(context.Background(), []string{"a", "b"}); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if e.Dimensions() != 4 {
+		t.Errorf("expected 4 dimensions, got %d", e.Dimensions())
+	}
+
+	dims = 8
+	if _, err := e.Embed(context.Background(), []string{"c"}); err == nil || !strings.Contains(err.Error(), "expected 4") {
+		t.Errorf("expected a dimension mismatch error, got %v", err)
+	}
+
+	if NewOllama("", "nomic-embed-text:latest").Dimensions() != 768 {
+		t.Error("expected preset dimensions for nomic-embed-text")
+	}
+}
+
+func TestEmbed_EmptyInput(t *testing.T) {
+	e := NewOllama("http://127.0.0.1:0", "all-minilm")
+	vectors, err := e.Embed(context.Background(), nil)
+	if err != nil || len(vectors) != 0 {
+		t.Errorf("expected no vectors and no error, got %v, %v", vectors, err)
+	}
+}
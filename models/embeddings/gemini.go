@@ -0,0 +1,128 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// GeminiBaseURL is the Generative Language API root
+const GeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Gemini task types, which tune embeddings for how they will be compared
+const (
+	GeminiTaskRetrievalDocument  = "RETRIEVAL_DOCUMENT"
+	GeminiTaskRetrievalQuery     = "RETRIEVAL_QUERY"
+	GeminiTaskSemanticSimilarity = "SEMANTIC_SIMILARITY"
+)
+
+// Gemini calls the Gemini batchEmbedContents API
+type Gemini struct {
+	Config
+	APIKey   string
+	BaseURL  string // Defaults to GeminiBaseURL
+	Model    string // e.g. "text-embedding-004", "gemini-embedding-001"
+	TaskType string // Optional, e.g. GeminiTaskSemanticSimilarity
+
+	dims dimensions
+}
+
+// NewGemini creates an embedder for the Gemini API with the model's default dimensions
+func NewGemini(apiKey, model string) *Gemini {
+	e := &Gemini{APIKey: apiKey, BaseURL: GeminiBaseURL, Model: model}
+	dims := 768
+	if strings.TrimPrefix(model, "models/") == "gemini-embedding-001" {
+		dims = 3072
+	}
+	e.dims.set(dims)
+	return e
+}
+
+// WithDimensions sets the output dimensionality (models that support it truncate their vectors)
+func (e *Gemini) WithDimensions(dims int) *Gemini {
+	e.dims.set(dims)
+	return e
+}
+
+// Dimensions returns the vector length
+func (e *Gemini) Dimensions() int {
+	return e.dims.get()
+}
+
+// EmbeddingInfo describes the model (implements models.EmbeddingDescriber)
+func (e *Gemini) EmbeddingInfo() models.EmbeddingInfo {
+	return models.EmbeddingInfo{Provider: "gemini", Model: e.Model, Dimensions: e.Dimensions(), MaxBatchSize: e.batchSize()}
+}
+
+func (e *Gemini) batchSize() int {
+	// batchEmbedContents accepts at most 100 requests
+	if e.BatchSize > 0 && e.BatchSize <= 100 {
+		return e.BatchSize
+	}
+	return 100
+}
+
+type geminiEmbedRequest struct {
+	Model                string             `json:"model"`
+	Content              geminiEmbedContent `json:"content"`
+	TaskType             string             `json:"taskType,omitempty"`
+	OutputDimensionality int                `json:"outputDimensionality,omitempty"`
+}
+
+type geminiEmbedContent struct {
+	Parts []geminiEmbedPart `json:"parts"`
+}
+
+type geminiEmbedPart struct {
+	Text string `json:"text"`
+}
+
+type geminiBatchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
+// Embed embeds texts in batches of up to 100, preserving their order
+func (e *Gemini) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := embedBatches(ctx, texts, e.batchSize(), e.embedBatch)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.dims.check(vectors); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func (e *Gemini) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	model := "models/" + strings.TrimPrefix(e.Model, "models/")
+	requests := make([]geminiEmbedRequest, len(texts))
+	for i, text := range texts {
+		requests[i] = geminiEmbedRequest{
+			Model:                model,
+			Content:              geminiEmbedContent{Parts: []geminiEmbedPart{{Text: text}}},
+			TaskType:             e.TaskType,
+			OutputDimensionality: e.Dimensions(),
+		}
+	}
+
+	baseURL := e.BaseURL
+	if baseURL == "" {
+		baseURL = GeminiBaseURL
+	}
+	url := fmt.Sprintf("%s/%s:batchEmbedContents", strings.TrimRight(baseURL, "/"), model)
+	var resp geminiBatchEmbedResponse
+	headers := map[string]string{"x-goog-api-key": e.APIKey}
+	if err := e.postJSON(ctx, url, headers, map[string]interface{}{"requests": requests}, &resp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		vectors[i] = embedding.Values
+	}
+	return vectors, nil
+}
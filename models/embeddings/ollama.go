@@ -0,0 +1,93 @@
+package embeddings
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// OllamaBaseURL is the default address of a local Ollama server
+const OllamaBaseURL = "http://localhost:11434"
+
+// Ollama calls a local Ollama server's /api/embed endpoint
+type Ollama struct {
+	Config
+	BaseURL string // Defaults to OllamaBaseURL
+	Model   string // e.g. "nomic-embed-text"
+
+	dims dimensions
+}
+
+// NewOllama creates an embedder for a model served by Ollama at baseURL ("" for the local default).
+// Dimensions of well-known models are preset; others are learned from the first response.
+func NewOllama(baseURL, model string) *Ollama {
+	e := &Ollama{BaseURL: baseURL, Model: model}
+	e.dims.set(ollamaModelDimensions(model))
+	return e
+}
+
+func ollamaModelDimensions(model string) int {
+	name, _, _ := strings.Cut(model, ":")
+	switch name {
+	case "nomic-embed-text":
+		return 768
+	case "mxbai-embed-large", "snowflake-arctic-embed", "bge-m3":
+		return 1024
+	case "all-minilm":
+		return 384
+	}
+	return 0
+}
+
+// WithDimensions sets the expected vector length
+func (e *Ollama) WithDimensions(dims int) *Ollama {
+	e.dims.set(dims)
+	return e
+}
+
+// Dimensions returns the vector length (0 until the first response when unknown)
+func (e *Ollama) Dimensions() int {
+	return e.dims.get()
+}
+
+// EmbeddingInfo describes the model (implements models.EmbeddingDescriber)
+func (e *Ollama) EmbeddingInfo() models.EmbeddingInfo {
+	return models.EmbeddingInfo{Provider: "ollama", Model: e.Model, Dimensions: e.Dimensions(), MaxBatchSize: e.batchSize()}
+}
+
+func (e *Ollama) batchSize() int {
+	if e.BatchSize > 0 {
+		return e.BatchSize
+	}
+	return 32
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// Embed embeds texts in batches, preserving their order
+func (e *Ollama) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := embedBatches(ctx, texts, e.batchSize(), e.embedBatch)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.dims.check(vectors); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func (e *Ollama) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	baseURL := e.BaseURL
+	if baseURL == "" {
+		baseURL = OllamaBaseURL
+	}
+	var resp ollamaEmbedResponse
+	body := map[string]interface{}{"model": e.Model, "input": texts}
+	if err := e.postJSON(ctx, strings.TrimRight(baseURL, "/")+"/api/embed", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Embeddings, nil
+}
@@ -0,0 +1,143 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// Base URLs of common OpenAI-compatible embedding endpoints
+const (
+	OpenAIBaseURL     = "https://api.openai.com/v1"
+	OpenRouterBaseURL = "https://openrouter.ai/api/v1"
+)
+
+// OpenAI calls an OpenAI-compatible /embeddings endpoint. Point BaseURL at any
+// compatible provider (OpenRouter, Groq-style gateways, vLLM, LiteLLM, ...).
+type OpenAI struct {
+	Config
+	APIKey   string
+	BaseURL  string // Defaults to OpenAIBaseURL
+	Model    string // e.g. "text-embedding-3-small"
+	Provider string // Reported in EmbeddingInfo; defaults to "openai"
+
+	dims dimensions
+}
+
+// NewOpenAI creates an embedder for the OpenAI API. Known models get their default
+// dimensions; for others they are learned from the first response (or set WithDimensions).
+func NewOpenAI(apiKey, model string) *OpenAI {
+	e := &OpenAI{APIKey: apiKey, BaseURL: OpenAIBaseURL, Model: model, Provider: "openai"}
+	e.dims.set(openAIModelDimensions(model))
+	return e
+}
+
+// NewOpenAICompatible creates an embedder for an OpenAI-compatible endpoint at baseURL
+// (the URL that /embeddings is appended to)
+func NewOpenAICompatible(baseURL, apiKey, model string, dims int) *OpenAI {
+	e := &OpenAI{APIKey: apiKey, BaseURL: baseURL, Model: model, Provider: "openai-compatible"}
+	e.dims.set(dims)
+	return e
+}
+
+// NewOpenRouter creates an embedder for OpenRouter's embeddings API
+func NewOpenRouter(apiKey, model string, dims int) *OpenAI {
+	e := NewOpenAICompatible(OpenRouterBaseURL, apiKey, model, dims)
+	e.Provider = "openrouter"
+	return e
+}
+
+func openAIModelDimensions(model string) int {
+	switch strings.TrimPrefix(model, "openai/") {
+	case "text-embedding-3-small", "text-embedding-ada-002":
+		return 1536
+	case "text-embedding-3-large":
+		return 3072
+	}
+	return 0
+}
+
+// WithDimensions sets the vector length. text-embedding-3 models shorten their vectors to it.
+func (e *OpenAI) WithDimensions(dims int) *OpenAI {
+	e.dims.set(dims)
+	return e
+}
+
+// Dimensions returns the vector length (0 until the first response when unknown)
+func (e *OpenAI) Dimensions() int {
+	return e.dims.get()
+}
+
+// EmbeddingInfo describes the model (implements models.EmbeddingDescriber)
+func (e *OpenAI) EmbeddingInfo() models.EmbeddingInfo {
+	return models.EmbeddingInfo{Provider: e.Provider, Model: e.Model, Dimensions: e.Dimensions(), MaxBatchSize: e.batchSize()}
+}
+
+func (e *OpenAI) batchSize() int {
+	if e.BatchSize > 0 {
+		return e.BatchSize
+	}
+	return 256
+}
+
+type openAIEmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed embeds texts in batches, preserving their order
+func (e *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := embedBatches(ctx, texts, e.batchSize(), e.embedBatch)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.dims.check(vectors); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func (e *OpenAI) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	req := openAIEmbeddingRequest{Model: e.Model, Input: texts}
+	// Only text-embedding-3 models accept a dimensions parameter
+	if strings.Contains(e.Model, "text-embedding-3") {
+		req.Dimensions = e.Dimensions()
+	}
+
+	baseURL := e.BaseURL
+	if baseURL == "" {
+		baseURL = OpenAIBaseURL
+	}
+	headers := map[string]string{}
+	if e.APIKey != "" {
+		headers["Authorization"] = "Bearer " + e.APIKey
+	}
+	var resp openAIEmbeddingResponse
+	if err := e.postJSON(ctx, strings.TrimRight(baseURL, "/")+"/embeddings", headers, req, &resp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding response has out-of-range index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vec := range vectors {
+		if vec == nil {
+			return nil, fmt.Errorf("embedding response is missing input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -19,8 +19,10 @@ type Content struct {
 }
 
 type Part struct {
-	Text         *string       `json:"text,omitempty"`
-	FunctionCall *FunctionCall `json:"functionCall,omitempty"`
+	Text             *string       `json:"text,omitempty"`
+	FunctionCall     *FunctionCall `json:"functionCall,omitempty"`
+	Thought          bool          `json:"thought,omitempty"`          // Text is a thought summary
+	ThoughtSignature *string       `json:"thoughtSignature,omitempty"` // Must be sent back with this part
 }
 
 type FunctionCall struct {
@@ -74,6 +76,7 @@ type Request_Part struct {
 	InlineData       *InlineData              `json:"inline_data,omitempty"`
 	FunctionCall     *models.FunctionCall     `json:"function_call,omitempty"`
 	FunctionResponse *models.FunctionResponse `json:"function_response,omitempty"`
+	ThoughtSignature string                   `json:"thought_signature,omitempty"`
 }
 
 type FileData struct {
@@ -90,6 +93,16 @@ type Gemini_Request_Body struct {
 	Contents          *[]Gemini_Content  `json:"contents"`
 	Tools             *[]Gemini_Tools    `json:"tools,omitempty"`
 	SystemInstruction *SystemInstruction `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GenerationConfig  `json:"generationConfig,omitempty"`
+}
+
+type GenerationConfig struct {
+	ThinkingConfig *ThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type ThinkingConfig struct {
+	ThinkingBudget  *int `json:"thinkingBudget,omitempty"` // 0 disables thinking, -1 lets the model decide
+	IncludeThoughts bool `json:"includeThoughts,omitempty"`
 }
 
 type SystemInstruction struct {
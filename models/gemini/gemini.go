@@ -2,44 +2,56 @@ package gemini
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"path"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Desarso/godantic/logging"
 	models "github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
-	"github.com/joho/godotenv"
 )
 
-// Initialize log file
-var (
-	logFile *os.File
-)
-
-func init() {
-	// Load .env file if it exists (not present in production)
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-	var err error
-	logFile, err = os.OpenFile("debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalln("Failed to open log file")
-	}
-}
+// DefaultBaseURL is the Gemini API endpoint used when no ProviderClient BaseURL is set
+const DefaultBaseURL = "https://generativelanguage.googleapis.com"
 
 type Gemini_Model struct {
 	Model           string                                 `json:"model"`
 	SystemPrompt    string                                 `json:"system_prompt,omitempty"`
-	WarningCallback func(warnings []models.HistoryWarning) `json:"-"` // Called when history is adapted with warnings
+	WarningCallback func(warnings []models.HistoryWarning) `json:"-"`                          // Called when history is adapted with warnings
+	Logger          *slog.Logger                           `json:"-"`                          // Optional: defaults to logging.Default()
+	Client          *models.ProviderClient                 `json:"-"`                          // Optional: API key, endpoint and HTTP client (defaults to GEMINI_API_KEY)
+	ThinkingBudget  *int                                   `json:"thinking_budget,omitempty"`  // Optional: thinking tokens; 0 disables thinking, -1 is dynamic
+	IncludeThoughts bool                                   `json:"include_thoughts,omitempty"` // Stream thought summaries as Reasoning parts
+}
+
+// generationConfig returns the thinking settings, or nil to use the model's defaults
+func (g *Gemini_Model) generationConfig() *GenerationConfig {
+	if g.ThinkingBudget == nil && !g.IncludeThoughts {
+		return nil
+	}
+	return &GenerationConfig{ThinkingConfig: &ThinkingConfig{ThinkingBudget: g.ThinkingBudget, IncludeThoughts: g.IncludeThoughts}}
+}
+
+// client resolves the connection settings for a request, with override taking precedence over g.Client
+func (g *Gemini_Model) client(override *models.ProviderClient) models.ProviderClient {
+	return resolveClient(g.Client, override)
+}
+
+func resolveClient(client, override *models.ProviderClient) models.ProviderClient {
+	return models.ProviderClient{BaseURL: DefaultBaseURL}.Merge(client).Merge(override).APIKeyFromEnv("", "GEMINI_API_KEY")
+}
+
+// logger returns the model's logger tagged with the provider and model
+func (g *Gemini_Model) logger() *slog.Logger {
+	return logging.Or(g.Logger).With(logging.KeyProvider, "gemini", logging.KeyModel, g.Model)
 }
 
 // SetHistoryWarningCallback sets the callback function for history adaptation warnings
@@ -68,7 +80,7 @@ func (g *Gemini_Model) Model_Request(request models.Model_Request, tools []model
 	if modelToUse == "" {
 		modelToUse = "gemini-2.0-flash"
 	}
-	geminiResponse, err := g.model_request(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	geminiResponse, err := g.model_request(modelToUse, g.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 	if err != nil {
 		return models.Model_Response{}, err
 	}
@@ -78,11 +90,24 @@ func (g *Gemini_Model) Model_Request(request models.Model_Request, tools []model
 func (g *Gemini_Model) gemini_response_to_model_response(response Gemini_response) (models.Model_Response, error) {
 	modelResponse := models.Model_Response{}
 	for _, candidate := range response.Candidates {
+		if candidate.FinishReason != "" {
+			modelResponse.FinishReason = candidate.FinishReason
+		}
 		for _, part := range candidate.Content.Parts {
 			var modelPart models.Model_Part
-			if part.Text != nil && *part.Text != "" {
+			if part.Thought {
+				modelPart.Reasoning = part.Text
+			} else if part.Text != nil && *part.Text != "" {
 				modelPart.Text = part.Text
 			}
+			if part.ThoughtSignature != nil {
+				modelPart.Signature = part.ThoughtSignature
+				// Signatures ride on a text or function call part so they are not mistaken for Anthropic thinking
+				if modelPart.Text == nil && part.FunctionCall == nil {
+					empty := ""
+					modelPart.Text = &empty
+				}
+			}
 			if part.FunctionCall != nil {
 				modelPart.FunctionCall = &models.FunctionCall{
 					Name: part.FunctionCall.Name,
@@ -92,6 +117,14 @@ func (g *Gemini_Model) gemini_response_to_model_response(response Gemini_respons
 			modelResponse.Parts = append(modelResponse.Parts, modelPart)
 		}
 	}
+	// Usage is cumulative across stream chunks; report it once generation has finished
+	if modelResponse.FinishReason != "" && response.UsageMetadata.TotalTokenCount > 0 {
+		modelResponse.Usage = &models.Usage{
+			InputTokens:  response.UsageMetadata.PromptTokenCount,
+			OutputTokens: response.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:  response.UsageMetadata.TotalTokenCount,
+		}
+	}
 	return modelResponse, nil
 }
 
@@ -163,12 +196,12 @@ func (g *Gemini_Model) Stream_Model_Request(request models.Model_Request, tools
 		modelToUse = "gemini-2.0-flash"
 	}
 	// Pass all parts of the request to stream_model_request
-	geminiRespChan, geminiErrChan := g.stream_model_request(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	geminiRespChan, geminiErrChan := g.stream_model_request(modelToUse, g.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 	return convertStream(g, geminiRespChan, geminiErrChan)
 }
 
-func (g *Gemini_Model) model_request(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (Gemini_response, error) {
-	result, err := create_gemini_request(message, tools, toolResults, conversationHistory, g.SystemPrompt)
+func (g *Gemini_Model) model_request(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (Gemini_response, error) {
+	result, err := create_gemini_request(g.logger(), client, message, tools, toolResults, conversationHistory, g.SystemPrompt)
 	if err != nil {
 		return Gemini_response{}, fmt.Errorf("failed to create gemini request: %w", err)
 	}
@@ -177,23 +210,20 @@ func (g *Gemini_Model) model_request(model string, message models.User_Message,
 	if len(result.Warnings) > 0 && g.WarningCallback != nil {
 		g.WarningCallback(result.Warnings)
 	}
+	result.Body.GenerationConfig = g.generationConfig()
 
-	//save request_body to json
 	jsonBytes, err := json.Marshal(result.Body)
 	if err != nil {
 		return Gemini_response{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
+	g.logger().Debug("Sending request", "body_bytes", len(jsonBytes))
 
-	if err := os.WriteFile("request_body.json", jsonBytes, 0644); err != nil {
-		return Gemini_response{}, fmt.Errorf("failed to write request body to file: %w", err)
-	}
-
-	return make_request(string(jsonBytes), model)
+	return make_request(client, string(jsonBytes), model)
 }
 
-func (g *Gemini_Model) stream_model_request(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan Gemini_response, <-chan error) {
+func (g *Gemini_Model) stream_model_request(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan Gemini_response, <-chan error) {
 	// create_gemini_request now handles potentially empty 'message' if 'toolResults' is present
-	result, err := create_gemini_request(message, tools, toolResults, conversationHistory, g.SystemPrompt)
+	result, err := create_gemini_request(g.logger(), client, message, tools, toolResults, conversationHistory, g.SystemPrompt)
 	if err != nil {
 		errChan := make(chan error, 1)
 		errChan <- fmt.Errorf("failed to create gemini stream request body: %w", err)
@@ -208,8 +238,9 @@ func (g *Gemini_Model) stream_model_request(model string, message models.User_Me
 	if len(result.Warnings) > 0 && g.WarningCallback != nil {
 		g.WarningCallback(result.Warnings)
 	}
+	result.Body.GenerationConfig = g.generationConfig()
 
-	jsonBytes, err := json.MarshalIndent(result.Body, "", "  ") // Use MarshalIndent for logging
+	jsonBytes, err := json.Marshal(result.Body)
 	if err != nil {
 		errChan := make(chan error, 1)
 		errChan <- fmt.Errorf("failed to marshal stream request body: %w", err)
@@ -219,45 +250,50 @@ func (g *Gemini_Model) stream_model_request(model string, message models.User_Me
 		return respChan, errChan
 	}
 
-	// Log the request body for debugging
-	// Request body logging disabled - enable for debugging if needed
-	// log.Printf("Gemini Stream Request Body:\n%s", string(jsonBytes))
+	g.logger().Debug("Sending stream request", "body_bytes", len(jsonBytes))
 
-	// Optional: Write to file for very detailed debugging
-	// if err := os.WriteFile("stream_request_body.json", jsonBytes, 0644); err != nil {
-	// 	log.Printf("Warning: failed to write stream request body to file: %v", err)
-	// }
+	return make_request_stream(client, string(jsonBytes), model)
+}
 
-	return make_request_stream(string(jsonBytes), model)
+// newRequest builds a POST to the Gemini API authenticated with the client's API key
+func newRequest(client models.ProviderClient, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest("POST", client.BaseURL+url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", client.APIKey)
+	return req, nil
 }
 
-func make_request(request_body string, model string) (Gemini_response, error) {
+func make_request(client models.ProviderClient, request_body string, model string) (Gemini_response, error) {
+	req, err := newRequest(client, fmt.Sprintf("/v1beta/models/%s:generateContent", model), strings.NewReader(request_body))
+	if err != nil {
+		return Gemini_response{}, fmt.Errorf("failed to create request: %w", err)
+	}
 
-	resp, err := http.Post(fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, os.Getenv("GEMINI_API_KEY")), "application/json", strings.NewReader(request_body))
+	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return Gemini_response{}, err
+		return Gemini_response{}, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error reading body:", err)
-		return Gemini_response{}, err
+		return Gemini_response{}, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	var response Gemini_response
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		fmt.Println("Error unmarshalling response:", err)
-		return Gemini_response{}, err
+		return Gemini_response{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	return response, nil
 
 }
 
-func make_request_stream(request_body string, model string) (<-chan Gemini_response, <-chan error) {
+func make_request_stream(client models.ProviderClient, request_body string, model string) (<-chan Gemini_response, <-chan error) {
 	resChan := make(chan Gemini_response)
 	errChan := make(chan error, 1) // Buffered error channel
 
@@ -265,7 +301,12 @@ func make_request_stream(request_body string, model string) (<-chan Gemini_respo
 		defer close(resChan)
 		defer close(errChan)
 
-		resp, err := http.Post(fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?key=%s", model, os.Getenv("GEMINI_API_KEY")), "application/json", strings.NewReader(request_body))
+		req, err := newRequest(client, fmt.Sprintf("/v1beta/models/%s:streamGenerateContent", model), strings.NewReader(request_body))
+		if err != nil {
+			errChan <- fmt.Errorf("failed to create request: %w", err)
+			return
+		}
+		resp, err := client.Do(req)
 		if err != nil {
 			errChan <- fmt.Errorf("error making POST request: %w", err)
 			return
@@ -342,7 +383,7 @@ func SimplePrompt(prompt string) (Gemini_response, error) {
 			}
 		]
 	}`, prompt)
-	return make_request(request_body, "gemini-2.0-flash")
+	return make_request(resolveClient(nil, nil), request_body, "gemini-2.0-flash")
 }
 
 func StreamPrompt(prompt string) (<-chan Gemini_response, <-chan error) {
@@ -357,18 +398,18 @@ func StreamPrompt(prompt string) (<-chan Gemini_response, <-chan error) {
 			}
 		]
 	}`, prompt)
-	return make_request_stream(request_body, "gemini-2.0-flash")
+	return make_request_stream(resolveClient(nil, nil), request_body, "gemini-2.0-flash")
 }
 
-func uploadFileFromURLToGemini(fileURL string) (string, error) {
-	// 1. Get API Key from Environment
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY environment variable not set")
+func uploadFileFromURLToGemini(logger *slog.Logger, client models.ProviderClient, fileURL string) (string, error) {
+	// 1. Check the API key
+	if client.APIKey == "" {
+		return "", fmt.Errorf("no Gemini API key configured (set ProviderClient.APIKey or GEMINI_API_KEY)")
 	}
 
 	// 2. Download the file from the URL
-	log.Printf("Attempting to download file from: %s", fileURL)
+	logger = logger.With("file_url", fileURL)
+	logger.Debug("Downloading file for upload")
 	downloadResp, err := http.Get(fileURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to start download from URL %s: %w", fileURL, err)
@@ -378,17 +419,16 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 	if downloadResp.StatusCode != http.StatusOK {
 		// Try to read body for more details, but don't fail if read fails
 		bodyBytes, _ := io.ReadAll(io.LimitReader(downloadResp.Body, 1024)) // Limit read size
-		log.Printf("Download failed. URL: %s, Status: %s, Body Sample: %s", fileURL, downloadResp.Status, string(bodyBytes))
+		logger.Warn("File download failed", "status", downloadResp.Status, "body", string(bodyBytes))
 		return "", fmt.Errorf("failed to download file from %s: status code %s", fileURL, downloadResp.Status)
 	}
-	log.Printf("Successfully initiated download from: %s", fileURL)
 
 	// 3. Get file details from download response headers
 	mimeType := downloadResp.Header.Get("Content-Type")
 	if mimeType == "" {
 		// You might want to default or try to detect, but Gemini API requires it.
 		// For simplicity, we'll error out if not provided by the source URL.
-		log.Printf("Warning: Content-Type header missing from download response for %s. Upload might fail.", fileURL)
+		logger.Warn("Content-Type header missing from download response, upload might fail")
 		// return "", fmt.Errorf("could not determine mime type: Content-Type header missing from %s", fileURL)
 		// Let's proceed but log a warning, Gemini might guess it, or fail.
 	}
@@ -414,12 +454,9 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 		displayName = "downloaded_file" // Provide a default if extraction fails
 	}
 
-	log.Printf("File Details - DisplayName: %s, MimeType: %s, Size: %d bytes", displayName, mimeType, fileSize)
+	logger.Debug("Uploading file", "display_name", displayName, "mime_type", mimeType, "size", fileSize)
 
 	// 4. Initial Resumable Request (start)
-	baseURL := "https://generativelanguage.googleapis.com"
-	startURL := fmt.Sprintf("%s/upload/v1beta/files?key=%s", baseURL, apiKey)
-
 	startRequestBody := UploadStartRequest{
 		File: FileMetadata{DisplayName: displayName},
 	}
@@ -428,7 +465,9 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 		return "", fmt.Errorf("failed to marshal start request body: %w", err)
 	}
 
-	startReq, err := http.NewRequest("POST", startURL, bytes.NewBuffer(startRequestBodyBytes))
+	startCtx, cancelStart := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelStart()
+	startReq, err := newRequest(client, "/upload/v1beta/files", bytes.NewBuffer(startRequestBodyBytes))
 	if err != nil {
 		return "", fmt.Errorf("failed to create start request: %w", err)
 	}
@@ -436,11 +475,8 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 	startReq.Header.Set("X-Goog-Upload-Command", "start")
 	startReq.Header.Set("X-Goog-Upload-Header-Content-Length", fmt.Sprintf("%d", fileSize))
 	startReq.Header.Set("X-Goog-Upload-Header-Content-Type", mimeType)
-	startReq.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second} // Add a timeout
-	log.Printf("Sending Start request to Gemini: %s", startURL)
-	startResp, err := client.Do(startReq)
+	startResp, err := client.Do(startReq.WithContext(startCtx))
 	if err != nil {
 		return "", fmt.Errorf("failed to execute start request: %w", err)
 	}
@@ -448,7 +484,7 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 
 	if startResp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(startResp.Body)
-		log.Printf("Start request failed. Status: %s, Body: %s", startResp.Status, string(bodyBytes))
+		logger.Warn("Upload start request failed", "status", startResp.Status, "body", string(bodyBytes))
 		return "", fmt.Errorf("start request failed with status: %s", startResp.Status)
 	}
 
@@ -456,15 +492,16 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 	if uploadURL == "" {
 		// Read body for potential error details if URL is missing
 		bodyBytes, _ := io.ReadAll(startResp.Body)
-		log.Printf("Start request succeeded (Status OK) but did not return upload URL. Body: %s", string(bodyBytes))
+		logger.Warn("Upload start request returned no upload URL", "body", string(bodyBytes))
 		return "", fmt.Errorf("did not receive upload URL from start request")
 	}
-	log.Printf("Received resumable upload URL: %s", uploadURL)
 
 	// 5. Upload Bytes (upload, finalize)
 	// Use the response body from the download GET request directly.
 	// No need to re-open or read into memory unless Content-Length was missing earlier.
-	uploadReq, err := http.NewRequest("POST", uploadURL, downloadResp.Body) // Use the download response body
+	uploadCtx, cancelUpload := context.WithTimeout(context.Background(), 5*time.Minute) // Adjust timeout as needed for large files
+	defer cancelUpload()
+	uploadReq, err := http.NewRequestWithContext(uploadCtx, "POST", uploadURL, downloadResp.Body) // Use the download response body
 	if err != nil {
 		return "", fmt.Errorf("failed to create upload request: %w", err)
 	}
@@ -481,10 +518,7 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 	// when a body is present. Check Gemini docs if a specific Content-Type
 	// is required for this POST request itself. Let's omit it for now.
 
-	// Use a client with a potentially longer timeout for the actual upload
-	uploadClient := &http.Client{Timeout: 5 * time.Minute} // Adjust timeout as needed for large files
-	log.Printf("Sending Upload request to Gemini: %s", uploadURL)
-	uploadResp, err := uploadClient.Do(uploadReq)
+	uploadResp, err := client.Do(uploadReq)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute upload request: %w", err)
 	}
@@ -492,10 +526,9 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 
 	if uploadResp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(uploadResp.Body)
-		log.Printf("Upload request failed. Status: %s, Body: %s", uploadResp.Status, string(bodyBytes))
+		logger.Warn("Upload request failed", "status", uploadResp.Status, "body", string(bodyBytes))
 		return "", fmt.Errorf("upload request failed with status: %s", uploadResp.Status)
 	}
-	log.Printf("Upload request successful (Status: %s)", uploadResp.Status)
 
 	// 6. Parse final response for file URI
 	var fileInfoResponse FileInfoResponse
@@ -503,16 +536,16 @@ func uploadFileFromURLToGemini(fileURL string) (string, error) {
 		// It's possible the body was empty on success, or not JSON.
 		// Read remaining body for debugging.
 		bodyBytes, _ := io.ReadAll(uploadResp.Body) // Try reading again
-		log.Printf("Failed to decode JSON response after successful upload. Body: %s", string(bodyBytes))
+		logger.Warn("Failed to decode upload response", "body", string(bodyBytes))
 		return "", fmt.Errorf("failed to decode file info response: %w", err)
 	}
 
 	if fileInfoResponse.File.URI == "" {
-		log.Printf("Upload response JSON decoded, but File URI is missing. Response: %+v", fileInfoResponse)
+		logger.Warn("Upload response has no file URI", "response", fmt.Sprintf("%+v", fileInfoResponse))
 		return "", fmt.Errorf("file URI not found in final response")
 	}
 
-	log.Printf("Successfully uploaded file. Gemini URI: %s", fileInfoResponse.File.URI)
+	logger.Debug("Uploaded file", "uri", fileInfoResponse.File.URI)
 	return fileInfoResponse.File.URI, nil
 }
 
@@ -525,22 +558,22 @@ func lessThan2mb(url string) bool {
 	return resp.ContentLength > 0 && resp.ContentLength < 2*1024*1024
 }
 
-func getInlineData(url string) string {
+func getInlineData(logger *slog.Logger, url string) string {
 	resp, err := http.Get(url)
 	if err != nil {
-		log.Printf("Error fetching file for inline data from %s: %v", url, err)
+		logger.Warn("Failed to fetch file for inline data", "file_url", url, logging.KeyError, err)
 		return ""
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Error fetching file for inline data from %s: status %s", url, resp.Status)
+		logger.Warn("Failed to fetch file for inline data", "file_url", url, "status", resp.Status)
 		return ""
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading response body for inline data from %s: %v", url, err)
+		logger.Warn("Failed to read file for inline data", "file_url", url, logging.KeyError, err)
 		return ""
 	}
 
@@ -557,7 +590,7 @@ type GeminiRequestResult struct {
 
 // create_gemini_request turns User_Message to something gemini likes
 // Returns the request body and any warnings about content that was filtered/skipped
-func create_gemini_request(message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message, systemPrompt string) (GeminiRequestResult, error) {
+func create_gemini_request(logger *slog.Logger, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message, systemPrompt string) (GeminiRequestResult, error) {
 	var warnings []models.HistoryWarning
 	allContents := []Gemini_Content{}
 
@@ -572,7 +605,7 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 			if role == "user" {
 				var userParts []models.User_Part
 				if err := json.Unmarshal([]byte(histMsg.PartsJSON), &userParts); err != nil {
-					log.Printf("Warning: Failed to unmarshal PartsJSON for user history message %d: %v. Content: %s", histMsg.ID, err, histMsg.PartsJSON)
+					logger.Warn("Failed to unmarshal user history message", "message_id", histMsg.ID, logging.KeyError, err)
 					warnings = append(warnings, models.HistoryWarning{
 						Type:    "parse_error",
 						Message: "Failed to parse message content",
@@ -598,23 +631,23 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 					if p.ImageData != nil && inlineDataPart == nil {
 						if p.ImageData.FileUrl != "" {
 							// Try to upload the image to Gemini from the public URL
-							log.Printf("Converting ImageData to Gemini format: uploading from %s", p.ImageData.FileUrl)
+							logger.Debug("Converting history image", "file_url", p.ImageData.FileUrl)
 							if lessThan2mb(p.ImageData.FileUrl) {
 								// Try inline first for small images
-								inline := getInlineData(p.ImageData.FileUrl)
+								inline := getInlineData(logger, p.ImageData.FileUrl)
 								if inline != "" {
 									inlineDataPart = &InlineData{
 										MimeType: p.ImageData.MimeType,
 										Data:     inline,
 									}
-									log.Printf("Successfully inlined ImageData from history")
+									logger.Debug("Inlined history image")
 								}
 							}
 							// If inline didn't work or file is too large, upload to Gemini
 							if inlineDataPart == nil {
-								uri, err := uploadFileFromURLToGemini(p.ImageData.FileUrl)
+								uri, err := uploadFileFromURLToGemini(logger, client, p.ImageData.FileUrl)
 								if err != nil {
-									log.Printf("Warning: Failed to upload ImageData to Gemini: %v", err)
+									logger.Warn("Failed to upload history image", logging.KeyError, err)
 									warnings = append(warnings, models.HistoryWarning{
 										Type:    "upload_failed",
 										Message: "Failed to transfer image to Gemini",
@@ -625,11 +658,11 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 										MimeType: p.ImageData.MimeType,
 										URI:      uri,
 									}
-									log.Printf("Successfully uploaded ImageData to Gemini: %s", uri)
+									logger.Debug("Uploaded history image", "uri", uri)
 								}
 							}
 						} else {
-							log.Printf("Warning: ImageData has no FileUrl, cannot transfer to Gemini")
+							logger.Warn("History image has no file URL")
 							warnings = append(warnings, models.HistoryWarning{
 								Type:    "unsupported_content",
 								Message: "Image not available for Gemini",
@@ -647,23 +680,23 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 							}
 						} else if p.FileData.FileUrl != "" {
 							// No GoogleUri but we have FileUrl - upload to Gemini
-							log.Printf("FileData has no GoogleUri, uploading from FileUrl: %s", p.FileData.FileUrl)
+							logger.Debug("Converting history file", "file_url", p.FileData.FileUrl)
 							if lessThan2mb(p.FileData.FileUrl) {
 								// Try inline first for small files (images)
-								inline := getInlineData(p.FileData.FileUrl)
+								inline := getInlineData(logger, p.FileData.FileUrl)
 								if inline != "" {
 									inlineDataPart = &InlineData{
 										MimeType: p.FileData.MimeType,
 										Data:     inline,
 									}
-									log.Printf("Successfully inlined FileData from history")
+									logger.Debug("Inlined history file")
 								}
 							}
 							// If inline didn't work or file is too large, upload to Gemini
 							if inlineDataPart == nil {
-								uri, err := uploadFileFromURLToGemini(p.FileData.FileUrl)
+								uri, err := uploadFileFromURLToGemini(logger, client, p.FileData.FileUrl)
 								if err != nil {
-									log.Printf("Warning: Failed to upload FileData to Gemini: %v", err)
+									logger.Warn("Failed to upload history file", logging.KeyError, err)
 									warnings = append(warnings, models.HistoryWarning{
 										Type:    "upload_failed",
 										Message: "Failed to transfer file to Gemini",
@@ -674,11 +707,11 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 										MimeType: p.FileData.MimeType,
 										URI:      uri,
 									}
-									log.Printf("Successfully uploaded FileData to Gemini: %s", uri)
+									logger.Debug("Uploaded history file", "uri", uri)
 								}
 							}
 						} else {
-							log.Printf("Warning: FileData has no GoogleUri or FileUrl, cannot use")
+							logger.Warn("History file has no Google URI or file URL")
 							warnings = append(warnings, models.HistoryWarning{
 								Type:    "unsupported_content",
 								Message: "File not available for Gemini",
@@ -699,13 +732,13 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 					if reqPart.Text != "" || reqPart.InlineData != nil || reqPart.FileData != nil || reqPart.FunctionResponse != nil {
 						historyParts = append(historyParts, reqPart)
 					} else {
-						log.Printf("Warning: Skipping empty user part in history (no text, inline_data, file_data, or function_response)")
+						logger.Debug("Skipping empty user part in history")
 					}
 				}
 			} else if role == "model" {
 				var modelParts []models.Model_Part
 				if err := json.Unmarshal([]byte(histMsg.PartsJSON), &modelParts); err != nil {
-					log.Printf("Warning: Failed to unmarshal PartsJSON for model history message %d: %v. Content: %s", histMsg.ID, err, histMsg.PartsJSON)
+					logger.Warn("Failed to unmarshal model history message", "message_id", histMsg.ID, logging.KeyError, err)
 					warnings = append(warnings, models.HistoryWarning{
 						Type:    "parse_error",
 						Message: "Failed to parse assistant response",
@@ -722,31 +755,37 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 						textContent = *p.Text
 					}
 
-					// Note: Reasoning field from Model_Part is not sent to Gemini
-					// (it's internal chain-of-thought that other models may have)
+					// Note: Reasoning field from Model_Part is not sent to Gemini; thought summaries
+					// need not be replayed, only the thought signatures on text and function call parts
 					if p.Reasoning != nil && *p.Reasoning != "" {
-						log.Printf("Note: Reasoning content from previous model not included in Gemini history")
+						logger.Debug("Dropping reasoning content from history")
 					}
 
 					reqPart := Request_Part{
 						Text:         textContent,
 						FunctionCall: p.FunctionCall,
 					}
+					if p.Signature != nil && (p.Text != nil || p.FunctionCall != nil) {
+						reqPart.ThoughtSignature = *p.Signature
+					}
 
 					// Only add non-empty parts (Gemini requires at least one field to be set)
 					if reqPart.Text != "" || reqPart.FunctionCall != nil {
 						historyParts = append(historyParts, reqPart)
+					} else if last := len(historyParts) - 1; reqPart.ThoughtSignature != "" && last >= 0 && historyParts[last].ThoughtSignature == "" {
+						// A signature streamed on an empty text part belongs to the text before it
+						historyParts[last].ThoughtSignature = reqPart.ThoughtSignature
 					} else {
-						log.Printf("Warning: Skipping empty model part in history (no text or function_call)")
+						logger.Debug("Skipping empty model part in history")
 					}
 				}
 			} else {
-				log.Printf("Warning: Unknown role '%s' for history message %d. Cannot unmarshal parts.", role, histMsg.ID)
+				logger.Warn("Unknown role for history message", "role", role, "message_id", histMsg.ID)
 				continue
 			}
 		} else {
 			// Handle cases where PartsJSON might be empty/null but perhaps shouldn't be based on Type?
-			log.Printf("Warning: History message %d (Role: %s, Type: %s) has empty/null PartsJSON.", histMsg.ID, role, histMsg.Type)
+			logger.Warn("History message has no parts", "message_id", histMsg.ID, "role", role, "type", histMsg.Type)
 			// Potentially skip or handle based on Type
 			continue
 		}
@@ -758,7 +797,7 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 				Parts: historyParts, // Use the unmarshaled and converted parts
 			})
 		} else {
-			log.Printf("Warning: No parts generated after unmarshalling history message %d (Role: %s, Type: %s). Skipping.", histMsg.ID, role, histMsg.Type)
+			logger.Debug("Skipping history message with no usable parts", "message_id", histMsg.ID, "role", role, "type", histMsg.Type)
 		}
 	}
 
@@ -785,7 +824,7 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 		if message.Content.Parts != nil {
 			for _, part := range message.Content.Parts {
 				if part.FunctionResponse != nil {
-					log.Printf("Warning: Skipping FunctionResponse found in input User_Message parts; should be handled via toolResults or history.")
+					logger.Warn("Skipping function response in user message parts; send it as a tool result")
 					continue
 				}
 
@@ -797,34 +836,34 @@ func create_gemini_request(message models.User_Message, tools []models.FunctionD
 					var err error
 					if part.FileData.GoogleUri == nil || *part.FileData.GoogleUri == "" {
 						if lessThan2mb(part.FileData.FileUrl) {
-							inline := getInlineData(part.FileData.FileUrl)
+							inline := getInlineData(logger, part.FileData.FileUrl)
 							if inline != "" {
-								log.Printf("Inlining data for %s (less than 2MB)", part.FileData.FileUrl)
+								logger.Debug("Inlining file", "file_url", part.FileData.FileUrl)
 								currentUserParts = append(currentUserParts, Request_Part{InlineData: &InlineData{MimeType: part.FileData.MimeType, Data: inline}})
 							} else {
-								log.Printf("Failed to get inline data for %s, attempting upload.", part.FileData.FileUrl)
-								uri, err = uploadFileFromURLToGemini(part.FileData.FileUrl)
+								logger.Debug("Failed to inline file, uploading instead", "file_url", part.FileData.FileUrl)
+								uri, err = uploadFileFromURLToGemini(logger, client, part.FileData.FileUrl)
 								if err != nil {
 									return GeminiRequestResult{}, fmt.Errorf("failed to upload file %s after failed inline attempt: %w", part.FileData.FileUrl, err)
 								}
-								log.Printf("Uploaded file %s to %s", part.FileData.FileUrl, uri)
+								logger.Debug("Uploaded file", "file_url", part.FileData.FileUrl, "uri", uri)
 								currentUserParts = append(currentUserParts, Request_Part{FileData: &FileData{URI: uri, MimeType: part.FileData.MimeType}})
 							}
 						} else {
-							uri, err = uploadFileFromURLToGemini(part.FileData.FileUrl)
+							uri, err = uploadFileFromURLToGemini(logger, client, part.FileData.FileUrl)
 							if err != nil {
 								return GeminiRequestResult{}, fmt.Errorf("failed to upload file %s to gemini: %w", part.FileData.FileUrl, err)
 							}
-							log.Printf("Uploaded file %s to %s", part.FileData.FileUrl, uri)
+							logger.Debug("Uploaded file", "file_url", part.FileData.FileUrl, "uri", uri)
 							currentUserParts = append(currentUserParts, Request_Part{FileData: &FileData{URI: uri, MimeType: part.FileData.MimeType}})
 						}
 					} else {
 						uri = *part.FileData.GoogleUri
-						log.Printf("Using provided Google URI %s for file %s", uri, part.FileData.FileUrl)
+						logger.Debug("Using provided Google URI", "uri", uri, "file_url", part.FileData.FileUrl)
 						currentUserParts = append(currentUserParts, Request_Part{FileData: &FileData{URI: uri, MimeType: part.FileData.MimeType}})
 					}
 				} else if part.InlineData != nil {
-					log.Printf("Adding inline data part (MimeType: %s)", part.InlineData.MimeType)
+					logger.Debug("Adding inline data part", "mime_type", part.InlineData.MimeType)
 					currentUserParts = append(currentUserParts, Request_Part{InlineData: &InlineData{MimeType: part.InlineData.MimeType, Data: part.InlineData.Data}})
 				}
 			}
@@ -0,0 +1,84 @@
+package gemini
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+func TestGeminiResponseToModelResponse_Thoughts(t *testing.T) {
+	str := func(s string) *string { return &s }
+	response := Gemini_response{Candidates: []Candidate{{Content: Content{Parts: []Part{
+		{Text: str("Planning the answer"), Thought: true},
+		{Text: str("Answer"), ThoughtSignature: str("sig-text")},
+		{ThoughtSignature: str("sig-trailing")},
+		{FunctionCall: &FunctionCall{Name: "search", Args: map[string]interface{}{}}, ThoughtSignature: str("sig-call")},
+	}}}}}
+
+	got, err := (&Gemini_Model{}).gemini_response_to_model_response(response)
+	if err != nil {
+		t.Fatalf("gemini_response_to_model_response: %v", err)
+	}
+	if len(got.Parts) != 4 {
+		t.Fatalf("Expected 4 parts, got %+v", got.Parts)
+	}
+	if thought := got.Parts[0]; thought.Reasoning == nil || *thought.Reasoning != "Planning the answer" || thought.Text != nil {
+		t.Errorf("Expected the thought as a Reasoning part, got %+v", thought)
+	}
+	if text := got.Parts[1]; text.Text == nil || *text.Text != "Answer" || text.Signature == nil || *text.Signature != "sig-text" {
+		t.Errorf("Expected signed text, got %+v", text)
+	}
+	// A bare signature is kept on an empty text part so Anthropic doesn't replay it as thinking
+	if trailing := got.Parts[2]; trailing.Text == nil || *trailing.Text != "" || trailing.Signature == nil {
+		t.Errorf("Expected the bare signature on an empty text part, got %+v", trailing)
+	}
+	if call := got.Parts[3]; call.FunctionCall == nil || call.Signature == nil || *call.Signature != "sig-call" {
+		t.Errorf("Expected a signed function call, got %+v", call)
+	}
+}
+
+func TestCreateGeminiRequest_ReplaysSignatures(t *testing.T) {
+	str := func(s string) *string { return &s }
+	parts, err := json.Marshal([]models.Model_Part{
+		{Reasoning: str("Planning the answer")},
+		{Text: str("Answer")},
+		{Text: str(""), Signature: str("sig-text")},
+		{FunctionCall: &models.FunctionCall{Name: "search", Args: map[string]interface{}{}}, Signature: str("sig-call")},
+		{Reasoning: str("Anthropic thinking")},
+		{Signature: str("anthropic-sig")},
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	history := []stores.Message{
+		{Role: "user", Type: "user_message", PartsJSON: `[{"text":"Question"}]`},
+		{Role: "model", Type: "model_message", PartsJSON: string(parts)},
+	}
+	message := models.User_Message{Role: "user", Content: models.Content{Parts: []models.User_Part{{Text: "Next"}}}}
+
+	result, err := create_gemini_request(slog.Default(), models.ProviderClient{}, message, nil, nil, history, "")
+	if err != nil {
+		t.Fatalf("create_gemini_request: %v", err)
+	}
+	contents := *result.Body.Contents
+	if len(contents) != 3 {
+		t.Fatalf("Expected 3 contents, got %+v", contents)
+	}
+
+	var got []string
+	for _, part := range contents[1].Parts {
+		what := part.Text
+		if part.FunctionCall != nil {
+			what = "call:" + part.FunctionCall.Name
+		}
+		got = append(got, what+"/"+part.ThoughtSignature)
+	}
+	// Thought summaries and Anthropic signatures are dropped, Gemini's go back on their parts
+	if want := []string{"Answer/sig-text", "call:search/sig-call"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected model parts %v, got %v", want, got)
+	}
+}
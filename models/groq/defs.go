@@ -90,9 +90,16 @@ type StreamResponse struct {
 	Created           int64    `json:"created"`
 	Model             string   `json:"model"`
 	Choices           []Choice `json:"choices"`
+	Usage             *Usage   `json:"usage,omitempty"`  // Sent on the final chunk by providers that report stream usage
+	XGroq             *XGroq   `json:"x_groq,omitempty"` // Groq reports stream usage here
 	SystemFingerprint string   `json:"system_fingerprint,omitempty"`
 }
 
+// XGroq holds Groq-specific stream metadata
+type XGroq struct {
+	Usage *Usage `json:"usage,omitempty"`
+}
+
 // Error response
 type ErrorResponse struct {
 	Error GroqError `json:"error"`
@@ -150,3 +157,23 @@ func ConvertToGroqTools(fds []models.FunctionDeclaration) []Tool {
 	}
 	return tools
 }
+
+// toModelUsage converts token counts to godantic's Usage
+func (u *Usage) toModelUsage() *models.Usage {
+	if u == nil {
+		return nil
+	}
+	return &models.Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+}
+
+// streamSummary builds the final stream chunk reporting why generation stopped and how many tokens were used
+func streamSummary(finishReason string, usage *Usage) (models.Model_Response, bool) {
+	if finishReason == "" && usage == nil {
+		return models.Model_Response{}, false
+	}
+	return models.Model_Response{FinishReason: finishReason, Usage: usage.toModelUsage()}, true
+}
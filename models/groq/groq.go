@@ -6,14 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/Desarso/godantic/logging"
 	models "github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
-	"github.com/joho/godotenv"
 )
 
 const (
@@ -21,31 +20,22 @@ const (
 	DefaultModel = "llama-3.1-70b-versatile"
 )
 
-var (
-	logFile *os.File
-)
-
-func init() {
-	// Load .env file if it exists (not present in production)
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-	var err error
-	logFile, err = os.OpenFile("debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalln("Failed to open log file")
-	}
-}
-
 // Groq_Model implements the Model interface for Groq API
 // Groq uses OpenAI-compatible API format
 type Groq_Model struct {
 	Model        string // Model identifier (e.g., "llama-3.1-70b-versatile", "mixtral-8x7b-32768")
 	Temperature  *float64
 	MaxTokens    *int
-	SystemPrompt string // Optional: System prompt for the AI
-	BaseURL      string // Optional: Custom API base URL (defaults to Groq)
-	APIKeyEnv    string // Optional: Environment variable name for API key (defaults to GROQ_API_KEY)
+	SystemPrompt string                 // Optional: System prompt for the AI
+	BaseURL      string                 // Optional: Custom API base URL (defaults to Groq)
+	APIKeyEnv    string                 // Optional: Environment variable name for API key (defaults to GROQ_API_KEY)
+	Client       *models.ProviderClient `json:"-"` // Optional: API key, endpoint, HTTP client and headers; takes precedence over BaseURL and APIKeyEnv
+	Logger       *slog.Logger           `json:"-"` // Optional: defaults to logging.Default()
+}
+
+// logger returns the model's logger tagged with the provider and model
+func (g *Groq_Model) logger() *slog.Logger {
+	return logging.Or(g.Logger).With(logging.KeyProvider, "groq", logging.KeyModel, g.Model)
 }
 
 // Model_Request implements the Model interface
@@ -66,7 +56,7 @@ func (g *Groq_Model) Model_Request(request models.Model_Request, tools []models.
 		modelToUse = DefaultModel
 	}
 
-	groqResponse, err := g.makeRequest(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	groqResponse, err := g.makeRequest(modelToUse, g.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 	if err != nil {
 		return models.Model_Response{}, err
 	}
@@ -97,7 +87,7 @@ func (g *Groq_Model) Stream_Model_Request(request models.Model_Request, tools []
 		modelToUse = DefaultModel
 	}
 
-	return g.makeStreamRequest(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	return g.makeStreamRequest(modelToUse, g.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 }
 
 // groqResponseToModelResponse converts Groq response to the standard Model_Response
@@ -105,6 +95,10 @@ func (g *Groq_Model) groqResponseToModelResponse(response GroqResponse) (models.
 	modelResponse := models.Model_Response{}
 
 	for _, choice := range response.Choices {
+		if choice.FinishReason != nil {
+			modelResponse.FinishReason = *choice.FinishReason
+		}
+
 		// Handle text content
 		if choice.Message.Content != nil {
 			switch content := choice.Message.Content.(type) {
@@ -123,7 +117,7 @@ func (g *Groq_Model) groqResponseToModelResponse(response GroqResponse) (models.
 			if toolCall.Type == "function" {
 				var args map[string]interface{}
 				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					log.Printf("Warning: Failed to unmarshal tool call arguments: %v", err)
+					g.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 					args = map[string]interface{}{}
 				}
 
@@ -138,11 +132,12 @@ func (g *Groq_Model) groqResponseToModelResponse(response GroqResponse) (models.
 		}
 	}
 
+	modelResponse.Usage = response.Usage.toModelUsage()
 	return modelResponse, nil
 }
 
 // makeRequest sends a non-streaming request to Groq
-func (g *Groq_Model) makeRequest(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (GroqResponse, error) {
+func (g *Groq_Model) makeRequest(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (GroqResponse, error) {
 	requestBody, err := g.createGroqRequest(model, message, tools, toolResults, conversationHistory, false)
 	if err != nil {
 		return GroqResponse{}, fmt.Errorf("failed to create Groq request: %w", err)
@@ -152,26 +147,15 @@ func (g *Groq_Model) makeRequest(model string, message models.User_Message, tool
 	if err != nil {
 		return GroqResponse{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
+	g.logger().Debug("Sending request", "body_bytes", len(jsonBytes))
 
-	// Debug: save request body
-	if err := os.WriteFile("groq_request_body.json", jsonBytes, 0644); err != nil {
-		log.Printf("Warning: failed to write request body to file: %v", err)
-	}
-
-	// Use custom base URL if provided, otherwise use Groq
-	baseURL := g.BaseURL
-	if baseURL == "" {
-		baseURL = GroqBaseURL
-	}
-
-	req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+	req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 	if err != nil {
 		return GroqResponse{}, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	g.setHeaders(req)
+	g.setHeaders(req, client)
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return GroqResponse{}, fmt.Errorf("HTTP request failed: %w", err)
@@ -200,7 +184,7 @@ func (g *Groq_Model) makeRequest(model string, message models.User_Message, tool
 }
 
 // makeStreamRequest sends a streaming request to Groq
-func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
+func (g *Groq_Model) makeStreamRequest(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
 	respChan := make(chan models.Model_Response)
 	errChan := make(chan error, 1)
 
@@ -220,24 +204,16 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 			return
 		}
 
-		// Request body logging disabled - enable for debugging if needed
-		// log.Printf("Groq Stream Request Body:\n%s", string(jsonBytes))
-
-		// Use custom base URL if provided, otherwise use Groq
-		baseURL := g.BaseURL
-		if baseURL == "" {
-			baseURL = GroqBaseURL
-		}
+		g.logger().Debug("Sending stream request", "body_bytes", len(jsonBytes))
 
-		req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+		req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 		if err != nil {
 			errChan <- fmt.Errorf("failed to create HTTP request: %w", err)
 			return
 		}
 
-		g.setHeaders(req)
+		g.setHeaders(req, client)
 
-		client := &http.Client{}
 		resp, err := client.Do(req)
 		if err != nil {
 			errChan <- fmt.Errorf("HTTP request failed: %w", err)
@@ -258,6 +234,8 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 
 		// Track accumulated tool calls across stream chunks
 		toolCallAccumulator := make(map[int]*ToolCall)
+		var finishReason string
+		var usage *Usage
 
 		reader := bufio.NewReader(resp.Body)
 		for {
@@ -270,7 +248,7 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 						for _, tc := range toolCallAccumulator {
 							var args map[string]interface{}
 							if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-								log.Printf("Warning: Failed to unmarshal final tool call arguments: %v", err)
+								g.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 								args = map[string]interface{}{}
 							}
 							modelResp.Parts = append(modelResp.Parts, models.Model_Part{
@@ -283,6 +261,9 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 						}
 						respChan <- modelResp
 					}
+					if summary, ok := streamSummary(finishReason, usage); ok {
+						respChan <- summary
+					}
 					return
 				}
 				errChan <- fmt.Errorf("error reading stream: %w", err)
@@ -307,7 +288,7 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 					for _, tc := range toolCallAccumulator {
 						var args map[string]interface{}
 						if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-							log.Printf("Warning: Failed to unmarshal final tool call arguments: %v", err)
+							g.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 							args = map[string]interface{}{}
 						}
 						modelResp.Parts = append(modelResp.Parts, models.Model_Part{
@@ -320,16 +301,28 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 					}
 					respChan <- modelResp
 				}
+				if summary, ok := streamSummary(finishReason, usage); ok {
+					respChan <- summary
+				}
 				return
 			}
 
 			var streamResp StreamResponse
 			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
-				log.Printf("Warning: Failed to unmarshal stream chunk: %v, data: %s", err, data)
+				g.logger().Warn("Failed to unmarshal stream chunk", "data", data, logging.KeyError, err)
 				continue
 			}
 
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage
+			} else if streamResp.XGroq != nil && streamResp.XGroq.Usage != nil {
+				usage = streamResp.XGroq.Usage
+			}
+
 			for _, choice := range streamResp.Choices {
+				if choice.FinishReason != nil && *choice.FinishReason != "" {
+					finishReason = *choice.FinishReason
+				}
 				if choice.Delta == nil {
 					continue
 				}
@@ -379,15 +372,19 @@ func (g *Groq_Model) makeStreamRequest(model string, message models.User_Message
 	return respChan, errChan
 }
 
-// setHeaders sets the required headers for Groq API requests
-func (g *Groq_Model) setHeaders(req *http.Request) {
-	// Use custom API key environment variable if provided, otherwise use GROQ_API_KEY
-	apiKeyEnv := g.APIKeyEnv
-	if apiKeyEnv == "" {
-		apiKeyEnv = "GROQ_API_KEY"
+// client returns the connection settings for a request: override, then Client, then BaseURL,
+// GroqBaseURL and the API key environment variable
+func (g *Groq_Model) client(override *models.ProviderClient) models.ProviderClient {
+	defaults := models.ProviderClient{BaseURL: g.BaseURL}
+	if defaults.BaseURL == "" {
+		defaults.BaseURL = GroqBaseURL
 	}
-	apiKey := os.Getenv(apiKeyEnv)
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	return defaults.Merge(g.Client).Merge(override).APIKeyFromEnv(g.APIKeyEnv, "GROQ_API_KEY")
+}
+
+// setHeaders sets the required headers for Groq API requests
+func (g *Groq_Model) setHeaders(req *http.Request, client models.ProviderClient) {
+	req.Header.Set("Authorization", "Bearer "+client.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 }
 
@@ -407,7 +404,7 @@ func (g *Groq_Model) createGroqRequest(model string, message models.User_Message
 	for _, histMsg := range conversationHistory {
 		msg, err := g.convertHistoryMessage(histMsg)
 		if err != nil {
-			log.Printf("Warning: Failed to convert history message %d: %v", histMsg.ID, err)
+			g.logger().Warn("Failed to convert history message", "message_id", histMsg.ID, logging.KeyError, err)
 			continue
 		}
 		if msg != nil {
@@ -6,14 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/Desarso/godantic/logging"
 	models "github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
-	"github.com/joho/godotenv"
 )
 
 const (
@@ -21,22 +20,6 @@ const (
 	DefaultModel      = "openai/gpt-4o-mini"
 )
 
-var (
-	logFile *os.File
-)
-
-func init() {
-	// Load .env file if it exists (not present in production)
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-	var err error
-	logFile, err = os.OpenFile("debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalln("Failed to open log file")
-	}
-}
-
 // OpenRouter_Model implements the Model interface for OpenRouter API
 // Also supports any OpenAI-compatible API endpoint
 type OpenRouter_Model struct {
@@ -48,8 +31,15 @@ type OpenRouter_Model struct {
 	SystemPrompt    string                                 // Optional: System prompt for the AI
 	BaseURL         string                                 // Optional: Custom API base URL (defaults to OpenRouter)
 	APIKeyEnv       string                                 // Optional: Environment variable name for API key (defaults to OPENROUTER_API_KEY)
+	Client          *models.ProviderClient                 `json:"-"` // Optional: API key, endpoint, HTTP client and headers; takes precedence over BaseURL and APIKeyEnv
 	SupportsVision  bool                                   // Whether the model supports image/vision input
 	WarningCallback func(warnings []models.HistoryWarning) `json:"-"` // Called when history is adapted with warnings
+	Logger          *slog.Logger                           `json:"-"` // Optional: defaults to logging.Default()
+}
+
+// logger returns the model's logger tagged with the provider and model
+func (o *OpenRouter_Model) logger() *slog.Logger {
+	return logging.Or(o.Logger).With(logging.KeyProvider, "openrouter", logging.KeyModel, o.Model)
 }
 
 // SetHistoryWarningCallback sets the callback function for history adaptation warnings
@@ -76,7 +66,7 @@ func (o *OpenRouter_Model) Model_Request(request models.Model_Request, tools []m
 		modelToUse = DefaultModel
 	}
 
-	openRouterResponse, err := o.makeRequest(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	openRouterResponse, err := o.makeRequest(modelToUse, o.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 	if err != nil {
 		return models.Model_Response{}, err
 	}
@@ -107,7 +97,7 @@ func (o *OpenRouter_Model) Stream_Model_Request(request models.Model_Request, to
 		modelToUse = DefaultModel
 	}
 
-	return o.makeStreamRequest(modelToUse, msg, tools, request.Tool_Results, conversationHistory)
+	return o.makeStreamRequest(modelToUse, o.client(request.Client), msg, tools, request.Tool_Results, conversationHistory)
 }
 
 // openRouterResponseToModelResponse converts OpenRouter response to the standard Model_Response
@@ -115,6 +105,10 @@ func (o *OpenRouter_Model) openRouterResponseToModelResponse(response OpenRouter
 	modelResponse := models.Model_Response{}
 
 	for _, choice := range response.Choices {
+		if choice.FinishReason != nil {
+			modelResponse.FinishReason = *choice.FinishReason
+		}
+
 		// Handle text content
 		if choice.Message.Content != nil {
 			switch content := choice.Message.Content.(type) {
@@ -133,7 +127,7 @@ func (o *OpenRouter_Model) openRouterResponseToModelResponse(response OpenRouter
 			if toolCall.Type == "function" {
 				var args map[string]interface{}
 				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					log.Printf("Warning: Failed to unmarshal tool call arguments: %v", err)
+					o.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 					args = map[string]interface{}{}
 				}
 
@@ -148,11 +142,12 @@ func (o *OpenRouter_Model) openRouterResponseToModelResponse(response OpenRouter
 		}
 	}
 
+	modelResponse.Usage = response.Usage.toModelUsage()
 	return modelResponse, nil
 }
 
 // makeRequest sends a non-streaming request to OpenRouter
-func (o *OpenRouter_Model) makeRequest(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (OpenRouterResponse, error) {
+func (o *OpenRouter_Model) makeRequest(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (OpenRouterResponse, error) {
 	requestBody, err := o.createOpenRouterRequest(model, message, tools, toolResults, conversationHistory, false)
 	if err != nil {
 		return OpenRouterResponse{}, fmt.Errorf("failed to create OpenRouter request: %w", err)
@@ -162,26 +157,15 @@ func (o *OpenRouter_Model) makeRequest(model string, message models.User_Message
 	if err != nil {
 		return OpenRouterResponse{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
+	o.logger().Debug("Sending request", "body_bytes", len(jsonBytes))
 
-	// Debug: save request body
-	if err := os.WriteFile("openrouter_request_body.json", jsonBytes, 0644); err != nil {
-		log.Printf("Warning: failed to write request body to file: %v", err)
-	}
-
-	// Use custom base URL if provided, otherwise use OpenRouter
-	baseURL := o.BaseURL
-	if baseURL == "" {
-		baseURL = OpenRouterBaseURL
-	}
-
-	req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+	req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 	if err != nil {
 		return OpenRouterResponse{}, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	o.setHeaders(req)
+	o.setHeaders(req, client)
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return OpenRouterResponse{}, fmt.Errorf("HTTP request failed: %w", err)
@@ -210,7 +194,7 @@ func (o *OpenRouter_Model) makeRequest(model string, message models.User_Message
 }
 
 // makeStreamRequest sends a streaming request to OpenRouter
-func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
+func (o *OpenRouter_Model) makeStreamRequest(model string, client models.ProviderClient, message models.User_Message, tools []models.FunctionDeclaration, toolResults *[]models.Tool_Result, conversationHistory []stores.Message) (<-chan models.Model_Response, <-chan error) {
 	respChan := make(chan models.Model_Response)
 	errChan := make(chan error, 1)
 
@@ -230,24 +214,16 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 			return
 		}
 
-		// Request body logging disabled - enable for debugging if needed
-		// log.Printf("OpenRouter Stream Request Body:\n%s", string(jsonBytes))
-
-		// Use custom base URL if provided, otherwise use OpenRouter
-		baseURL := o.BaseURL
-		if baseURL == "" {
-			baseURL = OpenRouterBaseURL
-		}
+		o.logger().Debug("Sending stream request", "body_bytes", len(jsonBytes))
 
-		req, err := http.NewRequest("POST", baseURL, bytes.NewReader(jsonBytes))
+		req, err := http.NewRequest("POST", client.BaseURL, bytes.NewReader(jsonBytes))
 		if err != nil {
 			errChan <- fmt.Errorf("failed to create HTTP request: %w", err)
 			return
 		}
 
-		o.setHeaders(req)
+		o.setHeaders(req, client)
 
-		client := &http.Client{}
 		resp, err := client.Do(req)
 		if err != nil {
 			errChan <- fmt.Errorf("HTTP request failed: %w", err)
@@ -268,6 +244,8 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 
 		// Track accumulated tool calls across stream chunks
 		toolCallAccumulator := make(map[int]*ToolCall)
+		var finishReason string
+		var usage *Usage
 
 		reader := bufio.NewReader(resp.Body)
 		for {
@@ -280,7 +258,7 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 						for _, tc := range toolCallAccumulator {
 							var args map[string]interface{}
 							if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-								log.Printf("Warning: Failed to unmarshal final tool call arguments: %v", err)
+								o.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 								args = map[string]interface{}{}
 							}
 							modelResp.Parts = append(modelResp.Parts, models.Model_Part{
@@ -293,6 +271,9 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 						}
 						respChan <- modelResp
 					}
+					if summary, ok := streamSummary(finishReason, usage); ok {
+						respChan <- summary
+					}
 					return
 				}
 				errChan <- fmt.Errorf("error reading stream: %w", err)
@@ -317,7 +298,7 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 					for _, tc := range toolCallAccumulator {
 						var args map[string]interface{}
 						if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-							log.Printf("Warning: Failed to unmarshal final tool call arguments: %v", err)
+							o.logger().Warn("Failed to unmarshal tool call arguments", logging.KeyError, err)
 							args = map[string]interface{}{}
 						}
 						modelResp.Parts = append(modelResp.Parts, models.Model_Part{
@@ -330,16 +311,26 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 					}
 					respChan <- modelResp
 				}
+				if summary, ok := streamSummary(finishReason, usage); ok {
+					respChan <- summary
+				}
 				return
 			}
 
 			var streamResp StreamResponse
 			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
-				log.Printf("Warning: Failed to unmarshal stream chunk: %v, data: %s", err, data)
+				o.logger().Warn("Failed to unmarshal stream chunk", "data", data, logging.KeyError, err)
 				continue
 			}
 
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage
+			}
+
 			for _, choice := range streamResp.Choices {
+				if choice.FinishReason != nil && *choice.FinishReason != "" {
+					finishReason = *choice.FinishReason
+				}
 				if choice.Delta == nil {
 					continue
 				}
@@ -403,15 +394,19 @@ func (o *OpenRouter_Model) makeStreamRequest(model string, message models.User_M
 	return respChan, errChan
 }
 
+// client returns the connection settings for a request: override, then Client, then BaseURL,
+// OpenRouterBaseURL and the API key environment variable
+func (o *OpenRouter_Model) client(override *models.ProviderClient) models.ProviderClient {
+	defaults := models.ProviderClient{BaseURL: o.BaseURL}
+	if defaults.BaseURL == "" {
+		defaults.BaseURL = OpenRouterBaseURL
+	}
+	return defaults.Merge(o.Client).Merge(override).APIKeyFromEnv(o.APIKeyEnv, "OPENROUTER_API_KEY")
+}
+
 // setHeaders sets the required headers for OpenRouter API requests
-func (o *OpenRouter_Model) setHeaders(req *http.Request) {
-	// Use custom API key environment variable if provided, otherwise use OPENROUTER_API_KEY
-	apiKeyEnv := o.APIKeyEnv
-	if apiKeyEnv == "" {
-		apiKeyEnv = "OPENROUTER_API_KEY"
-	}
-	apiKey := os.Getenv(apiKeyEnv)
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+func (o *OpenRouter_Model) setHeaders(req *http.Request, client models.ProviderClient) {
+	req.Header.Set("Authorization", "Bearer "+client.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	// Optional headers for OpenRouter
@@ -496,7 +491,7 @@ func (o *OpenRouter_Model) createOpenRouterRequest(model string, message models.
 	for _, histMsg := range conversationHistory {
 		msg, warnings, err := o.convertHistoryMessageWithWarnings(histMsg, stripImages)
 		if err != nil {
-			log.Printf("Warning: Failed to convert history message %d: %v", histMsg.ID, err)
+			o.logger().Warn("Failed to convert history message", "message_id", histMsg.ID, logging.KeyError, err)
 			allWarnings = append(allWarnings, models.HistoryWarning{
 				Type:    "parse_error",
 				Message: "Failed to parse message content",
@@ -619,13 +614,16 @@ func (o *OpenRouter_Model) convertHistoryMessageWithWarnings(histMsg stores.Mess
 			Role: "assistant",
 		}
 
-		var textContent strings.Builder
+		var textContent, reasoning strings.Builder
 		var toolCalls []ToolCall
 
 		for _, part := range modelParts {
 			if part.Text != nil && *part.Text != "" {
 				textContent.WriteString(*part.Text)
 			}
+			if part.Reasoning != nil {
+				reasoning.WriteString(*part.Reasoning)
+			}
 			if part.FunctionCall != nil {
 				argsBytes, _ := json.Marshal(part.FunctionCall.Args)
 				toolCalls = append(toolCalls, ToolCall{
@@ -637,7 +635,6 @@ func (o *OpenRouter_Model) convertHistoryMessageWithWarnings(histMsg stores.Mess
 					},
 				})
 			}
-			// Note: Reasoning content is internal and not sent to OpenRouter
 		}
 
 		if textContent.Len() > 0 {
@@ -645,6 +642,11 @@ func (o *OpenRouter_Model) convertHistoryMessageWithWarnings(histMsg stores.Mess
 		}
 		if len(toolCalls) > 0 {
 			msg.ToolCalls = toolCalls
+			// Reasoning models continue a tool loop from their own reasoning; final answers don't need it
+			if reasoning.Len() > 0 {
+				text := reasoning.String()
+				msg.Reasoning = &text
+			}
 		}
 
 		if msg.Content == nil && len(msg.ToolCalls) == 0 {
@@ -732,7 +734,7 @@ func (o *OpenRouter_Model) buildContentFromUserPartsWithWarnings(parts []models.
 					},
 				})
 			} else {
-				log.Printf("Warning: Skipping non-image InlineData (mime: %s)", part.InlineData.MimeType)
+				o.logger().Debug("Skipping non-image inline data", "mime_type", part.InlineData.MimeType)
 				warnings = append(warnings, models.HistoryWarning{
 					Type:    "unsupported_content",
 					Message: "File type not supported",
@@ -759,7 +761,7 @@ func (o *OpenRouter_Model) buildContentFromUserPartsWithWarnings(parts []models.
 					})
 				} else {
 					// Non-image file (PDF, etc.) - skip with warning
-					log.Printf("Warning: Skipping non-image file in history (mime: %s)", part.FileData.MimeType)
+					o.logger().Debug("Skipping non-image file", "mime_type", part.FileData.MimeType)
 					warnings = append(warnings, models.HistoryWarning{
 						Type:    "unsupported_content",
 						Message: "File type not supported by this model",
@@ -768,7 +770,7 @@ func (o *OpenRouter_Model) buildContentFromUserPartsWithWarnings(parts []models.
 				}
 			} else if part.FileData.GoogleUri != nil && *part.FileData.GoogleUri != "" {
 				// File was uploaded to Gemini but we don't have a public URL
-				log.Printf("Warning: FileData has GoogleUri but no FileUrl, cannot use for OpenRouter")
+				o.logger().Warn("File has a Google URI but no file URL")
 				warnings = append(warnings, models.HistoryWarning{
 					Type:    "unsupported_content",
 					Message: "File not available for this model",
@@ -794,7 +796,7 @@ func (o *OpenRouter_Model) buildContentFromUserPartsWithWarnings(parts []models.
 						},
 					})
 				} else {
-					log.Printf("Warning: Skipping non-image ImageData (mime: %s)", part.ImageData.MimeType)
+					o.logger().Debug("Skipping non-image image data", "mime_type", part.ImageData.MimeType)
 					warnings = append(warnings, models.HistoryWarning{
 						Type:    "unsupported_content",
 						Message: "File type not supported",
@@ -802,7 +804,7 @@ func (o *OpenRouter_Model) buildContentFromUserPartsWithWarnings(parts []models.
 					})
 				}
 			} else {
-				log.Printf("Warning: ImageData has no FileUrl")
+				o.logger().Warn("Image has no file URL")
 				warnings = append(warnings, models.HistoryWarning{
 					Type:    "unsupported_content",
 					Message: "Image not available",
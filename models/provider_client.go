@@ -0,0 +1,70 @@
+package models
+
+import (
+	"net/http"
+	"os"
+)
+
+// ProviderClient holds the connection settings a chat model uses to reach its provider.
+// Zero fields fall back to the model's defaults, so one process can serve several API keys
+// or endpoints by giving each model (or each Model_Request) its own ProviderClient.
+//
+// None of its fields are JSON-encoded: BaseURL and Headers can carry credentials too
+// (tokens in a proxy URL, Authorization headers).
+type ProviderClient struct {
+	APIKey     string            `json:"-"` // Defaults to the provider's environment variable (e.g. ANTHROPIC_API_KEY)
+	BaseURL    string            `json:"-"` // Defaults to the provider's public endpoint
+	HTTPClient *http.Client      `json:"-"` // Proxies, TLS and timeouts; defaults to http.DefaultClient
+	Headers    map[string]string `json:"-"` // Extra headers sent with every request, applied after the provider's own
+}
+
+// Merge returns c with the fields set in override taking precedence. Headers are combined.
+func (c ProviderClient) Merge(override *ProviderClient) ProviderClient {
+	if override == nil {
+		return c
+	}
+	if override.APIKey != "" {
+		c.APIKey = override.APIKey
+	}
+	if override.BaseURL != "" {
+		c.BaseURL = override.BaseURL
+	}
+	if override.HTTPClient != nil {
+		c.HTTPClient = override.HTTPClient
+	}
+	if len(override.Headers) > 0 {
+		headers := make(map[string]string, len(c.Headers)+len(override.Headers))
+		for key, value := range c.Headers {
+			headers[key] = value
+		}
+		for key, value := range override.Headers {
+			headers[key] = value
+		}
+		c.Headers = headers
+	}
+	return c
+}
+
+// APIKeyFromEnv fills a missing APIKey from the environment variable name, or fallback when name is empty
+func (c ProviderClient) APIKeyFromEnv(name, fallback string) ProviderClient {
+	if c.APIKey != "" {
+		return c
+	}
+	if name == "" {
+		name = fallback
+	}
+	c.APIKey = os.Getenv(name)
+	return c
+}
+
+// Do applies the extra headers to req and sends it with the configured HTTP client
+func (c ProviderClient) Do(req *http.Request) (*http.Response, error) {
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
@@ -16,6 +16,9 @@ type Model_Request struct {
 	// Language_Code optionally indicates the user's preferred language.
 	// Supported values: "en" (English, default), "es" (Spanish), etc.
 	Language_Code string `json:"language_code,omitempty"`
+	// Client optionally overrides the model's ProviderClient for this request (e.g. a user's own API key).
+	// Sessions carry it over to the tool-result requests of the same turn.
+	Client *ProviderClient `json:"-"`
 }
 
 type Tool_Result struct {
@@ -11,6 +11,20 @@ type HistoryWarning struct {
 type Model_Response struct {
 	Parts    []Model_Part     `json:"parts"`
 	Warnings []HistoryWarning `json:"warnings,omitempty"` // Warnings about history adaptation (only sent in first chunk)
+	// FinishReason and Usage are reported by the provider, typically on the final chunk of a stream.
+	// A chunk may carry only these fields and no parts.
+	FinishReason string `json:"finish_reason,omitempty"` // Provider stop reason, e.g. "stop", "tool_calls", "end_turn", "MAX_TOKENS"
+	Usage        *Usage `json:"usage,omitempty"`
+}
+
+// Usage reports token consumption for a single model call
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`  // Input tokens served from the prompt cache (included in InputTokens)
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"` // Input tokens written to the prompt cache (included in InputTokens)
 }
 
 //may be a string or a function call and it will be parts
@@ -25,6 +39,11 @@ type Model_Part struct {
 	Text         *string       `json:"text,omitempty"`
 	FunctionCall *FunctionCall `json:"functionCall,omitempty"`
 	Reasoning    *string       `json:"reasoning,omitempty"` // Chain-of-thought reasoning content
+
+	// Signature is an opaque provider token that must be sent back with this part: it closes an
+	// Anthropic thinking block (following its Reasoning) or is a Gemini thought signature.
+	Signature         *string `json:"signature,omitempty"`
+	RedactedReasoning *string `json:"redacted_reasoning,omitempty"` // Encrypted thinking the provider withheld; replayed as-is
 }
 
 type Model_Text_Part struct {
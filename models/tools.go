@@ -13,3 +13,7 @@ type Parameters struct {
 	Properties map[string]interface{} `json:"properties"`
 	Required   []string               `json:"required"`
 }
+
+// ArgsCallable is a tool Callable that receives the model's arguments as-is instead of
+// typed parameters, for tools without a Go signature (e.g. proxies for remote tools)
+type ArgsCallable func(args map[string]interface{}) (string, error)
@@ -0,0 +1,85 @@
+// Package rag indexes documents for retrieval-augmented generation.
+//
+// Documents (Markdown, HTML, plain text and PDF) are extracted to text, split into chunks,
+// embedded with a memory.Embedder and stored in a memory.VectorStore, so they share the
+// SQLite and pgvector backends used for long-term memory. Each collection lives in its own
+// vector namespace. Agents query collections through the Search_Documents tool, which returns
+// numbered, cited chunks.
+package rag
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCollectionNotFound is returned when a collection does not exist for the tenant
+	ErrCollectionNotFound = errors.New("collection not found")
+	// ErrCollectionExists is returned when creating a collection whose name is taken
+	ErrCollectionExists = errors.New("collection already exists")
+	// ErrDocumentNotFound is returned when a document does not exist in the collection
+	ErrDocumentNotFound = errors.New("document not found")
+)
+
+// Collection is a named set of documents searched together
+type Collection struct {
+	ID           string    `gorm:"primaryKey;size:36" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	TenantID     string    `gorm:"uniqueIndex:idx_rag_collections_name;not null;default:''" json:"tenant_id,omitempty"`
+	Name         string    `gorm:"uniqueIndex:idx_rag_collections_name;not null" json:"name"`
+	Description  string    `gorm:"type:text" json:"description,omitempty"`
+	ChunkSize    int       `json:"chunk_size"`    // Target chunk size in characters
+	ChunkOverlap int       `json:"chunk_overlap"` // Characters repeated from the previous chunk
+}
+
+func (Collection) TableName() string {
+	return "rag_collections"
+}
+
+// namespace is the vector store namespace holding the collection's chunks
+func (c *Collection) namespace() string {
+	return "rag:" + c.ID
+}
+
+// Document is an ingested source. Its extracted text is kept so the collection can be
+// re-indexed (e.g. with new chunk settings or a new embedder) without the original file.
+type Document struct {
+	ID           string                 `gorm:"primaryKey;size:36" json:"id"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+	CollectionID string                 `gorm:"uniqueIndex:idx_rag_documents_source;size:36;not null" json:"collection_id"`
+	SourceID     string                 `gorm:"uniqueIndex:idx_rag_documents_source;not null" json:"source_id"` // Caller's identifier, e.g. a path or URL
+	Title        string                 `json:"title"`
+	Format       Format                 `gorm:"size:16" json:"format"`
+	ContentHash  string                 `gorm:"size:64" json:"content_hash"`
+	Content      string                 `gorm:"type:text" json:"-"`
+	MetadataJSON string                 `gorm:"type:text" json:"-"`
+	Metadata     map[string]interface{} `gorm:"-" json:"metadata,omitempty"`
+	ChunkCount   int                    `json:"chunk_count"`
+}
+
+func (Document) TableName() string {
+	return "rag_documents"
+}
+
+func (d *Document) encodeMetadata() error {
+	if len(d.Metadata) == 0 {
+		d.MetadataJSON = ""
+		return nil
+	}
+	data, err := json.Marshal(d.Metadata)
+	if err != nil {
+		return err
+	}
+	d.MetadataJSON = string(data)
+	return nil
+}
+
+func (d *Document) decodeMetadata() {
+	d.Metadata = nil
+	if d.MetadataJSON != "" {
+		_ = json.Unmarshal([]byte(d.MetadataJSON), &d.Metadata)
+	}
+}
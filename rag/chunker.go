@@ -0,0 +1,204 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default chunk settings for new collections
+const (
+	DefaultChunkSize    = 1000
+	DefaultChunkOverlap = 150
+)
+
+// Chunk is a piece of a document that is embedded and retrieved on its own
+type Chunk struct {
+	Index   int    `json:"index"`
+	Content string `json:"content"`
+	Section string `json:"section,omitempty"` // Markdown heading path, e.g. "Setup > Docker"
+}
+
+// Chunker splits a document's text into chunks
+type Chunker interface {
+	Chunk(text string) []Chunk
+}
+
+// TextChunker packs paragraphs into chunks of about Size characters. Chunks never cross a
+// Markdown heading, and consecutive chunks of the same section share Overlap characters.
+type TextChunker struct {
+	Size    int
+	Overlap int
+}
+
+// NewTextChunker creates a chunker; non-positive values fall back to the defaults
+func NewTextChunker(size, overlap int) *TextChunker {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = DefaultChunkOverlap
+		if overlap >= size {
+			overlap = size / 5
+		}
+	}
+	return &TextChunker{Size: size, Overlap: overlap}
+}
+
+var (
+	reHeading    = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	reBlankLines = regexp.MustCompile(`\n\s*\n`)
+	reSentence   = regexp.MustCompile(`[.!?]["')\]]*\s+`)
+)
+
+// Chunk implements Chunker
+func (c *TextChunker) Chunk(text string) []Chunk {
+	var chunks []Chunk
+	var headings []string
+	var current strings.Builder
+	section := ""
+
+	flush := func() {
+		content := strings.TrimSpace(current.String())
+		current.Reset()
+		if content == "" {
+			return
+		}
+		chunks = append(chunks, Chunk{Index: len(chunks), Content: content, Section: section})
+	}
+	// startNext begins a chunk in the same section, carrying the tail of the previous one
+	startNext := func() {
+		previous := current.String()
+		flush()
+		if tail := overlapTail(previous, c.Overlap); tail != "" {
+			current.WriteString(tail)
+			current.WriteString(" ")
+		}
+	}
+
+	for _, block := range reBlankLines.Split(text, -1) {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+
+		// A heading ends the current chunk and is repeated at the top of the next one
+		firstLine, rest, _ := strings.Cut(block, "\n")
+		if m := reHeading.FindStringSubmatch(firstLine); m != nil {
+			flush()
+			level := len(m[1])
+			if len(headings) >= level {
+				headings = headings[:level-1]
+			}
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings, strings.TrimSpace(m[2]))
+			section = joinHeadings(headings)
+			current.WriteString(firstLine)
+			block = strings.TrimSpace(rest)
+			if block == "" {
+				continue
+			}
+			current.WriteString("\n\n")
+		}
+
+		for _, piece := range splitToSize(block, c.Size) {
+			if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(piece) > c.Size {
+				startNext()
+			}
+			if current.Len() > 0 && !strings.HasSuffix(current.String(), " ") && !strings.HasSuffix(current.String(), "\n") {
+				current.WriteString("\n\n")
+			}
+			current.WriteString(piece)
+		}
+	}
+	flush()
+	return chunks
+}
+
+func joinHeadings(headings []string) string {
+	var parts []string
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// splitToSize splits a block longer than size at sentence, then word, boundaries
+func splitToSize(block string, size int) []string {
+	if utf8.RuneCountInString(block) <= size {
+		return []string{block}
+	}
+
+	var units []string
+	last := 0
+	for _, loc := range reSentence.FindAllStringIndex(block, -1) {
+		units = append(units, block[last:loc[1]])
+		last = loc[1]
+	}
+	units = append(units, block[last:])
+
+	var pieces []string
+	var current strings.Builder
+	for _, unit := range units {
+		for _, part := range splitWords(unit, size) {
+			if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(part) > size {
+				pieces = append(pieces, strings.TrimSpace(current.String()))
+				current.Reset()
+			}
+			current.WriteString(part)
+		}
+	}
+	if s := strings.TrimSpace(current.String()); s != "" {
+		pieces = append(pieces, s)
+	}
+	return pieces
+}
+
+// splitWords splits text longer than size at spaces, cutting words that are longer still
+func splitWords(text string, size int) []string {
+	if utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+	var parts []string
+	var current []rune
+	for _, word := range strings.SplitAfter(text, " ") {
+		runes := []rune(word)
+		for len(runes) > size {
+			if len(current) > 0 {
+				parts = append(parts, string(current))
+				current = nil
+			}
+			parts = append(parts, string(runes[:size]))
+			runes = runes[size:]
+		}
+		if len(current)+len(runes) > size {
+			parts = append(parts, string(current))
+			current = nil
+		}
+		current = append(current, runes...)
+	}
+	if len(current) > 0 {
+		parts = append(parts, string(current))
+	}
+	return parts
+}
+
+// overlapTail returns roughly the last n characters of text, starting at a word boundary
+func overlapTail(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= n {
+		return ""
+	}
+	tail := string(runes[len(runes)-n:])
+	if i := strings.IndexAny(tail, " \n"); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return strings.TrimSpace(tail)
+}
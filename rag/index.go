@@ -0,0 +1,490 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Desarso/godantic/memory"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Index manages document collections: the catalog lives in a gorm database and the
+// chunk embeddings in a memory.VectorStore (which may share the same database)
+type Index struct {
+	db        *gorm.DB
+	embedder  memory.Embedder
+	store     memory.VectorStore
+	chunker   Chunker
+	pdf       PDFExtractor
+	tenantID  string
+	batchSize int
+}
+
+// NewIndex creates the collection and document tables on db. Chunks are embedded with
+// embedder and stored in store, e.g. memory.NewSQLiteVectorStore(db) or
+// memory.NewPostgresVectorStore(db, embedder.Dimensions()).
+func NewIndex(db *gorm.DB, embedder memory.Embedder, store memory.VectorStore) (*Index, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := db.AutoMigrate(&Collection{}, &Document{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate document tables: %w", err)
+	}
+	return &Index{
+		db:        db,
+		embedder:  embedder,
+		store:     store,
+		pdf:       ExtractPDFText,
+		batchSize: 64,
+	}, nil
+}
+
+// WithChunker replaces the per-collection TextChunker for every collection
+func (ix *Index) WithChunker(chunker Chunker) *Index {
+	ix.chunker = chunker
+	return ix
+}
+
+// WithPDFExtractor replaces the built-in PDF text extractor
+func (ix *Index) WithPDFExtractor(extractor PDFExtractor) *Index {
+	ix.pdf = extractor
+	return ix
+}
+
+// WithBatchSize sets how many chunks are embedded per Embed call (default 64)
+func (ix *Index) WithBatchSize(size int) *Index {
+	ix.batchSize = size
+	return ix
+}
+
+// ForTenant returns a copy whose collections belong to tenantID
+func (ix *Index) ForTenant(tenantID string) *Index {
+	scoped := *ix
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// CollectionOptions configures a collection's chunking
+type CollectionOptions struct {
+	Description  string
+	ChunkSize    int // Defaults to DefaultChunkSize
+	ChunkOverlap int // Defaults to DefaultChunkOverlap
+}
+
+// CreateCollection creates an empty collection, returning ErrCollectionExists if the name is taken
+func (ix *Index) CreateCollection(ctx context.Context, name string, opts CollectionOptions) (*Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("collection name is required")
+	}
+	if _, err := ix.Collection(ctx, name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionExists, name)
+	} else if !errors.Is(err, ErrCollectionNotFound) {
+		return nil, err
+	}
+
+	chunker := NewTextChunker(opts.ChunkSize, opts.ChunkOverlap)
+	collection := &Collection{
+		ID:           uuid.New().String(),
+		TenantID:     ix.tenantID,
+		Name:         name,
+		Description:  opts.Description,
+		ChunkSize:    chunker.Size,
+		ChunkOverlap: chunker.Overlap,
+	}
+	if err := ix.db.WithContext(ctx).Create(collection).Error; err != nil {
+		return nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+	return collection, nil
+}
+
+// Collection returns the named collection
+func (ix *Index) Collection(ctx context.Context, name string) (*Collection, error) {
+	var collection Collection
+	err := ix.db.WithContext(ctx).Where("tenant_id = ? AND name = ?", ix.tenantID, name).First(&collection).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch collection: %w", err)
+	}
+	return &collection, nil
+}
+
+// Collections lists the tenant's collections by name
+func (ix *Index) Collections(ctx context.Context) ([]Collection, error) {
+	var collections []Collection
+	if err := ix.db.WithContext(ctx).Where("tenant_id = ?", ix.tenantID).Order("name").Find(&collections).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch collections: %w", err)
+	}
+	return collections, nil
+}
+
+// Documents lists the documents of a collection by source ID
+func (ix *Index) Documents(ctx context.Context, collectionName string) ([]Document, error) {
+	collection, err := ix.Collection(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	var documents []Document
+	if err := ix.db.WithContext(ctx).Omit("content").Where("collection_id = ?", collection.ID).Order("source_id").Find(&documents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch documents: %w", err)
+	}
+	for i := range documents {
+		documents[i].decodeMetadata()
+	}
+	return documents, nil
+}
+
+// DeleteCollection removes a collection with its documents and chunks
+func (ix *Index) DeleteCollection(ctx context.Context, name string) error {
+	collection, err := ix.Collection(ctx, name)
+	if err != nil {
+		return err
+	}
+	if _, err := ix.store.DeleteMatching(ctx, memory.SearchOptions{Scope: ix.scope(collection)}); err != nil {
+		return fmt.Errorf("failed to delete collection chunks: %w", err)
+	}
+	return ix.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&Document{}).Error; err != nil {
+			return fmt.Errorf("failed to delete documents: %w", err)
+		}
+		if err := tx.Delete(collection).Error; err != nil {
+			return fmt.Errorf("failed to delete collection: %w", err)
+		}
+		return nil
+	})
+}
+
+// DocumentInput is a document to ingest
+type DocumentInput struct {
+	SourceID string // Stable identifier (path, URL, ...); re-ingesting the same ID replaces the document
+	Title    string // Defaults to the document's own title, then the base name of SourceID
+	Format   Format // Detected from SourceID and Data when empty
+	Data     []byte
+	Metadata map[string]interface{} // Copied onto every chunk and returned with search results
+}
+
+// Ingest extracts, chunks, embeds and stores a document. Re-ingesting an unchanged
+// document is a no-op; a changed one replaces its previous chunks.
+func (ix *Index) Ingest(ctx context.Context, collectionName string, input DocumentInput) (*Document, error) {
+	if input.SourceID == "" {
+		return nil, fmt.Errorf("document source ID is required")
+	}
+	collection, err := ix.Collection(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	format := input.Format
+	if format == "" {
+		format = DetectFormat(input.SourceID, input.Data)
+	}
+	text, title, err := extractText(format, input.Data, ix.pdf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", input.SourceID, err)
+	}
+	if input.Title != "" {
+		title = input.Title
+	}
+	if title == "" {
+		title = filepath.Base(input.SourceID)
+	}
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+
+	var document Document
+	err = ix.db.WithContext(ctx).Where("collection_id = ? AND source_id = ?", collection.ID, input.SourceID).First(&document).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		document = Document{ID: uuid.New().String(), CollectionID: collection.ID, SourceID: input.SourceID}
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch document: %w", err)
+	case document.ContentHash == hash && document.Title == title:
+		document.decodeMetadata()
+		return &document, nil
+	}
+
+	document.Title = title
+	document.Format = format
+	document.Content = text
+	document.ContentHash = hash
+	document.Metadata = input.Metadata
+	if err := document.encodeMetadata(); err != nil {
+		return nil, fmt.Errorf("failed to marshal document metadata: %w", err)
+	}
+	if err := ix.indexDocument(ctx, collection, &document); err != nil {
+		// Drop partially stored chunks and clear the hash so the next ingest retries
+		_, _ = ix.store.DeleteMatching(ctx, memory.SearchOptions{
+			Scope:  ix.scope(collection),
+			Filter: map[string]interface{}{"document_id": document.ID},
+		})
+		if !document.CreatedAt.IsZero() {
+			ix.db.WithContext(ctx).Model(&document).Updates(map[string]interface{}{"content_hash": "", "chunk_count": 0})
+		}
+		return nil, err
+	}
+	if err := ix.db.WithContext(ctx).Save(&document).Error; err != nil {
+		return nil, fmt.Errorf("failed to save document: %w", err)
+	}
+	return &document, nil
+}
+
+// IngestFile reads and ingests a file, using its path as the source ID
+func (ix *Index) IngestFile(ctx context.Context, collectionName, path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ix.Ingest(ctx, collectionName, DocumentInput{SourceID: path, Data: data})
+}
+
+// DeleteDocument removes a document and its chunks from a collection
+func (ix *Index) DeleteDocument(ctx context.Context, collectionName, sourceID string) error {
+	collection, err := ix.Collection(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	var document Document
+	err = ix.db.WithContext(ctx).Omit("content").Where("collection_id = ? AND source_id = ?", collection.ID, sourceID).First(&document).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s", ErrDocumentNotFound, sourceID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch document: %w", err)
+	}
+	if _, err := ix.store.DeleteMatching(ctx, memory.SearchOptions{
+		Scope:  ix.scope(collection),
+		Filter: map[string]interface{}{"document_id": document.ID},
+	}); err != nil {
+		return fmt.Errorf("failed to delete document chunks: %w", err)
+	}
+	if err := ix.db.WithContext(ctx).Delete(&document).Error; err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// ReindexCollection re-chunks and re-embeds every document of a collection from its stored
+// text, e.g. after switching embedders. Non-nil opts also change the collection's chunk settings.
+func (ix *Index) ReindexCollection(ctx context.Context, name string, opts *CollectionOptions) (*Collection, error) {
+	collection, err := ix.Collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		chunker := NewTextChunker(opts.ChunkSize, opts.ChunkOverlap)
+		collection.ChunkSize, collection.ChunkOverlap = chunker.Size, chunker.Overlap
+		if opts.Description != "" {
+			collection.Description = opts.Description
+		}
+		if err := ix.db.WithContext(ctx).Save(collection).Error; err != nil {
+			return nil, fmt.Errorf("failed to update collection: %w", err)
+		}
+	}
+
+	if _, err := ix.store.DeleteMatching(ctx, memory.SearchOptions{Scope: ix.scope(collection)}); err != nil {
+		return nil, fmt.Errorf("failed to clear collection chunks: %w", err)
+	}
+	var documents []Document
+	if err := ix.db.WithContext(ctx).Where("collection_id = ?", collection.ID).Find(&documents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch documents: %w", err)
+	}
+	for i := range documents {
+		document := &documents[i]
+		document.decodeMetadata()
+		if err := ix.indexDocument(ctx, collection, document); err != nil {
+			return nil, err
+		}
+		if err := ix.db.WithContext(ctx).Model(document).Update("chunk_count", document.ChunkCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to update document: %w", err)
+		}
+	}
+	return collection, nil
+}
+
+// indexDocument replaces the document's chunks in the vector store
+func (ix *Index) indexDocument(ctx context.Context, collection *Collection, document *Document) error {
+	scope := ix.scope(collection)
+	if _, err := ix.store.DeleteMatching(ctx, memory.SearchOptions{
+		Scope:  scope,
+		Filter: map[string]interface{}{"document_id": document.ID},
+	}); err != nil {
+		return fmt.Errorf("failed to delete previous chunks: %w", err)
+	}
+
+	chunker := ix.chunker
+	if chunker == nil {
+		chunker = NewTextChunker(collection.ChunkSize, collection.ChunkOverlap)
+	}
+	chunks := chunker.Chunk(document.Content)
+
+	batchSize := ix.batchSize
+	if batchSize <= 0 {
+		batchSize = len(chunks)
+	}
+	for start := 0; start < len(chunks); start += batchSize {
+		batch := chunks[start:min(start+batchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			// Embedding the title and section lets short chunks match on their context
+			texts[i] = strings.TrimSpace(document.Title + "\n" + chunk.Section + "\n\n" + chunk.Content)
+		}
+		vectors, err := ix.embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed %s: %w", document.SourceID, err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(batch))
+		}
+
+		for i, chunk := range batch {
+			metadata := map[string]interface{}{
+				"document_id": document.ID,
+				"source_id":   document.SourceID,
+				"title":       document.Title,
+				"chunk_index": chunk.Index,
+			}
+			if chunk.Section != "" {
+				metadata["section"] = chunk.Section
+			}
+			if len(document.Metadata) > 0 {
+				metadata["document_metadata"] = document.Metadata
+			}
+			record := &memory.Record{
+				Namespace: scope.Namespace,
+				TenantID:  scope.TenantID,
+				Content:   chunk.Content,
+				Metadata:  metadata,
+				Embedding: vectors[i],
+			}
+			if err := ix.store.Add(ctx, record); err != nil {
+				return fmt.Errorf("failed to store chunk %d of %s: %w", chunk.Index, document.SourceID, err)
+			}
+		}
+	}
+	document.ChunkCount = len(chunks)
+	return nil
+}
+
+func (ix *Index) scope(collection *Collection) memory.Scope {
+	return memory.Scope{Namespace: collection.namespace(), TenantID: collection.TenantID}
+}
+
+// SearchOptions narrows a document search
+type SearchOptions struct {
+	Collections []string // Collection names; all of the tenant's collections when empty
+	Limit       int      // Maximum results (default 5)
+	MinScore    float64  // Minimum cosine similarity
+}
+
+// Result is a retrieved chunk with its source
+type Result struct {
+	Collection string                 `json:"collection"`
+	DocumentID string                 `json:"document_id"`
+	SourceID   string                 `json:"source_id"`
+	Title      string                 `json:"title"`
+	Section    string                 `json:"section,omitempty"`
+	ChunkID    string                 `json:"chunk_id"`
+	ChunkIndex int                    `json:"chunk_index"`
+	Content    string                 `json:"content"`
+	Score      float64                `json:"score"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"` // The document's ingestion metadata
+}
+
+// Citation formats the result's source for display, e.g. "Deploy Guide > Rollbacks (docs/deploy.md)"
+func (r Result) Citation() string {
+	label := r.Title
+	section := strings.TrimPrefix(r.Section, r.Title+" > ")
+	if section != "" && section != r.Title {
+		label += " > " + section
+	}
+	return fmt.Sprintf("%s (%s)", label, r.SourceID)
+}
+
+// Search returns the chunks most similar to query across collections, best first
+func (ix *Index) Search(ctx context.Context, query string, opts SearchOptions) ([]Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+
+	var collections []Collection
+	if len(opts.Collections) == 0 {
+		all, err := ix.Collections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		collections = all
+	} else {
+		for _, name := range opts.Collections {
+			collection, err := ix.Collection(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			collections = append(collections, *collection)
+		}
+	}
+	if len(collections) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := ix.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 input", len(vectors))
+	}
+
+	var results []Result
+	for i := range collections {
+		collection := &collections[i]
+		hits, err := ix.store.Search(ctx, vectors[0], memory.SearchOptions{
+			Scope:    ix.scope(collection),
+			Limit:    limit,
+			MinScore: opts.MinScore,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search collection %s: %w", collection.Name, err)
+		}
+		for _, hit := range hits {
+			results = append(results, resultFromHit(collection.Name, hit))
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func resultFromHit(collection string, hit memory.ScoredRecord) Result {
+	result := Result{
+		Collection: collection,
+		ChunkID:    hit.ID,
+		Content:    hit.Content,
+		Score:      hit.Score,
+	}
+	result.DocumentID, _ = hit.Metadata["document_id"].(string)
+	result.SourceID, _ = hit.Metadata["source_id"].(string)
+	result.Title, _ = hit.Metadata["title"].(string)
+	result.Section, _ = hit.Metadata["section"].(string)
+	if index, ok := hit.Metadata["chunk_index"].(float64); ok {
+		result.ChunkIndex = int(index)
+	}
+	result.Metadata, _ = hit.Metadata["document_metadata"].(map[string]interface{})
+	return result
+}
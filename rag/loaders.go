@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Desarso/godantic/common_tools"
+)
+
+// Format is the source format of a document
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatText     Format = "text"
+	FormatPDF      Format = "pdf"
+)
+
+// PDFExtractor turns a PDF into text. The built-in extractor handles text-based PDFs with
+// standard font encodings; plug in another (e.g. one wrapping pdftotext) for anything else.
+type PDFExtractor func(data []byte) (string, error)
+
+// DetectFormat picks a format from the file extension of name, falling back to sniffing data
+func DetectFormat(name string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mdx":
+		return FormatMarkdown
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML
+	case ".pdf":
+		return FormatPDF
+	case ".txt", ".text", ".log", ".csv":
+		return FormatText
+	}
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return FormatPDF
+	}
+	if strings.HasPrefix(http.DetectContentType(data), "text/html") {
+		return FormatHTML
+	}
+	return FormatText
+}
+
+var (
+	reHTMLTitle     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	reHTMLHead      = regexp.MustCompile(`(?is)<head[^>]*>.*?</head>`)
+	reMarkdownTitle = regexp.MustCompile(`(?m)^#\s+(.+)$`)
+)
+
+// extractText converts a document to text (Markdown for HTML) and finds its title, if any
+func extractText(format Format, data []byte, pdf PDFExtractor) (text string, title string, err error) {
+	switch format {
+	case FormatMarkdown, FormatText:
+		if !utf8.Valid(data) {
+			return "", "", fmt.Errorf("document is not valid UTF-8 text")
+		}
+		text = string(data)
+	case FormatHTML:
+		html := string(data)
+		if m := reHTMLTitle.FindStringSubmatch(html); m != nil {
+			title = strings.TrimSpace(common_tools.HTMLToMarkdown(m[1]))
+		}
+		text = common_tools.HTMLToMarkdown(reHTMLHead.ReplaceAllString(html, ""))
+	case FormatPDF:
+		if pdf == nil {
+			pdf = ExtractPDFText
+		}
+		if text, err = pdf(data); err != nil {
+			return "", "", fmt.Errorf("failed to extract PDF text: %w", err)
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported document format %q", format)
+	}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if title == "" && (format == FormatMarkdown || format == FormatHTML) {
+		if m := reMarkdownTitle.FindStringSubmatch(text); m != nil {
+			title = strings.TrimSpace(m[1])
+		}
+	}
+	return text, title, nil
+}
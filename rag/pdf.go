@@ -0,0 +1,376 @@
+package rag
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	reStreamStart = regexp.MustCompile(`stream\r?\n`)
+	reStreamLen   = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+)
+
+// ExtractPDFText is the built-in PDFExtractor. It decodes uncompressed and FlateDecode
+// content streams and collects the strings shown by text operators (Tj, TJ, ' and ").
+// Scanned PDFs and fonts with custom CID encodings yield no text and return an error.
+func ExtractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	var pages []string
+	for _, stream := range pdfStreams(data) {
+		if text := strings.TrimSpace(contentStreamText(stream)); text != "" {
+			pages = append(pages, text)
+		}
+	}
+	if len(pages) == 0 {
+		return "", fmt.Errorf("PDF has no extractable text (it may be scanned or use unsupported font encodings)")
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// pdfStreams returns the decoded bodies of the streams that may hold page content
+func pdfStreams(data []byte) [][]byte {
+	var streams [][]byte
+	offset := 0
+	for {
+		loc := reStreamStart.FindIndex(data[offset:])
+		if loc == nil {
+			return streams
+		}
+		start := offset + loc[1]
+		dictStart := bytes.LastIndex(data[:offset+loc[0]], []byte("obj"))
+		if dictStart == -1 {
+			dictStart = offset
+		}
+		dict := data[dictStart : offset+loc[0]]
+
+		end := -1
+		if m := reStreamLen.FindSubmatch(dict); m != nil && len(m[2]) == 0 {
+			if n, err := strconv.Atoi(string(m[1])); err == nil && start+n <= len(data) {
+				end = start + n
+			}
+		}
+		if end == -1 {
+			idx := bytes.Index(data[start:], []byte("endstream"))
+			if idx == -1 {
+				return streams
+			}
+			end = start + idx
+		}
+		body := bytes.TrimRight(data[start:end], "\r\n")
+		offset = end
+
+		// Images, fonts and other binary streams never contain text operators
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/FontFile")) {
+			continue
+		}
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			decoded, err := io.ReadAll(zlibReader(body))
+			if len(decoded) == 0 && err != nil {
+				continue
+			}
+			streams = append(streams, decoded)
+		case !bytes.Contains(dict, []byte("/Filter")):
+			streams = append(streams, body)
+		}
+	}
+}
+
+func zlibReader(body []byte) io.Reader {
+	r, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return bytes.NewReader(nil)
+	}
+	return r
+}
+
+// contentStreamText interprets the text operators of a page content stream
+func contentStreamText(stream []byte) string {
+	var out strings.Builder
+	var operands []interface{} // string, float64 or []interface{}
+	var array []interface{}
+	inArray := false
+	inText := false
+
+	newline := func() {
+		if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	push := func(v interface{}) {
+		if inArray {
+			array = append(array, v)
+		} else {
+			operands = append(operands, v)
+		}
+	}
+	lastString := func() (string, bool) {
+		for i := len(operands) - 1; i >= 0; i-- {
+			if s, ok := operands[i].(string); ok {
+				return s, true
+			}
+		}
+		return "", false
+	}
+
+	lex := &pdfLexer{data: stream}
+	for {
+		tok, kind := lex.next()
+		if kind == tokEOF {
+			break
+		}
+		switch kind {
+		case tokString:
+			push(tok)
+		case tokNumber:
+			n, _ := strconv.ParseFloat(tok, 64)
+			push(n)
+		case tokArrayStart:
+			inArray, array = true, nil
+		case tokArrayEnd:
+			inArray = false
+			operands = append(operands, array)
+		case tokOperator:
+			switch tok {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				newline()
+			case "Tj":
+				if s, ok := lastString(); ok && inText {
+					out.WriteString(s)
+				}
+			case "'", "\"":
+				newline()
+				if s, ok := lastString(); ok && inText {
+					out.WriteString(s)
+				}
+			case "TJ":
+				if len(operands) > 0 && inText {
+					if items, ok := operands[len(operands)-1].([]interface{}); ok {
+						for _, item := range items {
+							switch v := item.(type) {
+							case string:
+								out.WriteString(v)
+							case float64:
+								// Large negative kerning is how most generators encode word spaces
+								if v < -200 && !strings.HasSuffix(out.String(), " ") {
+									out.WriteByte(' ')
+								}
+							}
+						}
+					}
+				}
+			case "T*":
+				newline()
+			case "Td", "TD":
+				if len(operands) >= 2 {
+					if ty, ok := operands[len(operands)-1].(float64); ok && ty != 0 {
+						newline()
+					} else if s := out.String(); len(s) > 0 && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+						out.WriteByte(' ')
+					}
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+	return out.String()
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokString
+	tokNumber
+	tokName
+	tokOperator
+	tokArrayStart
+	tokArrayEnd
+	tokOther
+)
+
+// pdfLexer tokenizes PDF content streams
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) next() (string, tokenKind) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			l.pos++
+			return decodePDFString(l.literalString()), tokString
+		case c == '<':
+			if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+				l.pos += 2
+				return "<<", tokOther
+			}
+			l.pos++
+			return decodePDFString(l.hexString()), tokString
+		case c == '>':
+			l.pos++
+			if l.pos < len(l.data) && l.data[l.pos] == '>' {
+				l.pos++
+			}
+			return ">>", tokOther
+		case c == '[':
+			l.pos++
+			return "[", tokArrayStart
+		case c == ']':
+			l.pos++
+			return "]", tokArrayEnd
+		case c == '{' || c == '}':
+			l.pos++
+			return string(c), tokOther
+		case c == '/':
+			l.pos++
+			return "/" + l.word(), tokName
+		default:
+			w := l.word()
+			if w == "" {
+				l.pos++
+				continue
+			}
+			if _, err := strconv.ParseFloat(w, 64); err == nil {
+				return w, tokNumber
+			}
+			return w, tokOperator
+		}
+	}
+	return "", tokEOF
+}
+
+func (l *pdfLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literalString reads a (...) string after the opening parenthesis, resolving escapes
+func (l *pdfLexer) literalString() []byte {
+	var buf []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return buf
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'b':
+				buf = append(buf, '\b')
+			case 'f':
+				buf = append(buf, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					buf = append(buf, byte(n))
+				} else {
+					buf = append(buf, e)
+				}
+			}
+			continue
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// hexString reads a <...> string after the opening bracket
+func (l *pdfLexer) hexString() []byte {
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	buf := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		n, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return buf
+		}
+		buf = append(buf, byte(n))
+	}
+	return buf
+}
+
+// decodePDFString decodes UTF-16BE strings (with BOM) and treats everything else as Latin-1
+func decodePDFString(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, 0, len(raw))
+	for _, b := range raw {
+		if b < 0x20 && b != '\n' && b != '\t' {
+			continue
+		}
+		runes = append(runes, rune(b))
+	}
+	return string(runes)
+}
@@ -0,0 +1,238 @@
+package rag
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic/memory"
+	"github.com/Desarso/godantic/stores"
+)
+
+func newTestIndex(t *testing.T) (*Index, *memory.SQLiteVectorStore) {
+	t.Helper()
+	base, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { base.Close() })
+
+	vectors, err := memory.NewSQLiteVectorStore(base.DB())
+	if err != nil {
+		t.Fatalf("NewSQLiteVectorStore: %v", err)
+	}
+	index, err := NewIndex(base.DB(), memory.NewHashingEmbedder(256), vectors)
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	return index, vectors
+}
+
+const deployGuide = `# Deploy Guide
+
+Deploys run through the release pipeline every weekday.
+
+## Rollbacks
+
+To roll back a release, run the rollback job with the previous version tag.
+Rollbacks take about five minutes.
+
+## Secrets
+
+Secrets are stored in the vault and rotated every ninety days.`
+
+func TestTextChunker_RespectsHeadingsAndSize(t *testing.T) {
+	chunks := NewTextChunker(120, 20).Chunk(deployGuide + "\n\n" + strings.Repeat("Filler sentence about vault policies. ", 10))
+
+	if len(chunks) < 4 {
+		t.Fatalf("Expected the long section to be split, got %d chunks", len(chunks))
+	}
+	if chunks[0].Section != "Deploy Guide" || !strings.HasPrefix(chunks[0].Content, "# Deploy Guide") {
+		t.Errorf("Unexpected first chunk: %+v", chunks[0])
+	}
+	if chunks[1].Section != "Deploy Guide > Rollbacks" {
+		t.Errorf("Expected the rollback section path, got %q", chunks[1].Section)
+	}
+	for i, chunk := range chunks {
+		if chunk.Index != i {
+			t.Errorf("Chunk %d has index %d", i, chunk.Index)
+		}
+		if strings.Contains(chunk.Content, "Rollbacks take") && strings.Contains(chunk.Content, "vault") {
+			t.Errorf("Chunk %d crosses a heading: %q", i, chunk.Content)
+		}
+		if n := len([]rune(chunk.Content)); n > 120+20+len("## Secrets")+2 {
+			t.Errorf("Chunk %d is too long (%d chars)", i, n)
+		}
+	}
+}
+
+func TestIndex_IngestAndSearchWithCitations(t *testing.T) {
+	index, _ := newTestIndex(t)
+	ctx := context.Background()
+
+	if _, err := index.CreateCollection(ctx, "handbook", CollectionOptions{ChunkSize: 200}); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	if _, err := index.CreateCollection(ctx, "handbook", CollectionOptions{}); !errors.Is(err, ErrCollectionExists) {
+		t.Errorf("Expected ErrCollectionExists, got %v", err)
+	}
+
+	doc, err := index.Ingest(ctx, "handbook", DocumentInput{SourceID: "docs/deploy.md", Data: []byte(deployGuide)})
+	if err != nil {
+		t.Fatalf("Ingest markdown: %v", err)
+	}
+	if doc.Title != "Deploy Guide" || doc.Format != FormatMarkdown || doc.ChunkCount == 0 {
+		t.Errorf("Unexpected document: %+v", doc)
+	}
+
+	html := `<html><head><title>Expense Policy</title></head><body><h1>Expenses</h1><p>Meals are reimbursed up to <b>50 euros</b> per day.</p></body></html>`
+	if _, err := index.Ingest(ctx, "handbook", DocumentInput{SourceID: "https://intranet/expenses", Data: []byte(html), Format: FormatHTML}); err != nil {
+		t.Fatalf("Ingest html: %v", err)
+	}
+
+	results, err := index.Search(ctx, "how do I roll back a release", SearchOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 || results[0].SourceID != "docs/deploy.md" || results[0].Section != "Deploy Guide > Rollbacks" {
+		t.Fatalf("Expected the rollback section first, got %+v", results)
+	}
+	if got := results[0].Citation(); got != "Deploy Guide > Rollbacks (docs/deploy.md)" {
+		t.Errorf("Unexpected citation %q", got)
+	}
+
+	out, err := SearchDocumentsTool(index, SearchOptions{Collections: []string{"handbook"}}).Callable.(func(string) (string, error))("meal reimbursement per day")
+	if err != nil {
+		t.Fatalf("Search_Documents: %v", err)
+	}
+	var parsed struct {
+		Results []citedResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("Invalid tool output %q: %v", out, err)
+	}
+	if len(parsed.Results) == 0 || parsed.Results[0].Citation != 1 || parsed.Results[0].SourceID != "https://intranet/expenses" || parsed.Results[0].Title != "Expense Policy" {
+		t.Errorf("Expected the expense policy as citation 1, got %s", out)
+	}
+}
+
+func TestIndex_ReingestReindexAndDelete(t *testing.T) {
+	index, vectors := newTestIndex(t)
+	ctx := context.Background()
+	collection, err := index.CreateCollection(ctx, "handbook", CollectionOptions{ChunkSize: 100, ChunkOverlap: 10})
+	if err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	scope := memory.SearchOptions{Scope: memory.Scope{Namespace: collection.namespace()}}
+	countChunks := func() int {
+		records, err := vectors.List(ctx, scope)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		return len(records)
+	}
+
+	first, err := index.Ingest(ctx, "handbook", DocumentInput{SourceID: "deploy.md", Data: []byte(deployGuide)})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	initial := countChunks()
+	if initial != first.ChunkCount {
+		t.Fatalf("Expected %d stored chunks, got %d", first.ChunkCount, initial)
+	}
+
+	// Unchanged content is not re-embedded; changed content replaces the old chunks
+	if _, err := index.Ingest(ctx, "handbook", DocumentInput{SourceID: "deploy.md", Data: []byte(deployGuide)}); err != nil {
+		t.Fatalf("Re-ingest: %v", err)
+	}
+	if countChunks() != initial {
+		t.Errorf("Expected re-ingesting unchanged content to keep %d chunks, got %d", initial, countChunks())
+	}
+	updated, err := index.Ingest(ctx, "handbook", DocumentInput{SourceID: "deploy.md", Data: []byte("# Deploy Guide\n\nDeploys are frozen.")})
+	if err != nil {
+		t.Fatalf("Ingest update: %v", err)
+	}
+	if updated.ID != first.ID || countChunks() != 1 {
+		t.Errorf("Expected the document to be replaced in place with 1 chunk, got %d chunks", countChunks())
+	}
+
+	if _, err := index.Ingest(ctx, "handbook", DocumentInput{SourceID: "deploy.md", Data: []byte(deployGuide)}); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if _, err := index.ReindexCollection(ctx, "handbook", &CollectionOptions{ChunkSize: 1000}); err != nil {
+		t.Fatalf("ReindexCollection: %v", err)
+	}
+	if countChunks() != 3 {
+		t.Errorf("Expected one chunk per section after re-indexing with larger chunks, got %d", countChunks())
+	}
+
+	// Other tenants and memories never see the collection
+	if results, err := index.ForTenant("other").Search(ctx, "rollback", SearchOptions{}); err != nil || len(results) != 0 {
+		t.Errorf("Expected no results for another tenant, got %v (err %v)", results, err)
+	}
+	if records, _ := vectors.List(ctx, memory.SearchOptions{}); len(records) != 0 {
+		t.Errorf("Expected document chunks to stay out of the memory namespace, got %d", len(records))
+	}
+
+	if err := index.DeleteDocument(ctx, "handbook", "deploy.md"); err != nil {
+		t.Fatalf("DeleteDocument: %v", err)
+	}
+	if countChunks() != 0 {
+		t.Errorf("Expected the document's chunks to be deleted")
+	}
+	if err := index.DeleteCollection(ctx, "handbook"); err != nil {
+		t.Fatalf("DeleteCollection: %v", err)
+	}
+	if _, err := index.Collection(ctx, "handbook"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("Expected ErrCollectionNotFound, got %v", err)
+	}
+}
+
+// buildPDF writes a minimal PDF with one page content stream
+func buildPDF(content string, compress bool) []byte {
+	var stream bytes.Buffer
+	dict := fmt.Sprintf("<< /Length %d >>", len(content))
+	if compress {
+		w := zlib.NewWriter(&stream)
+		w.Write([]byte(content))
+		w.Close()
+		dict = fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", stream.Len())
+	} else {
+		stream.WriteString(content)
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("4 0 obj\n" + dict + "\nstream\n")
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Quarterly Report) Tj 0 -14 Td [(Revenue grew) -250 (\\(strongly\\))] TJ T* <48656C6C6F> Tj ET"
+	for _, compress := range []bool{false, true} {
+		text, err := ExtractPDFText(buildPDF(content, compress))
+		if err != nil {
+			t.Fatalf("ExtractPDFText(compress=%v): %v", compress, err)
+		}
+		want := "Quarterly Report\nRevenue grew (strongly)\nHello"
+		if text != want {
+			t.Errorf("ExtractPDFText(compress=%v) = %q, want %q", compress, text, want)
+		}
+	}
+
+	if _, err := ExtractPDFText(buildPDF("q 1 0 0 1 0 0 cm Q", true)); err == nil {
+		t.Error("Expected an error for a PDF without text")
+	}
+	if DetectFormat("upload", buildPDF("BT ET", false)) != FormatPDF {
+		t.Error("Expected PDF data to be detected")
+	}
+}
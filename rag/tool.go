@@ -0,0 +1,91 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// SearchDocumentsToolName is the name of the document search tool, as seen by the model
+const SearchDocumentsToolName = "Search_Documents"
+
+// citedResult is a search result as returned to the model
+type citedResult struct {
+	Citation int     `json:"citation"`
+	SourceID string  `json:"source_id"`
+	Title    string  `json:"title"`
+	Section  string  `json:"section,omitempty"`
+	ChunkID  string  `json:"chunk_id"`
+	Score    float64 `json:"score"`
+	Content  string  `json:"content"`
+}
+
+// SearchDocumentsTool returns a Search_Documents declaration that searches index with opts
+// (collections, result limit, minimum score). Results are numbered so the model can cite
+// them as [1], [2], ... together with their source IDs.
+func SearchDocumentsTool(index *Index, opts SearchOptions) models.FunctionDeclaration {
+	description := "Search internal documents and return the most relevant passages with their sources. " +
+		"Use it to answer questions about company documentation, and cite the passages you use as [n] with their source_id."
+	if len(opts.Collections) > 0 {
+		description += " Collections: " + strings.Join(opts.Collections, ", ") + "."
+	}
+
+	search := func(query string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return SearchDocuments(ctx, index, query, opts)
+	}
+
+	return models.FunctionDeclaration{
+		Name:        SearchDocumentsToolName,
+		Description: description,
+		Parameters: models.Parameters{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "What to look for, phrased as a question or keywords",
+				},
+			},
+			Required: []string{"query"},
+		},
+		Callable: search,
+	}
+}
+
+// SearchDocuments runs a search and formats the results as the Search_Documents tool output
+func SearchDocuments(ctx context.Context, index *Index, query string, opts SearchOptions) (string, error) {
+	results, err := index.Search(ctx, query, opts)
+	if err != nil {
+		return "", err
+	}
+
+	cited := make([]citedResult, len(results))
+	for i, result := range results {
+		cited[i] = citedResult{
+			Citation: i + 1,
+			SourceID: result.SourceID,
+			Title:    result.Title,
+			Section:  result.Section,
+			ChunkID:  result.ChunkID,
+			Score:    result.Score,
+			Content:  result.Content,
+		}
+	}
+	output := map[string]interface{}{
+		"query":   query,
+		"results": cited,
+	}
+	if len(cited) == 0 {
+		output["note"] = "No matching documents. Say so rather than guessing."
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal search results: %w", err)
+	}
+	return string(data), nil
+}
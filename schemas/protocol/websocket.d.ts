@@ -0,0 +1,315 @@
+// Code generated by cmd/gen-protocol. DO NOT EDIT.
+
+export const PROTOCOL_VERSION = 2;
+export const SUPPORTED_PROTOCOL_VERSIONS = [1, 2] as const;
+
+/** Wraps every server event from protocol version 2 on. */
+export interface Envelope<T extends string, D> {
+  v: number;
+  type: T;
+  /** Absent on acknowledgements that are not replayed (e.g. "resumed"). */
+  event_id?: number;
+  data: D;
+}
+
+export interface Content {
+  parts: User_Part[];
+}
+
+export interface FileData {
+  mimeType: string;
+  fileUrl: string;
+  googleUri?: string;
+}
+
+export interface FrontendActionMessage {
+  type: string;
+  action: string;
+  data: Record<string, unknown>;
+  timestamp: number;
+}
+
+export interface FunctionCall {
+  id?: string;
+  name: string;
+  args: Record<string, unknown>;
+}
+
+export interface FunctionResponse {
+  id: string;
+  name: string;
+  response: Record<string, unknown>;
+}
+
+export interface HistoryWarning {
+  type: string;
+  message: string;
+  details: string;
+}
+
+export interface ImageData {
+  mimeType: string;
+  fileUrl?: string;
+  googleUri?: string;
+}
+
+export interface InlineData {
+  mimeType: string;
+  data: string;
+}
+
+export interface MemoryEntry {
+  id: string;
+  content: string;
+  metadata?: Record<string, unknown>;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface Model_Part {
+  text?: string;
+  functionCall?: FunctionCall;
+  reasoning?: string;
+  signature?: string;
+  redacted_reasoning?: string;
+}
+
+export interface Model_Response {
+  parts: Model_Part[];
+  warnings?: HistoryWarning[];
+  finish_reason?: string;
+  usage?: Usage;
+}
+
+export interface Tool_Result {
+  tool_id: string;
+  tool_name: string;
+  tool_output: string;
+}
+
+export interface Usage {
+  input_tokens: number;
+  output_tokens: number;
+  total_tokens: number;
+  cache_read_tokens?: number;
+  cache_write_tokens?: number;
+}
+
+export interface User_Message {
+  role: string;
+  content: Content;
+}
+
+export interface User_Part {
+  text?: string;
+  inline_data?: InlineData;
+  image_data?: ImageData;
+  file_data?: FileData;
+  function_response?: FunctionResponse;
+}
+
+export interface WebSocketChatRequest {
+  message?: User_Message;
+  tool_results?: Tool_Result[];
+  client_id?: string;
+  input_mode?: string;
+  language_code?: string;
+  delivery?: string;
+  input_id?: string;
+}
+
+export interface WebSocketDoneMessage {
+  type: string;
+}
+
+export interface WebSocketErrorMessage {
+  error: string;
+}
+
+export interface WebSocketFeedbackMessage {
+  type: string;
+  sequence: number;
+  rating: number;
+  comment?: string;
+  tags?: string[];
+}
+
+export interface WebSocketFeedbackSavedMessage {
+  type: string;
+  sequence: number;
+  rating: number;
+}
+
+export interface WebSocketHelloMessage {
+  type: string;
+  versions: number[];
+}
+
+export interface WebSocketHistoryWarningsMessage {
+  type: string;
+  warnings: HistoryWarning[];
+}
+
+export interface WebSocketInputStatusMessage {
+  type: string;
+  input_id: string;
+  delivery: string;
+  status: string;
+  position?: number;
+}
+
+export interface WebSocketMemoriesMessage {
+  type: string;
+  memories: MemoryEntry[];
+}
+
+export interface WebSocketMemoryForgottenMessage {
+  type: string;
+  id: string;
+}
+
+export interface WebSocketMemoryMessage {
+  type: string;
+  id?: string;
+  limit?: number;
+}
+
+export interface WebSocketResumeMessage {
+  type: string;
+  session_id: string;
+  last_event_id: number;
+}
+
+export interface WebSocketResumedMessage {
+  type: string;
+  session_id: string;
+  last_event_id: number;
+  replayed: number;
+  active: boolean;
+  gap?: boolean;
+}
+
+export interface WebSocketTTSAudioMessage {
+  type: string;
+  context_id: string;
+  audio: string;
+  format: string;
+}
+
+export interface WebSocketTTSContextMessage {
+  type: string;
+  context_id: string;
+  format?: string;
+}
+
+export interface WebSocketTTSStatusMessage {
+  type: string;
+  error?: string;
+}
+
+export interface WebSocketToolResultMessage {
+  type: string;
+  function_name: string;
+  function_id: string;
+  result: Record<string, unknown>;
+  result_json: string;
+}
+
+export interface WebSocketTraceMessage {
+  type: string;
+  trace_id: string;
+  parent_id?: string;
+  tool_call_id: string;
+  tool: string;
+  operation: string;
+  status: string;
+  label: string;
+  details?: Record<string, unknown>;
+  timestamp: number;
+  duration_ms?: number;
+}
+
+export interface WebSocketWelcomeMessage {
+  type: string;
+  version: number;
+  supported: number[];
+  session_id: string;
+}
+
+/** Events the server sends (protocol version 2). */
+export type ServerEvent =
+  /** A streamed chunk of the model's reply (text, reasoning or function calls) */
+  | Envelope<"model_chunk", Model_Response>
+  /** The turn finished */
+  | Envelope<"done", WebSocketDoneMessage>
+  /** An error; the session stays usable */
+  | Envelope<"error", WebSocketErrorMessage>
+  /** Output of an executed tool */
+  | Envelope<"tool_result", WebSocketToolResultMessage>
+  /** Tool progress for the UI timeline */
+  | Envelope<"execution_trace", WebSocketTraceMessage>
+  /** An action the frontend must perform and answer */
+  | Envelope<"frontend_action", FrontendActionMessage>
+  /** History content the model could not use */
+  | Envelope<"history_warnings", WebSocketHistoryWarningsMessage>
+  /** Audio chunks for this context follow */
+  | Envelope<"tts_context_started", WebSocketTTSContextMessage>
+  /** Voice mode was requested but TTS is not configured */
+  | Envelope<"tts_unconfigured", WebSocketTTSStatusMessage>
+  /** TTS failed; the reply continues as text */
+  | Envelope<"tts_error", WebSocketTTSStatusMessage>
+  /** A base64 audio chunk */
+  | Envelope<"tts_audio_chunk", WebSocketTTSAudioMessage>
+  /** All audio for the context was sent */
+  | Envelope<"tts_context_final", WebSocketTTSContextMessage>
+  /** Acknowledges "feedback" */
+  | Envelope<"feedback_saved", WebSocketFeedbackSavedMessage>
+  /** Answers "list_memories" */
+  | Envelope<"memories", WebSocketMemoriesMessage>
+  /** Acknowledges "forget_memory" */
+  | Envelope<"memory_forgotten", WebSocketMemoryForgottenMessage>
+  /** Acknowledges "resume"; missed events follow */
+  | Envelope<"resumed", WebSocketResumedMessage>
+  /** Answers "hello" with the negotiated protocol version */
+  | Envelope<"welcome", WebSocketWelcomeMessage>
+  /** Reports a user message sent during a turn as queued, injected, started or dropped */
+  | Envelope<"input_status", WebSocketInputStatusMessage>
+  ;
+
+export type ServerEventType = ServerEvent["type"];
+
+/** Events the server sends in protocol version 1: the bare payload with an event_id. */
+export type LegacyServerEvent = (
+  | Model_Response
+  | WebSocketDoneMessage
+  | WebSocketErrorMessage
+  | WebSocketToolResultMessage
+  | WebSocketTraceMessage
+  | FrontendActionMessage
+  | WebSocketHistoryWarningsMessage
+  | WebSocketTTSContextMessage
+  | WebSocketTTSStatusMessage
+  | WebSocketTTSAudioMessage
+  | WebSocketFeedbackSavedMessage
+  | WebSocketMemoriesMessage
+  | WebSocketMemoryForgottenMessage
+  | WebSocketResumedMessage
+  | WebSocketWelcomeMessage
+  | WebSocketInputStatusMessage
+) & { event_id?: number };
+
+/** Messages the client sends. They are never enveloped. */
+export type ClientMessage =
+  /** A chat request: a user message or tool results. Sent during a turn, it is queued or steers the turn */
+  | WebSocketChatRequest
+  /** Negotiates the protocol version */
+  | (WebSocketHelloMessage & { type: "hello" })
+  /** Replays missed events after a reconnect */
+  | (WebSocketResumeMessage & { type: "resume" })
+  /** Rates a message */
+  | (WebSocketFeedbackMessage & { type: "feedback" })
+  /** Lists the user's memories */
+  | (WebSocketMemoryMessage & { type: "list_memories" })
+  /** Deletes a memory */
+  | (WebSocketMemoryMessage & { type: "forget_memory" })
+  ;
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// httpSession builds an HTTP session for the caller's tenant. With a request it also checks the
+// caller may use the conversation, creating it for them if it is new. It returns nil after
+// writing an error response.
+func (s *Server) httpSession(c *gin.Context, req *models.Model_Request) *godantic.HTTPSession {
+	conversationID := c.Param("conversationID")
+	if req != nil {
+		if err := c.ShouldBindJSON(req); err != nil {
+			abortWithError(c, http.StatusBadRequest, err.Error())
+			return nil
+		}
+		if req.User_Message == nil && req.Tool_Results == nil {
+			abortWithError(c, http.StatusBadRequest, "request must contain either user message or tool results")
+			return nil
+		}
+	}
+
+	config := s.configFor(c)
+	if config == nil {
+		return nil
+	}
+	if !authorizeConversation(c, config.Store, conversationID, req != nil) {
+		return nil
+	}
+	agent, err := newAgent(config)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	session := godantic.NewHTTPSession(conversationID, agent, config.Store)
+	session.SetLogger(config.Logger)
+	if err := session.SetTenant(config.TenantID); err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	return session
+}
+
+// Chat runs a turn, including any tool calls, and returns the final response
+//
+// @Summary		Chat with the AI agent
+// @Description	Sends a user message (or tool results) to the agent and returns the final response once all tool calls have run.
+// @Tags			Chat
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Param			conversationID	path		string					true	"Conversation ID"
+// @Param			request			body		models.Model_Request	true	"User message or tool results"
+// @Success		200				{object}	models.Model_Response
+// @Failure		400				{object}	HTTPError	"Invalid request"
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation belongs to another user"
+// @Failure		500				{object}	HTTPError	"Error processing request"
+// @Router			/chat/{conversationID} [post]
+func (s *Server) Chat(c *gin.Context) {
+	var req models.Model_Request
+	session := s.httpSession(c, &req)
+	if session == nil {
+		return
+	}
+
+	response, err := session.RunSingleInteractionWithRequest(req)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ChatStream runs a turn and streams it as Server-Sent Events
+//
+// @Summary		Chat stream route
+// @Description	Streams a turn as numbered Server-Sent Events named delta, tool_call, tool_result, trace, done and error. The turn keeps running if the client disconnects; reconnect with GET and Last-Event-ID.
+// @Tags			Chat
+// @Accept			json
+// @Produce		text/event-stream
+// @Security		BearerAuth
+// @Param			conversationID	path		string					true	"Conversation ID"
+// @Param			request			body		models.Model_Request	true	"User message or tool results"
+// @Success		200				{string}	string					"Server-Sent Events stream"
+// @Failure		400				{object}	HTTPError				"Invalid request"
+// @Failure		401				{object}	HTTPError				"Not authenticated"
+// @Failure		404				{object}	HTTPError				"Conversation belongs to another user"
+// @Failure		409				{object}	HTTPError				"A turn is already streaming on the conversation"
+// @Failure		500				{object}	HTTPError				"Server error"
+// @Router			/chat/stream/{conversationID} [post]
+func (s *Server) ChatStream(c *gin.Context) {
+	var req models.Model_Request
+	session := s.httpSession(c, &req)
+	if session == nil {
+		return
+	}
+
+	writer := sessions.NewResponseSSEWriter(c.Writer)
+	err := session.RunSSEInteractionWithRequest(req, writer, c.Request.Context())
+	if errors.Is(err, sessions.ErrSSEStreamActive) {
+		c.Writer.Header().Del("Content-Type")
+		abortWithError(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		session.Logger.Warn("SSE interaction ended", logging.KeyError, err)
+	}
+}
+
+// ResumeChatStream replays the events after Last-Event-ID and follows the running turn
+//
+// @Summary		Resume a chat stream
+// @Description	Replays the conversation's buffered events after the Last-Event-ID header (or last_event_id query parameter) and follows the live stream until the turn finishes. Returns 204 when nothing is buffered, which stops EventSource from reconnecting.
+// @Tags			Chat
+// @Produce		text/event-stream
+// @Security		BearerAuth
+// @Param			conversationID	path		string		true	"Conversation ID"
+// @Param			Last-Event-ID	header		string		false	"ID of the last event received"
+// @Param			last_event_id	query		string		false	"Alternative to the Last-Event-ID header"
+// @Success		200				{string}	string		"Server-Sent Events stream"
+// @Success		204				"No stream buffered for the conversation"
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation not found"
+// @Router			/chat/stream/{conversationID} [get]
+func (s *Server) ResumeChatStream(c *gin.Context) {
+	session := s.httpSession(c, nil)
+	if session == nil {
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	writer := sessions.NewResponseSSEWriter(c.Writer)
+	if err := session.ResumeSSE(lastEventID, writer, c.Request.Context()); errors.Is(err, sessions.ErrSSEStreamNotFound) {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ChatHistory returns a conversation's messages
+//
+// @Summary		Get Chat History
+// @Description	Retrieves the entire chat history for a specific conversation.
+// @Tags			Chat
+// @Produce		json
+// @Security		BearerAuth
+// @Param			conversationID	path		string	true	"Conversation ID"
+// @Success		200				{array}		models.ChatMessageResponse
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation not found"
+// @Failure		500				{object}	HTTPError	"Failed to retrieve history"
+// @Router			/chat/history/{conversationID} [get]
+func (s *Server) ChatHistory(c *gin.Context) {
+	session := s.httpSession(c, nil)
+	if session == nil {
+		return
+	}
+
+	history, err := session.GetChatHistory()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if history == nil {
+		history = []models.ChatMessageResponse{}
+	}
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/stores"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateConversationRequest is the body of POST /conversations
+type CreateConversationRequest struct {
+	ConversationID string `json:"conversation_id,omitempty"` // Generated when empty
+	Title          string `json:"title,omitempty"`
+}
+
+// RenameConversationRequest is the body of PATCH /conversations/{conversationID}
+type RenameConversationRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// conversationManager returns the caller's scoped config and its store as a ConversationManager,
+// or writes an error and returns nil
+func (s *Server) conversationManager(c *gin.Context) (*godantic.WSConfig, stores.ConversationManager) {
+	config := s.configFor(c)
+	if config == nil {
+		return nil, nil
+	}
+	manager, ok := config.Store.(stores.ConversationManager)
+	if !ok {
+		abortWithError(c, http.StatusNotImplemented, "message store does not support conversation management")
+		return nil, nil
+	}
+	return config, manager
+}
+
+// ListConversations returns the caller's conversations
+//
+// @Summary		List conversations
+// @Description	Lists the caller's conversations, most recently updated first.
+// @Tags			Conversations
+// @Produce		json
+// @Security		BearerAuth
+// @Success		200	{array}		stores.ConversationInfo
+// @Failure		401	{object}	HTTPError	"Not authenticated"
+// @Failure		500	{object}	HTTPError	"Failed to list conversations"
+// @Router			/conversations [get]
+func (s *Server) ListConversations(c *gin.Context) {
+	config := s.configFor(c)
+	if config == nil {
+		return
+	}
+
+	conversations, err := config.Store.ListConversationsForUser(IdentityFrom(c).UserID)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if conversations == nil {
+		conversations = []stores.ConversationInfo{}
+	}
+	c.JSON(http.StatusOK, conversations)
+}
+
+// CreateConversation creates an empty conversation owned by the caller
+//
+// @Summary		Create a conversation
+// @Description	Creates an empty conversation owned by the caller. Conversations are also created by the first message sent to a new ID.
+// @Tags			Conversations
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Param			request	body		CreateConversationRequest	false	"Optional ID and title"
+// @Success		201		{object}	stores.ConversationInfo
+// @Failure		400		{object}	HTTPError	"Invalid request"
+// @Failure		401		{object}	HTTPError	"Not authenticated"
+// @Failure		409		{object}	HTTPError	"Conversation already exists"
+// @Failure		500		{object}	HTTPError	"Failed to create conversation"
+// @Failure		501		{object}	HTTPError	"Store does not support conversation management"
+// @Router			/conversations [post]
+func (s *Server) CreateConversation(c *gin.Context) {
+	var req CreateConversationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.ConversationID == "" {
+		req.ConversationID = uuid.NewString()
+	}
+
+	config, manager := s.conversationManager(c)
+	if manager == nil {
+		return
+	}
+	if _, err := manager.GetConversation(req.ConversationID); !errors.Is(err, stores.ErrConversationNotFound) {
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, err.Error())
+		} else {
+			abortWithError(c, http.StatusConflict, "conversation already exists")
+		}
+		return
+	}
+
+	if err := config.Store.CreateConversation(req.ConversationID, IdentityFrom(c).UserID); err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if req.Title != "" {
+		if err := manager.RenameConversation(req.ConversationID, req.Title); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	info, err := manager.GetConversation(req.ConversationID)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, info)
+}
+
+// GetConversation returns a conversation's details
+//
+// @Summary		Get a conversation
+// @Tags			Conversations
+// @Produce		json
+// @Security		BearerAuth
+// @Param			conversationID	path		string	true	"Conversation ID"
+// @Success		200				{object}	stores.ConversationInfo
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation not found"
+// @Failure		501				{object}	HTTPError	"Store does not support conversation management"
+// @Router			/conversations/{conversationID} [get]
+func (s *Server) GetConversation(c *gin.Context) {
+	config, manager := s.conversationManager(c)
+	if manager == nil || !authorizeConversation(c, config.Store, c.Param("conversationID"), false) {
+		return
+	}
+
+	info, err := manager.GetConversation(c.Param("conversationID"))
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// RenameConversation sets a conversation's title
+//
+// @Summary		Rename a conversation
+// @Tags			Conversations
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Param			conversationID	path		string						true	"Conversation ID"
+// @Param			request			body		RenameConversationRequest	true	"New title"
+// @Success		200				{object}	stores.ConversationInfo
+// @Failure		400				{object}	HTTPError	"Invalid request"
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation not found"
+// @Failure		501				{object}	HTTPError	"Store does not support conversation management"
+// @Router			/conversations/{conversationID} [patch]
+func (s *Server) RenameConversation(c *gin.Context) {
+	var req RenameConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conversationID := c.Param("conversationID")
+	config, manager := s.conversationManager(c)
+	if manager == nil || !authorizeConversation(c, config.Store, conversationID, false) {
+		return
+	}
+
+	if err := manager.RenameConversation(conversationID, req.Title); err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	info, err := manager.GetConversation(conversationID)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// DeleteConversation removes a conversation with its messages, feedback and traces
+//
+// @Summary		Delete a conversation
+// @Description	Permanently deletes a conversation with its messages, feedback and execution traces.
+// @Tags			Conversations
+// @Security		BearerAuth
+// @Param			conversationID	path	string	true	"Conversation ID"
+// @Success		204				"Deleted"
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation not found"
+// @Failure		500				{object}	HTTPError	"Failed to delete conversation"
+// @Failure		501				{object}	HTTPError	"Store does not support conversation management"
+// @Router			/conversations/{conversationID} [delete]
+func (s *Server) DeleteConversation(c *gin.Context) {
+	conversationID := c.Param("conversationID")
+	config, manager := s.conversationManager(c)
+	if manager == nil || !authorizeConversation(c, config.Store, conversationID, false) {
+		return
+	}
+
+	if err := manager.DeleteConversation(conversationID); err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if config.TraceStore != nil {
+		if err := config.TraceStore.DeleteTracesByConversation(conversationID); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListTraces returns a conversation's execution traces
+//
+// @Summary		List execution traces
+// @Description	Returns the tool execution traces recorded for a conversation.
+// @Tags			Conversations
+// @Produce		json
+// @Security		BearerAuth
+// @Param			conversationID	path		string	true	"Conversation ID"
+// @Success		200				{array}		stores.ExecutionTrace
+// @Failure		401				{object}	HTTPError	"Not authenticated"
+// @Failure		404				{object}	HTTPError	"Conversation not found"
+// @Failure		500				{object}	HTTPError	"Failed to fetch traces"
+// @Failure		501				{object}	HTTPError	"No trace store configured"
+// @Router			/conversations/{conversationID}/traces [get]
+func (s *Server) ListTraces(c *gin.Context) {
+	conversationID := c.Param("conversationID")
+	config := s.configFor(c)
+	if config == nil || !authorizeConversation(c, config.Store, conversationID, false) {
+		return
+	}
+	if config.TraceStore == nil {
+		abortWithError(c, http.StatusNotImplemented, "no trace store configured")
+		return
+	}
+
+	traces, err := config.TraceStore.GetTracesByConversation(conversationID)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if traces == nil {
+		traces = []*stores.ExecutionTrace{}
+	}
+	c.JSON(http.StatusOK, traces)
+}
@@ -0,0 +1,405 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DefaultConversationHeader carries the conversation a chat completion is saved to
+const DefaultConversationHeader = "X-Conversation-ID"
+
+// DefaultMaxToolRounds bounds the model calls of one completion that run agent tools
+const DefaultMaxToolRounds = 20
+
+// OpenAIServer exposes agents through the OpenAI Chat Completions API, so clients that only
+// speak that protocol can use them. Each agent is listed as a model under the ID it was added with.
+//
+// Requests without "tools" run the agent's own tools server-side until the model answers.
+// Requests with "tools" are passed through: the model sees only the client's tools and its
+// tool calls are returned with finish_reason "tool_calls" for the client to execute.
+type OpenAIServer struct {
+	agents             map[string]*godantic.Agent
+	modelIDs           []string // Registration order, for /v1/models
+	created            int64
+	store              stores.MessageStore
+	conversationHeader string
+	maxToolRounds      int
+	authenticate       Authenticator
+	resolveUser        UserResolver
+	logger             *slog.Logger
+}
+
+// NewOpenAIServer creates an OpenAI-compatible server with no agents
+func NewOpenAIServer() *OpenAIServer {
+	return &OpenAIServer{
+		agents:             make(map[string]*godantic.Agent),
+		created:            time.Now().Unix(),
+		conversationHeader: DefaultConversationHeader,
+		maxToolRounds:      DefaultMaxToolRounds,
+		logger:             logging.Default().With(logging.KeyComponent, "openai"),
+	}
+}
+
+// WithAgent exposes agent as the model modelID
+func (s *OpenAIServer) WithAgent(modelID string, agent *godantic.Agent) *OpenAIServer {
+	if _, exists := s.agents[modelID]; !exists {
+		s.modelIDs = append(s.modelIDs, modelID)
+	}
+	s.agents[modelID] = agent
+	return s
+}
+
+// WithStore saves completions to store. Only requests carrying a conversation ID header are saved;
+// the model always sees the history sent in "messages".
+func (s *OpenAIServer) WithStore(store stores.MessageStore) *OpenAIServer {
+	s.store = store
+	return s
+}
+
+// WithConversationHeader changes the header that carries the conversation ID (default X-Conversation-ID)
+func (s *OpenAIServer) WithConversationHeader(header string) *OpenAIServer {
+	s.conversationHeader = header
+	return s
+}
+
+// WithMaxToolRounds changes how many times a completion may run agent tools and call the
+// model again (default DefaultMaxToolRounds). A completion that needs more fails.
+func (s *OpenAIServer) WithMaxToolRounds(rounds int) *OpenAIServer {
+	s.maxToolRounds = rounds
+	return s
+}
+
+// WithAuthenticator sets the hook that rejects unauthenticated requests
+func (s *OpenAIServer) WithAuthenticator(authenticate Authenticator) *OpenAIServer {
+	s.authenticate = authenticate
+	return s
+}
+
+// WithUserResolver sets the hook that resolves the user and tenant saved conversations belong to
+func (s *OpenAIServer) WithUserResolver(resolve UserResolver) *OpenAIServer {
+	s.resolveUser = resolve
+	return s
+}
+
+// WithLogger sets the logger for tool and storage failures; secrets are redacted
+func (s *OpenAIServer) WithLogger(logger *slog.Logger) *OpenAIServer {
+	s.logger = logging.Or(logging.Redact(logger)).With(logging.KeyComponent, "openai")
+	return s
+}
+
+// Mount registers /v1/chat/completions and /v1/models on r
+func (s *OpenAIServer) Mount(r gin.IRouter) {
+	v1 := r.Group("/v1", s.identify)
+	v1.POST("/chat/completions", s.ChatCompletions)
+	v1.GET("/models", s.ListModels)
+}
+
+func (s *OpenAIServer) identify(c *gin.Context) {
+	if herr := resolveIdentity(c, s.authenticate, s.resolveUser); herr != nil {
+		abortWithOpenAIError(c, herr.Code, herr.Message, "")
+		return
+	}
+	c.Next()
+}
+
+// ListModels lists the agents exposed as models
+//
+// @Summary		List models
+// @Description	Lists the agents exposed through the OpenAI-compatible API.
+// @Tags			OpenAI
+// @Produce		json
+// @Security		BearerAuth
+// @Success		200	{object}	ModelList
+// @Failure		401	{object}	OpenAIErrorResponse	"Not authenticated"
+// @Router			/v1/models [get]
+func (s *OpenAIServer) ListModels(c *gin.Context) {
+	list := ModelList{Object: "list", Data: make([]ModelCard, 0, len(s.modelIDs))}
+	for _, id := range s.modelIDs {
+		list.Data = append(list.Data, ModelCard{ID: id, Object: "model", Created: s.created, OwnedBy: "godantic"})
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// ChatCompletions runs an agent on an OpenAI Chat Completions request
+//
+// @Summary		Create a chat completion
+// @Description	Runs the agent registered as "model" on the conversation in "messages". With "stream" the completion is sent as chat.completion.chunk Server-Sent Events ending with [DONE]. Without "tools" the agent's tools run server-side; with "tools" tool calls are returned to the client. Set the X-Conversation-ID header to save the exchange to the message store.
+// @Tags			OpenAI
+// @Accept			json
+// @Produce		json
+// @Produce		text/event-stream
+// @Security		BearerAuth
+// @Param			X-Conversation-ID	header		string					false	"Conversation to save the exchange to"
+// @Param			request				body		ChatCompletionRequest	true	"Chat completion request"
+// @Success		200					{object}	ChatCompletion
+// @Failure		400					{object}	OpenAIErrorResponse	"Invalid request"
+// @Failure		401					{object}	OpenAIErrorResponse	"Not authenticated"
+// @Failure		404					{object}	OpenAIErrorResponse	"Unknown model or conversation"
+// @Failure		500					{object}	OpenAIErrorResponse	"Model or tool error"
+// @Router			/v1/chat/completions [post]
+func (s *OpenAIServer) ChatCompletions(c *gin.Context) {
+	var req ChatCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithOpenAIError(c, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+	agent, ok := s.agents[req.Model]
+	if !ok {
+		abortWithOpenAIError(c, http.StatusNotFound, fmt.Sprintf("the model '%s' does not exist", req.Model), "model")
+		return
+	}
+
+	history, request, err := splitMessages(req.Messages)
+	if err != nil {
+		abortWithOpenAIError(c, http.StatusBadRequest, err.Error(), "messages")
+		return
+	}
+
+	run := &completionRun{
+		agent:     agent,
+		history:   history,
+		maxRounds: s.maxToolRounds,
+		logger:    s.logger,
+	}
+	if len(req.Tools) > 0 {
+		tools, err := toolDeclarations(req.Tools)
+		if err != nil {
+			abortWithOpenAIError(c, http.StatusBadRequest, err.Error(), "tools")
+			return
+		}
+		passthrough := *agent
+		passthrough.Tools = tools
+		run.agent = &passthrough
+		run.passthrough = true
+	}
+	if !s.attachStore(c, run) {
+		return
+	}
+
+	completion := ChatCompletion{
+		ID:      "chatcmpl-" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	if req.Stream {
+		s.streamCompletion(c, run, request, completion, req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+		return
+	}
+
+	result, err := run.complete(request, nil)
+	if err != nil {
+		abortWithOpenAIError(c, http.StatusInternalServerError, err.Error(), "")
+		return
+	}
+	message := &ChatCompletionResponseMessage{Role: "assistant", ToolCalls: toolCalls(result.calls, false)}
+	if result.text != "" || len(message.ToolCalls) == 0 {
+		message.Content = &result.text
+	}
+	completion.Object = "chat.completion"
+	completion.Choices = []ChatCompletionChoice{{Message: message, FinishReason: &result.finishReason}}
+	completion.Usage = &result.usage
+	c.JSON(http.StatusOK, completion)
+}
+
+// attachStore points run at the store when the request names a conversation the caller may use.
+// It returns false after writing an error response.
+func (s *OpenAIServer) attachStore(c *gin.Context, run *completionRun) bool {
+	conversationID := c.GetHeader(s.conversationHeader)
+	if s.store == nil || conversationID == "" {
+		return true
+	}
+
+	identity := IdentityFrom(c)
+	store, err := stores.ScopeStoreToTenant(s.store, identity.TenantID)
+	if err != nil {
+		abortWithOpenAIError(c, http.StatusInternalServerError, err.Error(), "")
+		return false
+	}
+	if herr := checkConversation(c, store, conversationID, true); herr != nil {
+		abortWithOpenAIError(c, herr.Code, herr.Message, "")
+		return false
+	}
+	run.store = store
+	run.conversationID = conversationID
+	run.userID = identity.UserID
+	return true
+}
+
+// streamCompletion sends the completion as chat.completion.chunk events
+func (s *OpenAIServer) streamCompletion(c *gin.Context, run *completionRun, request models.Model_Request, completion ChatCompletion, includeUsage bool) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	completion.Object = "chat.completion.chunk"
+	send := func(choices []ChatCompletionChoice, usage *ChatCompletionUsage) {
+		chunk := completion
+		chunk.Choices = choices
+		chunk.Usage = usage
+		data, _ := json.Marshal(chunk)
+		// The turn keeps running if the client goes away, so the saved conversation stays complete
+		fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+		c.Writer.Flush()
+	}
+
+	send([]ChatCompletionChoice{{Delta: &ChatCompletionDelta{Role: "assistant"}}}, nil)
+	result, err := run.complete(request, func(text string) {
+		send([]ChatCompletionChoice{{Delta: &ChatCompletionDelta{Content: text}}}, nil)
+	})
+	if err != nil {
+		data, _ := json.Marshal(OpenAIErrorResponse{Error: OpenAIError{Message: err.Error(), Type: "server_error"}})
+		fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	} else {
+		if calls := toolCalls(result.calls, true); len(calls) > 0 {
+			send([]ChatCompletionChoice{{Delta: &ChatCompletionDelta{ToolCalls: calls}}}, nil)
+		}
+		send([]ChatCompletionChoice{{Delta: &ChatCompletionDelta{}, FinishReason: &result.finishReason}}, nil)
+		if includeUsage {
+			send([]ChatCompletionChoice{}, &result.usage)
+		}
+	}
+	fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+	c.Writer.Flush()
+}
+
+// completionRun runs the tool loop of one chat completion
+type completionRun struct {
+	agent       *godantic.Agent
+	history     []stores.Message
+	passthrough bool // Tool calls are returned to the client instead of executed
+	maxRounds   int  // Tool executions allowed before the completion fails
+	logger      *slog.Logger
+
+	// Set when the exchange is saved
+	store          stores.MessageStore
+	conversationID string
+	userID         string
+}
+
+// completionResult is the outcome of a completion
+type completionResult struct {
+	text         string
+	calls        []models.Model_Part // Tool calls for the client, in passthrough mode
+	finishReason string
+	usage        ChatCompletionUsage
+}
+
+// complete runs the model until it answers without calling tools, or calls client tools.
+// onText, when set, receives the text of each streamed chunk.
+func (r *completionRun) complete(request models.Model_Request, onText func(string)) (completionResult, error) {
+	var result completionResult
+	for round := 0; ; round++ {
+		// The model receives the input in the request, so it joins the history only after the call
+		respChan, errChan := r.agent.Run_Stream(request, r.history)
+		r.record(requestMessages(request)...)
+
+		var parts []models.Model_Part
+		var providerReason string
+		for respChan != nil || errChan != nil {
+			select {
+			case response, ok := <-respChan:
+				if !ok {
+					respChan = nil
+					continue
+				}
+				if response.Usage != nil {
+					result.usage.PromptTokens += response.Usage.InputTokens
+					result.usage.CompletionTokens += response.Usage.OutputTokens
+					result.usage.TotalTokens += response.Usage.TotalTokens
+					if response.Usage.CacheReadTokens > 0 {
+						if result.usage.PromptTokensDetails == nil {
+							result.usage.PromptTokensDetails = &PromptTokensDetails{}
+						}
+						result.usage.PromptTokensDetails.CachedTokens += response.Usage.CacheReadTokens
+					}
+				}
+				if response.FinishReason != "" {
+					providerReason = response.FinishReason
+				}
+				for _, part := range response.Parts {
+					if part.Text != nil && *part.Text != "" && onText != nil {
+						onText(*part.Text)
+					}
+				}
+				parts = append(parts, response.Parts...)
+			case err, ok := <-errChan:
+				if !ok {
+					errChan = nil
+					continue
+				}
+				if err != nil {
+					return result, fmt.Errorf("agent error: %w", err)
+				}
+			}
+		}
+
+		text, collapsed := collapseParts(parts)
+		result.text += text
+		if len(collapsed) > 0 {
+			r.record(modelMessage(collapsed))
+		}
+
+		var calls []models.Model_Part
+		for _, part := range collapsed {
+			if part.FunctionCall != nil {
+				calls = append(calls, part)
+			}
+		}
+		if len(calls) == 0 || r.passthrough {
+			result.calls = calls
+			result.finishReason = finishReason(providerReason, len(calls) > 0)
+			return result, nil
+		}
+
+		if round >= r.maxRounds {
+			return result, fmt.Errorf("model still calling tools after %d rounds", r.maxRounds)
+		}
+
+		toolResults := make([]models.Tool_Result, 0, len(calls))
+		for _, part := range calls {
+			call := part.FunctionCall
+			output, err := r.agent.ExecuteTool(call.Name, call.Args, r.conversationID)
+			if err != nil {
+				r.logger.Warn("Tool failed", logging.KeyTool, call.Name, logging.KeyError, err)
+			}
+			toolResults = append(toolResults, models.Tool_Result{Tool_ID: call.ID, Tool_Name: call.Name, Tool_Output: output})
+		}
+		request = models.Model_Request{Tool_Results: &toolResults, Client: request.Client}
+	}
+}
+
+// record appends messages to the history sent to the model and saves them when a store is attached
+func (r *completionRun) record(messages ...stores.Message) {
+	r.history = append(r.history, messages...)
+	if r.store == nil {
+		return
+	}
+	for _, msg := range messages {
+		if err := r.store.SaveMessageWithUser(r.conversationID, r.userID, msg.Role, msg.Type, json.RawMessage(msg.PartsJSON), ""); err != nil {
+			r.logger.Error("Failed to save message", "type", msg.Type, logging.KeySessionID, r.conversationID, logging.KeyError, err)
+		}
+	}
+}
+
+func abortWithOpenAIError(c *gin.Context, code int, message, param string) {
+	errorType := "invalid_request_error"
+	switch {
+	case code == http.StatusUnauthorized:
+		errorType = "authentication_error"
+	case code >= http.StatusInternalServerError:
+		errorType = "server_error"
+	}
+	c.AbortWithStatusJSON(code, OpenAIErrorResponse{Error: OpenAIError{Message: message, Type: errorType, Param: param}})
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+	"github.com/gin-gonic/gin"
+)
+
+// scriptedModel calls the first tool it is given once, then answers with the tool output.
+// With loop set it keeps calling the tool.
+type scriptedModel struct {
+	histories [][]stores.Message
+	loop      bool
+}
+
+func (m *scriptedModel) Model_Request(request models.Model_Request, tools []models.FunctionDeclaration, history []stores.Message) (models.Model_Response, error) {
+	m.histories = append(m.histories, history)
+	usage := &models.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}
+	if request.Tool_Results != nil && !m.loop {
+		text := "result: " + (*request.Tool_Results)[0].Tool_Output
+		usage.CacheReadTokens = 8 // The second call of the tool loop reads the first call's prefix from the cache
+		return models.Model_Response{Parts: []models.Model_Part{{Text: &text}}, FinishReason: "stop", Usage: usage}, nil
+	}
+	if len(tools) > 0 {
+		call := &models.FunctionCall{Name: tools[0].Name, Args: map[string]interface{}{"city": "Paris"}}
+		return models.Model_Response{Parts: []models.Model_Part{{FunctionCall: call}}, Usage: usage}, nil
+	}
+	text := "hello"
+	return models.Model_Response{Parts: []models.Model_Part{{Text: &text}}, Usage: usage}, nil
+}
+
+func (m *scriptedModel) Stream_Model_Request(request models.Model_Request, tools []models.FunctionDeclaration, history []stores.Message) (<-chan models.Model_Response, <-chan error) {
+	responses := make(chan models.Model_Response, 1)
+	errs := make(chan error, 1)
+	response, err := m.Model_Request(request, tools, history)
+	if err != nil {
+		errs <- err
+	} else {
+		responses <- response
+	}
+	close(responses)
+	close(errs)
+	return responses, errs
+}
+
+func newTestOpenAIServer(t *testing.T) (*gin.Engine, *scriptedModel, *stores.SQLiteStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	model := &scriptedModel{}
+	weather := models.FunctionDeclaration{
+		Name:     "Get_Weather",
+		Callable: func(city string) (string, error) { return "sunny in " + city, nil },
+	}
+	agent := godantic.Create_Agent(model, []models.FunctionDeclaration{weather})
+
+	router := gin.New()
+	NewOpenAIServer().WithAgent("assistant", &agent).WithStore(store).Mount(router)
+	return router, model, store
+}
+
+func postCompletion(router *gin.Engine, body, conversationID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if conversationID != "" {
+		req.Header.Set(DefaultConversationHeader, conversationID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOpenAIServer_RunsAgentToolsServerSide(t *testing.T) {
+	router, model, store := newTestOpenAIServer(t)
+
+	w := postCompletion(router, `{"model":"assistant","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Weather?"}]}`, "conv-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var completion ChatCompletion
+	if err := json.Unmarshal(w.Body.Bytes(), &completion); err != nil {
+		t.Fatalf("Invalid completion: %v", err)
+	}
+	choice := completion.Choices[0]
+	if *choice.FinishReason != "stop" || choice.Message.Content == nil || !strings.Contains(*choice.Message.Content, "sunny in Paris") {
+		t.Errorf("Expected the tool result in the answer, got %+v", choice.Message)
+	}
+	if completion.Usage.TotalTokens != 30 {
+		t.Errorf("Expected usage summed over both model calls, got %+v", completion.Usage)
+	}
+	if details := completion.Usage.PromptTokensDetails; details == nil || details.CachedTokens != 8 {
+		t.Errorf("Expected 8 cached prompt tokens, got %+v", details)
+	}
+
+	// The second call sees the user message and the tool call once, and the tool result in the request
+	if got := len(model.histories[1]); got != 2 {
+		t.Errorf("Expected 2 history messages on the second call, got %d", got)
+	}
+
+	history, err := store.FetchHistory("conv-1", 0)
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	var types []string
+	for _, msg := range history {
+		types = append(types, msg.Type)
+	}
+	if strings.Join(types, ",") != "user_message,function_call,function_response,model_message" {
+		t.Errorf("Unexpected saved messages %v", types)
+	}
+	if !strings.Contains(history[0].PartsJSON, "Be brief.") {
+		t.Errorf("Expected the system message to prefix the user message, got %s", history[0].PartsJSON)
+	}
+}
+
+func TestOpenAIServer_PassesClientToolsThrough(t *testing.T) {
+	router, _, _ := newTestOpenAIServer(t)
+
+	body := `{"model":"assistant","messages":[{"role":"user","content":"Look it up"}],
+		"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}}]}`
+	w := postCompletion(router, body, "")
+	var completion ChatCompletion
+	if err := json.Unmarshal(w.Body.Bytes(), &completion); err != nil {
+		t.Fatalf("Invalid completion: %v (%s)", err, w.Body)
+	}
+	choice := completion.Choices[0]
+	if *choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Name != "lookup" {
+		t.Fatalf("Expected a lookup tool call, got %+v", choice.Message)
+	}
+	call := choice.Message.ToolCalls[0]
+
+	// The client answers the call
+	body = `{"model":"assistant","messages":[{"role":"user","content":"Look it up"},
+		{"role":"assistant","content":null,"tool_calls":[{"id":"` + call.ID + `","type":"function","function":{"name":"lookup","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"` + call.ID + `","content":"42"}],
+		"tools":[{"type":"function","function":{"name":"lookup"}}]}`
+	w = postCompletion(router, body, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &completion); err != nil {
+		t.Fatalf("Invalid completion: %v (%s)", err, w.Body)
+	}
+	if content := completion.Choices[0].Message.Content; content == nil || *content != "result: 42" {
+		t.Errorf("Expected the answer to use the tool output, got %+v", completion.Choices[0].Message)
+	}
+}
+
+func TestOpenAIServer_Streams(t *testing.T) {
+	router, _, _ := newTestOpenAIServer(t)
+
+	w := postCompletion(router, `{"model":"assistant","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Weather?"}]}`, "")
+	body := w.Body.String()
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("Expected the stream to end with [DONE], got %q", body)
+	}
+	var content strings.Builder
+	var finish string
+	var usage *ChatCompletionUsage
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk ChatCompletion
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("Invalid chunk %q: %v", data, err)
+		}
+		if chunk.Object != "chat.completion.chunk" {
+			t.Errorf("Unexpected object %q", chunk.Object)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+		}
+	}
+	if content.String() != "result: {\"result\":\"sunny in Paris\"}" || finish != "stop" {
+		t.Errorf("Unexpected streamed content %q (finish %q)", content.String(), finish)
+	}
+	if usage == nil || usage.TotalTokens != 30 {
+		t.Errorf("Expected a usage chunk, got %+v", usage)
+	}
+}
+
+func TestOpenAIServer_ListsModelsAndRejectsUnknownOnes(t *testing.T) {
+	router, _, _ := newTestOpenAIServer(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	var list ModelList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Data) != 1 || list.Data[0].ID != "assistant" {
+		t.Errorf("Unexpected model list %s", w.Body)
+	}
+
+	w = postCompletion(router, `{"model":"gpt-4","messages":[{"role":"user","content":"hi"}]}`, "")
+	var resp OpenAIErrorResponse
+	if w.Code != http.StatusNotFound || json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.Error.Param != "model" {
+		t.Errorf("Expected a 404 model error, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestOpenAIServer_StopsEndlessToolLoops(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	model := &scriptedModel{loop: true}
+	weather := models.FunctionDeclaration{
+		Name:     "Get_Weather",
+		Callable: func(city string) (string, error) { return "sunny in " + city, nil },
+	}
+	agent := godantic.Create_Agent(model, []models.FunctionDeclaration{weather})
+	router := gin.New()
+	NewOpenAIServer().WithAgent("assistant", &agent).WithMaxToolRounds(2).Mount(router)
+
+	w := postCompletion(router, `{"model":"assistant","messages":[{"role":"user","content":"Weather?"}]}`, "")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "after 2 rounds") {
+		t.Fatalf("Expected the tool loop to fail after 2 rounds, got %d: %s", w.Code, w.Body)
+	}
+	if got := len(model.histories); got != 3 {
+		t.Errorf("Expected 3 model calls, got %d", got)
+	}
+
+	w = postCompletion(router, `{"model":"assistant","stream":true,"messages":[{"role":"user","content":"Weather?"}]}`, "")
+	if body := w.Body.String(); !strings.Contains(body, "after 2 rounds") || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Errorf("Expected a streamed error before [DONE], got %s", body)
+	}
+}
@@ -0,0 +1,433 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+	"github.com/google/uuid"
+)
+
+// ChatCompletionRequest is an OpenAI Chat Completions request.
+// Sampling parameters are not listed: the agent's model configuration applies.
+type ChatCompletionRequest struct {
+	Model         string                       `json:"model" binding:"required"`
+	Messages      []ChatCompletionMessage      `json:"messages" binding:"required"`
+	Stream        bool                         `json:"stream,omitempty"`
+	StreamOptions *ChatCompletionStreamOptions `json:"stream_options,omitempty"`
+	Tools         []ChatCompletionTool         `json:"tools,omitempty"` // Client-executed tools; when set, the agent's own tools are not used
+	User          string                       `json:"user,omitempty"`
+}
+
+// ChatCompletionStreamOptions controls the streamed response
+type ChatCompletionStreamOptions struct {
+	IncludeUsage bool `json:"include_usage,omitempty"` // Send a final chunk with the usage of the whole completion
+}
+
+// ChatCompletionMessage is a message in a request
+type ChatCompletionMessage struct {
+	Role       string                   `json:"role"`                                   // system, developer, user, assistant or tool
+	Content    json.RawMessage          `json:"content,omitempty" swaggertype:"object"` // A string or an array of text and image_url parts
+	ToolCalls  []ChatCompletionToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string                   `json:"tool_call_id,omitempty"`
+}
+
+// ChatCompletionContentPart is one part of an array-valued message content
+type ChatCompletionContentPart struct {
+	Type     string `json:"type"` // "text" or "image_url"
+	Text     string `json:"text,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+}
+
+// ChatCompletionTool is a tool the client can execute
+type ChatCompletionTool struct {
+	Type     string                     `json:"type"` // "function"
+	Function ChatCompletionToolFunction `json:"function"`
+}
+
+// ChatCompletionToolFunction declares a client tool
+type ChatCompletionToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"` // JSON Schema of the arguments
+}
+
+// ChatCompletionToolCall is a tool call made by the assistant
+type ChatCompletionToolCall struct {
+	Index    *int                       `json:"index,omitempty"` // Only in streamed deltas
+	ID       string                     `json:"id,omitempty"`
+	Type     string                     `json:"type,omitempty"` // "function"
+	Function ChatCompletionFunctionCall `json:"function"`
+}
+
+// ChatCompletionFunctionCall names the function called and its JSON-encoded arguments
+type ChatCompletionFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatCompletion is a completion, or a chunk of one when streaming
+type ChatCompletion struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"` // "chat.completion" or "chat.completion.chunk"
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   *ChatCompletionUsage   `json:"usage,omitempty"`
+}
+
+// ChatCompletionChoice holds the message of a completion, or the delta of a chunk
+type ChatCompletionChoice struct {
+	Index        int                            `json:"index"`
+	Message      *ChatCompletionResponseMessage `json:"message,omitempty"`
+	Delta        *ChatCompletionDelta           `json:"delta,omitempty"`
+	FinishReason *string                        `json:"finish_reason"` // stop, length or tool_calls; null in chunks before the last
+}
+
+// ChatCompletionResponseMessage is the assistant message of a completion
+type ChatCompletionResponseMessage struct {
+	Role      string                   `json:"role"`
+	Content   *string                  `json:"content"`
+	ToolCalls []ChatCompletionToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatCompletionDelta is the part of the assistant message carried by a chunk
+type ChatCompletionDelta struct {
+	Role      string                   `json:"role,omitempty"`
+	Content   string                   `json:"content,omitempty"`
+	ToolCalls []ChatCompletionToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatCompletionUsage reports the tokens used by all model calls of a completion
+type ChatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt tokens; set when the provider served some from its prompt cache
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// ModelList is the response of GET /v1/models
+type ModelList struct {
+	Object string      `json:"object"` // "list"
+	Data   []ModelCard `json:"data"`
+}
+
+// ModelCard describes an agent exposed as a model
+type ModelCard struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"` // "model"
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// OpenAIErrorResponse is the error body of the OpenAI-compatible endpoints
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError describes an error in the OpenAI format
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Param   string `json:"param,omitempty"`
+	Code    string `json:"code,omitempty"`
+}
+
+// contentParts converts message content (a string or an array of parts) to user parts
+func contentParts(content json.RawMessage) ([]models.User_Part, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		if text == "" {
+			return nil, nil
+		}
+		return []models.User_Part{{Text: text}}, nil
+	}
+
+	var parts []ChatCompletionContentPart
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of parts")
+	}
+	userParts := make([]models.User_Part, 0, len(parts))
+	for _, part := range parts {
+		switch {
+		case part.Type == "text":
+			userParts = append(userParts, models.User_Part{Text: part.Text})
+		case part.Type == "image_url" && part.ImageURL != nil:
+			userParts = append(userParts, imagePart(part.ImageURL.URL))
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+	}
+	return userParts, nil
+}
+
+// imagePart converts an image URL, which may be a base64 data URL, to a user part
+func imagePart(url string) models.User_Part {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		if meta, data, ok := strings.Cut(rest, ","); ok && strings.HasSuffix(meta, ";base64") {
+			return models.User_Part{InlineData: &models.InlineData{MimeType: strings.TrimSuffix(meta, ";base64"), Data: data}}
+		}
+	}
+	return models.User_Part{ImageData: &models.ImageData{FileUrl: url}}
+}
+
+// contentText joins the text of message content
+func contentText(content json.RawMessage) string {
+	parts, _ := contentParts(content)
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(part.Text)
+	}
+	return b.String()
+}
+
+// toolDeclarations converts client tools to function declarations without a callable
+func toolDeclarations(tools []ChatCompletionTool) ([]models.FunctionDeclaration, error) {
+	declarations := make([]models.FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Type != "" && tool.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type %q", tool.Type)
+		}
+		var params models.Parameters
+		if tool.Function.Parameters != nil {
+			data, err := json.Marshal(tool.Function.Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal parameters of tool %s: %w", tool.Function.Name, err)
+			}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, fmt.Errorf("invalid parameters for tool %s: %w", tool.Function.Name, err)
+			}
+		}
+		if params.Type == "" {
+			params.Type = "object"
+		}
+		declarations = append(declarations, models.FunctionDeclaration{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  params,
+		})
+	}
+	return declarations, nil
+}
+
+// toolResponse parses a tool's output the way sessions store tool results
+func toolResponse(output string) map[string]interface{} {
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		response = map[string]interface{}{"output": output}
+	}
+	return response
+}
+
+// splitMessages converts OpenAI messages to stored history and the request for the new input:
+// the last user message, or the tool messages that answer the last assistant message.
+// System and developer messages are prepended to the first user message.
+func splitMessages(messages []ChatCompletionMessage) ([]stores.Message, models.Model_Request, error) {
+	var system []string
+	var history []stores.Message
+	var request models.Model_Request
+	toolNames := make(map[string]string)
+
+	if len(messages) == 0 {
+		return nil, request, fmt.Errorf("messages must not be empty")
+	}
+
+	// The new input is the last user message, or else the tool messages after the last assistant message
+	lastIsUser := messages[len(messages)-1].Role == "user"
+	inputStart := 0
+	for i, msg := range messages {
+		if msg.Role == "assistant" {
+			inputStart = i + 1
+		}
+	}
+
+	for i, msg := range messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := contentText(msg.Content); text != "" {
+				system = append(system, text)
+			}
+
+		case "user":
+			parts, err := contentParts(msg.Content)
+			if err != nil {
+				return nil, request, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			if i == len(messages)-1 {
+				request.User_Message = &models.User_Message{Role: "user", Content: models.Content{Parts: parts}}
+				continue
+			}
+			history = append(history, storedMessage("user", "user_message", parts))
+
+		case "assistant":
+			var parts []models.Model_Part
+			if text := contentText(msg.Content); text != "" {
+				parts = append(parts, models.Model_Part{Text: &text})
+			}
+			for _, call := range msg.ToolCalls {
+				args := map[string]interface{}{}
+				if call.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+						return nil, request, fmt.Errorf("messages[%d]: invalid arguments for tool call %s: %w", i, call.ID, err)
+					}
+				}
+				toolNames[call.ID] = call.Function.Name
+				parts = append(parts, models.Model_Part{FunctionCall: &models.FunctionCall{ID: call.ID, Name: call.Function.Name, Args: args}})
+			}
+			history = append(history, modelMessage(parts))
+
+		case "tool":
+			output := contentText(msg.Content)
+			if i >= inputStart && !lastIsUser {
+				results := []models.Tool_Result{}
+				if request.Tool_Results != nil {
+					results = *request.Tool_Results
+				}
+				results = append(results, models.Tool_Result{Tool_ID: msg.ToolCallID, Tool_Name: toolNames[msg.ToolCallID], Tool_Output: output})
+				request.Tool_Results = &results
+				continue
+			}
+			history = append(history, storedMessage("user", "function_response", []models.User_Part{{
+				FunctionResponse: &models.FunctionResponse{ID: msg.ToolCallID, Name: toolNames[msg.ToolCallID], Response: toolResponse(output)},
+			}}))
+
+		default:
+			return nil, request, fmt.Errorf("messages[%d]: unsupported role %q", i, msg.Role)
+		}
+	}
+
+	if request.User_Message == nil && request.Tool_Results == nil {
+		return nil, request, fmt.Errorf("the last message must be from the user, or tool results for the last assistant message")
+	}
+
+	if len(system) > 0 {
+		prefix := models.User_Part{Text: strings.Join(system, "\n\n")}
+		if first := firstUserMessage(history); first != nil {
+			var parts []models.User_Part
+			if err := json.Unmarshal([]byte(first.PartsJSON), &parts); err == nil {
+				*first = storedMessage("user", "user_message", append([]models.User_Part{prefix}, parts...))
+			}
+		} else if request.User_Message != nil {
+			request.User_Message.Content.Parts = append([]models.User_Part{prefix}, request.User_Message.Content.Parts...)
+		}
+	}
+	return history, request, nil
+}
+
+func firstUserMessage(history []stores.Message) *stores.Message {
+	for i := range history {
+		if history[i].Type == "user_message" {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// storedMessage builds a history message the way stores save it
+func storedMessage(role, messageType string, parts interface{}) stores.Message {
+	data, _ := json.Marshal(parts)
+	return stores.Message{Role: role, Type: messageType, PartsJSON: string(data)}
+}
+
+// modelMessage builds a model history message; it is a function_call when it calls tools
+func modelMessage(parts []models.Model_Part) stores.Message {
+	messageType := "model_message"
+	for _, part := range parts {
+		if part.FunctionCall != nil {
+			messageType = "function_call"
+			break
+		}
+	}
+	return storedMessage("model", messageType, parts)
+}
+
+// requestMessages returns the history messages for a request's input
+func requestMessages(request models.Model_Request) []stores.Message {
+	if request.User_Message != nil {
+		return []stores.Message{storedMessage("user", "user_message", request.User_Message.Content.Parts)}
+	}
+	if request.Tool_Results == nil {
+		return nil
+	}
+	messages := make([]stores.Message, 0, len(*request.Tool_Results))
+	for _, result := range *request.Tool_Results {
+		messages = append(messages, storedMessage("user", "function_response", []models.User_Part{{
+			FunctionResponse: &models.FunctionResponse{ID: result.Tool_ID, Name: result.Tool_Name, Response: toolResponse(result.Tool_Output)},
+		}}))
+	}
+	return messages
+}
+
+// collapseParts joins streamed text into a single part, followed by the function calls.
+// Calls without an ID get one, since OpenAI clients answer calls by ID.
+func collapseParts(parts []models.Model_Part) (string, []models.Model_Part) {
+	var text strings.Builder
+	var calls []models.Model_Part
+	for _, part := range parts {
+		if part.Text != nil {
+			text.WriteString(*part.Text)
+		}
+		if part.FunctionCall != nil {
+			call := *part.FunctionCall
+			if call.ID == "" {
+				call.ID = "call_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+			}
+			calls = append(calls, models.Model_Part{FunctionCall: &call})
+		}
+	}
+
+	collapsed := make([]models.Model_Part, 0, len(calls)+1)
+	if text.Len() > 0 {
+		joined := text.String()
+		collapsed = append(collapsed, models.Model_Part{Text: &joined})
+	}
+	return text.String(), append(collapsed, calls...)
+}
+
+// toolCalls converts function call parts to OpenAI tool calls; withIndex numbers them for stream deltas
+func toolCalls(parts []models.Model_Part, withIndex bool) []ChatCompletionToolCall {
+	var calls []ChatCompletionToolCall
+	for _, part := range parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		args, _ := json.Marshal(part.FunctionCall.Args)
+		call := ChatCompletionToolCall{
+			ID:       part.FunctionCall.ID,
+			Type:     "function",
+			Function: ChatCompletionFunctionCall{Name: part.FunctionCall.Name, Arguments: string(args)},
+		}
+		if withIndex {
+			index := len(calls)
+			call.Index = &index
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// finishReason maps a provider stop reason to an OpenAI finish_reason
+func finishReason(providerReason string, calledTools bool) string {
+	if calledTools {
+		return "tool_calls"
+	}
+	switch strings.ToLower(providerReason) {
+	case "length", "max_tokens":
+		return "length"
+	}
+	return "stop"
+}
@@ -0,0 +1,239 @@
+// Package server mounts ready-made chat endpoints onto a gin router: single-shot and
+// streaming (SSE) chat, the WebSocket session, history, conversation CRUD and traces.
+// OpenAIServer exposes agents through the OpenAI Chat Completions API.
+//
+//	srv := server.New(config).WithUserResolver(resolveUser)
+//	srv.Mount(router.Group("/api/v1"))
+//
+// @title						Godantic Chat API
+// @version					1.0
+// @description				Chat, streaming, WebSocket, conversation and trace endpoints mounted by the godantic server package.
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Type "Bearer" followed by a space and your token.
+package server
+
+//go:generate swag init -g server.go -d .,../models,../stores -o ../docs --parseInternal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/docs"
+	"github.com/Desarso/godantic/stores"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// identityKey is the gin context key holding the caller's Identity
+const identityKey = "godantic.identity"
+
+// Identity is the caller of a request, as returned by the user resolver
+type Identity struct {
+	UserID   string // Owner of the conversations the caller creates; empty for anonymous callers
+	TenantID string // Tenant whose configuration and data the request uses; empty for the default tenant
+}
+
+// Authenticator rejects unauthenticated requests by returning an error (sent as 401)
+type Authenticator func(c *gin.Context) error
+
+// UserResolver resolves the user and tenant a request acts for. An error is sent as 401.
+type UserResolver func(c *gin.Context) (Identity, error)
+
+// HTTPError is the error body returned by every endpoint
+type HTTPError struct {
+	Code    int    `json:"code" example:"400"`
+	Message string `json:"message" example:"status bad request"`
+}
+
+// Server holds the configuration shared by the mounted endpoints
+type Server struct {
+	config       *godantic.WSConfig
+	authenticate Authenticator
+	resolveUser  UserResolver
+	upgrader     websocket.Upgrader
+	hub          *godantic.SessionHub
+	memory       godantic.MemoryManager
+	serveSwagger bool
+}
+
+// New creates a server for config. Without a user resolver every request is anonymous
+// and uses the default tenant.
+func New(config *godantic.WSConfig) *Server {
+	return &Server{
+		config:       config,
+		hub:          godantic.NewSessionHub(),
+		serveSwagger: true,
+	}
+}
+
+// WithAuthenticator sets the hook that rejects unauthenticated requests
+func (s *Server) WithAuthenticator(authenticate Authenticator) *Server {
+	s.authenticate = authenticate
+	return s
+}
+
+// WithUserResolver sets the hook that resolves the caller's user and tenant.
+// Browsers cannot set headers on WebSocket upgrades, so resolvers usually also
+// accept a token in the query string.
+func (s *Server) WithUserResolver(resolve UserResolver) *Server {
+	s.resolveUser = resolve
+	return s
+}
+
+// WithUpgrader sets the WebSocket upgrader, e.g. to allow cross-origin connections with CheckOrigin.
+// The default only accepts connections from the same origin.
+func (s *Server) WithUpgrader(upgrader websocket.Upgrader) *Server {
+	s.upgrader = upgrader
+	return s
+}
+
+// WithSessionHub shares a session hub with other handlers, so their connections join the same conversations
+func (s *Server) WithSessionHub(hub *godantic.SessionHub) *Server {
+	s.hub = hub
+	return s
+}
+
+// WithMemory sets the long-term memory used by WebSocket sessions (scoped to each caller's tenant)
+func (s *Server) WithMemory(memory godantic.MemoryManager) *Server {
+	s.memory = memory
+	return s
+}
+
+// WithoutSwagger stops Mount from serving the OpenAPI document at /swagger/doc.json
+func (s *Server) WithoutSwagger() *Server {
+	s.serveSwagger = false
+	return s
+}
+
+// Mount registers the endpoints on r. Every endpoint except the OpenAPI document runs
+// the authenticator and user resolver first.
+func (s *Server) Mount(r gin.IRouter) {
+	if s.serveSwagger {
+		r.GET("/swagger/doc.json", s.SwaggerDoc)
+	}
+
+	api := r.Group("", s.identify)
+	api.POST("/chat/:conversationID", s.Chat)
+	api.POST("/chat/stream/:conversationID", s.ChatStream)
+	api.GET("/chat/stream/:conversationID", s.ResumeChatStream)
+	api.GET("/chat/history/:conversationID", s.ChatHistory)
+	api.GET("/chat/ws/:session_id", s.WebSocket)
+
+	api.GET("/conversations", s.ListConversations)
+	api.POST("/conversations", s.CreateConversation)
+	api.GET("/conversations/:conversationID", s.GetConversation)
+	api.PATCH("/conversations/:conversationID", s.RenameConversation)
+	api.DELETE("/conversations/:conversationID", s.DeleteConversation)
+	api.GET("/conversations/:conversationID/traces", s.ListTraces)
+}
+
+// SwaggerDoc serves the OpenAPI document generated from these handlers
+func (s *Server) SwaggerDoc(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(docs.SwaggerInfo.ReadDoc()))
+}
+
+// IdentityFrom returns the caller resolved for the request
+func IdentityFrom(c *gin.Context) Identity {
+	if identity, ok := c.Get(identityKey); ok {
+		return identity.(Identity)
+	}
+	return Identity{}
+}
+
+// identify runs the auth hooks and stores the caller's identity on the context
+func (s *Server) identify(c *gin.Context) {
+	if herr := resolveIdentity(c, s.authenticate, s.resolveUser); herr != nil {
+		abortWithError(c, herr.Code, herr.Message)
+		return
+	}
+	c.Next()
+}
+
+// resolveIdentity runs the auth hooks (either may be nil) and stores the caller's identity on the context
+func resolveIdentity(c *gin.Context, authenticate Authenticator, resolveUser UserResolver) *HTTPError {
+	if authenticate != nil {
+		if err := authenticate(c); err != nil {
+			return &HTTPError{Code: http.StatusUnauthorized, Message: err.Error()}
+		}
+	}
+
+	var identity Identity
+	if resolveUser != nil {
+		resolved, err := resolveUser(c)
+		if err != nil {
+			return &HTTPError{Code: http.StatusUnauthorized, Message: err.Error()}
+		}
+		identity = resolved
+	}
+	c.Set(identityKey, identity)
+	return nil
+}
+
+// configFor returns the configuration for the caller's tenant, or writes an error and returns nil
+func (s *Server) configFor(c *gin.Context) *godantic.WSConfig {
+	config, err := s.config.ForTenant(IdentityFrom(c).TenantID)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	if config.Store == nil {
+		abortWithError(c, http.StatusInternalServerError, "no message store configured")
+		return nil
+	}
+	return config
+}
+
+// newAgent builds an agent and its tools from a resolved configuration
+func newAgent(config *godantic.WSConfig) (*godantic.Agent, error) {
+	tools, err := godantic.Create_Tools(config.Tools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tools: %w", err)
+	}
+	agent := godantic.Create_Agent_From_Config(config, tools)
+	return &agent, nil
+}
+
+// authorizeConversation checks the caller may use conversationID (see checkConversation).
+// It returns false after writing an error response.
+func authorizeConversation(c *gin.Context, store stores.MessageStore, conversationID string, create bool) bool {
+	if herr := checkConversation(c, store, conversationID, create); herr != nil {
+		abortWithError(c, herr.Code, herr.Message)
+		return false
+	}
+	return true
+}
+
+// checkConversation checks the caller may use conversationID. Conversations owned by another
+// user are reported as not found, as are ownerless ones unless the caller is anonymous too.
+// When create is set a missing conversation is created for the caller. Stores that cannot
+// look up conversations are not checked.
+func checkConversation(c *gin.Context, store stores.MessageStore, conversationID string, create bool) *HTTPError {
+	manager, ok := store.(stores.ConversationManager)
+	if !ok {
+		return nil
+	}
+
+	userID := IdentityFrom(c).UserID
+	info, err := manager.GetConversation(conversationID)
+	switch {
+	case errors.Is(err, stores.ErrConversationNotFound) && create:
+		if err := store.CreateConversation(conversationID, userID); err != nil {
+			return &HTTPError{Code: http.StatusInternalServerError, Message: fmt.Sprintf("failed to create conversation: %v", err)}
+		}
+	case errors.Is(err, stores.ErrConversationNotFound):
+		return &HTTPError{Code: http.StatusNotFound, Message: "conversation not found"}
+	case err != nil:
+		return &HTTPError{Code: http.StatusInternalServerError, Message: err.Error()}
+	case info.UserID != userID:
+		return &HTTPError{Code: http.StatusNotFound, Message: "conversation not found"}
+	}
+	return nil
+}
+
+func abortWithError(c *gin.Context, code int, message string) {
+	c.AbortWithStatusJSON(code, HTTPError{Code: code, Message: message})
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/stores"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestServer(t *testing.T) (*gin.Engine, *stores.SQLiteStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	srv := New(&godantic.WSConfig{Store: store}).
+		WithUserResolver(func(c *gin.Context) (Identity, error) {
+			user := c.GetHeader("X-User")
+			if user == "" {
+				return Identity{}, errors.New("missing user")
+			}
+			return Identity{UserID: user, TenantID: c.GetHeader("X-Tenant")}, nil
+		})
+	router := gin.New()
+	srv.Mount(router.Group("/api"))
+	return router, store
+}
+
+func do(router *gin.Engine, method, path, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_ConversationCRUD(t *testing.T) {
+	router, _ := newTestServer(t)
+
+	if w := do(router, http.MethodGet, "/api/conversations", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without a user, got %d", w.Code)
+	}
+
+	w := do(router, http.MethodPost, "/api/conversations", "alice", `{"conversation_id":"conv-1","title":"Trip"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create: expected 201, got %d: %s", w.Code, w.Body)
+	}
+	if w := do(router, http.MethodPost, "/api/conversations", "alice", `{"conversation_id":"conv-1"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 creating a duplicate, got %d", w.Code)
+	}
+
+	if w := do(router, http.MethodPatch, "/api/conversations/conv-1", "alice", `{"title":"Paris trip"}`); w.Code != http.StatusOK {
+		t.Fatalf("Rename: expected 200, got %d: %s", w.Code, w.Body)
+	}
+
+	var list []stores.ConversationInfo
+	w = do(router, http.MethodGet, "/api/conversations", "alice", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("List: %v (%s)", err, w.Body)
+	}
+	if len(list) != 1 || list[0].Title != "Paris trip" || list[0].UserID != "alice" {
+		t.Errorf("Unexpected conversations %+v", list)
+	}
+
+	if w := do(router, http.MethodDelete, "/api/conversations/conv-1", "alice", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Delete: expected 204, got %d: %s", w.Code, w.Body)
+	}
+	if w := do(router, http.MethodGet, "/api/conversations/conv-1", "alice", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestServer_OtherUsersConversationsAreHidden(t *testing.T) {
+	router, store := newTestServer(t)
+	if err := store.SaveMessageWithUser("conv-1", "alice", "user", "user_message", []map[string]string{{"text": "hi"}}, ""); err != nil {
+		t.Fatalf("SaveMessageWithUser: %v", err)
+	}
+
+	if w := do(router, http.MethodGet, "/api/chat/history/conv-1", "alice", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected owner to read history, got %d: %s", w.Code, w.Body)
+	}
+	for _, path := range []string{"/api/chat/history/conv-1", "/api/conversations/conv-1", "/api/conversations/conv-1/traces"} {
+		if w := do(router, http.MethodGet, path, "bob", ""); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404 for another user, got %d", path, w.Code)
+		}
+	}
+	if w := do(router, http.MethodDelete, "/api/conversations/conv-1", "bob", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting another user's conversation, got %d", w.Code)
+	}
+}
+
+func TestServer_OwnerlessConversationsNeedAnAnonymousCaller(t *testing.T) {
+	router, store := newTestServer(t)
+	if err := store.CreateConversation("conv-legacy", ""); err != nil {
+		t.Fatalf("CreateConversation: %v", err)
+	}
+
+	for _, path := range []string{"/api/chat/history/conv-legacy", "/api/conversations/conv-legacy"} {
+		if w := do(router, http.MethodGet, path, "alice", ""); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404 for an ownerless conversation, got %d", path, w.Code)
+		}
+	}
+	if w := do(router, http.MethodDelete, "/api/conversations/conv-legacy", "alice", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting an ownerless conversation, got %d", w.Code)
+	}
+	if w := do(router, http.MethodPost, "/api/chat/conv-legacy", "alice", `{"message":{"role":"user","content":{"parts":[{"text":"hi"}]}}}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 chatting in an ownerless conversation, got %d", w.Code)
+	}
+}
+
+func TestServer_SwaggerDocIsPublic(t *testing.T) {
+	router, _ := newTestServer(t)
+
+	w := do(router, http.MethodGet, "/api/swagger/doc.json", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var doc struct {
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid swagger document: %v", err)
+	}
+	if _, ok := doc.Paths["/conversations/{conversationID}"]; !ok {
+		t.Errorf("Expected conversation endpoints in the swagger document")
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Desarso/godantic"
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket upgrades the request and runs an interactive session on the connection
+//
+// @Summary		WebSocket Chat
+// @Description	Upgrade to WebSocket for interactive AI chat with streaming and tool confirmation. The message types are described by schemas/protocol/websocket.schema.json. Connections to the same session ID share its events and turns.
+// @Tags			Chat
+// @Security		BearerAuth
+// @Param			session_id	path		string		true	"Session (conversation) ID"
+// @Success		101			{string}	string		"Switching Protocols"
+// @Failure		401			{object}	HTTPError	"Not authenticated"
+// @Failure		404			{object}	HTTPError	"Conversation belongs to another user"
+// @Router			/chat/ws/{session_id} [get]
+func (s *Server) WebSocket(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	identity := IdentityFrom(c)
+
+	config := s.configFor(c)
+	if config == nil {
+		return
+	}
+	if !authorizeConversation(c, config.Store, sessionID, true) {
+		return
+	}
+	agent, err := newAgent(config)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	session := godantic.NewAgentSession(sessionID, identity.UserID, conn, agent, config.Store, s.memory)
+	session.SetLogger(config.Logger)
+	session.SetTraceStore(config.TraceStore)
+	if err := session.SetTenant(config.TenantID); err != nil {
+		session.Logger.Error("Failed to scope session to tenant", logging.KeyTenantID, config.TenantID, logging.KeyError, err)
+		return
+	}
+	detach, err := s.hub.Attach(session)
+	if err != nil {
+		session.Logger.Error("Failed to attach session", logging.KeyError, err)
+		return
+	}
+	defer detach()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				session.Logger.Warn("WebSocket closed unexpectedly", logging.KeyError, err)
+			}
+			return
+		}
+		if handled, _ := session.HandleHelloMessage(data); handled {
+			continue
+		}
+		if handled, _ := session.HandleResumeMessage(data); handled {
+			continue
+		}
+		if handled, _ := session.HandleFeedbackMessage(data); handled {
+			continue
+		}
+		if handled, _ := session.HandleMemoryMessage(data); handled {
+			continue
+		}
+		if handled, _ := session.HandleUserInputMessage(data); handled {
+			continue
+		}
+
+		var req models.Model_Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			session.Logger.Warn("Invalid request", logging.KeyError, err)
+			continue
+		}
+
+		// Turns run one at a time in the background, in the order they arrived, so this loop
+		// keeps reading tool results and steering input
+		session.EnqueueInteraction(req, func(err error) {
+			if err == nil {
+				return
+			}
+			if agentErr, ok := err.(*godantic.AgentError); ok && agentErr.Fatal {
+				session.Logger.Error("Turn failed, closing connection", logging.KeyError, err)
+				conn.Close()
+				return
+			}
+			session.Logger.Warn("Turn failed", logging.KeyError, err)
+		})
+	}
+}
@@ -3,23 +3,28 @@ package sessions
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
+	"github.com/Desarso/godantic/logging"
 	"github.com/Desarso/godantic/stores"
 	"github.com/gorilla/websocket"
 )
 
 // NewAgentSession creates a new WebSocket agent session
 func NewAgentSession(sessionID string, userID string, conn *websocket.Conn, agent AgentInterface, store stores.MessageStore, memory MemoryManager) *AgentSession {
-	logger := log.New(os.Stdout, fmt.Sprintf("[WS %s] ", sessionID), log.LstdFlags)
+	logger := sessionLogger(nil, sessionID, userID, "")
 	writer := &WebSocketWriter{
 		Conn:      conn,
 		Logger:    logger,
 		StartTime: time.Now(),
 	}
 
+	// Memory managers that support it only see this user's memories
+	if scoped, ok := memory.(UserScopedMemory); ok && userID != "" {
+		memory = scoped.ForUser(userID)
+	}
+
 	ttsConnCtx, ttsConnCancel := context.WithCancel(context.Background())
 	return &AgentSession{
 		Agent:          agent,
@@ -30,6 +35,8 @@ func NewAgentSession(sessionID string, userID string, conn *websocket.Conn, agen
 		Logger:         logger,
 		ResponseWaiter: NewResponseWaiter(),
 		Memory:         memory,
+		inputs:         newTurnInputs(),
+		conn:           conn,
 		ttsConnCtx:     ttsConnCtx,
 		ttsConnCancel:  ttsConnCancel,
 	}
@@ -37,7 +44,7 @@ func NewAgentSession(sessionID string, userID string, conn *websocket.Conn, agen
 
 // NewHTTPSession creates a new HTTP session
 func NewHTTPSession(conversationID string, agent AgentInterface, store stores.MessageStore) *HTTPSession {
-	logger := log.New(os.Stdout, fmt.Sprintf("[HTTP %s] ", conversationID), log.LstdFlags)
+	logger := sessionLogger(nil, conversationID, "", "")
 
 	return &HTTPSession{
 		Agent:          agent,
@@ -50,5 +57,81 @@ func NewHTTPSession(conversationID string, agent AgentInterface, store stores.Me
 // SetTraceStore sets the trace store for execution trace persistence
 // This allows traces to be saved to the database in addition to being sent over WebSocket
 func (as *AgentSession) SetTraceStore(traceStore stores.TraceStore) {
+	if scoped, err := stores.ScopeTraceStoreToTenant(traceStore, as.TenantID); err != nil {
+		as.Logger.Warn("Trace persistence disabled", logging.KeyError, err)
+		as.TraceStore = nil
+	} else {
+		as.TraceStore = scoped
+	}
+}
+
+// SetTenant scopes the session to a tenant.
+// The message store, trace store and memory are narrowed so that the session can
+// only read and write data belonging to tenantID. Memory managers that don't implement
+// TenantScopedMemory are left unscoped and a warning is logged.
+func (as *AgentSession) SetTenant(tenantID string) error {
+	store, err := stores.ScopeStoreToTenant(as.Store, tenantID)
+	if err != nil {
+		return err
+	}
+	traceStore, err := stores.ScopeTraceStoreToTenant(as.TraceStore, tenantID)
+	if err != nil {
+		return err
+	}
+	memory := as.Memory
+	if memory != nil && tenantID != "" {
+		if scoped, ok := memory.(TenantScopedMemory); ok {
+			memory = scoped.ForTenant(tenantID)
+		} else {
+			as.Logger.Warn("Memory manager does not support tenant scoping; memories are shared across tenants",
+				"memory_type", fmt.Sprintf("%T", memory))
+		}
+	}
+
+	as.TenantID = tenantID
+	as.Store = store
 	as.TraceStore = traceStore
+	as.Memory = memory
+	as.SetLogger(as.baseLogger)
+	return nil
+}
+
+// SetTenant scopes the HTTP session's message store to a tenant
+func (s *HTTPSession) SetTenant(tenantID string) error {
+	store, err := stores.ScopeStoreToTenant(s.Store, tenantID)
+	if err != nil {
+		return err
+	}
+	s.TenantID = tenantID
+	s.Store = store
+	s.SetLogger(s.baseLogger)
+	return nil
+}
+
+// SetLogger sets the logger the session writes to; nil uses logging.Default().
+// Records carry the session, user and tenant IDs.
+func (as *AgentSession) SetLogger(logger *slog.Logger) {
+	as.baseLogger = logger
+	as.Logger = sessionLogger(logger, as.SessionID, as.UserID, as.TenantID)
+	if as.Writer != nil {
+		as.Writer.Logger = as.Logger
+	}
+}
+
+// SetLogger sets the logger the HTTP session writes to; nil uses logging.Default()
+func (s *HTTPSession) SetLogger(logger *slog.Logger) {
+	s.baseLogger = logger
+	s.Logger = sessionLogger(logger, s.ConversationID, "", s.TenantID)
+}
+
+// sessionLogger scopes logger to a session
+func sessionLogger(logger *slog.Logger, sessionID, userID, tenantID string) *slog.Logger {
+	attrs := []any{logging.KeySessionID, sessionID}
+	if userID != "" {
+		attrs = append(attrs, logging.KeyUserID, userID)
+	}
+	if tenantID != "" {
+		attrs = append(attrs, logging.KeyTenantID, tenantID)
+	}
+	return logging.Redact(logging.Or(logger)).With(attrs...)
 }
@@ -0,0 +1,74 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Desarso/godantic/stores"
+)
+
+// WebSocketFeedbackMessage is sent by the client to rate a message in the current conversation.
+// Sequence identifies the rated message (as returned in chat history).
+type WebSocketFeedbackMessage struct {
+	Type     string   `json:"type"` // "feedback"
+	Sequence int      `json:"sequence"`
+	Rating   int      `json:"rating"` // stores.RatingPositive, stores.RatingNegative, ...
+	Comment  string   `json:"comment,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// WebSocketFeedbackSavedMessage acknowledges a saved feedback message
+type WebSocketFeedbackSavedMessage struct {
+	Type     string `json:"type"` // "feedback_saved"
+	Sequence int    `json:"sequence"`
+	Rating   int    `json:"rating"`
+}
+
+// feedbackStore returns the session's feedback store, falling back to the message store
+// when it also implements stores.FeedbackStore
+func (as *AgentSession) feedbackStore() stores.FeedbackStore {
+	if as.FeedbackStore != nil {
+		return as.FeedbackStore
+	}
+	if fs, ok := as.Store.(stores.FeedbackStore); ok {
+		return fs
+	}
+	return nil
+}
+
+// HandleFeedbackMessage saves feedback if data is a "feedback" WebSocket message.
+// It returns handled=false for any other message type so the caller can fall through
+// to RunInteraction. Errors are reported to the client and are never fatal.
+func (as *AgentSession) HandleFeedbackMessage(data []byte) (bool, error) {
+	var msg WebSocketFeedbackMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "feedback" {
+		return false, nil
+	}
+
+	fs := as.feedbackStore()
+	if fs == nil {
+		return true, as.sendError("feedback is not supported by this session's store", false)
+	}
+
+	feedback := &stores.MessageFeedback{
+		ConversationID: as.SessionID,
+		Sequence:       msg.Sequence,
+		UserID:         as.UserID,
+		Rating:         msg.Rating,
+		Comment:        msg.Comment,
+		Tags:           msg.Tags,
+	}
+	if err := fs.SaveFeedback(feedback); err != nil {
+		if errors.Is(err, stores.ErrMessageNotFound) {
+			return true, as.sendError(fmt.Sprintf("no message with sequence %d in this conversation", msg.Sequence), false)
+		}
+		return true, as.sendError(fmt.Sprintf("failed to save feedback: %v", err), false)
+	}
+
+	return true, as.Writer.WriteResponse(WebSocketFeedbackSavedMessage{
+		Type:     EventFeedbackSaved,
+		Sequence: msg.Sequence,
+		Rating:   msg.Rating,
+	})
+}
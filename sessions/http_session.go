@@ -4,15 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Desarso/godantic/logging"
 	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/telemetry"
 )
 
 // RunSingleInteraction handles a complete request-response cycle (legacy method)
-func (s *HTTPSession) RunSingleInteraction(userMessage models.User_Message) (models.Model_Response, error) {
+func (s *HTTPSession) RunSingleInteraction(userMessage models.User_Message) (_ models.Model_Response, err error) {
+	ctx, turn := telemetry.StartTurn(context.Background(), s.ConversationID, "")
+	defer func() { turn.End(err) }()
+
 	// Save user message
 	if err := s.saveUserMessage(userMessage); err != nil {
-		s.Logger.Printf("Error saving user message: %v", err)
+		s.Logger.Error("Failed to save user message", logging.KeyError, err)
 	}
 
 	// Get history and run agent
@@ -22,14 +30,17 @@ func (s *HTTPSession) RunSingleInteraction(userMessage models.User_Message) (mod
 	}
 
 	req := models.Model_Request{User_Message: &userMessage}
+	metrics := newResponseMetrics(ctx, s.Agent, false)
 	response, err := s.Agent.Run(req, history)
 	if err != nil {
+		metrics.metadata(err)
 		return models.Model_Response{}, fmt.Errorf("agent error: %w", err)
 	}
+	metrics.observe(response)
 
 	// Save model response and handle auto-approved tools
-	if err := s.processAndSaveResponse(response); err != nil {
-		s.Logger.Printf("Error processing response: %v", err)
+	if err := s.processAndSaveResponse(ctx, response, metrics.metadata(nil)); err != nil {
+		s.Logger.Error("Failed to process response", logging.KeyError, err)
 	}
 
 	return response, nil
@@ -44,20 +55,26 @@ func (s *HTTPSession) RunStreamInteraction(userMessage models.User_Message) (<-c
 		defer close(respChan)
 		defer close(errChan)
 
+		var err error
+		ctx, turn := telemetry.StartTurn(context.Background(), s.ConversationID, "")
+		defer func() { turn.End(err) }()
+
 		// Save user message
 		if err := s.saveUserMessage(userMessage); err != nil {
-			s.Logger.Printf("Error saving user message: %v", err)
+			s.Logger.Error("Failed to save user message", logging.KeyError, err)
 		}
 
 		// Get history and run agent stream
 		history, err := s.Store.FetchHistory(s.ConversationID, 0)
 		if err != nil {
-			errChan <- fmt.Errorf("failed to fetch history: %w", err)
+			err = fmt.Errorf("failed to fetch history: %w", err)
+			errChan <- err
 			return
 		}
 
 		req := models.Model_Request{User_Message: &userMessage}
-		agentRespChan, agentErrChan := s.Agent.Run_Stream(req, history)
+		metrics := newResponseMetrics(ctx, s.Agent, true)
+		agentRespChan, agentErrChan := runStream(ctx, s.Agent, req, history)
 
 		var accumulatedParts []models.Model_Part
 
@@ -67,19 +84,26 @@ func (s *HTTPSession) RunStreamInteraction(userMessage models.User_Message) (<-c
 			case response, ok := <-agentRespChan:
 				if !ok {
 					// Stream finished, save accumulated response
+					metadata := metrics.metadata(nil)
 					if len(accumulatedParts) > 0 {
 						finalResponse := models.Model_Response{Parts: accumulatedParts}
-						if err := s.processAndSaveResponse(finalResponse); err != nil {
-							s.Logger.Printf("Error saving final response: %v", err)
+						if err := s.processAndSaveResponse(ctx, finalResponse, metadata); err != nil {
+							s.Logger.Error("Failed to save final response", logging.KeyError, err)
 						}
 					}
 					return
 				}
+				metrics.observe(response)
+				if isMetadataOnly(response) {
+					continue
+				}
 				accumulatedParts = append(accumulatedParts, response.Parts...)
 				respChan <- response
 
-			case err, ok := <-agentErrChan:
-				if ok && err != nil {
+			case streamErr, ok := <-agentErrChan:
+				if ok && streamErr != nil {
+					err = streamErr
+					s.savePartialResponse(accumulatedParts, metrics.metadata(err))
 					errChan <- err
 					return
 				}
@@ -90,10 +114,11 @@ func (s *HTTPSession) RunStreamInteraction(userMessage models.User_Message) (<-c
 
 			if agentRespChan == nil && agentErrChan == nil {
 				// Both channels closed, save accumulated response
+				metadata := metrics.metadata(nil)
 				if len(accumulatedParts) > 0 {
 					finalResponse := models.Model_Response{Parts: accumulatedParts}
-					if err := s.processAndSaveResponse(finalResponse); err != nil {
-						s.Logger.Printf("Error saving final response: %v", err)
+					if err := s.processAndSaveResponse(ctx, finalResponse, metadata); err != nil {
+						s.Logger.Error("Failed to save final response", logging.KeyError, err)
 					}
 				}
 				return
@@ -105,37 +130,39 @@ func (s *HTTPSession) RunStreamInteraction(userMessage models.User_Message) (<-c
 }
 
 // RunSingleInteractionWithRequest handles a complete request-response cycle with Model_Request format
-func (s *HTTPSession) RunSingleInteractionWithRequest(request models.Model_Request) (models.Model_Response, error) {
+func (s *HTTPSession) RunSingleInteractionWithRequest(request models.Model_Request) (_ models.Model_Response, err error) {
 	// Validate request has either user message or tool results
 	if request.User_Message == nil && request.Tool_Results == nil {
 		return models.Model_Response{}, fmt.Errorf("request must contain either user message or tool results")
 	}
 
+	ctx, turn := telemetry.StartTurn(context.Background(), s.ConversationID, "")
+	defer func() { turn.End(err) }()
+
 	currentReq := request
 	var finalResponse models.Model_Response
 	iteration := 0
 
 	for {
 		iteration++
-		s.Logger.Printf("=== HTTP Iteration %d ===", iteration)
+		logger := s.Logger.With("iteration", iteration)
 
 		// Save user message if present (only on first iteration)
 		if currentReq.User_Message != nil {
-			s.Logger.Printf("Processing user message with %d parts", len(currentReq.User_Message.Content.Parts))
+			logger.Debug("Processing user message", "parts", len(currentReq.User_Message.Content.Parts))
 			if err := s.saveUserMessage(*currentReq.User_Message); err != nil {
-				s.Logger.Printf("Error saving user message: %v", err)
+				logger.Error("Failed to save user message", logging.KeyError, err)
 			}
 		}
 
 		// Save tool results if present - Tool results should be saved when fed back as next request
 		if currentReq.Tool_Results != nil {
-			s.Logger.Printf("Processing tool results: %d tools", len(*currentReq.Tool_Results))
 			for _, tr := range *currentReq.Tool_Results {
-				s.Logger.Printf("  Tool: %s, Output length: %d", tr.Tool_Name, len(tr.Tool_Output))
+				logger.Debug("Processing tool result", logging.KeyTool, tr.Tool_Name, "output_length", len(tr.Tool_Output))
 			}
 			// TEMPORARILY COMMENTED OUT to test if tool results are saved elsewhere:
 			// if err := s.saveToolResults(*currentReq.Tool_Results); err != nil {
-			// 	s.Logger.Printf("Error saving tool results: %v", err)
+			// 	s.Logger.Error("Failed to save tool results", logging.KeyError, err)
 			// }
 		}
 
@@ -144,62 +171,58 @@ func (s *HTTPSession) RunSingleInteractionWithRequest(request models.Model_Reque
 		if err != nil {
 			return models.Model_Response{}, fmt.Errorf("failed to fetch history: %w", err)
 		}
-		s.Logger.Printf("Retrieved %d messages from history", len(history))
-
-		s.Logger.Printf("Calling agent.Run...")
+		logger.Debug("Running agent", "history_messages", len(history))
+		metrics := newResponseMetrics(ctx, s.Agent, false)
 		response, err := s.Agent.Run(currentReq, history)
 		if err != nil {
-			s.Logger.Printf("Agent error: %v", err)
+			metrics.metadata(err)
+			logger.Error("Agent run failed", logging.KeyError, err)
 			return models.Model_Response{}, fmt.Errorf("agent error: %w", err)
 		}
-		s.Logger.Printf("Agent returned %d parts", len(response.Parts))
+		metrics.observe(response)
+		metadata := metrics.metadata(nil)
 		for i, part := range response.Parts {
 			if part.FunctionCall != nil {
-				s.Logger.Printf("  Part %d: FunctionCall - %s", i, part.FunctionCall.Name)
+				logger.Debug("Agent returned function call", "part", i, logging.KeyTool, part.FunctionCall.Name)
 			} else if part.Text != nil {
-				s.Logger.Printf("  Part %d: Text - '%s'", i, *part.Text)
-			} else {
-				s.Logger.Printf("  Part %d: Empty/Other", i)
+				logger.Debug("Agent returned text", "part", i, "text_length", len(*part.Text))
 			}
 		}
 
 		// Process response for tool execution and extract text
-		toolResults, executed, finalText, err := s.processResponseForToolsAndText(response)
+		toolResults, executed, finalText, err := s.processResponseForToolsAndText(ctx, response, metadata)
 		if err != nil {
 			return models.Model_Response{}, fmt.Errorf("error processing tools: %w", err)
 		}
-		s.Logger.Printf("Tool processing: executed=%v, results=%d, finalText='%s'", executed, len(toolResults), finalText)
+		logger.Debug("Processed tool calls", "executed", executed, "results", len(toolResults), "text_length", len(finalText))
 
 		if !executed {
 			// No tools executed, this is the final response
-			s.Logger.Printf("No tools executed - final response")
 			if finalText != "" {
 				// Create a text response
-				s.Logger.Printf("Creating text response with: '%s'", finalText)
 				textPart := models.Model_Part{Text: &finalText}
 				finalResponse = models.Model_Response{Parts: []models.Model_Part{textPart}}
 
 				// Save the final text response
-				if err := s.Store.SaveMessage(s.ConversationID, "model", "model_message", []models.Model_Part{textPart}, ""); err != nil {
-					s.Logger.Printf("Error saving final text message: %v", err)
+				if err := saveModelMessage(s.Store, s.ConversationID, "", "model_message", []models.Model_Part{textPart}, "", metadata); err != nil {
+					logger.Error("Failed to save final text message", logging.KeyError, err)
 				}
 			} else {
 				// No text and no tools - return the original response
-				s.Logger.Printf("No final text, returning original response with %d parts", len(response.Parts))
 				finalResponse = response
 			}
 			break
 		}
 
 		// Prepare for next iteration with tool results
-		s.Logger.Printf("Preparing next iteration with %d tool results", len(toolResults))
 		currentReq = models.Model_Request{
 			User_Message: nil,
 			Tool_Results: &toolResults,
+			Client:       currentReq.Client,
 		}
 	}
 
-	s.Logger.Printf("Final response has %d parts", len(finalResponse.Parts))
+	s.Logger.Debug("Interaction finished", "iterations", iteration, "parts", len(finalResponse.Parts))
 	return finalResponse, nil
 }
 
@@ -212,129 +235,153 @@ func (s *HTTPSession) RunStreamInteractionWithRequest(request models.Model_Reque
 		defer close(respChan)
 		defer close(errChan)
 
-		// Validate request has either user message or tool results
-		if request.User_Message == nil && request.Tool_Results == nil {
-			errChan <- fmt.Errorf("request must contain either user message or tool results")
-			return
+		err := s.streamWithRequest(context.Background(), request, func(event string, payload interface{}) {
+			if response, ok := payload.(models.Model_Response); ok && event == SSEEventDelta {
+				respChan <- response
+			}
+		})
+		if err != nil {
+			errChan <- err
 		}
+	}()
 
-		currentReq := request
-		var allParts []models.Model_Part
+	return respChan, errChan
+}
 
-		for {
-			// Save user message if present (only on first iteration)
-			if currentReq.User_Message != nil {
-				if err := s.saveUserMessage(*currentReq.User_Message); err != nil {
-					s.Logger.Printf("Error saving user message: %v", err)
-				}
-			}
+// streamEmitter receives the events of a streamed interaction: every model chunk as
+// SSEEventDelta, then SSEEventTrace and SSEEventToolResult events for executed tools
+type streamEmitter func(event string, payload interface{})
 
-			// Save tool results if present
-			if currentReq.Tool_Results != nil {
-				if err := s.saveToolResults(*currentReq.Tool_Results); err != nil {
-					s.Logger.Printf("Error saving tool results: %v", err)
-				}
+// streamWithRequest runs the streaming tool loop, passing events to emit. ctx only
+// parents the turn's telemetry span; the loop is not cancelled with it.
+func (s *HTTPSession) streamWithRequest(ctx context.Context, request models.Model_Request, emit streamEmitter) (err error) {
+	// Validate request has either user message or tool results
+	if request.User_Message == nil && request.Tool_Results == nil {
+		return fmt.Errorf("request must contain either user message or tool results")
+	}
+
+	ctx, turn := telemetry.StartTurn(ctx, s.ConversationID, "")
+	defer func() { turn.End(err) }()
+
+	currentReq := request
+	for {
+		// Save user message if present (only on first iteration)
+		if currentReq.User_Message != nil {
+			if err := s.saveUserMessage(*currentReq.User_Message); err != nil {
+				s.Logger.Error("Failed to save user message", logging.KeyError, err)
 			}
+		}
 
-			// Get history and run agent stream
-			history, err := s.Store.FetchHistory(s.ConversationID, 0)
-			if err != nil {
-				errChan <- fmt.Errorf("failed to fetch history: %w", err)
-				return
+		// Save tool results if present
+		if currentReq.Tool_Results != nil {
+			if err := s.saveToolResults(*currentReq.Tool_Results); err != nil {
+				s.Logger.Error("Failed to save tool results", logging.KeyError, err)
 			}
+		}
 
-			agentRespChan, agentErrChan := s.Agent.Run_Stream(currentReq, history)
+		// Get history and run agent stream
+		history, err := s.Store.FetchHistory(s.ConversationID, 0)
+		if err != nil {
+			return fmt.Errorf("failed to fetch history: %w", err)
+		}
 
-			var iterationParts []models.Model_Part
+		metrics := newResponseMetrics(ctx, s.Agent, true)
+		// Not cancelled with ctx: the turn keeps running for resumed streams
+		agentRespChan, agentErrChan := s.Agent.Run_Stream(currentReq, history)
 
-			// Forward stream responses and accumulate parts for this iteration
-			for {
-				select {
-				case response, ok := <-agentRespChan:
-					if !ok {
-						// Stream finished for this iteration
-						goto processIteration
-					}
-					iterationParts = append(iterationParts, response.Parts...)
-					allParts = append(allParts, response.Parts...)
-					respChan <- response
-
-				case err, ok := <-agentErrChan:
-					if ok && err != nil {
-						errChan <- err
-						return
-					}
-					if !ok {
-						agentErrChan = nil
-					}
-				}
+		var iterationParts []models.Model_Part
 
-				if agentRespChan == nil && agentErrChan == nil {
-					// Both channels closed
+		// Forward stream responses and accumulate parts for this iteration
+		for {
+			select {
+			case response, ok := <-agentRespChan:
+				if !ok {
+					// Stream finished for this iteration
 					goto processIteration
 				}
-			}
-
-		processIteration:
-			// Process this iteration's parts for tool execution
-			if len(iterationParts) > 0 {
-				iterationResponse := models.Model_Response{Parts: iterationParts}
-				toolResults, executed, err := s.processResponseForTools(iterationResponse)
-				if err != nil {
-					errChan <- fmt.Errorf("error processing tools: %w", err)
-					return
+				metrics.observe(response)
+				if isMetadataOnly(response) {
+					continue
 				}
+				iterationParts = append(iterationParts, response.Parts...)
+				emit(SSEEventDelta, response)
 
-				if !executed {
-					// No tools executed, interaction complete
-					break
+			case err, ok := <-agentErrChan:
+				if ok && err != nil {
+					s.savePartialResponse(iterationParts, metrics.metadata(err))
+					return err
 				}
-
-				// Prepare for next iteration with tool results
-				currentReq = models.Model_Request{
-					User_Message: nil,
-					Tool_Results: &toolResults,
+				if !ok {
+					agentErrChan = nil
 				}
-			} else {
-				// No parts in this iteration, break
-				break
+			}
+
+			if agentRespChan == nil && agentErrChan == nil {
+				// Both channels closed
+				goto processIteration
 			}
 		}
-	}()
 
-	return respChan, errChan
+	processIteration:
+		// End the model call span before any exit from this iteration
+		metadata := metrics.metadata(nil)
+
+		// Process this iteration's parts for tool execution
+		if len(iterationParts) == 0 {
+			// No parts in this iteration, interaction complete
+			return nil
+		}
+		iterationResponse := models.Model_Response{Parts: iterationParts}
+		toolResults, executed, err := s.processResponseForTools(ctx, iterationResponse, metadata, emit)
+		if err != nil {
+			return fmt.Errorf("error processing tools: %w", err)
+		}
+
+		if !executed {
+			// No tools executed, interaction complete
+			return nil
+		}
+
+		// Prepare for next iteration with tool results
+		currentReq = models.Model_Request{
+			User_Message: nil,
+			Tool_Results: &toolResults,
+			Client:       currentReq.Client,
+		}
+	}
 }
 
-// RunSSEInteraction handles complete SSE streaming interaction with context cancellation (legacy method)
+// RunSSEInteraction handles complete SSE streaming interaction with context cancellation (legacy method).
+// It makes one streaming call and writes each Model_Response as JSON through WriteSSE;
+// use RunSSEInteractionWithRequest for the tool loop and named, resumable events.
 func (s *HTTPSession) RunSSEInteraction(userMessage models.User_Message, writer SSEWriter, ctx context.Context) error {
-	// Run streaming interaction
 	respChan, errChan := s.RunStreamInteraction(userMessage)
 
 	for {
 		select {
 		case response, ok := <-respChan:
 			if !ok {
-				s.Logger.Printf("SSE stream finished.")
+				s.Logger.Debug("SSE stream finished")
 				return nil
 			}
 
 			jsonData, err := json.Marshal(response)
 			if err != nil {
-				s.Logger.Printf("Error marshalling response: %v", err)
+				s.Logger.Warn("Failed to marshal response", logging.KeyError, err)
 				continue
 			}
 
 			if err := writer.WriteSSE(string(jsonData)); err != nil {
-				s.Logger.Printf("Error writing to SSE stream: %v", err)
+				s.Logger.Warn("Failed to write to SSE stream", logging.KeyError, err)
 				return err
 			}
 			writer.Flush()
 
 		case err, ok := <-errChan:
 			if ok && err != nil {
-				s.Logger.Printf("SSE stream error: %v", err)
+				s.Logger.Warn("SSE stream failed", logging.KeyError, err)
 				if writeErr := writer.WriteSSEError(err); writeErr != nil {
-					s.Logger.Printf("Error writing SSE error: %v", writeErr)
+					s.Logger.Warn("Failed to write SSE error", logging.KeyError, writeErr)
 				}
 				writer.Flush()
 				return err
@@ -344,65 +391,46 @@ func (s *HTTPSession) RunSSEInteraction(userMessage models.User_Message, writer
 			}
 
 		case <-ctx.Done():
-			s.Logger.Printf("SSE client disconnected")
+			s.Logger.Debug("SSE client disconnected")
 			return ctx.Err()
 		}
 
 		if respChan == nil && errChan == nil {
-			s.Logger.Printf("Both SSE channels closed.")
 			return nil
 		}
 	}
 }
 
-// RunSSEInteractionWithRequest handles complete SSE streaming interaction with Model_Request format
+// RunSSEInteractionWithRequest handles complete SSE streaming interaction with Model_Request format.
+// Events are named (delta, tool_call, tool_result, trace, done, error) and numbered per conversation.
+// The interaction keeps running if the client disconnects; it can reconnect with ResumeSSE.
 func (s *HTTPSession) RunSSEInteractionWithRequest(request models.Model_Request, writer SSEWriter, ctx context.Context) error {
-	// Run streaming interaction with Model_Request using the updated method
-	respChan, errChan := s.RunStreamInteractionWithRequest(request)
-
-	for {
-		select {
-		case response, ok := <-respChan:
-			if !ok {
-				s.Logger.Printf("SSE stream finished.")
-				return nil
-			}
-
-			jsonData, err := json.Marshal(response)
-			if err != nil {
-				s.Logger.Printf("Error marshalling response: %v", err)
-				continue
-			}
-
-			if err := writer.WriteSSE(string(jsonData)); err != nil {
-				s.Logger.Printf("Error writing to SSE stream: %v", err)
-				return err
-			}
-			writer.Flush()
-
-		case err, ok := <-errChan:
-			if ok && err != nil {
-				s.Logger.Printf("SSE stream error: %v", err)
-				if writeErr := writer.WriteSSEError(err); writeErr != nil {
-					s.Logger.Printf("Error writing SSE error: %v", writeErr)
-				}
-				writer.Flush()
-				return err
-			}
-			if !ok {
-				errChan = nil
-			}
+	stream, cursor, err := openSSEStream(s.TenantID, s.ConversationID)
+	if err != nil {
+		return err
+	}
 
-		case <-ctx.Done():
-			s.Logger.Printf("SSE client disconnected")
-			return ctx.Err()
+	go func() {
+		err := s.streamWithRequest(ctx, request, stream.emit)
+		if err != nil {
+			s.Logger.Warn("SSE stream failed", logging.KeyError, err)
 		}
+		stream.finish(s.ConversationID, err)
+	}()
 
-		if respChan == nil && errChan == nil {
-			s.Logger.Printf("Both SSE channels closed.")
-			return nil
-		}
+	return stream.follow(ctx, cursor, writer, s.Logger)
+}
+
+// ResumeSSE replays the conversation's events after lastEventID (the Last-Event-ID header) and
+// follows the live stream until the interaction finishes. It returns ErrSSEStreamNotFound when
+// nothing is buffered for the conversation; respond with 204 No Content so EventSource stops reconnecting.
+func (s *HTTPSession) ResumeSSE(lastEventID string, writer SSEWriter, ctx context.Context) error {
+	stream := lookupSSEStream(s.TenantID, s.ConversationID)
+	if stream == nil {
+		return ErrSSEStreamNotFound
 	}
+	cursor, _ := strconv.ParseInt(strings.TrimSpace(lastEventID), 10, 64)
+	return stream.follow(ctx, cursor, writer, s.Logger)
 }
 
 // saveUserMessage saves user message to store
@@ -453,8 +481,19 @@ func (s *HTTPSession) saveToolResults(toolResults []models.Tool_Result) error {
 	return nil
 }
 
+// savePartialResponse saves the text streamed before an error so the message and its error metadata are kept
+func (s *HTTPSession) savePartialResponse(parts []models.Model_Part, metadata *models.MessageMetadata) {
+	partial := partialTextParts(parts)
+	if partial == nil {
+		return
+	}
+	if err := saveModelMessage(s.Store, s.ConversationID, "", "model_message", partial, "", metadata); err != nil {
+		s.Logger.Error("Failed to save partial response", logging.KeyError, err)
+	}
+}
+
 // processAndSaveResponse processes and saves model response, handling auto-approved tools
-func (s *HTTPSession) processAndSaveResponse(response models.Model_Response) error {
+func (s *HTTPSession) processAndSaveResponse(ctx context.Context, response models.Model_Response, metadata *models.MessageMetadata) error {
 	if len(response.Parts) == 0 {
 		return nil
 	}
@@ -479,20 +518,20 @@ func (s *HTTPSession) processAndSaveResponse(response models.Model_Response) err
 	}
 
 	// Save the model response
-	if err := s.Store.SaveMessage(s.ConversationID, "model", msgType, response.Parts, functionID); err != nil {
+	if err := saveModelMessage(s.Store, s.ConversationID, "", msgType, response.Parts, functionID, metadata); err != nil {
 		return fmt.Errorf("failed to save model response: %w", err)
 	}
 
 	// Handle auto-approved function calls
 	if foundFunctionCall {
 		if autoApproved, err := s.Agent.ApproveTool(firstFunctionName, firstFunctionArgs); err != nil {
-			s.Logger.Printf("Error checking tool approval: %v", err)
+			s.Logger.Error("Failed to check tool approval", logging.KeyTool, firstFunctionName, logging.KeyError, err)
 		} else if autoApproved {
-			s.Logger.Printf("Tool %s is auto-approved. Executing...", firstFunctionName)
+			s.Logger.Info("Executing auto-approved tool", logging.KeyTool, firstFunctionName)
 
-			toolResult, err := s.Agent.ExecuteTool(firstFunctionName, firstFunctionArgs, s.ConversationID)
+			toolResult, err := s.executeTool(ctx, firstFunctionName, firstFunctionArgs, functionID)
 			if err != nil {
-				s.Logger.Printf("Tool execution error: %v", err)
+				s.Logger.Error("Tool execution failed", logging.KeyTool, firstFunctionName, logging.KeyError, err)
 			}
 
 			// Save tool result
@@ -517,6 +556,14 @@ func (s *HTTPSession) processAndSaveResponse(response models.Model_Response) err
 	return nil
 }
 
+// executeTool runs a tool through the agent inside a telemetry span
+func (s *HTTPSession) executeTool(ctx context.Context, name string, args map[string]interface{}, callID string) (string, error) {
+	_, span := telemetry.StartToolCall(ctx, name, callID)
+	result, err := s.Agent.ExecuteTool(name, args, s.ConversationID)
+	span.End(err)
+	return result, err
+}
+
 // GetChatHistory retrieves and converts chat history to API response format
 func (s *HTTPSession) GetChatHistory() ([]models.ChatMessageResponse, error) {
 	// Get history from store
@@ -539,13 +586,14 @@ func (s *HTTPSession) GetChatHistory() ([]models.ChatMessageResponse, error) {
 			FunctionID:     msg.FunctionID,
 			Text:           "",
 			Parts:          nil,
+			Metadata:       decodeMessageMetadata(msg),
 		}
 
 		// Unmarshal PartsJSON and extract text content
 		if msg.PartsJSON != "" && msg.PartsJSON != "{}" && msg.PartsJSON != "null" {
 			var unmarshalledParts interface{}
 			if err := json.Unmarshal([]byte(msg.PartsJSON), &unmarshalledParts); err != nil {
-				s.Logger.Printf("Error unmarshalling PartsJSON for msg ID %d: %v", msg.ID, err)
+				s.Logger.Warn("Failed to unmarshal message parts", "message_id", msg.ID, logging.KeyError, err)
 			} else {
 				apiMsg.Parts = unmarshalledParts
 
@@ -579,7 +627,8 @@ func (s *HTTPSession) GetChatHistory() ([]models.ChatMessageResponse, error) {
 }
 
 // processResponseForTools processes model response for tool execution and returns tool results
-func (s *HTTPSession) processResponseForTools(response models.Model_Response) ([]models.Tool_Result, bool, error) {
+// Executed tools are reported to emit as trace and tool_result events.
+func (s *HTTPSession) processResponseForTools(ctx context.Context, response models.Model_Response, metadata *models.MessageMetadata, emit streamEmitter) ([]models.Tool_Result, bool, error) {
 	if len(response.Parts) == 0 {
 		return nil, false, nil
 	}
@@ -622,7 +671,7 @@ func (s *HTTPSession) processResponseForTools(response models.Model_Response) ([
 	}
 
 	// Save the model response first
-	if err := s.Store.SaveMessage(s.ConversationID, "model", msgType, response.Parts, functionID); err != nil {
+	if err := saveModelMessage(s.Store, s.ConversationID, "", msgType, response.Parts, functionID, metadata); err != nil {
 		return nil, false, fmt.Errorf("failed to save model response: %w", err)
 	}
 
@@ -630,16 +679,24 @@ func (s *HTTPSession) processResponseForTools(response models.Model_Response) ([
 	for _, fc := range functionCalls {
 		// Check approval and execute if auto-approved
 		if autoApproved, err := s.Agent.ApproveTool(fc.Name, fc.Args); err != nil {
-			s.Logger.Printf("Error checking tool approval for %s: %v", fc.Name, err)
+			s.Logger.Error("Failed to check tool approval", logging.KeyTool, fc.Name, logging.KeyError, err)
 			continue
 		} else if autoApproved {
-			s.Logger.Printf("Tool %s is auto-approved. Executing...", fc.Name)
+			s.Logger.Info("Executing auto-approved tool", logging.KeyTool, fc.Name)
+
+			startTime := time.Now()
+			traceID := fmt.Sprintf("tool_%s_%d", fc.ID, startTime.UnixMilli())
+			emit(SSEEventTrace, newToolTrace(fc.ID, traceID, fc.Name, "start", getToolStartLabel(fc.Name, fc.Args), startTime, nil))
 
-			toolResult, err := s.Agent.ExecuteTool(fc.Name, fc.Args, s.ConversationID)
+			toolResult, err := s.executeTool(ctx, fc.Name, fc.Args, fc.ID)
+			durationMs := time.Since(startTime).Milliseconds()
 			if err != nil {
-				s.Logger.Printf("Tool execution error for %s: %v", fc.Name, err)
+				s.Logger.Error("Tool execution failed", logging.KeyTool, fc.Name, logging.KeyError, err)
+				emit(SSEEventTrace, newToolTrace(fc.ID, traceID, fc.Name, "error", getToolErrorLabel(fc.Name, err), time.Now(), &durationMs))
 				continue
 			}
+			emit(SSEEventTrace, newToolTrace(fc.ID, traceID, fc.Name, "end", getToolEndLabel(fc.Name, fc.Args), time.Now(), &durationMs))
+			emit(SSEEventToolResult, newToolResultMessage(fc.ID, fc.Name, toolResult))
 
 			// Save tool result to database
 			var resultMap map[string]interface{}
@@ -656,7 +713,7 @@ func (s *HTTPSession) processResponseForTools(response models.Model_Response) ([
 			}
 
 			if err := s.Store.SaveMessage(s.ConversationID, "user", "function_response", []models.User_Part{toolResponsePart}, fc.ID); err != nil {
-				s.Logger.Printf("Failed to save tool result for %s: %v", fc.Name, err)
+				s.Logger.Error("Failed to save tool result", logging.KeyTool, fc.Name, logging.KeyError, err)
 			}
 
 			// Add to results for next iteration
@@ -673,7 +730,7 @@ func (s *HTTPSession) processResponseForTools(response models.Model_Response) ([
 }
 
 // processResponseForToolsAndText processes model response for tool execution and returns tool results and final text
-func (s *HTTPSession) processResponseForToolsAndText(response models.Model_Response) ([]models.Tool_Result, bool, string, error) {
+func (s *HTTPSession) processResponseForToolsAndText(ctx context.Context, response models.Model_Response, metadata *models.MessageMetadata) ([]models.Tool_Result, bool, string, error) {
 	if len(response.Parts) == 0 {
 		return nil, false, "", nil
 	}
@@ -717,7 +774,7 @@ func (s *HTTPSession) processResponseForToolsAndText(response models.Model_Respo
 	}
 
 	// Save the model response first
-	if err := s.Store.SaveMessage(s.ConversationID, "model", msgType, response.Parts, functionID); err != nil {
+	if err := saveModelMessage(s.Store, s.ConversationID, "", msgType, response.Parts, functionID, metadata); err != nil {
 		return nil, false, "", fmt.Errorf("failed to save model response: %w", err)
 	}
 
@@ -725,14 +782,14 @@ func (s *HTTPSession) processResponseForToolsAndText(response models.Model_Respo
 	for _, fc := range functionCalls {
 		// Check approval and execute if auto-approved
 		if autoApproved, err := s.Agent.ApproveTool(fc.Name, fc.Args); err != nil {
-			s.Logger.Printf("Error checking tool approval for %s: %v", fc.Name, err)
+			s.Logger.Error("Failed to check tool approval", logging.KeyTool, fc.Name, logging.KeyError, err)
 			continue
 		} else if autoApproved {
-			s.Logger.Printf("Tool %s is auto-approved. Executing...", fc.Name)
+			s.Logger.Info("Executing auto-approved tool", logging.KeyTool, fc.Name)
 
-			toolResult, err := s.Agent.ExecuteTool(fc.Name, fc.Args, s.ConversationID)
+			toolResult, err := s.executeTool(ctx, fc.Name, fc.Args, fc.ID)
 			if err != nil {
-				s.Logger.Printf("Tool execution error for %s: %v", fc.Name, err)
+				s.Logger.Error("Tool execution failed", logging.KeyTool, fc.Name, logging.KeyError, err)
 				continue
 			}
 
@@ -0,0 +1,38 @@
+package sessions
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHTTPSession_EndsModelSpanWithoutParts(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	store, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	defer store.Close()
+
+	// The model answers with usage only, so the iteration has no parts
+	agent := &scriptedAgent{responses: []models.Model_Response{{Usage: &models.Usage{InputTokens: 3}}}}
+	session := NewHTTPSession("http-empty", agent, store)
+	err = session.streamWithRequest(context.Background(), userRequest("hello"), func(string, interface{}) {})
+	if err != nil {
+		t.Fatalf("streamWithRequest: %v", err)
+	}
+
+	if started, ended := len(spans.Started()), len(spans.Ended()); started == 0 || started != ended {
+		t.Errorf("Expected every span to end, %d started and %d ended", started, ended)
+	}
+}
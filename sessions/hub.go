@@ -0,0 +1,102 @@
+package sessions
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// SessionHub lets several connections share one conversation, e.g. the same chat open on a
+// phone and a desktop. Every event is sent to all of them and their turns run one at a time.
+type SessionHub struct {
+	mu       sync.Mutex
+	sessions map[string]*hubSession
+}
+
+// hubSession is the session shared by the connections of a conversation
+type hubSession struct {
+	session *AgentSession
+	conns   int
+}
+
+// NewSessionHub creates an empty session hub
+func NewSessionHub() *SessionHub {
+	return &SessionHub{sessions: make(map[string]*hubSession)}
+}
+
+// Attach joins the connection of as to its conversation (keyed by tenant and session ID).
+// The first session attached is shared: later ones adopt its writer, waiters and input queue,
+// so they receive its events, can answer its tools and queue or steer its turns. A connection
+// that joins mid-turn can catch up with a "resume" message. Only the user who owns the shared
+// session may attach. Call detach when the connection closes.
+func (h *SessionHub) Attach(as *AgentSession) (detach func(), err error) {
+	conn := as.connection()
+	if conn == nil {
+		return nil, fmt.Errorf("session %s has no connection to attach", as.SessionID)
+	}
+	key := resumableKey(as.TenantID, as.SessionID)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	shared := h.sessions[key]
+	switch {
+	case shared == nil:
+		h.sessions[key] = &hubSession{session: as, conns: 1}
+	case shared.session.UserID != as.UserID:
+		return nil, fmt.Errorf("session %s is open for another user", as.SessionID)
+	default:
+		if shared.session.Writer != as.Writer {
+			version := as.Writer.ProtocolVersion(conn)
+			as.Writer.detach(conn)
+			as.share(shared.session)
+
+			as.Writer.mu.Lock()
+			as.Writer.addPeer(conn, version)
+			as.Writer.mu.Unlock()
+		}
+		shared.conns++
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { h.detach(key, as, conn) }) }, nil
+}
+
+func (h *SessionHub) detach(key string, as *AgentSession, conn *websocket.Conn) {
+	as.Writer.detach(conn)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if shared := h.sessions[key]; shared != nil {
+		if shared.conns--; shared.conns <= 0 {
+			delete(h.sessions, key)
+		}
+	}
+}
+
+// Connections returns the number of connections attached to a conversation
+func (h *SessionHub) Connections(tenantID, sessionID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if shared := h.sessions[resumableKey(tenantID, sessionID)]; shared != nil {
+		return shared.conns
+	}
+	return 0
+}
+
+// connection returns this session's own connection
+func (as *AgentSession) connection() *websocket.Conn {
+	if as.conn != nil {
+		return as.conn
+	}
+	return as.Writer.Conn
+}
+
+// share makes as send through live's writer and share its waiters and input queue
+func (as *AgentSession) share(live *AgentSession) {
+	as.conn = as.connection()
+	as.Writer = live.Writer
+	as.ResponseWaiter = live.ResponseWaiter
+	as.FrontendActionWaiter = live.FrontendActionWaiter
+	as.inputs = live.inputs
+}
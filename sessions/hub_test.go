@@ -0,0 +1,102 @@
+package sessions
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionHub_AttachDetach(t *testing.T) {
+	hub := NewSessionHub()
+	first, firstClient := newTestSession(t, "hub-attach")
+	second, secondClient := newTestSession(t, "hub-attach")
+
+	detachFirst, err := hub.Attach(first)
+	if err != nil {
+		t.Fatalf("Attach first: %v", err)
+	}
+	detachSecond, err := hub.Attach(second)
+	if err != nil {
+		t.Fatalf("Attach second: %v", err)
+	}
+	if n := hub.Connections("", "hub-attach"); n != 2 {
+		t.Fatalf("Expected 2 connections, got %d", n)
+	}
+	if second.Writer != first.Writer || second.inputs != first.inputs {
+		t.Error("Expected the second session to share the first one's writer and input queue")
+	}
+
+	other, _ := newTestSession(t, "hub-attach")
+	other.UserID = "mallory"
+	if _, err := hub.Attach(other); err == nil {
+		t.Error("Expected another user's session to be refused")
+	}
+
+	// Events from either session reach both connections
+	writeTextEvents(t, second.Writer, "hello")
+	for _, client := range []struct {
+		name string
+		read func() map[string]interface{}
+	}{
+		{"first", func() map[string]interface{} { return readEvent(t, firstClient) }},
+		{"second", func() map[string]interface{} { return readEvent(t, secondClient) }},
+	} {
+		if event := client.read(); event["text"] != "hello" {
+			t.Errorf("Expected %s connection to get the event, got %v", client.name, event)
+		}
+	}
+
+	detachSecond()
+	detachSecond() // Idempotent
+	if n := hub.Connections("", "hub-attach"); n != 1 {
+		t.Fatalf("Expected 1 connection after detaching, got %d", n)
+	}
+	writeTextEvents(t, first.Writer, "again")
+	if event := readEvent(t, firstClient); event["text"] != "again" {
+		t.Errorf("Expected the remaining connection to get the event, got %v", event)
+	}
+	expectNoEvent(t, secondClient)
+
+	detachFirst()
+	if n := hub.Connections("", "hub-attach"); n != 0 {
+		t.Errorf("Expected no connections, got %d", n)
+	}
+}
+
+func TestSessionHub_SlowConnection(t *testing.T) {
+	hub := NewSessionHub()
+	fast, fastClient := newTestSession(t, "hub-slow")
+	slow, _ := newTestSession(t, "hub-slow") // Its client never reads
+	for _, as := range []*AgentSession{fast, slow} {
+		detach, err := hub.Attach(as)
+		if err != nil {
+			t.Fatalf("Attach: %v", err)
+		}
+		defer detach()
+	}
+
+	// Enough data to fill the slow connection's socket buffers
+	const events = 32
+	big := strings.Repeat("x", 1<<20)
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < events; i++ {
+			writeTextEvents(t, fast.Writer, big)
+		}
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writing events blocked on the slow connection")
+	}
+	for i := 0; i < events; i++ {
+		if event := readEvent(t, fastClient); event["text"] != big {
+			t.Fatalf("Expected event %d on the fast connection", i+1)
+		}
+	}
+
+	// Fail the stuck write so detaching doesn't wait for the write timeout
+	slow.connection().Close()
+}
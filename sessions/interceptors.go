@@ -0,0 +1,381 @@
+package sessions
+
+import (
+	"context"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+// Stage identifies where an error passed to OnError hooks happened
+type Stage string
+
+const (
+	StageModelCall Stage = "model_call"
+	StageToolCall  Stage = "tool_call"
+)
+
+// ModelCall is a model request as seen by interceptors. BeforeModelCall hooks may change any field.
+type ModelCall struct {
+	Request models.Model_Request
+	Tools   []models.FunctionDeclaration
+	History []stores.Message
+	Stream  bool
+}
+
+// ToolCall is a tool call as seen by interceptors. BeforeToolCall hooks may rename it or rewrite its arguments.
+type ToolCall struct {
+	ID        string // Provider call ID; empty when the executor does not know it
+	Name      string
+	Args      map[string]interface{}
+	SessionID string
+}
+
+// ToolResult is the outcome of a tool call: the tool's own output (before it is wrapped as
+// {"result": ...} for the model) or its error. AfterToolCall hooks may replace either.
+type ToolResult struct {
+	Output string
+	Err    error
+}
+
+// ErrorEvent is passed to OnError hooks
+type ErrorEvent struct {
+	Stage Stage
+	Model *ModelCall // Set for model call errors
+	Tool  *ToolCall  // Set for tool call errors
+	Err   error
+}
+
+// Interceptor is middleware around model calls and tool execution, added with Agent.Use.
+// It implements any of the hook interfaces below; the Func types adapt plain functions.
+// Hooks run in the order their interceptors were added.
+type Interceptor interface{}
+
+// BeforeModelCallHook may modify a model call before it is sent. Returning a response skips
+// the model (e.g. a cache hit) and later BeforeModelCall hooks; returning an error vetoes the call.
+type BeforeModelCallHook interface {
+	BeforeModelCall(call *ModelCall) (*models.Model_Response, error)
+}
+
+// AfterModelChunkHook sees each streamed chunk before it is delivered and may modify it.
+// An error ends the stream.
+type AfterModelChunkHook interface {
+	AfterModelChunk(call *ModelCall, chunk *models.Model_Response) error
+}
+
+// AfterModelCallHook sees the complete response and may modify it; an error fails the call.
+// For streams it runs on the chunks merged into one response, so while any AfterModelCall
+// hook is present the stream is buffered and delivered as that single, modified chunk.
+type AfterModelCallHook interface {
+	AfterModelCall(call *ModelCall, response *models.Model_Response) error
+}
+
+// BeforeToolCallHook may modify a tool call before it runs. Returning a result skips the tool
+// (e.g. a cached output) and later BeforeToolCall hooks; returning an error vetoes the call.
+type BeforeToolCallHook interface {
+	BeforeToolCall(call *ToolCall) (*ToolResult, error)
+}
+
+// AfterToolCallHook sees the result of a tool call that ran (or was answered by a
+// BeforeToolCall hook) and may modify it
+type AfterToolCallHook interface {
+	AfterToolCall(call *ToolCall, result *ToolResult)
+}
+
+// OnErrorHook observes model and tool errors, including vetoes
+type OnErrorHook interface {
+	OnError(event ErrorEvent)
+}
+
+// BeforeModelCallFunc adapts a function to BeforeModelCallHook
+type BeforeModelCallFunc func(call *ModelCall) (*models.Model_Response, error)
+
+func (f BeforeModelCallFunc) BeforeModelCall(call *ModelCall) (*models.Model_Response, error) {
+	return f(call)
+}
+
+// AfterModelChunkFunc adapts a function to AfterModelChunkHook
+type AfterModelChunkFunc func(call *ModelCall, chunk *models.Model_Response) error
+
+func (f AfterModelChunkFunc) AfterModelChunk(call *ModelCall, chunk *models.Model_Response) error {
+	return f(call, chunk)
+}
+
+// AfterModelCallFunc adapts a function to AfterModelCallHook
+type AfterModelCallFunc func(call *ModelCall, response *models.Model_Response) error
+
+func (f AfterModelCallFunc) AfterModelCall(call *ModelCall, response *models.Model_Response) error {
+	return f(call, response)
+}
+
+// BeforeToolCallFunc adapts a function to BeforeToolCallHook
+type BeforeToolCallFunc func(call *ToolCall) (*ToolResult, error)
+
+func (f BeforeToolCallFunc) BeforeToolCall(call *ToolCall) (*ToolResult, error) {
+	return f(call)
+}
+
+// AfterToolCallFunc adapts a function to AfterToolCallHook
+type AfterToolCallFunc func(call *ToolCall, result *ToolResult)
+
+func (f AfterToolCallFunc) AfterToolCall(call *ToolCall, result *ToolResult) {
+	f(call, result)
+}
+
+// OnErrorFunc adapts a function to OnErrorHook
+type OnErrorFunc func(event ErrorEvent)
+
+func (f OnErrorFunc) OnError(event ErrorEvent) {
+	f(event)
+}
+
+// InterceptorHost is implemented by agents that run interceptors (godantic.Agent does).
+// Sessions use it to apply the agent's interceptors to tools they execute themselves.
+type InterceptorHost interface {
+	Use(interceptors ...Interceptor)
+	Interceptors() Interceptors
+}
+
+// Interceptors is a chain of interceptors
+type Interceptors []Interceptor
+
+// RunModel runs a model call through the chain; run sends the (possibly modified) call
+func (is Interceptors) RunModel(call *ModelCall, run func(call *ModelCall) (models.Model_Response, error)) (models.Model_Response, error) {
+	cached, err := is.beforeModelCall(call)
+	if err != nil {
+		is.onError(ErrorEvent{Stage: StageModelCall, Model: call, Err: err})
+		return models.Model_Response{}, err
+	}
+
+	var response models.Model_Response
+	if cached != nil {
+		response = *cached
+	} else if response, err = run(call); err != nil {
+		is.onError(ErrorEvent{Stage: StageModelCall, Model: call, Err: err})
+		return response, err
+	}
+
+	if err := is.afterModelCall(call, &response); err != nil {
+		is.onError(ErrorEvent{Stage: StageModelCall, Model: call, Err: err})
+		return response, err
+	}
+	return response, nil
+}
+
+// StreamModel runs a streaming model call through the chain; stream starts the (possibly
+// modified) call. A response from a BeforeModelCall hook is delivered as a single chunk.
+// When ctx is done the call is abandoned and the channels are closed.
+func (is Interceptors) StreamModel(ctx context.Context, call *ModelCall, stream func(call *ModelCall) (<-chan models.Model_Response, <-chan error)) (<-chan models.Model_Response, <-chan error) {
+	call.Stream = true
+	if len(is) == 0 {
+		return stream(call)
+	}
+
+	out := make(chan models.Model_Response)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer close(errs)
+		fail := func(err error) {
+			is.onError(ErrorEvent{Stage: StageModelCall, Model: call, Err: err})
+			errs <- err
+		}
+		send := func(chunk models.Model_Response) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		// AfterModelCall hooks may change the whole response, so hold chunks back for them
+		buffered := is.hasAfterModelCall()
+
+		cached, err := is.beforeModelCall(call)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if cached != nil {
+			chunk := *cached
+			if err := is.afterModelChunk(call, &chunk); err != nil {
+				fail(err)
+				return
+			}
+			if err := is.afterModelCall(call, &chunk); err != nil {
+				fail(err)
+				return
+			}
+			send(chunk)
+			return
+		}
+
+		responses, streamErrs := stream(call)
+		var merged models.Model_Response
+		for responses != nil || streamErrs != nil {
+			select {
+			case <-ctx.Done():
+				go drain(responses, streamErrs)
+				return
+			case chunk, ok := <-responses:
+				if !ok {
+					responses = nil
+					continue
+				}
+				if err := is.afterModelChunk(call, &chunk); err != nil {
+					go drain(responses, streamErrs)
+					fail(err)
+					return
+				}
+				mergeChunk(&merged, chunk)
+				if !buffered && !send(chunk) {
+					go drain(responses, streamErrs)
+					return
+				}
+			case err, ok := <-streamErrs:
+				if !ok {
+					streamErrs = nil
+					continue
+				}
+				if err != nil {
+					go drain(responses, streamErrs)
+					fail(err)
+					return
+				}
+			}
+		}
+		if err := is.afterModelCall(call, &merged); err != nil {
+			fail(err)
+			return
+		}
+		if buffered {
+			send(merged)
+		}
+	}()
+	return out, errs
+}
+
+// RunTool runs a tool call through the chain; run executes the (possibly modified) call
+func (is Interceptors) RunTool(call *ToolCall, run func(call *ToolCall) (string, error)) (string, error) {
+	result, err := is.beforeToolCall(call)
+	if err != nil {
+		is.onError(ErrorEvent{Stage: StageToolCall, Tool: call, Err: err})
+		return "", err
+	}
+	if result == nil {
+		output, err := run(call)
+		result = &ToolResult{Output: output, Err: err}
+	}
+
+	for _, interceptor := range is {
+		if hook, ok := interceptor.(AfterToolCallHook); ok {
+			hook.AfterToolCall(call, result)
+		}
+	}
+	if result.Err != nil {
+		is.onError(ErrorEvent{Stage: StageToolCall, Tool: call, Err: result.Err})
+	}
+	return result.Output, result.Err
+}
+
+func (is Interceptors) beforeModelCall(call *ModelCall) (*models.Model_Response, error) {
+	for _, interceptor := range is {
+		if hook, ok := interceptor.(BeforeModelCallHook); ok {
+			if response, err := hook.BeforeModelCall(call); err != nil || response != nil {
+				return response, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (is Interceptors) afterModelChunk(call *ModelCall, chunk *models.Model_Response) error {
+	for _, interceptor := range is {
+		if hook, ok := interceptor.(AfterModelChunkHook); ok {
+			if err := hook.AfterModelChunk(call, chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (is Interceptors) afterModelCall(call *ModelCall, response *models.Model_Response) error {
+	for _, interceptor := range is {
+		if hook, ok := interceptor.(AfterModelCallHook); ok {
+			if err := hook.AfterModelCall(call, response); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (is Interceptors) hasAfterModelCall() bool {
+	for _, interceptor := range is {
+		if _, ok := interceptor.(AfterModelCallHook); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (is Interceptors) beforeToolCall(call *ToolCall) (*ToolResult, error) {
+	for _, interceptor := range is {
+		if hook, ok := interceptor.(BeforeToolCallHook); ok {
+			if result, err := hook.BeforeToolCall(call); err != nil || result != nil {
+				return result, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (is Interceptors) onError(event ErrorEvent) {
+	for _, interceptor := range is {
+		if hook, ok := interceptor.(OnErrorHook); ok {
+			hook.OnError(event)
+		}
+	}
+}
+
+// mergeChunk appends a streamed chunk to the response built so far, joining consecutive text
+func mergeChunk(merged *models.Model_Response, chunk models.Model_Response) {
+	for _, part := range chunk.Parts {
+		last := len(merged.Parts) - 1
+		if isPlainText(part) && last >= 0 && isPlainText(merged.Parts[last]) {
+			text := *merged.Parts[last].Text + *part.Text
+			merged.Parts[last].Text = &text
+			continue
+		}
+		merged.Parts = append(merged.Parts, part)
+	}
+	merged.Warnings = append(merged.Warnings, chunk.Warnings...)
+	if chunk.FinishReason != "" {
+		merged.FinishReason = chunk.FinishReason
+	}
+	if chunk.Usage != nil {
+		merged.Usage = chunk.Usage
+	}
+}
+
+// isPlainText reports whether a part is text alone, with no signature a provider needs back
+func isPlainText(part models.Model_Part) bool {
+	return part.Text != nil && part.FunctionCall == nil && part.Reasoning == nil && part.Signature == nil && part.RedactedReasoning == nil
+}
+
+// drain discards the rest of an abandoned stream so its producer can finish
+func drain(responses <-chan models.Model_Response, errs <-chan error) {
+	for responses != nil || errs != nil {
+		select {
+		case _, ok := <-responses:
+			if !ok {
+				responses = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+)
+
+func responseText(response models.Model_Response) string {
+	var text string
+	for _, part := range response.Parts {
+		if part.Text != nil {
+			text += *part.Text
+		}
+	}
+	return text
+}
+
+// fakeStream returns a stream of chunks, closed once they are all read
+func fakeStream(chunks ...string) (<-chan models.Model_Response, <-chan error) {
+	responses := make(chan models.Model_Response)
+	errs := make(chan error)
+	go func() {
+		defer close(responses)
+		defer close(errs)
+		for _, chunk := range chunks {
+			responses <- textResponse(chunk)
+		}
+	}()
+	return responses, errs
+}
+
+// collect reads a stream to the end, returning its chunks' text and its error
+func collect(responses <-chan models.Model_Response, errs <-chan error) ([]string, error) {
+	var chunks []string
+	var streamErr error
+	timeout := time.After(2 * time.Second)
+	for responses != nil || errs != nil {
+		select {
+		case chunk, ok := <-responses:
+			if !ok {
+				responses = nil
+				continue
+			}
+			chunks = append(chunks, responseText(chunk))
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			streamErr = err
+		case <-timeout:
+			return chunks, errors.New("timed out waiting for the stream to close")
+		}
+	}
+	return chunks, streamErr
+}
+
+func TestInterceptors_Order(t *testing.T) {
+	var calls []string
+	hooks := func(name string) Interceptor {
+		return struct {
+			BeforeModelCallFunc
+			AfterModelCallFunc
+		}{
+			func(call *ModelCall) (*models.Model_Response, error) {
+				calls = append(calls, name+".before")
+				return nil, nil
+			},
+			func(call *ModelCall, response *models.Model_Response) error {
+				calls = append(calls, name+".after")
+				return nil
+			},
+		}
+	}
+	chain := Interceptors{hooks("a"), hooks("b")}
+
+	_, err := chain.RunModel(&ModelCall{}, func(call *ModelCall) (models.Model_Response, error) {
+		calls = append(calls, "model")
+		return textResponse("hi"), nil
+	})
+	if err != nil {
+		t.Fatalf("RunModel: %v", err)
+	}
+	want := []string{"a.before", "b.before", "model", "a.after", "b.after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected hooks in order %v, got %v", want, calls)
+	}
+
+	calls = nil
+	tools := Interceptors{
+		BeforeToolCallFunc(func(call *ToolCall) (*ToolResult, error) {
+			calls = append(calls, "a.before")
+			call.Args = map[string]interface{}{"city": "Paris"}
+			return nil, nil
+		}),
+		AfterToolCallFunc(func(call *ToolCall, result *ToolResult) {
+			calls = append(calls, "a.after")
+			result.Output += "!"
+		}),
+		AfterToolCallFunc(func(call *ToolCall, result *ToolResult) {
+			calls = append(calls, "b.after")
+			result.Output += "?"
+		}),
+	}
+	output, err := tools.RunTool(&ToolCall{Name: "weather"}, func(call *ToolCall) (string, error) {
+		calls = append(calls, "tool")
+		return "sunny in " + call.Args["city"].(string), nil
+	})
+	if err != nil {
+		t.Fatalf("RunTool: %v", err)
+	}
+	if output != "sunny in Paris!?" {
+		t.Errorf("Expected after hooks applied in order, got %q", output)
+	}
+	want = []string{"a.before", "tool", "a.after", "b.after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected tool hooks in order %v, got %v", want, calls)
+	}
+}
+
+func TestInterceptors_ShortCircuit(t *testing.T) {
+	var later, ran bool
+	var events []ErrorEvent
+	cached := textResponse("cached")
+	chain := Interceptors{
+		BeforeModelCallFunc(func(call *ModelCall) (*models.Model_Response, error) {
+			return &cached, nil
+		}),
+		BeforeModelCallFunc(func(call *ModelCall) (*models.Model_Response, error) {
+			later = true
+			return nil, nil
+		}),
+	}
+	response, err := chain.RunModel(&ModelCall{}, func(call *ModelCall) (models.Model_Response, error) {
+		ran = true
+		return textResponse("model"), nil
+	})
+	if err != nil || responseText(response) != "cached" {
+		t.Fatalf("Expected the cached response, got %q, %v", responseText(response), err)
+	}
+	if later || ran {
+		t.Errorf("Expected later hooks and the model to be skipped (later=%v, model=%v)", later, ran)
+	}
+
+	veto := errors.New("not allowed")
+	tools := Interceptors{
+		BeforeToolCallFunc(func(call *ToolCall) (*ToolResult, error) {
+			return nil, veto
+		}),
+		OnErrorFunc(func(event ErrorEvent) {
+			events = append(events, event)
+		}),
+	}
+	ran = false
+	if _, err := tools.RunTool(&ToolCall{Name: "delete"}, func(call *ToolCall) (string, error) {
+		ran = true
+		return "", nil
+	}); !errors.Is(err, veto) {
+		t.Fatalf("Expected the veto error, got %v", err)
+	}
+	if ran {
+		t.Error("Expected a vetoed tool not to run")
+	}
+	if len(events) != 1 || events[0].Stage != StageToolCall || !errors.Is(events[0].Err, veto) {
+		t.Errorf("Expected one tool_call error event, got %+v", events)
+	}
+}
+
+func TestInterceptors_StreamModel(t *testing.T) {
+	chunkHook := AfterModelChunkFunc(func(call *ModelCall, chunk *models.Model_Response) error {
+		text := responseText(*chunk) + "."
+		chunk.Parts = []models.Model_Part{{Text: &text}}
+		return nil
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		chain := Interceptors{chunkHook}
+		chunks, err := collect(chain.StreamModel(context.Background(), &ModelCall{}, func(call *ModelCall) (<-chan models.Model_Response, <-chan error) {
+			if !call.Stream {
+				t.Error("Expected call.Stream to be set")
+			}
+			return fakeStream("a", "b")
+		}))
+		if err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		if want := []string{"a.", "b."}; !reflect.DeepEqual(chunks, want) {
+			t.Errorf("Expected chunks %v, got %v", want, chunks)
+		}
+	})
+
+	t.Run("after model call", func(t *testing.T) {
+		var seen string
+		chain := Interceptors{chunkHook, AfterModelCallFunc(func(call *ModelCall, response *models.Model_Response) error {
+			seen = responseText(*response)
+			text := "[redacted]"
+			response.Parts = []models.Model_Part{{Text: &text}}
+			return nil
+		})}
+		chunks, err := collect(chain.StreamModel(context.Background(), &ModelCall{}, func(call *ModelCall) (<-chan models.Model_Response, <-chan error) {
+			return fakeStream("a", "b")
+		}))
+		if err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		if seen != "a.b." {
+			t.Errorf("Expected AfterModelCall to see the merged chunks, got %q", seen)
+		}
+		if want := []string{"[redacted]"}; !reflect.DeepEqual(chunks, want) {
+			t.Errorf("Expected the modified response as one chunk, got %v", chunks)
+		}
+	})
+
+	t.Run("chunk error", func(t *testing.T) {
+		stop := errors.New("stop")
+		chain := Interceptors{AfterModelChunkFunc(func(call *ModelCall, chunk *models.Model_Response) error {
+			if responseText(*chunk) == "b" {
+				return stop
+			}
+			return nil
+		})}
+		chunks, err := collect(chain.StreamModel(context.Background(), &ModelCall{}, func(call *ModelCall) (<-chan models.Model_Response, <-chan error) {
+			return fakeStream("a", "b", "c")
+		}))
+		if !errors.Is(err, stop) {
+			t.Errorf("Expected the hook error, got %v", err)
+		}
+		if want := []string{"a"}; !reflect.DeepEqual(chunks, want) {
+			t.Errorf("Expected chunks up to the error, got %v", chunks)
+		}
+	})
+
+	t.Run("consumer gone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		chain := Interceptors{chunkHook}
+		responses, errs := chain.StreamModel(ctx, &ModelCall{}, func(call *ModelCall) (<-chan models.Model_Response, <-chan error) {
+			return fakeStream("a", "b", "c")
+		})
+		<-responses
+		cancel()
+
+		// The forwarding goroutine must not block on the chunks nobody reads
+		select {
+		case <-errs:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected the stream to close after ctx was cancelled")
+		}
+	})
+}
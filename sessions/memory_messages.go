@@ -0,0 +1,60 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WebSocketMemoryMessage is sent by the client to review or delete its long-term memories.
+// Type is "list_memories" (with an optional Limit) or "forget_memory" (with ID).
+type WebSocketMemoryMessage struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// WebSocketMemoriesMessage answers "list_memories"
+type WebSocketMemoriesMessage struct {
+	Type     string        `json:"type"` // "memories"
+	Memories []MemoryEntry `json:"memories"`
+}
+
+// WebSocketMemoryForgottenMessage acknowledges "forget_memory"
+type WebSocketMemoryForgottenMessage struct {
+	Type string `json:"type"` // "memory_forgotten"
+	ID   string `json:"id"`
+}
+
+// HandleMemoryMessage handles "list_memories" and "forget_memory" WebSocket messages.
+// It returns handled=false for any other message type so the caller can fall through
+// to RunInteraction. Errors are reported to the client and are never fatal.
+func (as *AgentSession) HandleMemoryMessage(data []byte) (bool, error) {
+	var msg WebSocketMemoryMessage
+	if err := json.Unmarshal(data, &msg); err != nil || (msg.Type != "list_memories" && msg.Type != "forget_memory") {
+		return false, nil
+	}
+
+	manageable, ok := as.Memory.(ManageableMemory)
+	if !ok {
+		return true, as.sendError("memory management is not supported by this session's memory", false)
+	}
+
+	if msg.Type == "list_memories" {
+		entries, err := manageable.ListMemories(msg.Limit)
+		if err != nil {
+			return true, as.sendError(fmt.Sprintf("failed to list memories: %v", err), false)
+		}
+		if entries == nil {
+			entries = []MemoryEntry{}
+		}
+		return true, as.Writer.WriteResponse(WebSocketMemoriesMessage{Type: EventMemories, Memories: entries})
+	}
+
+	if msg.ID == "" {
+		return true, as.sendError("forget_memory requires an id", false)
+	}
+	if err := manageable.ForgetMemory(msg.ID); err != nil {
+		return true, as.sendError(fmt.Sprintf("failed to forget memory %s: %v", msg.ID, err), false)
+	}
+	return true, as.Writer.WriteResponse(WebSocketMemoryForgottenMessage{Type: EventMemoryForgotten, ID: msg.ID})
+}
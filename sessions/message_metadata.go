@@ -0,0 +1,122 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+	"github.com/Desarso/godantic/telemetry"
+)
+
+// ModelInfoProvider is implemented by agents that can report which provider and model they call.
+// Sessions use it to fill in the provider/model fields of message metadata.
+type ModelInfoProvider interface {
+	ModelInfo() (provider string, model string)
+}
+
+// responseMetrics collects generation metadata for a single model call and records its telemetry span
+type responseMetrics struct {
+	provider     string
+	model        string
+	stream       bool
+	start        time.Time
+	firstChunk   time.Time
+	finishReason string
+	usage        *models.Usage
+	span         *telemetry.ModelSpan
+}
+
+func newResponseMetrics(ctx context.Context, agent AgentInterface, stream bool) *responseMetrics {
+	m := &responseMetrics{start: time.Now(), stream: stream}
+	if info, ok := agent.(ModelInfoProvider); ok {
+		m.provider, m.model = info.ModelInfo()
+	}
+	_, m.span = telemetry.StartModelCall(ctx, m.provider, m.model, stream)
+	return m
+}
+
+// observe records first-token timing, finish reason and usage from a response chunk
+func (m *responseMetrics) observe(chunk models.Model_Response) {
+	if m.firstChunk.IsZero() && len(chunk.Parts) > 0 {
+		m.firstChunk = time.Now()
+		if m.stream {
+			m.span.FirstChunk()
+		}
+	}
+	if chunk.FinishReason != "" {
+		m.finishReason = chunk.FinishReason
+	}
+	if chunk.Usage != nil {
+		m.usage = chunk.Usage
+	}
+}
+
+// metadata finalizes the metrics and ends the span. callErr is recorded when the response was cut short.
+func (m *responseMetrics) metadata(callErr error) *models.MessageMetadata {
+	md := &models.MessageMetadata{
+		Provider:     m.provider,
+		Model:        m.model,
+		LatencyMS:    time.Since(m.start).Milliseconds(),
+		FinishReason: m.finishReason,
+		Usage:        m.usage,
+	}
+	if !m.firstChunk.IsZero() {
+		md.TimeToFirstToken = m.firstChunk.Sub(m.start).Milliseconds()
+	}
+	if callErr != nil {
+		md.Error = callErr.Error()
+		if md.FinishReason == "" {
+			md.FinishReason = "error"
+		}
+	}
+
+	result := telemetry.ModelResult{FinishReason: md.FinishReason, Err: callErr}
+	if m.usage != nil {
+		result.InputTokens, result.OutputTokens = m.usage.InputTokens, m.usage.OutputTokens
+		result.CacheReadTokens, result.CacheWriteTokens = m.usage.CacheReadTokens, m.usage.CacheWriteTokens
+	}
+	m.span.End(result)
+	return md
+}
+
+// isMetadataOnly reports whether a chunk carries only finish reason/usage and nothing to show the client
+func isMetadataOnly(chunk models.Model_Response) bool {
+	return len(chunk.Parts) == 0 && len(chunk.Warnings) == 0
+}
+
+// saveModelMessage saves a model message, attaching metadata when the store supports it
+func saveModelMessage(store stores.MessageStore, sessionID, userID, messageType string, parts interface{}, functionID string, metadata *models.MessageMetadata) error {
+	if metadataStore, ok := store.(stores.MessageMetadataStore); ok && metadata != nil {
+		return metadataStore.SaveMessageWithMetadata(sessionID, userID, "model", messageType, parts, functionID, metadata)
+	}
+	return store.SaveMessageWithUser(sessionID, userID, "model", messageType, parts, functionID)
+}
+
+// partialTextParts keeps only the text of a response that was interrupted.
+// Function calls are dropped because they would have no matching responses in history.
+func partialTextParts(parts []models.Model_Part) []models.Model_Part {
+	text := ""
+	for _, part := range parts {
+		if part.Text != nil {
+			text += *part.Text
+		}
+	}
+	if text == "" {
+		return nil
+	}
+	return []models.Model_Part{{Text: &text}}
+}
+
+// decodeMessageMetadata parses stored message metadata, returning nil when there is none
+func decodeMessageMetadata(msg stores.Message) *models.MessageMetadata {
+	if msg.MetadataJSON == "" {
+		return nil
+	}
+	var metadata models.MessageMetadata
+	if err := json.Unmarshal([]byte(msg.MetadataJSON), &metadata); err != nil {
+		return nil
+	}
+	return &metadata
+}
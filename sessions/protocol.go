@@ -0,0 +1,248 @@
+package sessions
+
+//go:generate go run ../cmd/gen-protocol -out ../schemas/protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/Desarso/godantic/models"
+)
+
+// WebSocket protocol versions. Connections start at ProtocolVersionLegacy until the client
+// negotiates a newer version with a "hello" message.
+const (
+	// ProtocolVersionLegacy sends each event as its bare payload with an "event_id" field added
+	ProtocolVersionLegacy = 1
+	// ProtocolVersionEnvelope wraps every event in an Envelope
+	ProtocolVersionEnvelope = 2
+
+	LatestProtocolVersion = ProtocolVersionEnvelope
+)
+
+// SupportedProtocolVersions lists the versions a client may request, oldest first
+var SupportedProtocolVersions = []int{ProtocolVersionLegacy, ProtocolVersionEnvelope}
+
+// Envelope wraps every outbound WebSocket event from protocol version 2 on.
+// Type names the payload in Data (see OutboundEvents).
+type Envelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	EventID int64           `json:"event_id,omitempty"` // Absent on acknowledgements that are not replayed ("resumed", "welcome")
+	Data    json.RawMessage `json:"data"`
+}
+
+// Outbound event types
+const (
+	EventModelChunk        = "model_chunk"
+	EventDone              = "done"
+	EventError             = "error"
+	EventToolResult        = "tool_result"
+	EventExecutionTrace    = "execution_trace"
+	EventFrontendAction    = "frontend_action"
+	EventHistoryWarnings   = "history_warnings"
+	EventTTSContextStarted = "tts_context_started"
+	EventTTSUnconfigured   = "tts_unconfigured"
+	EventTTSError          = "tts_error"
+	EventTTSAudioChunk     = "tts_audio_chunk"
+	EventTTSContextFinal   = "tts_context_final"
+	EventFeedbackSaved     = "feedback_saved"
+	EventMemories          = "memories"
+	EventMemoryForgotten   = "memory_forgotten"
+	EventResumed           = "resumed"
+	EventWelcome           = "welcome"
+	EventInputStatus       = "input_status"
+)
+
+// WebSocketDoneMessage marks the end of a turn
+type WebSocketDoneMessage struct {
+	Type string `json:"type"` // "done"
+}
+
+// WebSocketErrorMessage reports an error to the client
+type WebSocketErrorMessage struct {
+	Error string `json:"error"`
+}
+
+// WebSocketHistoryWarningsMessage reports history content the model could not use
+type WebSocketHistoryWarningsMessage struct {
+	Type     string                  `json:"type"` // "history_warnings"
+	Warnings []models.HistoryWarning `json:"warnings"`
+}
+
+// WebSocketTTSContextMessage announces ("tts_context_started") or ends ("tts_context_final")
+// the ElevenLabs context that audio chunks belong to
+type WebSocketTTSContextMessage struct {
+	Type      string `json:"type"`
+	ContextID string `json:"context_id"`
+	Format    string `json:"format,omitempty"` // Audio format, on "tts_context_started"
+}
+
+// WebSocketTTSAudioMessage carries a base64 audio chunk
+type WebSocketTTSAudioMessage struct {
+	Type      string `json:"type"` // "tts_audio_chunk"
+	ContextID string `json:"context_id"`
+	Audio     string `json:"audio"`
+	Format    string `json:"format"`
+}
+
+// WebSocketTTSStatusMessage reports that voice output is unavailable ("tts_unconfigured")
+// or failed ("tts_error")
+type WebSocketTTSStatusMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error,omitempty"`
+}
+
+// WebSocketHelloMessage is sent by the client to negotiate the protocol version
+type WebSocketHelloMessage struct {
+	Type     string `json:"type"`     // "hello"
+	Versions []int  `json:"versions"` // Protocol versions the client supports
+}
+
+// WebSocketWelcomeMessage answers "hello" with the negotiated version
+type WebSocketWelcomeMessage struct {
+	Type      string `json:"type"` // "welcome"
+	Version   int    `json:"version"`
+	Supported []int  `json:"supported"`
+	SessionID string `json:"session_id"`
+}
+
+// EventSpec describes one message of the WebSocket protocol
+type EventSpec struct {
+	Type        string      // Envelope type (outbound) or "type" field (inbound; empty for chat requests)
+	Payload     interface{} // Zero value of the Go payload type
+	Description string
+}
+
+// OutboundEvents is the catalog of events the server sends
+var OutboundEvents = []EventSpec{
+	{EventModelChunk, models.Model_Response{}, "A streamed chunk of the model's reply (text, reasoning or function calls)"},
+	{EventDone, WebSocketDoneMessage{}, "The turn finished"},
+	{EventError, WebSocketErrorMessage{}, "An error; the session stays usable"},
+	{EventToolResult, WebSocketToolResultMessage{}, "Output of an executed tool"},
+	{EventExecutionTrace, WebSocketTraceMessage{}, "Tool progress for the UI timeline"},
+	{EventFrontendAction, FrontendActionMessage{}, "An action the frontend must perform and answer"},
+	{EventHistoryWarnings, WebSocketHistoryWarningsMessage{}, "History content the model could not use"},
+	{EventTTSContextStarted, WebSocketTTSContextMessage{}, "Audio chunks for this context follow"},
+	{EventTTSUnconfigured, WebSocketTTSStatusMessage{}, "Voice mode was requested but TTS is not configured"},
+	{EventTTSError, WebSocketTTSStatusMessage{}, "TTS failed; the reply continues as text"},
+	{EventTTSAudioChunk, WebSocketTTSAudioMessage{}, "A base64 audio chunk"},
+	{EventTTSContextFinal, WebSocketTTSContextMessage{}, "All audio for the context was sent"},
+	{EventFeedbackSaved, WebSocketFeedbackSavedMessage{}, "Acknowledges \"feedback\""},
+	{EventMemories, WebSocketMemoriesMessage{}, "Answers \"list_memories\""},
+	{EventMemoryForgotten, WebSocketMemoryForgottenMessage{}, "Acknowledges \"forget_memory\""},
+	{EventResumed, WebSocketResumedMessage{}, "Acknowledges \"resume\"; missed events follow"},
+	{EventWelcome, WebSocketWelcomeMessage{}, "Answers \"hello\" with the negotiated protocol version"},
+	{EventInputStatus, WebSocketInputStatusMessage{}, "Reports a user message sent during a turn as queued, injected, started or dropped"},
+}
+
+// InboundMessages is the catalog of messages the client sends. They are never enveloped.
+var InboundMessages = []EventSpec{
+	{"", WebSocketChatRequest{}, "A chat request: a user message or tool results. Sent during a turn, it is queued or steers the turn"},
+	{"hello", WebSocketHelloMessage{}, "Negotiates the protocol version"},
+	{"resume", WebSocketResumeMessage{}, "Replays missed events after a reconnect"},
+	{"feedback", WebSocketFeedbackMessage{}, "Rates a message"},
+	{"list_memories", WebSocketMemoryMessage{}, "Lists the user's memories"},
+	{"forget_memory", WebSocketMemoryMessage{}, "Deletes a memory"},
+}
+
+// eventTypeOf returns the event type of an outbound payload: the value of a struct's Type
+// field or a map's "type" key, "error" for error messages and "model_chunk" for model responses
+func eventTypeOf(payload interface{}) string {
+	switch p := payload.(type) {
+	case models.Model_Response, *models.Model_Response:
+		return EventModelChunk
+	case WebSocketErrorMessage, *WebSocketErrorMessage:
+		return EventError
+	case map[string]interface{}:
+		if t, ok := p["type"].(string); ok && t != "" {
+			return t
+		}
+		if _, ok := p["error"]; ok {
+			return EventError
+		}
+	case map[string]string:
+		if t := p["type"]; t != "" {
+			return t
+		}
+		if _, ok := p["error"]; ok {
+			return EventError
+		}
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(payload))
+	if v.Kind() == reflect.Struct {
+		if field := v.FieldByName("Type"); field.IsValid() && field.Kind() == reflect.String && field.String() != "" {
+			return field.String()
+		}
+	}
+	return "message"
+}
+
+// injectEventID adds an "event_id" field to a JSON object. Non-object payloads are wrapped in "data".
+func injectEventID(data []byte, id int64) []byte {
+	prefix := fmt.Sprintf(`{"event_id":%d`, id)
+	if len(data) < 2 || data[0] != '{' {
+		return []byte(prefix + `,"data":` + string(data) + "}")
+	}
+	body := bytes.TrimSpace(data[1:])
+	if len(body) > 0 && body[0] == '}' {
+		return []byte(prefix + "}")
+	}
+	return append([]byte(prefix+","), body...)
+}
+
+// encodeEvent renders a payload for the wire in the given protocol version.
+// id is 0 for messages that are not numbered.
+func encodeEvent(version int, eventType string, payload []byte, id int64) ([]byte, error) {
+	if version >= ProtocolVersionEnvelope {
+		return json.Marshal(Envelope{V: version, Type: eventType, EventID: id, Data: payload})
+	}
+	if id == 0 {
+		return payload, nil
+	}
+	return injectEventID(payload, id), nil
+}
+
+// negotiateProtocolVersion returns the newest version both sides support, or 0
+func negotiateProtocolVersion(clientVersions []int) int {
+	best := 0
+	for _, v := range clientVersions {
+		for _, supported := range SupportedProtocolVersions {
+			if v == supported && v > best {
+				best = v
+			}
+		}
+	}
+	return best
+}
+
+// HandleHelloMessage negotiates the protocol version of this session's connection if data is
+// a "hello" WebSocket message and answers with "welcome" in the negotiated version. Send it
+// before any other message.
+// It returns handled=false for any other message type so the caller can fall through
+// to RunInteraction. Errors are reported to the client and are never fatal.
+func (as *AgentSession) HandleHelloMessage(data []byte) (bool, error) {
+	var msg WebSocketHelloMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "hello" {
+		return false, nil
+	}
+
+	version := negotiateProtocolVersion(msg.Versions)
+	if version == 0 {
+		return true, as.sendError(fmt.Sprintf("unsupported protocol versions %v; supported: %v", msg.Versions, SupportedProtocolVersions), false)
+	}
+	conn := as.connection()
+	as.Writer.SetProtocolVersion(conn, version)
+
+	as.Writer.mu.Lock()
+	defer as.Writer.mu.Unlock()
+	return true, as.Writer.sendUnnumbered(conn, WebSocketWelcomeMessage{
+		Type:      EventWelcome,
+		Version:   version,
+		Supported: SupportedProtocolVersions,
+		SessionID: as.SessionID,
+	})
+}
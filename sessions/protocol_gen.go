@@ -0,0 +1,359 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// generatedHeader marks generated protocol files
+const generatedHeader = "Code generated by cmd/gen-protocol. DO NOT EDIT."
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// protocolField is a JSON field of a protocol struct
+type protocolField struct {
+	name     string
+	t        reflect.Type
+	optional bool
+}
+
+// protocolTypes collects the named struct types reachable from the event catalogs
+type protocolTypes struct {
+	names  map[reflect.Type]string
+	types  map[string]reflect.Type
+	fields map[string][]protocolField
+}
+
+func collectProtocolTypes() *protocolTypes {
+	pt := &protocolTypes{
+		names:  make(map[reflect.Type]string),
+		types:  make(map[string]reflect.Type),
+		fields: make(map[string][]protocolField),
+	}
+	for _, spec := range append(append([]EventSpec{}, OutboundEvents...), InboundMessages...) {
+		pt.visit(reflect.TypeOf(spec.Payload))
+	}
+	return pt
+}
+
+// visit registers t and every struct type it references
+func (pt *protocolTypes) visit(t reflect.Type) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		if t == rawMessageType {
+			return
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return
+	}
+	if t.Name() != "" {
+		if _, ok := pt.names[t]; ok {
+			return
+		}
+		name := t.Name()
+		if _, taken := pt.types[name]; taken {
+			// Same name in another package: qualify it
+			pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		pt.names[t] = name
+		pt.types[name] = t
+		pt.fields[name] = structFields(t)
+	}
+	for _, f := range structFields(t) {
+		pt.visit(f.t)
+	}
+}
+
+// structFields returns the JSON fields of t in declaration order, flattening embedded structs
+func structFields(t reflect.Type) []protocolField {
+	var fields []protocolField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, structFields(ft)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, protocolField{
+			name:     name,
+			t:        f.Type,
+			optional: strings.Contains(opts, "omitempty") || f.Type.Kind() == reflect.Ptr,
+		})
+	}
+	return fields
+}
+
+// sortedNames returns the registered type names in alphabetical order
+func (pt *protocolTypes) sortedNames() []string {
+	names := make([]string, 0, len(pt.types))
+	for name := range pt.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tsType renders t as a TypeScript type
+func (pt *protocolTypes) tsType(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "string"
+	case t == rawMessageType:
+		return "unknown"
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return pt.tsType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string" // base64
+		}
+		elem := pt.tsType(t.Elem())
+		if strings.ContainsAny(elem, " |&") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + pt.tsType(t.Elem()) + ">"
+	case reflect.Struct:
+		if name, ok := pt.names[t]; ok {
+			return name
+		}
+		return pt.tsObject(structFields(t), "")
+	}
+	return "unknown"
+}
+
+// tsObject renders fields as a TypeScript object type
+func (pt *protocolTypes) tsObject(fields []protocolField, indent string) string {
+	if len(fields) == 0 {
+		return "Record<string, never>"
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, f := range fields {
+		optional := ""
+		if f.optional {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsPropertyName(f.name), optional, pt.tsType(f.t))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func tsPropertyName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
+
+// GenerateTypeScript renders the WebSocket protocol (OutboundEvents and InboundMessages)
+// as TypeScript type definitions
+func GenerateTypeScript() string {
+	pt := collectProtocolTypes()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\n", generatedHeader)
+	fmt.Fprintf(&b, "export const PROTOCOL_VERSION = %d;\n", LatestProtocolVersion)
+	versions := make([]string, len(SupportedProtocolVersions))
+	for i, v := range SupportedProtocolVersions {
+		versions[i] = fmt.Sprint(v)
+	}
+	fmt.Fprintf(&b, "export const SUPPORTED_PROTOCOL_VERSIONS = [%s] as const;\n\n", strings.Join(versions, ", "))
+
+	b.WriteString("/** Wraps every server event from protocol version 2 on. */\n")
+	b.WriteString("export interface Envelope<T extends string, D> {\n  v: number;\n  type: T;\n  /** Absent on acknowledgements that are not replayed (e.g. \"resumed\"). */\n  event_id?: number;\n  data: D;\n}\n\n")
+
+	for _, name := range pt.sortedNames() {
+		fmt.Fprintf(&b, "export interface %s %s\n\n", name, pt.tsObject(pt.fields[name], ""))
+	}
+
+	b.WriteString("/** Events the server sends (protocol version 2). */\nexport type ServerEvent =\n")
+	for _, spec := range OutboundEvents {
+		fmt.Fprintf(&b, "  /** %s */\n  | Envelope<%q, %s>\n", spec.Description, spec.Type, pt.tsType(reflect.TypeOf(spec.Payload)))
+	}
+	b.WriteString("  ;\n\nexport type ServerEventType = ServerEvent[\"type\"];\n\n")
+
+	b.WriteString("/** Events the server sends in protocol version 1: the bare payload with an event_id. */\nexport type LegacyServerEvent = (\n")
+	seen := make(map[string]bool)
+	for _, spec := range OutboundEvents {
+		if name := pt.tsType(reflect.TypeOf(spec.Payload)); !seen[name] {
+			seen[name] = true
+			fmt.Fprintf(&b, "  | %s\n", name)
+		}
+	}
+	b.WriteString(") & { event_id?: number };\n\n")
+
+	b.WriteString("/** Messages the client sends. They are never enveloped. */\nexport type ClientMessage =\n")
+	for _, spec := range InboundMessages {
+		fmt.Fprintf(&b, "  /** %s */\n", spec.Description)
+		if spec.Type == "" {
+			fmt.Fprintf(&b, "  | %s\n", pt.tsType(reflect.TypeOf(spec.Payload)))
+		} else {
+			fmt.Fprintf(&b, "  | (%s & { type: %q })\n", pt.tsType(reflect.TypeOf(spec.Payload)), spec.Type)
+		}
+	}
+	b.WriteString("  ;\n")
+	return b.String()
+}
+
+// schemaType renders t as a JSON Schema
+func (pt *protocolTypes) schemaType(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return pt.schemaType(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": pt.schemaType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": pt.schemaType(t.Elem())}
+	case reflect.Struct:
+		if name, ok := pt.names[t]; ok {
+			return schemaRef(name)
+		}
+		return pt.schemaObject(structFields(t))
+	}
+	return map[string]interface{}{}
+}
+
+func (pt *protocolTypes) schemaObject(fields []protocolField) map[string]interface{} {
+	properties := make(map[string]interface{}, len(fields))
+	required := []string{}
+	for _, f := range fields {
+		properties[f.name] = pt.schemaType(f.t)
+		if !f.optional {
+			required = append(required, f.name)
+		}
+	}
+	object := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// GenerateJSONSchema renders the WebSocket protocol as a JSON Schema (draft 2020-12).
+// ServerEvent covers enveloped server events and ClientMessage covers client messages.
+func GenerateJSONSchema() ([]byte, error) {
+	pt := collectProtocolTypes()
+
+	defs := make(map[string]interface{}, len(pt.types)+3)
+	for _, name := range pt.sortedNames() {
+		defs[name] = pt.schemaObject(pt.fields[name])
+	}
+	defs["Envelope"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"v":        map[string]interface{}{"type": "integer", "enum": SupportedProtocolVersions},
+			"type":     map[string]interface{}{"type": "string"},
+			"event_id": map[string]interface{}{"type": "integer"},
+			"data":     map[string]interface{}{},
+		},
+		"required": []string{"v", "type", "data"},
+	}
+
+	var serverEvents []interface{}
+	for _, spec := range OutboundEvents {
+		serverEvents = append(serverEvents, map[string]interface{}{
+			"description": spec.Description,
+			"allOf": []interface{}{
+				schemaRef("Envelope"),
+				map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": map[string]interface{}{"const": spec.Type},
+						"data": pt.schemaType(reflect.TypeOf(spec.Payload)),
+					},
+				},
+			},
+		})
+	}
+	defs["ServerEvent"] = map[string]interface{}{"oneOf": serverEvents}
+
+	var clientMessages []interface{}
+	for _, spec := range InboundMessages {
+		payload := pt.schemaType(reflect.TypeOf(spec.Payload))
+		if spec.Type == "" {
+			payload["description"] = spec.Description
+			clientMessages = append(clientMessages, payload)
+			continue
+		}
+		clientMessages = append(clientMessages, map[string]interface{}{
+			"description": spec.Description,
+			"allOf": []interface{}{
+				payload,
+				map[string]interface{}{
+					"properties": map[string]interface{}{"type": map[string]interface{}{"const": spec.Type}},
+					"required":   []string{"type"},
+				},
+			},
+		})
+	}
+	// anyOf: a chat request has no required fields, so it also matches typed messages
+	defs["ClientMessage"] = map[string]interface{}{"anyOf": clientMessages}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"$comment": generatedHeader,
+		"title":    "godantic WebSocket protocol",
+		"anyOf":    []interface{}{schemaRef("ServerEvent"), schemaRef("ClientMessage")},
+		"$defs":    defs,
+	}, "", "  ")
+}
@@ -0,0 +1,167 @@
+package sessions
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	cases := []struct {
+		name   string
+		client []int
+		want   int
+	}{
+		{"newest common", []int{1, 2}, ProtocolVersionEnvelope},
+		{"order does not matter", []int{2, 1}, ProtocolVersionEnvelope},
+		{"legacy only", []int{1}, ProtocolVersionLegacy},
+		{"unknown versions ignored", []int{1, 99}, ProtocolVersionLegacy},
+		{"unsupported", []int{99}, 0},
+		{"missing", nil, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := negotiateProtocolVersion(tc.client); got != tc.want {
+				t.Errorf("negotiateProtocolVersion(%v) = %d, want %d", tc.client, got, tc.want)
+			}
+		})
+	}
+}
+
+func sendHello(t *testing.T, as *AgentSession, hello WebSocketHelloMessage) error {
+	t.Helper()
+	data, _ := json.Marshal(hello)
+	handled, err := as.HandleHelloMessage(data)
+	if !handled {
+		t.Fatalf("Expected %s to be handled", data)
+	}
+	return err
+}
+
+func TestHandleHelloMessage(t *testing.T) {
+	t.Run("supported", func(t *testing.T) {
+		as, client := newTestSession(t, "hello-supported")
+		if err := sendHello(t, as, WebSocketHelloMessage{Type: "hello", Versions: []int{1, 2}}); err != nil {
+			t.Fatalf("HandleHelloMessage: %v", err)
+		}
+		welcome := readEvent(t, client)
+		if welcome["v"] != float64(2) || welcome["type"] != EventWelcome || welcome["event_id"] != nil {
+			t.Fatalf("Expected an unnumbered v2 welcome envelope, got %v", welcome)
+		}
+		if data := welcome["data"].(map[string]interface{}); data["version"] != float64(2) || data["session_id"] != "hello-supported" {
+			t.Errorf("Unexpected welcome %v", data)
+		}
+		if got := as.Writer.ProtocolVersion(as.connection()); got != ProtocolVersionEnvelope {
+			t.Errorf("Expected the connection to switch to v2, got v%d", got)
+		}
+
+		writeTextEvents(t, as.Writer, "hi")
+		event := readEvent(t, client)
+		if event["v"] != float64(2) || event["type"] != "text" {
+			t.Fatalf("Expected a v2 envelope, got %v", event)
+		}
+		if data := event["data"].(map[string]interface{}); data["text"] != "hi" || data["event_id"] != nil {
+			t.Errorf("Expected the bare payload in data, got %v", data)
+		}
+	})
+
+	for _, tc := range []struct {
+		name     string
+		versions []int
+	}{{"unsupported", []int{99}}, {"missing", nil}} {
+		t.Run(tc.name, func(t *testing.T) {
+			as, client := newTestSession(t, "hello-"+tc.name)
+			err := sendHello(t, as, WebSocketHelloMessage{Type: "hello", Versions: tc.versions})
+			if agentErr, ok := err.(*AgentError); !ok || agentErr.Fatal {
+				t.Fatalf("Expected a non-fatal AgentError, got %v", err)
+			}
+			if event := readEvent(t, client); event["error"] == nil || event["v"] != nil {
+				t.Errorf("Expected a legacy error message, got %v", event)
+			}
+			if got := as.Writer.ProtocolVersion(as.connection()); got != ProtocolVersionLegacy {
+				t.Errorf("Expected the connection to stay on v1, got v%d", got)
+			}
+		})
+	}
+
+	t.Run("other messages", func(t *testing.T) {
+		as, _ := newTestSession(t, "hello-other")
+		for _, data := range []string{`{"type":"resume"}`, `{"user_message":{}}`, `not json`} {
+			if handled, err := as.HandleHelloMessage([]byte(data)); handled || err != nil {
+				t.Errorf("Expected %s to fall through, got handled=%v err=%v", data, handled, err)
+			}
+		}
+	})
+}
+
+func TestEncodeEvent(t *testing.T) {
+	payload := []byte(`{"type":"text","text":"hi"}`)
+
+	cases := []struct {
+		name    string
+		version int
+		id      int64
+		want    string
+	}{
+		{"v1 numbered", ProtocolVersionLegacy, 3, `{"event_id":3,"type":"text","text":"hi"}`},
+		{"v1 unnumbered", ProtocolVersionLegacy, 0, `{"type":"text","text":"hi"}`},
+		{"v2 numbered", ProtocolVersionEnvelope, 3, `{"v":2,"type":"text","event_id":3,"data":{"type":"text","text":"hi"}}`},
+		{"v2 unnumbered", ProtocolVersionEnvelope, 0, `{"v":2,"type":"text","data":{"type":"text","text":"hi"}}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := encodeEvent(tc.version, "text", payload, tc.id)
+			if err != nil {
+				t.Fatalf("encodeEvent: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInjectEventID(t *testing.T) {
+	cases := []struct {
+		name, data, want string
+	}{
+		{"object", `{"a":1}`, `{"event_id":7,"a":1}`},
+		{"empty object", `{}`, `{"event_id":7}`},
+		{"padded empty object", `{ }`, `{"event_id":7}`},
+		{"array", `[1,2]`, `{"event_id":7,"data":[1,2]}`},
+		{"string", `"hi"`, `{"event_id":7,"data":"hi"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := injectEventID([]byte(tc.data), 7)
+			if string(got) != tc.want {
+				t.Errorf("Expected %s, got %s", tc.want, got)
+			}
+			if !json.Valid(got) {
+				t.Errorf("Result %s is not valid JSON", got)
+			}
+		})
+	}
+}
+
+// TestProtocolSchemas_UpToDate fails when the Go protocol types change without running go generate
+func TestProtocolSchemas_UpToDate(t *testing.T) {
+	schema, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema: %v", err)
+	}
+	files := map[string]string{
+		"websocket.d.ts":        GenerateTypeScript(),
+		"websocket.schema.json": string(schema) + "\n",
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join("..", "schemas", "protocol", name))
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("schemas/protocol/%s is out of date; run go generate ./sessions", name)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Desarso/godantic/logging"
+	"github.com/Desarso/godantic/models"
+)
+
+// SSE event names sent by HTTPSession.RunSSEInteractionWithRequest
+const (
+	SSEEventDelta      = "delta"       // Model_Response with text and reasoning parts
+	SSEEventToolCall   = "tool_call"   // models.FunctionCall requested by the model
+	SSEEventToolResult = "tool_result" // WebSocketToolResultMessage for an executed tool
+	SSEEventTrace      = "trace"       // WebSocketTraceMessage for tool start/end/error
+	SSEEventDone       = "done"        // {"conversation_id": "..."}; the interaction finished
+	SSEEventError      = "error"       // {"error": "..."}; the interaction failed
+)
+
+// ErrSSEStreamNotFound is returned by ResumeSSE when no events are buffered for the conversation
+var ErrSSEStreamNotFound = errors.New("no SSE stream buffered for this conversation")
+
+// ErrSSEStreamActive is returned by RunSSEInteractionWithRequest while another interaction on
+// the conversation is still streaming; nothing has been written to the writer
+var ErrSSEStreamActive = errors.New("an SSE interaction is already running for this conversation")
+
+// SSEEventWriter is implemented by SSE writers that can send named events with IDs.
+// Writers that only implement SSEWriter receive Model_Response JSON through WriteSSE, as
+// RunSSEInteraction sends it, and errors through WriteSSEError; other events are skipped.
+type SSEEventWriter interface {
+	WriteSSEEvent(id, event, data string) error
+}
+
+// ResponseSSEWriter writes Server-Sent Events to an http.ResponseWriter.
+// It implements SSEWriter and SSEEventWriter.
+type ResponseSSEWriter struct {
+	w http.ResponseWriter
+}
+
+// NewResponseSSEWriter sets the event-stream headers on w and returns a writer for it
+func NewResponseSSEWriter(w http.ResponseWriter) *ResponseSSEWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	return &ResponseSSEWriter{w: w}
+}
+
+// WriteSSEEvent writes one event; multi-line data is split into several data lines
+func (r *ResponseSSEWriter) WriteSSEEvent(id, event, data string) error {
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(r.w, b.String())
+	return err
+}
+
+// WriteSSE writes an unnamed event
+func (r *ResponseSSEWriter) WriteSSE(data string) error {
+	return r.WriteSSEEvent("", "", data)
+}
+
+// WriteSSEError writes an "error" event
+func (r *ResponseSSEWriter) WriteSSEError(err error) error {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return r.WriteSSEEvent("", SSEEventError, string(data))
+}
+
+// Flush sends buffered events to the client
+func (r *ResponseSSEWriter) Flush() {
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sseStream buffers a conversation's SSE events so clients can follow or resume them
+type sseStream struct {
+	mu      sync.Mutex
+	buffer  eventBuffer
+	changed chan struct{} // Closed and replaced whenever an event is added or the stream finishes
+	err     error         // Why the last interaction failed, if it did
+}
+
+// sseStreams maps tenant and conversation IDs to their event streams
+var sseStreams = struct {
+	sync.Mutex
+	streams map[string]*sseStream
+}{streams: make(map[string]*sseStream)}
+
+// openSSEStream starts buffering a new interaction for the conversation. Event IDs continue
+// from earlier interactions; the returned cursor is the last ID before this one. Only one
+// interaction per conversation streams at a time; others get ErrSSEStreamActive.
+func openSSEStream(tenantID, conversationID string) (*sseStream, int64, error) {
+	sseStreams.Lock()
+	key := resumableKey(tenantID, conversationID)
+	stream := sseStreams.streams[key]
+	if stream == nil {
+		stream = &sseStream{changed: make(chan struct{})}
+		sseStreams.streams[key] = stream
+	}
+	sseStreams.Unlock()
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if stream.buffer.active {
+		return nil, 0, ErrSSEStreamActive
+	}
+	stream.buffer.events = nil
+	stream.buffer.active = true
+	stream.err = nil
+	return stream, stream.buffer.lastID, nil
+}
+
+func lookupSSEStream(tenantID, conversationID string) *sseStream {
+	sseStreams.Lock()
+	defer sseStreams.Unlock()
+	return sseStreams.streams[resumableKey(tenantID, conversationID)]
+}
+
+// emit is the streamEmitter for SSE: model chunks are split into delta and tool_call events
+func (s *sseStream) emit(event string, payload interface{}) {
+	response, ok := payload.(models.Model_Response)
+	if !ok || event != SSEEventDelta {
+		s.publish(event, payload)
+		return
+	}
+
+	delta := response
+	delta.Parts = nil
+	for _, part := range response.Parts {
+		if part.FunctionCall != nil {
+			s.publish(SSEEventToolCall, part.FunctionCall)
+			continue
+		}
+		delta.Parts = append(delta.Parts, part)
+	}
+	if len(delta.Parts) > 0 || len(delta.Warnings) > 0 {
+		s.publish(SSEEventDelta, delta)
+	}
+}
+
+// publish numbers and buffers an event and wakes up followers
+func (s *sseStream) publish(event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logging.Default().Warn("Failed to marshal SSE event", "event", event, logging.KeyError, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffer.append(s.buffer.lastID+1, event, data)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// finish ends the interaction with a done or error event. The stream stays resumable
+// for StreamResumeWindow unless another interaction starts.
+func (s *sseStream) finish(conversationID string, err error) {
+	if err != nil {
+		s.publish(SSEEventError, map[string]string{"error": err.Error()})
+	} else {
+		s.publish(SSEEventDone, map[string]string{"conversation_id": conversationID})
+	}
+
+	s.mu.Lock()
+	s.buffer.active = false
+	s.err = err
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+
+	time.AfterFunc(StreamResumeWindow, func() {
+		sseStreams.Lock()
+		defer sseStreams.Unlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.buffer.active {
+			return
+		}
+		for key, stream := range sseStreams.streams {
+			if stream == s {
+				delete(sseStreams.streams, key)
+			}
+		}
+	})
+}
+
+// follow writes every event after cursor to writer until the interaction finishes
+// (returning its error) or ctx is cancelled
+func (s *sseStream) follow(ctx context.Context, cursor int64, writer SSEWriter, logger *slog.Logger) error {
+	for {
+		s.mu.Lock()
+		var pending []bufferedEvent
+		for _, event := range s.buffer.events {
+			if event.id > cursor {
+				pending = append(pending, event)
+			}
+		}
+		finished := !s.buffer.active
+		streamErr := s.err
+		changed := s.changed
+		s.mu.Unlock()
+
+		for _, event := range pending {
+			if err := writeBufferedSSE(writer, event); err != nil {
+				logger.Warn("Failed to write to SSE stream", logging.KeyError, err)
+				return err
+			}
+			cursor = event.id
+		}
+		if len(pending) > 0 {
+			writer.Flush()
+		}
+		if finished {
+			logger.Debug("SSE stream finished")
+			return streamErr
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			logger.Debug("SSE client disconnected")
+			return ctx.Err()
+		}
+	}
+}
+
+// writeBufferedSSE sends an event with its name and ID when the writer supports them.
+// Plain SSEWriters only get Model_Response payloads and errors.
+func writeBufferedSSE(writer SSEWriter, event bufferedEvent) error {
+	if eventWriter, ok := writer.(SSEEventWriter); ok {
+		return eventWriter.WriteSSEEvent(strconv.FormatInt(event.id, 10), event.name, string(event.data))
+	}
+	switch event.name {
+	case SSEEventDelta:
+		return writer.WriteSSE(string(event.data))
+	case SSEEventToolCall:
+		var call models.FunctionCall
+		if err := json.Unmarshal(event.data, &call); err != nil {
+			return fmt.Errorf("failed to decode tool call event: %w", err)
+		}
+		data, err := json.Marshal(models.Model_Response{Parts: []models.Model_Part{{FunctionCall: &call}}})
+		if err != nil {
+			return fmt.Errorf("failed to marshal tool call response: %w", err)
+		}
+		return writer.WriteSSE(string(data))
+	case SSEEventError:
+		var payload struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(event.data, &payload)
+		return writer.WriteSSEError(errors.New(payload.Error))
+	}
+	return nil
+}
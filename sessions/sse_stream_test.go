@@ -0,0 +1,218 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+// sseFrame is one event parsed back from the wire
+type sseFrame struct {
+	id, event, data string
+}
+
+func parseSSE(t *testing.T, body string) []sseFrame {
+	t.Helper()
+	var frames []sseFrame
+	for _, block := range strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n") {
+		if block == "" {
+			continue
+		}
+		var frame sseFrame
+		var data []string
+		for _, line := range strings.Split(block, "\n") {
+			field, value, ok := strings.Cut(line, ": ")
+			if !ok {
+				t.Fatalf("Malformed SSE line %q", line)
+			}
+			switch field {
+			case "id":
+				frame.id = value
+			case "event":
+				frame.event = value
+			case "data":
+				data = append(data, value)
+			default:
+				t.Fatalf("Unexpected SSE field %q", field)
+			}
+		}
+		frame.data = strings.Join(data, "\n")
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func newTestHTTPSession(t *testing.T, conversationID string, agent AgentInterface) *HTTPSession {
+	t.Helper()
+	store, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewHTTPSession(conversationID, agent, store)
+}
+
+// publishTexts runs one finished interaction on the conversation's stream
+func publishTexts(t *testing.T, conversationID string, texts ...string) {
+	t.Helper()
+	stream, _, err := openSSEStream("", conversationID)
+	if err != nil {
+		t.Fatalf("openSSEStream: %v", err)
+	}
+	for _, text := range texts {
+		stream.publish(SSEEventDelta, textResponse(text))
+	}
+	stream.finish(conversationID, nil)
+}
+
+func TestResponseSSEWriter_Framing(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewResponseSSEWriter(recorder)
+
+	if err := writer.WriteSSEEvent("7", SSEEventDelta, "line one\nline two"); err != nil {
+		t.Fatalf("WriteSSEEvent: %v", err)
+	}
+	if err := writer.WriteSSE(`{"a":1}`); err != nil {
+		t.Fatalf("WriteSSE: %v", err)
+	}
+	if err := writer.WriteSSEError(errors.New("boom")); err != nil {
+		t.Fatalf("WriteSSEError: %v", err)
+	}
+
+	want := "id: 7\nevent: delta\ndata: line one\ndata: line two\n\n" +
+		"data: {\"a\":1}\n\n" +
+		"event: error\ndata: {\"error\":\"boom\"}\n\n"
+	if got := recorder.Body.String(); got != want {
+		t.Errorf("Expected body %q, got %q", want, got)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", got)
+	}
+}
+
+func TestSSE_NamedNumberedEvents(t *testing.T) {
+	agent := &scriptedAgent{responses: []models.Model_Response{textResponse("hi")}}
+	session := newTestHTTPSession(t, "sse-events", agent)
+
+	recorder := httptest.NewRecorder()
+	if err := session.RunSSEInteractionWithRequest(userRequest("hello"), NewResponseSSEWriter(recorder), context.Background()); err != nil {
+		t.Fatalf("RunSSEInteractionWithRequest: %v", err)
+	}
+
+	frames := parseSSE(t, recorder.Body.String())
+	if len(frames) < 2 {
+		t.Fatalf("Expected a delta and a done event, got %+v", frames)
+	}
+	for i, frame := range frames {
+		if frame.id != strconv.Itoa(i+1) {
+			t.Errorf("Expected event %d to have ID %d, got %q", i, i+1, frame.id)
+		}
+	}
+	if first := frames[0]; first.event != SSEEventDelta || !strings.Contains(first.data, `"hi"`) {
+		t.Errorf("Expected a delta with the model text first, got %+v", first)
+	}
+	if last := frames[len(frames)-1]; last.event != SSEEventDone || !strings.Contains(last.data, `"sse-events"`) {
+		t.Errorf("Expected a done event last, got %+v", last)
+	}
+}
+
+func TestSSE_ResumeReplaysAfterLastEventID(t *testing.T) {
+	publishTexts(t, "sse-resume", "one", "two", "three")
+	session := newTestHTTPSession(t, "sse-resume", &scriptedAgent{})
+
+	recorder := httptest.NewRecorder()
+	if err := session.ResumeSSE("2", NewResponseSSEWriter(recorder), context.Background()); err != nil {
+		t.Fatalf("ResumeSSE: %v", err)
+	}
+
+	frames := parseSSE(t, recorder.Body.String())
+	if len(frames) != 2 {
+		t.Fatalf("Expected events 3 and 4, got %+v", frames)
+	}
+	if frames[0].id != "3" || frames[0].event != SSEEventDelta || !strings.Contains(frames[0].data, `"three"`) {
+		t.Errorf("Expected the third delta first, got %+v", frames[0])
+	}
+	if frames[1].id != "4" || frames[1].event != SSEEventDone {
+		t.Errorf("Expected the done event second, got %+v", frames[1])
+	}
+
+	if err := newTestHTTPSession(t, "sse-unknown", &scriptedAgent{}).ResumeSSE("1", NewResponseSSEWriter(httptest.NewRecorder()), context.Background()); !errors.Is(err, ErrSSEStreamNotFound) {
+		t.Errorf("Expected ErrSSEStreamNotFound, got %v", err)
+	}
+}
+
+func TestSSE_ResumeFromEvictedID(t *testing.T) {
+	session := newTestHTTPSession(t, "sse-evicted", &scriptedAgent{})
+
+	// A new interaction drops the previous one's events; IDs keep counting
+	publishTexts(t, "sse-evicted", "old")
+	publishTexts(t, "sse-evicted", "new")
+
+	recorder := httptest.NewRecorder()
+	if err := session.ResumeSSE("1", NewResponseSSEWriter(recorder), context.Background()); err != nil {
+		t.Fatalf("ResumeSSE: %v", err)
+	}
+	frames := parseSSE(t, recorder.Body.String())
+	if len(frames) != 2 || frames[0].id != "3" || !strings.Contains(frames[0].data, `"new"`) {
+		t.Errorf("Expected replay to start at the oldest buffered event 3, got %+v", frames)
+	}
+
+	// Events beyond maxBufferedEvents are dropped from the front
+	texts := make([]string, maxBufferedEvents+1)
+	publishTexts(t, "sse-evicted", texts...)
+	stream := lookupSSEStream("", "sse-evicted")
+	stream.mu.Lock()
+	oldest := stream.buffer.oldestID()
+	stream.mu.Unlock()
+	if oldest <= 5 {
+		t.Fatalf("Expected the first events to be evicted, oldest is %d", oldest)
+	}
+
+	recorder = httptest.NewRecorder()
+	if err := session.ResumeSSE("5", NewResponseSSEWriter(recorder), context.Background()); err != nil {
+		t.Fatalf("ResumeSSE: %v", err)
+	}
+	frames = parseSSE(t, recorder.Body.String())
+	if len(frames) == 0 || frames[0].id != strconv.FormatInt(oldest, 10) {
+		t.Errorf("Expected replay to start at event %d", oldest)
+	}
+	if last := frames[len(frames)-1]; last.event != SSEEventDone {
+		t.Errorf("Expected replay to end with done, got %+v", last)
+	}
+}
+
+func TestSSE_OneStreamPerConversation(t *testing.T) {
+	stream, _, err := openSSEStream("", "sse-active")
+	if err != nil {
+		t.Fatalf("openSSEStream: %v", err)
+	}
+
+	session := newTestHTTPSession(t, "sse-active", &scriptedAgent{})
+	recorder := httptest.NewRecorder()
+	err = session.RunSSEInteractionWithRequest(userRequest("hello"), NewResponseSSEWriter(recorder), context.Background())
+	if !errors.Is(err, ErrSSEStreamActive) {
+		t.Fatalf("Expected ErrSSEStreamActive, got %v", err)
+	}
+	if recorder.Body.Len() != 0 {
+		t.Errorf("Expected nothing written for a rejected interaction, got %q", recorder.Body.String())
+	}
+
+	// Other tenants have their own stream for the same conversation ID
+	other, _, err := openSSEStream("other-tenant", "sse-active")
+	if err != nil {
+		t.Fatalf("Expected another tenant's stream to open, got %v", err)
+	}
+	other.finish("sse-active", nil)
+
+	stream.finish("sse-active", nil)
+	if err := session.RunSSEInteractionWithRequest(userRequest("hello"), NewResponseSSEWriter(httptest.NewRecorder()), context.Background()); err != nil {
+		t.Errorf("Expected a new interaction once the first finished, got %v", err)
+	}
+}
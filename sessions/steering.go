@@ -0,0 +1,255 @@
+package sessions
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/google/uuid"
+)
+
+// Delivery modes for user messages sent while a turn is running
+const (
+	// DeliveryQueue runs the message as its own turn after the current one (the default)
+	DeliveryQueue = "queue"
+	// DeliverySteer injects the message into the current turn at the next tool-loop boundary
+	DeliverySteer = "steer"
+)
+
+// Input statuses reported in WebSocketInputStatusMessage
+const (
+	InputQueued   = "queued"   // Waiting for the current turn to finish
+	InputInjected = "injected" // Saved after the tool results and sent to the model mid-turn
+	InputStarted  = "started"  // A queued message's turn started
+	InputDropped  = "dropped"  // The turn failed or was cancelled before the message was used
+)
+
+// WebSocketChatRequest is a chat request. While a turn is running, user messages are queued
+// or steer the running turn according to Delivery (see HandleUserInputMessage).
+type WebSocketChatRequest struct {
+	models.Model_Request
+	Delivery string `json:"delivery,omitempty"` // "queue" (default) or "steer"; only used while a turn is running
+	InputID  string `json:"input_id,omitempty"` // Client-chosen ID echoed in "input_status" (generated when empty)
+}
+
+// WebSocketInputStatusMessage reports what happened to a user message sent during a turn
+type WebSocketInputStatusMessage struct {
+	Type     string `json:"type"` // "input_status"
+	InputID  string `json:"input_id"`
+	Delivery string `json:"delivery"`
+	Status   string `json:"status"`             // queued, injected, started or dropped
+	Position int    `json:"position,omitempty"` // 1-based place in the queue, on "queued"
+}
+
+// pendingInput is a user message waiting for a tool-loop boundary or the end of the turn
+type pendingInput struct {
+	id       string
+	delivery string
+	req      models.Model_Request
+}
+
+// queuedInteraction is a request waiting for the session's interaction worker
+type queuedInteraction struct {
+	req    models.Model_Request
+	onDone func(error)
+}
+
+// turnInputs collects the user messages sent while a turn is running.
+// It is shared by every connection attached to the session (see HandleResumeMessage).
+type turnInputs struct {
+	turn   sync.Mutex // Held for a whole interaction, so connections sharing the session take turns
+	mu     sync.Mutex
+	active bool // A turn is running; new input is held instead of starting a turn
+	steer  []pendingInput
+	queue  []pendingInput
+
+	pending  []queuedInteraction // Interactions waiting for the worker (see EnqueueInteraction)
+	draining bool                // The worker is running
+}
+
+func newTurnInputs() *turnInputs {
+	return &turnInputs{}
+}
+
+// start waits for any other interaction on the session to finish and marks a turn as running
+func (t *turnInputs) start() {
+	if t == nil {
+		return
+	}
+	t.turn.Lock()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active = true
+}
+
+// finish releases the session for the next interaction
+func (t *turnInputs) finish() {
+	if t == nil {
+		return
+	}
+	t.turn.Unlock()
+}
+
+// add holds input for the running turn. It returns the queue position (0 for steering
+// input) and false when no turn is running, in which case the caller starts one.
+func (t *turnInputs) add(input pendingInput) (int, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active {
+		return 0, false
+	}
+	if input.delivery == DeliverySteer {
+		t.steer = append(t.steer, input)
+		return 0, true
+	}
+	t.queue = append(t.queue, input)
+	return len(t.queue), true
+}
+
+// takeSteering removes and returns the steering input received so far
+func (t *turnInputs) takeSteering() []pendingInput {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steer := t.steer
+	t.steer = nil
+	return steer
+}
+
+// next is called when a turn ends. Steering input that never reached a tool-loop boundary
+// runs first, then the queue. When nothing is left the session goes idle and next
+// returns false. The check and the state change happen under one lock, so input that
+// arrives as the turn ends is either returned here or starts a turn of its own.
+func (t *turnInputs) next() (pendingInput, bool) {
+	if t == nil {
+		return pendingInput{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.steer) > 0 {
+		t.queue = append(t.steer, t.queue...)
+		t.steer = nil
+	}
+	if len(t.queue) == 0 {
+		t.active = false
+		return pendingInput{}, false
+	}
+	input := t.queue[0]
+	t.queue = t.queue[1:]
+	return input, true
+}
+
+// drop ends the turn and returns all held input
+func (t *turnInputs) drop() []pendingInput {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dropped := append(t.steer, t.queue...)
+	t.steer, t.queue = nil, nil
+	t.active = false
+	return dropped
+}
+
+// EnqueueInteraction runs req after the interactions queued before it and returns at once.
+// A single worker per session (shared by the connections attached to it) runs them in order,
+// so the read loop keeps receiving messages during the turn. onDone, if set, receives each
+// interaction's error.
+func (as *AgentSession) EnqueueInteraction(req models.Model_Request, onDone func(error)) {
+	t := as.inputs
+	if t == nil {
+		go func() {
+			err := as.RunInteraction(req)
+			if onDone != nil {
+				onDone(err)
+			}
+		}()
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, queuedInteraction{req: req, onDone: onDone})
+	if !t.draining {
+		t.draining = true
+		go as.drainInteractions(t)
+	}
+}
+
+// drainInteractions runs queued interactions until none are left
+func (as *AgentSession) drainInteractions(t *turnInputs) {
+	for {
+		t.mu.Lock()
+		if len(t.pending) == 0 {
+			t.draining = false
+			t.mu.Unlock()
+			return
+		}
+		next := t.pending[0]
+		t.pending = t.pending[1:]
+		t.mu.Unlock()
+
+		err := as.RunInteraction(next.req)
+		if next.onDone != nil {
+			next.onDone(err)
+		}
+	}
+}
+
+// HandleUserInputMessage handles a chat request sent while a turn is running: the message is
+// queued for after the turn or injected as steering input at the next tool-loop boundary,
+// depending on its "delivery", and acknowledged with "input_status".
+// It returns handled=false when no turn is running (or the request carries no user message)
+// so the caller can fall through to EnqueueInteraction, which keeps the read loop free
+// for messages during the turn.
+func (as *AgentSession) HandleUserInputMessage(data []byte) (bool, error) {
+	var msg WebSocketChatRequest
+	if err := json.Unmarshal(data, &msg); err != nil || msg.User_Message == nil {
+		return false, nil
+	}
+	if msg.Delivery != DeliverySteer {
+		msg.Delivery = DeliveryQueue
+	}
+	if msg.InputID == "" {
+		msg.InputID = uuid.NewString()
+	}
+
+	position, held := as.inputs.add(pendingInput{id: msg.InputID, delivery: msg.Delivery, req: msg.Model_Request})
+	if !held {
+		return false, nil
+	}
+	return true, as.sendInputStatus(msg.InputID, msg.Delivery, InputQueued, position)
+}
+
+func (as *AgentSession) sendInputStatus(inputID, delivery, status string, position int) error {
+	return as.Writer.WriteResponse(WebSocketInputStatusMessage{
+		Type:     EventInputStatus,
+		InputID:  inputID,
+		Delivery: delivery,
+		Status:   status,
+		Position: position,
+	})
+}
+
+// mergeSteering merges steering input into a single request, so history never holds
+// consecutive user_messages. It returns nil when there is no steering input.
+func mergeSteering(inputs []pendingInput) *models.Model_Request {
+	if len(inputs) == 0 {
+		return nil
+	}
+	merged := *inputs[0].req.User_Message
+	merged.Content.Parts = nil
+	for _, input := range inputs {
+		merged.Content.Parts = append(merged.Content.Parts, input.req.User_Message.Content.Parts...)
+	}
+	req := inputs[len(inputs)-1].req
+	req.User_Message = &merged
+	req.Tool_Results = nil
+	return &req
+}
@@ -0,0 +1,227 @@
+package sessions
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Desarso/godantic/models"
+	"github.com/Desarso/godantic/stores"
+)
+
+func textResponse(text string) models.Model_Response {
+	return models.Model_Response{Parts: []models.Model_Part{{Text: &text}}}
+}
+
+// scriptedAgent streams one scripted response per model call and records the requests
+type scriptedAgent struct {
+	mu        sync.Mutex
+	requests  []models.Model_Request
+	responses []models.Model_Response
+	onTool    func()
+}
+
+func (a *scriptedAgent) Run(request models.Model_Request, history []stores.Message) (models.Model_Response, error) {
+	return models.Model_Response{}, nil
+}
+
+func (a *scriptedAgent) Run_Stream(request models.Model_Request, history []stores.Message) (<-chan models.Model_Response, <-chan error) {
+	a.mu.Lock()
+	a.requests = append(a.requests, request)
+	response := textResponse("done")
+	if len(a.responses) > 0 {
+		response, a.responses = a.responses[0], a.responses[1:]
+	}
+	a.mu.Unlock()
+
+	responses := make(chan models.Model_Response, 1)
+	errs := make(chan error)
+	responses <- response
+	close(responses)
+	close(errs)
+	return responses, errs
+}
+
+func (a *scriptedAgent) ExecuteTool(name string, args map[string]interface{}, sessionID string) (string, error) {
+	if a.onTool != nil {
+		a.onTool()
+	}
+	return `{"result":"ok"}`, nil
+}
+
+func (a *scriptedAgent) ApproveTool(name string, args map[string]interface{}) (bool, error) {
+	return true, nil
+}
+
+func (a *scriptedAgent) SetHistoryWarningCallback(callback func(warnings []models.HistoryWarning)) bool {
+	return false
+}
+
+func (a *scriptedAgent) userTexts() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var texts []string
+	for _, req := range a.requests {
+		if req.User_Message != nil {
+			for _, part := range req.User_Message.Content.Parts {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+	return texts
+}
+
+func newScriptedSession(t *testing.T, sessionID string, agent *scriptedAgent) *AgentSession {
+	t.Helper()
+	store, err := stores.NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, _ := newTestSession(t, sessionID)
+	as.Agent = agent
+	as.Store = store
+	return as
+}
+
+func userRequest(text string) models.Model_Request {
+	return models.Model_Request{User_Message: &models.User_Message{
+		Role:    "user",
+		Content: models.Content{Parts: []models.User_Part{{Text: text}}},
+	}}
+}
+
+func chatMessage(t *testing.T, text, delivery string) []byte {
+	t.Helper()
+	data, err := json.Marshal(WebSocketChatRequest{Model_Request: userRequest(text), Delivery: delivery, InputID: text})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return data
+}
+
+func TestTurnInputs_Order(t *testing.T) {
+	inputs := newTurnInputs()
+	if _, held := inputs.add(pendingInput{id: "early"}); held {
+		t.Fatal("Expected input to start a turn when none is running")
+	}
+
+	inputs.start()
+	defer inputs.finish()
+	for _, input := range []pendingInput{
+		{id: "q1", delivery: DeliveryQueue},
+		{id: "s1", delivery: DeliverySteer},
+		{id: "q2", delivery: DeliveryQueue},
+		{id: "s2", delivery: DeliverySteer},
+	} {
+		position, held := inputs.add(input)
+		if !held {
+			t.Fatalf("Expected %s to be held during the turn", input.id)
+		}
+		if want := map[string]int{"q1": 1, "q2": 2}[input.id]; position != want {
+			t.Errorf("Expected %s at position %d, got %d", input.id, want, position)
+		}
+	}
+
+	// Steering input that missed the tool loop runs before the queue
+	var order []string
+	for {
+		input, ok := inputs.next()
+		if !ok {
+			break
+		}
+		order = append(order, input.id)
+	}
+	if want := []string{"s1", "s2", "q1", "q2"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected inputs in order %v, got %v", want, order)
+	}
+	if _, held := inputs.add(pendingInput{id: "late"}); held {
+		t.Error("Expected the session to be idle once the queue is empty")
+	}
+}
+
+func TestMergeSteering(t *testing.T) {
+	if mergeSteering(nil) != nil {
+		t.Error("Expected no request without steering input")
+	}
+	merged := mergeSteering([]pendingInput{{req: userRequest("first")}, {req: userRequest("second")}})
+	var texts []string
+	for _, part := range merged.User_Message.Content.Parts {
+		texts = append(texts, part.Text)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("Expected one user message with %v, got %v", want, texts)
+	}
+	if merged.Tool_Results != nil {
+		t.Error("Expected the merged request to carry no tool results")
+	}
+}
+
+func TestSteering_InjectedAtToolBoundary(t *testing.T) {
+	agent := &scriptedAgent{responses: []models.Model_Response{
+		{Parts: []models.Model_Part{{FunctionCall: &models.FunctionCall{ID: "call_1", Name: "lookup", Args: map[string]interface{}{}}}}},
+		textResponse("steered"),
+		textResponse("queued"),
+	}}
+	as := newScriptedSession(t, "steer-inject", agent)
+	agent.onTool = func() {
+		// Sent while the tool runs: one steers this turn, one waits for the next
+		for _, msg := range [][]byte{chatMessage(t, "queue me", DeliveryQueue), chatMessage(t, "steer me", DeliverySteer)} {
+			if handled, err := as.HandleUserInputMessage(msg); !handled || err != nil {
+				t.Errorf("HandleUserInputMessage: handled=%v, err=%v", handled, err)
+			}
+		}
+	}
+
+	if err := as.RunInteraction(userRequest("hello")); err != nil {
+		t.Fatalf("RunInteraction: %v", err)
+	}
+
+	agent.mu.Lock()
+	steered := agent.requests[1]
+	agent.mu.Unlock()
+	if steered.Tool_Results != nil || steered.User_Message == nil {
+		t.Errorf("Expected the steering message sent in place of the tool results, got %+v", steered)
+	}
+	if want := []string{"hello", "steer me", "queue me"}; !reflect.DeepEqual(agent.userTexts(), want) {
+		t.Errorf("Expected model calls with %v, got %v", want, agent.userTexts())
+	}
+}
+
+func TestEnqueueInteraction_Order(t *testing.T) {
+	agent := &scriptedAgent{}
+	as := newScriptedSession(t, "enqueue-order", agent)
+
+	done := make(chan struct{})
+	var mu sync.Mutex
+	var finished []int
+	const turns = 5
+	for i := 0; i < turns; i++ {
+		i := i
+		as.EnqueueInteraction(userRequest(string(rune('a'+i))), func(err error) {
+			if err != nil {
+				t.Errorf("Turn %d: %v", i, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if finished = append(finished, i); len(finished) == turns {
+				close(done)
+			}
+		})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the queued turns")
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(agent.userTexts(), want) {
+		t.Errorf("Expected turns in the order they were queued %v, got %v", want, agent.userTexts())
+	}
+	if want := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(finished, want) {
+		t.Errorf("Expected onDone in order %v, got %v", want, finished)
+	}
+}
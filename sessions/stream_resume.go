@@ -0,0 +1,381 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Desarso/godantic/logging"
+	"github.com/gorilla/websocket"
+)
+
+// StreamResumeWindow is how long a finished turn's events stay available to reconnecting clients
+var StreamResumeWindow = 5 * time.Minute
+
+// maxBufferedEvents bounds the events kept per turn; the oldest are dropped beyond it
+const maxBufferedEvents = 10000
+
+const (
+	// peerQueueSize bounds the messages waiting to be written to one connection. A connection
+	// that falls this far behind is closed; its client can reconnect and resume.
+	peerQueueSize = 1024
+	// peerWriteTimeout bounds a single write to a connection
+	peerWriteTimeout = 10 * time.Second
+)
+
+// WebSocketResumeMessage is sent by a reconnecting client to replay the events it missed
+// and reattach to the live stream. LastEventID is the last "event_id" the client received.
+type WebSocketResumeMessage struct {
+	Type        string `json:"type"` // "resume"
+	SessionID   string `json:"session_id"`
+	LastEventID int64  `json:"last_event_id"`
+}
+
+// WebSocketResumedMessage acknowledges "resume". It is sent before the replayed events and is not numbered.
+type WebSocketResumedMessage struct {
+	Type        string `json:"type"` // "resumed"
+	SessionID   string `json:"session_id"`
+	LastEventID int64  `json:"last_event_id"` // Newest event ID; the client is caught up once it sees it
+	Replayed    int    `json:"replayed"`      // Number of events replayed after this message
+	Active      bool   `json:"active"`        // A turn is still running and live events follow
+	Gap         bool   `json:"gap,omitempty"` // Some missed events are no longer buffered; reload history
+}
+
+// bufferedEvent is a numbered outbound event
+type bufferedEvent struct {
+	id   int64
+	name string // Event type (WebSocket) or event name (SSE)
+	data []byte // JSON payload
+}
+
+// eventBuffer numbers outbound events and keeps those of the current turn
+type eventBuffer struct {
+	lastID int64
+	events []bufferedEvent
+	active bool // A turn is running
+}
+
+func (b *eventBuffer) append(id int64, name string, data []byte) {
+	if len(b.events) >= maxBufferedEvents {
+		// Drop the oldest quarter at once so appends stay amortized O(1)
+		b.events = append([]bufferedEvent(nil), b.events[maxBufferedEvents/4:]...)
+	}
+	b.events = append(b.events, bufferedEvent{id: id, name: name, data: data})
+	b.lastID = id
+}
+
+// oldestID returns the ID of the oldest event that can still be replayed
+func (b *eventBuffer) oldestID() int64 {
+	if len(b.events) > 0 {
+		return b.events[0].id
+	}
+	return b.lastID + 1
+}
+
+// writeEvent numbers, buffers and sends payload to every attached connection. Callers hold w.mu.
+func (w *WebSocketWriter) writeEvent(payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	id := w.stream.lastID + 1
+	w.stream.append(id, eventTypeOf(payload), data)
+	event := w.stream.events[len(w.stream.events)-1]
+	for _, peer := range w.connections() {
+		w.sendEvent(peer, event)
+	}
+	return nil
+}
+
+// sendEvent encodes an event in the peer's protocol version and sends it. Callers hold w.mu.
+func (w *WebSocketWriter) sendEvent(peer *wsPeer, event bufferedEvent) bool {
+	data, err := encodeEvent(peer.version, event.name, event.data, event.id)
+	if err != nil {
+		if w.Logger != nil {
+			w.Logger.Error("Failed to encode event", "event_id", event.id, logging.KeyError, err)
+		}
+		return false
+	}
+	return w.send(peer, data)
+}
+
+// sendUnnumbered sends an acknowledgement to conn only. It is not buffered for replay.
+// Callers hold w.mu.
+func (w *WebSocketWriter) sendUnnumbered(conn *websocket.Conn, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", eventTypeOf(payload), err)
+	}
+	peer := w.peer(conn)
+	if peer == nil || !w.sendEvent(peer, bufferedEvent{name: eventTypeOf(payload), data: data}) {
+		return fmt.Errorf("failed to send %s", eventTypeOf(payload))
+	}
+	return nil
+}
+
+// send queues data for a connection, starting a goroutine to write it if none is running.
+// A connection whose queue is full is closed and detached instead of failing the caller, so
+// the turn keeps running and the event stays buffered for resume. Callers hold w.mu.
+func (w *WebSocketWriter) send(peer *wsPeer, data []byte) bool {
+	peer.mu.Lock()
+	if peer.detached {
+		peer.mu.Unlock()
+		return false
+	}
+	if len(peer.queue) >= peerQueueSize {
+		peer.mu.Unlock()
+		w.removePeer(peer.conn)
+		peer.conn.Close()
+		if w.Logger != nil {
+			w.Logger.Warn("Client connection too slow, closing it; events stay buffered until it resumes", "queued", peerQueueSize)
+		}
+		return false
+	}
+	peer.queue = append(peer.queue, data)
+	if peer.written == nil {
+		peer.written = make(chan struct{})
+		go w.writePeer(peer)
+	}
+	peer.mu.Unlock()
+	return true
+}
+
+// writePeer writes a connection's queued messages in order until the queue is empty, the
+// connection is detached or a write fails
+func (w *WebSocketWriter) writePeer(peer *wsPeer) {
+	for {
+		peer.mu.Lock()
+		if peer.detached || len(peer.queue) == 0 {
+			close(peer.written)
+			peer.written = nil
+			peer.mu.Unlock()
+			return
+		}
+		data := peer.queue[0]
+		peer.queue = peer.queue[1:]
+		peer.mu.Unlock()
+
+		peer.conn.SetWriteDeadline(time.Now().Add(peerWriteTimeout))
+		if err := peer.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			w.mu.Lock()
+			if w.peer(peer.conn) == peer {
+				w.removePeer(peer.conn)
+			}
+			w.mu.Unlock()
+			if w.Logger != nil {
+				w.Logger.Info("Client connection lost, buffering events until it resumes", logging.KeyError, err)
+			}
+		}
+	}
+}
+
+// connections returns the attached connections, adding Conn on first use. Callers hold w.mu.
+func (w *WebSocketWriter) connections() []*wsPeer {
+	if !w.seeded {
+		w.seeded = true
+		if w.Conn != nil {
+			w.peers = append(w.peers, &wsPeer{conn: w.Conn})
+		}
+	}
+	return w.peers
+}
+
+// peer returns the attached connection conn, or nil. Callers hold w.mu.
+func (w *WebSocketWriter) peer(conn *websocket.Conn) *wsPeer {
+	if conn == nil {
+		return nil
+	}
+	for _, peer := range w.connections() {
+		if peer.conn == conn {
+			return peer
+		}
+	}
+	return nil
+}
+
+// addPeer attaches conn (or updates its protocol version). Callers hold w.mu.
+func (w *WebSocketWriter) addPeer(conn *websocket.Conn, version int) *wsPeer {
+	if peer := w.peer(conn); peer != nil {
+		peer.version = version
+		return peer
+	}
+	peer := &wsPeer{conn: conn, version: version}
+	w.peers = append(w.peers, peer)
+	return peer
+}
+
+// removePeer detaches conn, dropping the messages still queued for it, and returns a channel
+// closed once nothing is writing to it (nil if nothing is). Callers hold w.mu.
+func (w *WebSocketWriter) removePeer(conn *websocket.Conn) <-chan struct{} {
+	var removed *wsPeer
+	peers := w.connections()[:0]
+	for _, peer := range w.peers {
+		if peer.conn != conn {
+			peers = append(peers, peer)
+		} else {
+			removed = peer
+		}
+	}
+	w.peers = peers
+	if removed == nil {
+		return nil
+	}
+	removed.mu.Lock()
+	defer removed.mu.Unlock()
+	removed.detached = true
+	removed.queue = nil
+	return removed.written
+}
+
+// detach removes conn, e.g. when its client disconnects, and waits until nothing is writing
+// to it, so the connection can be attached to another writer
+func (w *WebSocketWriter) detach(conn *websocket.Conn) {
+	w.mu.Lock()
+	written := w.removePeer(conn)
+	w.mu.Unlock()
+	if written != nil {
+		<-written
+	}
+}
+
+// beginTurn starts a new event buffer; event IDs keep increasing across turns
+func (w *WebSocketWriter) beginTurn() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stream.events = nil
+	w.stream.active = true
+}
+
+func (w *WebSocketWriter) endTurn() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stream.active = false
+}
+
+func (w *WebSocketWriter) turnActive() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stream.active
+}
+
+// attach adds conn (speaking the given protocol version) to the writer, sends it the
+// acknowledgement built by ack and replays every buffered event after lastEventID to it.
+// Holding the lock throughout means no live event is lost or duplicated between the replay
+// and the live stream.
+func (w *WebSocketWriter) attach(conn *websocket.Conn, version int, lastEventID int64, ack func(WebSocketResumedMessage) interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	peer := w.addPeer(conn, version)
+
+	var replay []bufferedEvent
+	for _, event := range w.stream.events {
+		if event.id > lastEventID {
+			replay = append(replay, event)
+		}
+	}
+	err := w.sendUnnumbered(conn, ack(WebSocketResumedMessage{
+		Type:        EventResumed,
+		LastEventID: w.stream.lastID,
+		Replayed:    len(replay),
+		Active:      w.stream.active,
+		Gap:         lastEventID+1 < w.stream.oldestID(),
+	}))
+	if err != nil {
+		return err
+	}
+	for _, event := range replay {
+		if !w.sendEvent(peer, event) {
+			return fmt.Errorf("connection lost while replaying event %d", event.id)
+		}
+	}
+	return nil
+}
+
+// resumableSessions maps tenant and session IDs to the session whose stream can be resumed
+var resumableSessions = struct {
+	sync.Mutex
+	sessions map[string]*AgentSession
+}{sessions: make(map[string]*AgentSession)}
+
+func resumableKey(tenantID, sessionID string) string {
+	return tenantID + "\x00" + sessionID
+}
+
+// beginResumableTurn starts buffering the turn's events and makes the session resumable
+func (as *AgentSession) beginResumableTurn() {
+	as.Writer.beginTurn()
+
+	resumableSessions.Lock()
+	resumableSessions.sessions[resumableKey(as.TenantID, as.SessionID)] = as
+	resumableSessions.Unlock()
+}
+
+// endResumableTurn keeps the finished turn resumable for StreamResumeWindow
+func (as *AgentSession) endResumableTurn() {
+	as.Writer.endTurn()
+
+	key := resumableKey(as.TenantID, as.SessionID)
+	time.AfterFunc(StreamResumeWindow, func() {
+		resumableSessions.Lock()
+		defer resumableSessions.Unlock()
+		if resumableSessions.sessions[key] == as && !as.Writer.turnActive() {
+			delete(resumableSessions.sessions, key)
+		}
+	})
+}
+
+func lookupResumableSession(tenantID, sessionID string) *AgentSession {
+	resumableSessions.Lock()
+	defer resumableSessions.Unlock()
+	return resumableSessions.sessions[resumableKey(tenantID, sessionID)]
+}
+
+// HandleResumeMessage handles a "resume" WebSocket message from a reconnecting client.
+// It replays the events after last_event_id and reattaches this connection to the turn that
+// is still running (possibly on another AgentSession for the same session, user and tenant).
+// It returns handled=false for any other message type so the caller can fall through
+// to RunInteraction. Errors are reported to the client and are never fatal.
+func (as *AgentSession) HandleResumeMessage(data []byte) (bool, error) {
+	var msg WebSocketResumeMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resume" {
+		return false, nil
+	}
+	if msg.SessionID == "" {
+		msg.SessionID = as.SessionID
+	}
+	if msg.SessionID != as.SessionID {
+		return true, as.sendError("resume session_id does not match this connection's session", false)
+	}
+
+	conn := as.connection()
+	version := as.Writer.ProtocolVersion(conn)
+	live := lookupResumableSession(as.TenantID, as.SessionID)
+	if live == nil || live.UserID != as.UserID {
+		// Nothing is buffered (expired, or the server restarted): the client should reload history
+		as.Writer.mu.Lock()
+		defer as.Writer.mu.Unlock()
+		return true, as.Writer.sendUnnumbered(conn, WebSocketResumedMessage{
+			Type:        EventResumed,
+			SessionID:   as.SessionID,
+			LastEventID: as.Writer.stream.lastID,
+			Gap:         true,
+		})
+	}
+
+	if live.Writer != as.Writer {
+		// Share the live session's writer, waiters and input queue so this connection receives
+		// its events, can answer the tools it is waiting on and can steer it
+		as.Writer.detach(conn)
+		as.share(live)
+	}
+	err := live.Writer.attach(conn, version, msg.LastEventID, func(ack WebSocketResumedMessage) interface{} {
+		ack.SessionID = as.SessionID
+		return ack
+	})
+	if err != nil {
+		as.Logger.Warn("Failed to resume stream", logging.KeyError, err)
+	}
+	return true, err
+}
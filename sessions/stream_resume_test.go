@@ -0,0 +1,193 @@
+package sessions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestConn returns the server and client ends of a WebSocket connection
+func newTestConn(t *testing.T) (server *websocket.Conn, client *websocket.Conn) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	server = <-conns
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+// newTestSession returns a session on a fresh connection and the client end of that connection
+func newTestSession(t *testing.T, sessionID string) (*AgentSession, *websocket.Conn) {
+	t.Helper()
+	server, client := newTestConn(t)
+	return NewAgentSession(sessionID, "alice", server, nil, nil, nil), client
+}
+
+// readEvent reads the next message from a client connection
+func readEvent(t *testing.T, client *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("Invalid event %s: %v", data, err)
+	}
+	return event
+}
+
+// expectNoEvent checks that nothing arrives on a client connection for a short while
+func expectNoEvent(t *testing.T, client *websocket.Conn) {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, data, err := client.ReadMessage(); err == nil {
+		t.Errorf("Expected no more events, got %s", data)
+	}
+}
+
+func writeTextEvents(t *testing.T, w *WebSocketWriter, texts ...string) {
+	t.Helper()
+	for _, text := range texts {
+		if err := w.WriteResponse(map[string]interface{}{"type": "text", "text": text}); err != nil {
+			t.Fatalf("WriteResponse: %v", err)
+		}
+	}
+}
+
+// resume sends a "resume" message on behalf of a reconnected client and returns the ack
+func resume(t *testing.T, as *AgentSession, client *websocket.Conn, lastEventID int64) WebSocketResumedMessage {
+	t.Helper()
+	data, _ := json.Marshal(WebSocketResumeMessage{Type: "resume", LastEventID: lastEventID})
+	if handled, err := as.HandleResumeMessage(data); !handled || err != nil {
+		t.Fatalf("HandleResumeMessage: handled=%v, err=%v", handled, err)
+	}
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var ack WebSocketResumedMessage
+	if err := client.ReadJSON(&ack); err != nil {
+		t.Fatalf("Reading resumed ack: %v", err)
+	}
+	if ack.Type != EventResumed {
+		t.Fatalf("Expected a %q ack, got %+v", EventResumed, ack)
+	}
+	return ack
+}
+
+func TestResume_ReplaysAfterLastEventID(t *testing.T) {
+	live, liveClient := newTestSession(t, "resume-replay")
+	live.beginResumableTurn()
+	writeTextEvents(t, live.Writer, "one", "two", "three")
+	for i := 0; i < 3; i++ {
+		readEvent(t, liveClient)
+	}
+
+	reconnected, client := newTestSession(t, "resume-replay")
+	ack := resume(t, reconnected, client, 1)
+	if ack.Replayed != 2 || ack.LastEventID != 3 || !ack.Active || ack.Gap {
+		t.Fatalf("Unexpected ack %+v", ack)
+	}
+	for _, want := range []struct {
+		id   float64
+		text string
+	}{{2, "two"}, {3, "three"}} {
+		event := readEvent(t, client)
+		if event["event_id"] != want.id || event["text"] != want.text {
+			t.Errorf("Expected event %v %q, got %v", want.id, want.text, event)
+		}
+	}
+
+	// The reconnected client follows the live turn from here on
+	writeTextEvents(t, live.Writer, "four")
+	if event := readEvent(t, client); event["event_id"] != float64(4) {
+		t.Errorf("Expected live event 4, got %v", event)
+	}
+	if event := readEvent(t, liveClient); event["event_id"] != float64(4) {
+		t.Errorf("Expected the original client to get event 4 too, got %v", event)
+	}
+	live.endResumableTurn()
+}
+
+func TestResume_Gap(t *testing.T) {
+	live, liveClient := newTestSession(t, "resume-gap")
+	live.beginResumableTurn()
+	writeTextEvents(t, live.Writer, "one", "two")
+	live.endResumableTurn()
+
+	// A new turn drops the previous turn's events from the buffer
+	live.beginResumableTurn()
+	writeTextEvents(t, live.Writer, "three", "four")
+	for i := 0; i < 4; i++ {
+		readEvent(t, liveClient)
+	}
+
+	reconnected, client := newTestSession(t, "resume-gap")
+	ack := resume(t, reconnected, client, 1)
+	if !ack.Gap || ack.Replayed != 2 || ack.LastEventID != 4 {
+		t.Fatalf("Expected a gap and the buffered events replayed, got %+v", ack)
+	}
+	if event := readEvent(t, client); event["event_id"] != float64(3) {
+		t.Errorf("Expected replay to start at the oldest buffered event, got %v", event)
+	}
+	live.endResumableTurn()
+
+	// Nothing buffered at all for this session
+	unknown, unknownClient := newTestSession(t, "resume-unknown")
+	if ack := resume(t, unknown, unknownClient, 7); !ack.Gap || ack.Replayed != 0 || ack.Active {
+		t.Errorf("Expected a gap and nothing replayed for an unknown session, got %+v", ack)
+	}
+}
+
+func TestResume_AfterTurnFinished(t *testing.T) {
+	live, liveClient := newTestSession(t, "resume-finished")
+	live.beginResumableTurn()
+	writeTextEvents(t, live.Writer, "one", "two")
+	if err := live.Writer.WriteDone(); err != nil {
+		t.Fatalf("WriteDone: %v", err)
+	}
+	live.endResumableTurn()
+	for i := 0; i < 3; i++ {
+		readEvent(t, liveClient)
+	}
+
+	reconnected, client := newTestSession(t, "resume-finished")
+	ack := resume(t, reconnected, client, 1)
+	if ack.Active || ack.Gap || ack.Replayed != 2 || ack.LastEventID != 3 {
+		t.Fatalf("Unexpected ack %+v", ack)
+	}
+	if event := readEvent(t, client); event["text"] != "two" {
+		t.Errorf("Expected event 2 replayed, got %v", event)
+	}
+	if event := readEvent(t, client); event["type"] != EventDone {
+		t.Errorf("Expected the done event replayed, got %v", event)
+	}
+	expectNoEvent(t, client)
+
+	// Caught-up clients get an ack with nothing to replay
+	caughtUp, caughtUpClient := newTestSession(t, "resume-finished")
+	if ack := resume(t, caughtUp, caughtUpClient, 3); ack.Replayed != 0 || ack.Gap {
+		t.Errorf("Expected nothing to replay, got %+v", ack)
+	}
+}
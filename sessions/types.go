@@ -3,7 +3,7 @@ package sessions
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +22,47 @@ type MemoryManager interface {
 	RetrieveMemories(queryText string, limit int) ([]string, error)
 }
 
+// TenantScopedMemory is implemented by memory managers that can isolate memories per tenant.
+// AgentSession.SetTenant uses it to narrow the session's memory to the session tenant.
+type TenantScopedMemory interface {
+	ForTenant(tenantID string) MemoryManager
+}
+
+// UserScopedMemory is implemented by memory managers that can isolate memories per user.
+// NewAgentSession uses it to narrow the session's memory to the session user.
+type UserScopedMemory interface {
+	ForUser(userID string) MemoryManager
+}
+
+// MemoryTurn is a completed user turn handed to a TurnMemory
+type MemoryTurn struct {
+	SessionID string
+	UserID    string
+	TenantID  string
+	Messages  []stores.Message // From the turn's user_message to the final model reply
+}
+
+// TurnMemory is implemented by memory managers that process each completed turn themselves
+// (e.g. extracting durable facts). Sessions then skip saving raw message text via AddMemory.
+type TurnMemory interface {
+	ProcessTurn(turn MemoryTurn) error
+}
+
+// MemoryEntry is a stored memory as shown to users managing their memories
+type MemoryEntry struct {
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// ManageableMemory is implemented by memory managers that let users review and delete their memories
+type ManageableMemory interface {
+	ListMemories(limit int) ([]MemoryEntry, error)
+	ForgetMemory(id string) error
+}
+
 // ConsultantEngine interface for the AI model consultation system.
 // Implement this interface and set it on AgentSession.ConsultantEngine to enable
 // the Consult_Model tool. Advisor mode is handled directly; takeover mode requires
@@ -56,14 +97,61 @@ func (e *AgentError) Error() string {
 	return e.Message
 }
 
-// WebSocketWriter handles all WebSocket communication
+// WebSocketWriter handles all WebSocket communication.
+// Events are encoded in each connection's negotiated protocol version (see protocol.go) and
+// sent to every attached connection: Conn, plus any joined through a SessionHub.
+// Every outbound event is numbered with an "event_id" and buffered for the current turn,
+// so a client that reconnects can replay what it missed (see AgentSession.HandleResumeMessage).
+// A connection that fails is detached; the writer keeps buffering instead of returning errors.
 type WebSocketWriter struct {
-	Conn             *websocket.Conn
-	Logger           *log.Logger
+	Conn             *websocket.Conn // Connection the writer was created with
+	Logger           *slog.Logger
 	StartTime        time.Time
 	FirstTokenTime   *time.Time
 	FirstTokenLogged bool
 	mu               sync.Mutex
+
+	stream eventBuffer
+	peers  []*wsPeer // Attached connections (see connections)
+	seeded bool      // Conn was added to peers
+}
+
+// wsPeer is a connection attached to a WebSocketWriter. Messages are queued for it and
+// written by a goroutine of its own, so a slow connection does not hold up the others.
+type wsPeer struct {
+	conn    *websocket.Conn
+	version int // Negotiated protocol version (ProtocolVersionLegacy until the client says hello)
+
+	mu       sync.Mutex
+	queue    [][]byte
+	detached bool
+	written  chan struct{} // While a goroutine is writing the queue, closed when it returns
+}
+
+// SetProtocolVersion switches the encoding of subsequent events sent to conn (see HandleHelloMessage)
+func (w *WebSocketWriter) SetProtocolVersion(conn *websocket.Conn, version int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if peer := w.peer(conn); peer != nil {
+		peer.version = version
+	}
+}
+
+// ProtocolVersion returns the protocol version negotiated on conn
+func (w *WebSocketWriter) ProtocolVersion(conn *websocket.Conn) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if peer := w.peer(conn); peer != nil && peer.version != 0 {
+		return peer.version
+	}
+	return ProtocolVersionLegacy
+}
+
+// Connections returns the number of attached connections
+func (w *WebSocketWriter) Connections() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.connections())
 }
 
 func (w *WebSocketWriter) WriteResponse(resp interface{}) error {
@@ -74,22 +162,22 @@ func (w *WebSocketWriter) WriteResponse(resp interface{}) error {
 		now := time.Now()
 		w.FirstTokenTime = &now
 		timeToFirstToken := now.Sub(w.StartTime)
-		w.Logger.Printf("Time to first token: %v", timeToFirstToken)
+		w.Logger.Debug("First token sent", "time_to_first_token", timeToFirstToken)
 		w.FirstTokenLogged = true
 	}
-	return w.Conn.WriteJSON(resp)
+	return w.writeEvent(resp)
 }
 
 func (w *WebSocketWriter) WriteError(message string) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.Conn.WriteJSON(map[string]string{"error": message})
+	return w.writeEvent(WebSocketErrorMessage{Error: message})
 }
 
 func (w *WebSocketWriter) WriteDone() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.Conn.WriteJSON(map[string]string{"type": "done"})
+	return w.writeEvent(WebSocketDoneMessage{Type: EventDone})
 }
 
 // WebSocketToolResultMessage represents tool results sent over WebSocket
@@ -139,7 +227,7 @@ type TraceEvent struct {
 // EmitTrace sends a trace event over WebSocket
 func (e *WebSocketTraceEmitter) EmitTrace(trace TraceEvent) error {
 	msg := WebSocketTraceMessage{
-		Type:       "execution_trace",
+		Type:       EventExecutionTrace,
 		TraceID:    trace.TraceID,
 		ParentID:   trace.ParentID,
 		ToolCallID: e.ToolCallID,
@@ -171,7 +259,7 @@ type FrontendActionMessage struct {
 // HandleFrontendAction sends a frontend action over WebSocket and waits for response
 func (h *WebSocketFrontendActionHandler) HandleFrontendAction(action common_tools.FrontendAction) (string, error) {
 	msg := FrontendActionMessage{
-		Type:      "frontend_action",
+		Type:      EventFrontendAction,
 		Action:    action.Action,
 		Data:      action.Data,
 		Timestamp: action.Timestamp,
@@ -251,7 +339,9 @@ func (rw *ResponseWaiter) IsWaiting() bool {
 	return rw.isWaiting
 }
 
-// ToolExecutorFunc is a function type for custom tool execution
+// ToolExecutorFunc is a function type for custom tool execution.
+// It runs inside the agent's interceptors, so it should call tools directly rather than
+// through Agent.ExecuteTool, which would run the hooks a second time.
 type ToolExecutorFunc func(
 	functionName string,
 	functionCallArgs map[string]interface{},
@@ -259,7 +349,7 @@ type ToolExecutorFunc func(
 	sessionID string,
 	writer *WebSocketWriter,
 	responseWaiter *ResponseWaiter,
-	logger *log.Logger,
+	logger *slog.Logger,
 ) (string, error)
 
 // AgentSession encapsulates WebSocket agent interaction logic
@@ -267,10 +357,12 @@ type AgentSession struct {
 	Agent                AgentInterface
 	SessionID            string
 	UserID               string // User ID for associating conversations with users
+	TenantID             string // Tenant ID for multi-tenant deployments (set via SetTenant)
 	Writer               *WebSocketWriter
 	Store                stores.MessageStore
-	TraceStore           stores.TraceStore // Optional: for persisting execution traces
-	Logger               *log.Logger
+	TraceStore           stores.TraceStore    // Optional: for persisting execution traces
+	FeedbackStore        stores.FeedbackStore // Optional: defaults to Store when it implements stores.FeedbackStore
+	Logger               *slog.Logger         // Scoped to the session; replace with SetLogger
 	History              []stores.Message
 	ResponseWaiter       *ResponseWaiter
 	FrontendActionWaiter *ResponseWaiter      // For frontend actions from TypeScript
@@ -280,6 +372,11 @@ type AgentSession struct {
 	FlowLogger           FlowLogger           // Optional: for logging message flow events
 	ConsultantEngine     ConsultantEngine     // Optional: for AI model consultation (Consult_Model tool)
 
+	// inputs holds user messages sent while a turn is running (see HandleUserInputMessage)
+	inputs *turnInputs
+	// conn is this session's own connection; Writer may be shared with other connections
+	conn *websocket.Conn
+
 	// ConsultantTakeoverFunc is called for takeover-mode consultations.
 	// The session layer sets this to a closure that has access to buildAgent, tools, etc.
 	// Signature: func(ctx context.Context, goal, whatTried, contextInfo, specificAsk string) (string, error)
@@ -301,14 +398,20 @@ type AgentSession struct {
 	// the ElevenLabs socket kills audio streaming mid-flight.
 	ttsConnCtx    context.Context
 	ttsConnCancel context.CancelFunc
+
+	// baseLogger is the logger passed to SetLogger, before session attributes are added
+	baseLogger *slog.Logger
 }
 
 // HTTPSession handles HTTP-based chat interactions
 type HTTPSession struct {
 	Agent          AgentInterface
 	ConversationID string
+	TenantID       string // Tenant ID for multi-tenant deployments (set via SetTenant)
 	Store          stores.MessageStore
-	Logger         *log.Logger
+	Logger         *slog.Logger // Scoped to the session; replace with SetLogger
+
+	baseLogger *slog.Logger
 }
 
 // SSEWriter handles Server-Sent Events writing
@@ -328,3 +431,9 @@ type AgentInterface interface {
 	// Returns true if the model supports warnings, false otherwise
 	SetHistoryWarningCallback(callback func(warnings []models.HistoryWarning)) bool
 }
+
+// ContextStreamer is implemented by agents whose streams stop when ctx is done
+// (godantic.Agent does). Sessions use it in place of Run_Stream when available.
+type ContextStreamer interface {
+	RunStreamContext(ctx context.Context, request models.Model_Request, history []stores.Message) (<-chan models.Model_Response, <-chan error)
+}
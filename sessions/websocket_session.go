@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
@@ -13,8 +13,10 @@ import (
 	"time"
 
 	"github.com/Desarso/godantic/common_tools"
+	"github.com/Desarso/godantic/logging"
 	"github.com/Desarso/godantic/models"
 	"github.com/Desarso/godantic/stores"
+	"github.com/Desarso/godantic/telemetry"
 	"github.com/google/uuid"
 
 	eleven_tts "github.com/Desarso/godantic/elevenlabs/tts/multi"
@@ -27,6 +29,7 @@ type functionCallInfo struct {
 	ID         string
 	ArgsJSON   string
 	TextInPart *string
+	Signature  *string
 }
 
 // RunInteraction handles the complete agent interaction loop.
@@ -38,10 +41,41 @@ func (as *AgentSession) RunInteraction(req models.Model_Request) error {
 }
 
 // RunInteractionWithContext runs a single interaction that can be cancelled via ctx.
+// User messages sent meanwhile (see HandleUserInputMessage) steer the turn at tool-loop
+// boundaries or run as further turns before it returns; they are dropped if a turn fails
+// or ctx is cancelled. Interactions on connections sharing the session run one at a time.
+func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req models.Model_Request) error {
+	as.inputs.start()
+	defer as.inputs.finish()
+
+	// Number and buffer this interaction's events so a reconnecting client can resume the stream.
+	// The interaction keeps running if the client disconnects.
+	as.beginResumableTurn()
+	defer as.endResumableTurn()
+
+	for {
+		if err := as.runTurn(ctx, req); err != nil || ctx.Err() != nil {
+			for _, input := range as.inputs.drop() {
+				_ = as.sendInputStatus(input.id, input.delivery, InputDropped, 0)
+			}
+			return err
+		}
+		input, ok := as.inputs.next()
+		if !ok {
+			return nil
+		}
+		_ = as.sendInputStatus(input.id, input.delivery, InputStarted, 0)
+		req = input.req
+	}
+}
+
+// runTurn runs one turn: the model call and its tool loop.
 // Important:
 // - We keep the ElevenLabs websocket alive across turns (for low overhead),
 // - BUT we create a fresh ElevenLabs context_id per turn so every response reliably produces audio.
-func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req models.Model_Request) error {
+func (as *AgentSession) runTurn(ctx context.Context, req models.Model_Request) (err error) {
+	ctx, turn := telemetry.StartTurn(ctx, as.SessionID, as.UserID)
+	defer func() { turn.End(err) }()
 
 	// Set up history warning callback to send warnings to frontend
 	// This is called when the model adapts conversation history and some content is filtered
@@ -49,9 +83,9 @@ func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req model
 	as.Agent.SetHistoryWarningCallback(func(warnings []models.HistoryWarning) {
 		if len(warnings) > 0 && !warningsSent {
 			warningsSent = true
-			_ = as.Writer.WriteResponse(map[string]any{
-				"type":     "history_warnings",
-				"warnings": warnings,
+			_ = as.Writer.WriteResponse(WebSocketHistoryWarningsMessage{
+				Type:     EventHistoryWarnings,
+				Warnings: warnings,
 			})
 		}
 	})
@@ -80,7 +114,7 @@ func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req model
 		// This ensures that when we fetch history, it includes the tool results we just saved
 		// (which are the responses to function_calls that were saved in the previous iteration)
 		if err := as.saveIncomingMessage(currentReq); err != nil {
-			as.Logger.Printf("Error saving incoming message: %v", err)
+			as.Logger.Error("Failed to save incoming message", logging.KeyError, err)
 		}
 
 		// Fetch latest history (after saving, so it includes the just-saved messages)
@@ -91,36 +125,43 @@ func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req model
 		// Enable TTS streaming only for voice interactions.
 		if strings.EqualFold(inputMode, "voice") {
 			if err := as.ensureTTS(ctx, voiceLanguageCode); err != nil {
-				as.Logger.Printf("TTS init error: %v", err)
-				_ = as.Writer.WriteResponse(map[string]any{"type": "tts_error", "error": err.Error()})
+				as.Logger.Warn("Failed to start text-to-speech", logging.KeyError, err)
+				_ = as.Writer.WriteResponse(WebSocketTTSStatusMessage{Type: EventTTSError, Error: err.Error()})
 				// Do not fail the interaction if TTS fails; continue with text-only.
 				as.shutdownTTS(ctx)
 			} else if as.ttsClient != nil && as.ttsContextID != "" {
 				// IMPORTANT: ElevenLabs enforces a max number of contexts per websocket connection.
 				// Keep ONE context per session and reuse it across turns; just reset our local buffer and
 				// let flush at end-of-turn trigger audio for that turn.
-				_ = as.Writer.WriteResponse(map[string]any{
-					"type":       "tts_context_started",
-					"context_id": as.ttsContextID,
-					"format":     as.ttsFormat,
+				_ = as.Writer.WriteResponse(WebSocketTTSContextMessage{
+					Type:      EventTTSContextStarted,
+					ContextID: as.ttsContextID,
+					Format:    as.ttsFormat,
 				})
 			} else {
 				// Voice mode requested, but TTS isn't configured (e.g., missing ELEVENLABS_API_KEY).
-				_ = as.Writer.WriteResponse(map[string]any{"type": "tts_unconfigured"})
+				_ = as.Writer.WriteResponse(WebSocketTTSStatusMessage{Type: EventTTSUnconfigured})
 			}
 		}
 
 		// Run agent stream - now we can pass history directly since types match
-		resChan, errChan := as.Agent.Run_Stream(currentReq, as.History)
+		metrics := newResponseMetrics(ctx, as.Agent, true)
+		resChan, errChan := runStream(ctx, as.Agent, as.withMemoryContext(currentReq, inputMode), as.History)
 
 		// Process stream and accumulate parts
-		accumulatedParts, err := as.processStream(ctx, resChan, errChan)
+		accumulatedParts, err := as.processStream(ctx, resChan, errChan, metrics)
 		if err != nil {
+			metrics.metadata(err)
 			return err
 		}
 
+		metadata := metrics.metadata(nil)
+		if ctx.Err() != nil && metadata.FinishReason == "" {
+			metadata.FinishReason = "cancelled"
+		}
+
 		// Process accumulated parts for tools and text
-		toolResults, executed, err := as.processAccumulatedParts(accumulatedParts)
+		toolResults, executed, err := as.processAccumulatedParts(ctx, accumulatedParts, metadata)
 		if err != nil {
 			return err
 		}
@@ -130,10 +171,24 @@ func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req model
 			break
 		}
 
+		// Steering input sent during the turn is saved after the tool results, so every
+		// function_call -> function_response cycle stays intact, and is sent in their place
+		if steering := as.inputs.takeSteering(); len(steering) > 0 {
+			if err := as.saveToolResults(toolResults); err != nil {
+				as.Logger.Error("Failed to save tool results", logging.KeyError, err)
+			}
+			for _, input := range steering {
+				_ = as.sendInputStatus(input.id, input.delivery, InputInjected, 0)
+			}
+			currentReq = *mergeSteering(steering)
+			continue
+		}
+
 		// Prepare for next iteration with tool results
 		currentReq = models.Model_Request{
 			User_Message: nil,
 			Tool_Results: &toolResults,
+			Client:       currentReq.Client,
 		}
 	}
 
@@ -142,6 +197,8 @@ func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req model
 		return nil
 	}
 
+	as.processTurnMemoryAsync()
+
 	// Important UX detail:
 	// Send "done" immediately so the frontend stops showing the typing indicator,
 	// and let the long-lived TTS forwarder deliver audio chunks asynchronously.
@@ -163,7 +220,7 @@ func (as *AgentSession) RunInteractionWithContext(ctx context.Context, req model
 func (as *AgentSession) fetchHistory() error {
 	history, err := as.Store.FetchHistory(as.SessionID, 0)
 	if err != nil {
-		as.Logger.Printf("Error fetching history: %v", err)
+		as.Logger.Error("Failed to fetch history", logging.KeyError, err)
 		return &AgentError{Message: "Failed to fetch history", Fatal: false}
 	}
 	as.History = history
@@ -201,7 +258,7 @@ func (as *AgentSession) saveUserMessage(userMsg *models.User_Message) error {
 
 	// Legacy support: if no parts but text exists, create text part
 	if len(userPartsToSave) == 0 && userText != "" {
-		as.Logger.Printf("Warning: User message had text but no parts structure; creating text part.")
+		as.Logger.Warn("User message had text but no parts; creating a text part")
 		userPartsToSave = append(userPartsToSave, models.User_Part{Text: userText})
 	}
 
@@ -241,7 +298,7 @@ func (as *AgentSession) saveToolResults(toolResults []models.Tool_Result) error
 }
 
 // processStream handles the agent stream processing
-func (as *AgentSession) processStream(ctx context.Context, resChan <-chan models.Model_Response, errChan <-chan error) ([]models.Model_Part, error) {
+func (as *AgentSession) processStream(ctx context.Context, resChan <-chan models.Model_Response, errChan <-chan error, metrics *responseMetrics) ([]models.Model_Part, error) {
 	var accumulated []models.Model_Part
 
 	for {
@@ -251,21 +308,11 @@ func (as *AgentSession) processStream(ctx context.Context, resChan <-chan models
 			return accumulated, nil
 		case chunk, ok := <-resChan:
 			if !ok {
-				as.Logger.Printf("Stream finished normally")
+				as.Logger.Debug("Stream finished")
 				return accumulated, nil
 			}
-			accumulated = append(accumulated, chunk.Parts...)
-			if err := as.Writer.WriteResponse(chunk); err != nil {
-				as.Logger.Printf("Error writing stream chunk: %v", err)
-				return nil, &AgentError{Message: "Error writing stream chunk", Fatal: true}
-			}
-
-			if as.ttsClient != nil {
-				for _, p := range chunk.Parts {
-					if p.Text != nil && *p.Text != "" {
-						as.ttsHandleDelta(ctx, *p.Text)
-					}
-				}
+			if err := as.handleStreamChunk(ctx, chunk, &accumulated, metrics); err != nil {
+				return nil, err
 			}
 			continue
 		default:
@@ -276,27 +323,18 @@ func (as *AgentSession) processStream(ctx context.Context, resChan <-chan models
 			return accumulated, nil
 		case chunk, ok := <-resChan:
 			if !ok {
-				as.Logger.Printf("Stream finished normally")
+				as.Logger.Debug("Stream finished")
 				return accumulated, nil
 			}
-			accumulated = append(accumulated, chunk.Parts...)
-			if err := as.Writer.WriteResponse(chunk); err != nil {
-				as.Logger.Printf("Error writing stream chunk: %v", err)
-				return nil, &AgentError{Message: "Error writing stream chunk", Fatal: true}
-			}
-
-			if as.ttsClient != nil {
-				for _, p := range chunk.Parts {
-					if p.Text != nil && *p.Text != "" {
-						as.ttsHandleDelta(ctx, *p.Text)
-					}
-				}
+			if err := as.handleStreamChunk(ctx, chunk, &accumulated, metrics); err != nil {
+				return nil, err
 			}
 
 		case streamErr, ok := <-errChan:
 			if ok && streamErr != nil {
-				as.Logger.Printf("Stream error: %v", streamErr)
+				as.Logger.Error("Stream failed", logging.KeyError, streamErr)
 				as.Writer.WriteError("Agent stream error: " + streamErr.Error())
+				as.savePartialResponse(accumulated, metrics.metadata(streamErr))
 				return nil, &AgentError{Message: "Agent stream error", Fatal: false}
 			}
 			if !ok {
@@ -306,12 +344,46 @@ func (as *AgentSession) processStream(ctx context.Context, resChan <-chan models
 		}
 
 		if resChan == nil && errChan == nil {
-			as.Logger.Printf("Both agent stream channels closed unexpectedly")
+			as.Logger.Warn("Both agent stream channels closed unexpectedly")
 			return accumulated, nil
 		}
 	}
 }
 
+// handleStreamChunk records metrics for a chunk, accumulates its parts and forwards it to the client
+func (as *AgentSession) handleStreamChunk(ctx context.Context, chunk models.Model_Response, accumulated *[]models.Model_Part, metrics *responseMetrics) error {
+	metrics.observe(chunk)
+	if isMetadataOnly(chunk) {
+		return nil
+	}
+
+	*accumulated = append(*accumulated, chunk.Parts...)
+	if err := as.Writer.WriteResponse(chunk); err != nil {
+		as.Logger.Warn("Failed to write stream chunk", logging.KeyError, err)
+		return &AgentError{Message: "Error writing stream chunk", Fatal: true}
+	}
+
+	if as.ttsClient != nil {
+		for _, p := range chunk.Parts {
+			if p.Text != nil && *p.Text != "" {
+				as.ttsHandleDelta(ctx, *p.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// savePartialResponse saves the text streamed before an error so the message and its error metadata are kept
+func (as *AgentSession) savePartialResponse(parts []models.Model_Part, metadata *models.MessageMetadata) {
+	partial := partialTextParts(parts)
+	if partial == nil {
+		return
+	}
+	if err := saveModelMessage(as.Store, as.SessionID, as.UserID, "model_message", partial, "", metadata); err != nil {
+		as.Logger.Error("Failed to save partial response", logging.KeyError, err)
+	}
+}
+
 func (as *AgentSession) ensureTTS(ctx context.Context, languageCode string) error {
 	lang := strings.ToLower(strings.TrimSpace(languageCode))
 
@@ -404,10 +476,10 @@ func (as *AgentSession) ensureTTS(ctx context.Context, languageCode string) erro
 	as.ensureTTSForwarder()
 
 	// Initialize the single long-lived context once per session.
-	_ = as.Writer.WriteResponse(map[string]any{
-		"type":       "tts_context_started",
-		"context_id": as.ttsContextID,
-		"format":     as.ttsFormat,
+	_ = as.Writer.WriteResponse(WebSocketTTSContextMessage{
+		Type:      EventTTSContextStarted,
+		ContextID: as.ttsContextID,
+		Format:    as.ttsFormat,
 	})
 	// Initialize context using a short-lived independent context so barge-in cancellation
 	// doesn't prevent TTS from ever starting.
@@ -518,16 +590,16 @@ func (as *AgentSession) forwardTTSEvent(ev eleven_tts.IncomingMessage) {
 		if ev.AudioB64 == "" {
 			return
 		}
-		_ = as.Writer.WriteResponse(map[string]any{
-			"type":       "tts_audio_chunk",
-			"context_id": ev.ContextID,
-			"audio":      ev.AudioB64,
-			"format":     as.ttsFormat,
+		_ = as.Writer.WriteResponse(WebSocketTTSAudioMessage{
+			Type:      EventTTSAudioChunk,
+			ContextID: ev.ContextID,
+			Audio:     ev.AudioB64,
+			Format:    as.ttsFormat,
 		})
 	case "final":
-		_ = as.Writer.WriteResponse(map[string]any{
-			"type":       "tts_context_final",
-			"context_id": ev.ContextID,
+		_ = as.Writer.WriteResponse(WebSocketTTSContextMessage{
+			Type:      EventTTSContextFinal,
+			ContextID: ev.ContextID,
 		})
 	default:
 		// ignore unknown
@@ -570,7 +642,7 @@ func (as *AgentSession) ensureTTSForwarder() {
 					as.forwardTTSEvent(ev)
 				case err, ok := <-errs:
 					if ok && err != nil {
-						_ = as.Writer.WriteResponse(map[string]any{"type": "tts_error", "error": err.Error()})
+						_ = as.Writer.WriteResponse(WebSocketTTSStatusMessage{Type: EventTTSError, Error: err.Error()})
 					}
 				}
 			}
@@ -593,7 +665,7 @@ func lastBoundaryIndex(s string) int {
 }
 
 // processAccumulatedParts processes accumulated parts for function calls and text
-func (as *AgentSession) processAccumulatedParts(parts []models.Model_Part) ([]models.Tool_Result, bool, error) {
+func (as *AgentSession) processAccumulatedParts(ctx context.Context, parts []models.Model_Part, metadata *models.MessageMetadata) ([]models.Tool_Result, bool, error) {
 	if len(parts) == 0 {
 		return nil, false, nil
 	}
@@ -607,8 +679,8 @@ func (as *AgentSession) processAccumulatedParts(parts []models.Model_Part) ([]mo
 	functionCalls := as.extractFunctionCalls(parts, &finalText, &finalReasoning)
 
 	if len(functionCalls) > 0 {
-		// Process function calls
-		modelPartsToSave := make([]models.Model_Part, 0, len(functionCalls))
+		// Process function calls, keeping the thinking that led to them
+		modelPartsToSave := reasoningParts(parts)
 
 		for _, fc := range functionCalls {
 			// Create model part for saving
@@ -618,25 +690,26 @@ func (as *AgentSession) processAccumulatedParts(parts []models.Model_Part) ([]mo
 					Name: fc.Name,
 					Args: fc.Args,
 				},
-				Text: fc.TextInPart,
+				Text:      fc.TextInPart,
+				Signature: fc.Signature,
 			}
 			modelPartsToSave = append(modelPartsToSave, part)
 
 			// Check approval and execute if auto-approved
 			if approved, err := as.checkAndExecuteTool(fc); err != nil {
-				as.Logger.Printf("Error checking tool approval for %s (ID: %s): %v", fc.Name, fc.ID, err)
+				as.Logger.Error("Failed to check tool approval", logging.KeyTool, fc.Name, "tool_call_id", fc.ID, logging.KeyError, err)
 				continue
 			} else if approved {
-				toolResult, execErr := as.executeTool(fc)
+				toolResult, execErr := as.executeTool(ctx, fc)
 				if execErr != nil {
-					as.Logger.Printf("Error executing tool %s (ID: %s): %v", fc.Name, fc.ID, execErr)
+					as.Logger.Error("Tool execution failed", logging.KeyTool, fc.Name, "tool_call_id", fc.ID, logging.KeyError, execErr)
 					// Include error message in result so the model can see what went wrong
 					toolResult = fmt.Sprintf(`{"error": %q}`, execErr.Error())
 				}
 
 				// Send tool result to client
 				if err := as.sendToolResult(fc, toolResult); err != nil {
-					as.Logger.Printf("Error sending tool result: %v", err)
+					as.Logger.Warn("Failed to send tool result", logging.KeyTool, fc.Name, logging.KeyError, err)
 				}
 
 				// Add to results for next iteration
@@ -651,8 +724,8 @@ func (as *AgentSession) processAccumulatedParts(parts []models.Model_Part) ([]mo
 
 		// Save function calls to database
 		if len(modelPartsToSave) > 0 {
-			if err := as.Store.SaveMessageWithUser(as.SessionID, as.UserID, "model", "function_call", modelPartsToSave, ""); err != nil {
-				as.Logger.Printf("Error saving function call message: %v", err)
+			if err := saveModelMessage(as.Store, as.SessionID, as.UserID, "function_call", modelPartsToSave, "", metadata); err != nil {
+				as.Logger.Error("Failed to save function call message", logging.KeyError, err)
 			}
 		}
 
@@ -663,15 +736,18 @@ func (as *AgentSession) processAccumulatedParts(parts []models.Model_Part) ([]mo
 		}
 
 		// Save text response with reasoning if present
-		partsToSave := []models.Model_Part{}
-		if finalReasoning != "" {
-			partsToSave = append(partsToSave, models.Model_Part{Reasoning: &finalReasoning})
-		}
+		partsToSave := reasoningParts(parts)
 		if finalText != "" {
-			partsToSave = append(partsToSave, models.Model_Part{Text: &finalText})
+			textPart := models.Model_Part{Text: &finalText}
+			for _, part := range parts {
+				if part.Text != nil && part.Signature != nil {
+					textPart.Signature = part.Signature
+				}
+			}
+			partsToSave = append(partsToSave, textPart)
 		}
-		if err := as.Store.SaveMessageWithUser(as.SessionID, as.UserID, "model", "model_message", partsToSave, ""); err != nil {
-			as.Logger.Printf("Error saving text message: %v", err)
+		if err := saveModelMessage(as.Store, as.SessionID, as.UserID, "model_message", partsToSave, "", metadata); err != nil {
+			as.Logger.Error("Failed to save text message", logging.KeyError, err)
 		}
 		if finalText != "" {
 			as.saveToMemoryAsync(finalText, "model")
@@ -718,6 +794,7 @@ func (as *AgentSession) extractFunctionCalls(parts []models.Model_Part, finalTex
 					ID:         id,
 					ArgsJSON:   argsJSON,
 					TextInPart: part.Text,
+					Signature:  part.Signature,
 				})
 			}
 		}
@@ -726,13 +803,43 @@ func (as *AgentSession) extractFunctionCalls(parts []models.Model_Part, finalTex
 	return functionCalls
 }
 
+// reasoningParts joins streamed reasoning deltas into one part per thinking block, keeping the
+// signatures and redacted blocks that providers require to be sent back during tool use
+func reasoningParts(parts []models.Model_Part) []models.Model_Part {
+	var result []models.Model_Part
+	var reasoning strings.Builder
+	flush := func(signature *string) {
+		if reasoning.Len() == 0 && signature == nil {
+			return
+		}
+		text := reasoning.String()
+		result = append(result, models.Model_Part{Reasoning: &text, Signature: signature})
+		reasoning.Reset()
+	}
+	for _, part := range parts {
+		if part.RedactedReasoning != nil {
+			flush(nil)
+			result = append(result, models.Model_Part{RedactedReasoning: part.RedactedReasoning})
+		}
+		if part.Reasoning != nil {
+			reasoning.WriteString(*part.Reasoning)
+		}
+		// Signatures on text or function call parts stay with those parts
+		if part.Signature != nil && part.Text == nil && part.FunctionCall == nil {
+			flush(part.Signature)
+		}
+	}
+	flush(nil)
+	return result
+}
+
 // checkAndExecuteTool checks if a tool should be auto-approved
 func (as *AgentSession) checkAndExecuteTool(fc functionCallInfo) (bool, error) {
 	return as.Agent.ApproveTool(fc.Name, fc.Args)
 }
 
 // executeTool executes a tool and returns the result
-func (as *AgentSession) executeTool(fc functionCallInfo) (string, error) {
+func (as *AgentSession) executeTool(ctx context.Context, fc functionCallInfo) (string, error) {
 	// Log tool call
 	if as.FlowLogger != nil {
 		as.FlowLogger.LogToolCall(as.SessionID, fc.Name, fc.Args)
@@ -742,36 +849,35 @@ func (as *AgentSession) executeTool(fc functionCallInfo) (string, error) {
 	startTime := time.Now()
 	traceID := fmt.Sprintf("tool_%s_%d", fc.ID, startTime.UnixMilli())
 	as.emitToolTrace(fc.ID, traceID, fc.Name, "start", getToolStartLabel(fc.Name, fc.Args), nil)
+	ctx, span := telemetry.StartToolCall(ctx, fc.Name, fc.ID)
 
 	var result string
 	var err error
 
-	// Special handling for Consult_Model — route through the session's consultant engine
-	if fc.Name == "Consult_Model" {
-		result, err = as.executeConsultModel(fc)
-	} else if fc.Name == "Execute_TypeScript" {
-		// Special handling for Execute_TypeScript to enable detailed internal tracing
-		result, err = as.executeTypeScriptWithTracing(fc)
-	} else if as.FrontendToolExecutor != nil && as.FrontendToolExecutor.IsFrontendTool(fc.Name) {
-		// Check FrontendToolExecutor if it exists and this is a frontend tool
-		result, err = as.FrontendToolExecutor.ExecuteFrontendTool(fc.Name, fc.Args)
+	if run := as.sessionTool(fc.Name); run != nil {
+		// Tools the session runs itself go through the agent's interceptors here;
+		// Agent.ExecuteTool applies them to the rest
+		result, err = as.interceptTool(ctx, fc, run)
 	} else if as.ToolExecutor != nil {
 		// If a custom tool executor is set (for frontend tools), use it
-		result, err = as.ToolExecutor(
-			fc.Name,
-			fc.Args,
-			as.Agent,
-			as.SessionID,
-			as.Writer,
-			as.ResponseWaiter,
-			as.Logger,
-		)
+		result, err = as.interceptTool(ctx, fc, func(ctx context.Context, fc functionCallInfo) (string, error) {
+			return as.ToolExecutor(
+				fc.Name,
+				fc.Args,
+				as.Agent,
+				as.SessionID,
+				as.Writer,
+				as.ResponseWaiter,
+				as.Logger.With(logging.KeyTool, fc.Name),
+			)
+		})
 	} else {
 		// Otherwise, use the standard agent ExecuteTool
 		result, err = as.Agent.ExecuteTool(fc.Name, fc.Args, as.SessionID)
 	}
 
 	// Emit end trace
+	span.End(err)
 	durationMs := time.Since(startTime).Milliseconds()
 	if err != nil {
 		as.emitToolTrace(fc.ID, traceID, fc.Name, "error", getToolErrorLabel(fc.Name, err), &durationMs)
@@ -791,8 +897,59 @@ func (as *AgentSession) executeTool(fc functionCallInfo) (string, error) {
 	return result, err
 }
 
+// sessionTool returns the session's own executor for tools that do not go through
+// Agent.ExecuteTool, or nil
+func (as *AgentSession) sessionTool(name string) func(ctx context.Context, fc functionCallInfo) (string, error) {
+	switch {
+	case name == "Consult_Model":
+		// Routed through the session's consultant engine
+		return as.executeConsultModel
+	case name == "Execute_TypeScript":
+		// Run with detailed internal tracing
+		return as.executeTypeScriptWithTracing
+	case common_tools.IsMemoryTool(name):
+		// Bound to this session's memory and user
+		return as.executeMemoryTool
+	case as.FrontendToolExecutor != nil && as.FrontendToolExecutor.IsFrontendTool(name):
+		return func(ctx context.Context, fc functionCallInfo) (string, error) {
+			return as.FrontendToolExecutor.ExecuteFrontendTool(fc.Name, fc.Args)
+		}
+	}
+	return nil
+}
+
+// runStream starts a model stream that stops when ctx is done, if the agent supports it
+func runStream(ctx context.Context, agent AgentInterface, request models.Model_Request, history []stores.Message) (<-chan models.Model_Response, <-chan error) {
+	if streamer, ok := agent.(ContextStreamer); ok {
+		return streamer.RunStreamContext(ctx, request, history)
+	}
+	return agent.Run_Stream(request, history)
+}
+
+// interceptTool runs a session tool through the agent's interceptors, if it has any
+func (as *AgentSession) interceptTool(ctx context.Context, fc functionCallInfo, run func(ctx context.Context, fc functionCallInfo) (string, error)) (string, error) {
+	host, ok := as.Agent.(InterceptorHost)
+	if !ok {
+		return run(ctx, fc)
+	}
+	call := &ToolCall{ID: fc.ID, Name: fc.Name, Args: fc.Args, SessionID: as.SessionID}
+	return host.Interceptors().RunTool(call, func(call *ToolCall) (string, error) {
+		fc.Name, fc.Args = call.Name, call.Args
+		return run(ctx, fc)
+	})
+}
+
+// Use adds interceptors to the session's agent. Returns false if the agent does not support them.
+func (as *AgentSession) Use(interceptors ...Interceptor) bool {
+	host, ok := as.Agent.(InterceptorHost)
+	if ok {
+		host.Use(interceptors...)
+	}
+	return ok
+}
+
 // executeConsultModel handles the Consult_Model tool by routing to the session's consultant engine.
-func (as *AgentSession) executeConsultModel(fc functionCallInfo) (string, error) {
+func (as *AgentSession) executeConsultModel(_ context.Context, fc functionCallInfo) (string, error) {
 	if as.ConsultantEngine == nil {
 		return `{"error": "Consultant is not configured for this session. Try solving the problem yourself or ask the user for help."}`, nil
 	}
@@ -805,12 +962,16 @@ func (as *AgentSession) executeConsultModel(fc functionCallInfo) (string, error)
 	specificAsk, _ := fc.Args["specific_ask"].(string)
 
 	// Notify the user that a consultation is happening
-	_ = as.Writer.WriteResponse(map[string]interface{}{
-		"type":    "execution_trace",
-		"tool":    "consultant",
-		"status":  "start",
-		"label":   fmt.Sprintf("Consulting %s model (%s mode)...", "premium", mode),
-		"traceId": fmt.Sprintf("consult_%d", time.Now().UnixMilli()),
+	now := time.Now()
+	_ = as.Writer.WriteResponse(WebSocketTraceMessage{
+		Type:       EventExecutionTrace,
+		TraceID:    fmt.Sprintf("consult_%d", now.UnixMilli()),
+		ToolCallID: fc.ID,
+		Tool:       "consultant",
+		Operation:  fc.Name,
+		Status:     "start",
+		Label:      fmt.Sprintf("Consulting %s model (%s mode)...", "premium", mode),
+		Timestamp:  now.UnixMilli(),
 	})
 
 	// Handle takeover mode separately — it needs to run a full agent loop
@@ -842,37 +1003,40 @@ func (as *AgentSession) executeConsultModel(fc functionCallInfo) (string, error)
 // tool_call/tool_result messages already in chat history. Only TypeScript executor
 // internal traces (from wsTraceEmitterAdapter) are persisted.
 func (as *AgentSession) emitToolTrace(toolCallID, traceID, toolName, status, label string, durationMs *int64) {
-	timestamp := time.Now().UnixMilli()
-	tool := getToolCategory(toolName)
-
 	// Send to WebSocket for real-time visualization
+	msg := newToolTrace(toolCallID, traceID, toolName, status, label, time.Now(), durationMs)
+	// Ignore errors - traces are non-critical for WebSocket
+	_ = as.Writer.WriteResponse(msg)
+
+	// Note: We intentionally do NOT persist regular tool traces to the database.
+	// Regular tools (Search, Generate_Image, etc.) have their execution recorded
+	// in the chat history as tool_call and tool_result messages. Persisting traces
+	// would be redundant. Only TypeScript executor internal operations (web.get,
+	// tavily.search, etc.) are persisted via wsTraceEmitterAdapter.
+}
+
+// newToolTrace builds the execution_trace message for a tool call
+func newToolTrace(toolCallID, traceID, toolName, status, label string, at time.Time, durationMs *int64) WebSocketTraceMessage {
 	msg := WebSocketTraceMessage{
-		Type:       "execution_trace",
+		Type:       EventExecutionTrace,
 		TraceID:    traceID,
 		ToolCallID: toolCallID,
-		Tool:       tool,
+		Tool:       getToolCategory(toolName),
 		Operation:  toolName,
 		Status:     status,
 		Label:      label,
-		Timestamp:  timestamp,
+		Timestamp:  at.UnixMilli(),
 	}
 	if durationMs != nil {
 		msg.DurationMS = *durationMs
 	}
-	// Ignore errors - traces are non-critical for WebSocket
-	_ = as.Writer.WriteResponse(msg)
-
-	// Note: We intentionally do NOT persist regular tool traces to the database.
-	// Regular tools (Search, Generate_Image, etc.) have their execution recorded
-	// in the chat history as tool_call and tool_result messages. Persisting traces
-	// would be redundant. Only TypeScript executor internal operations (web.get,
-	// tavily.search, etc.) are persisted via wsTraceEmitterAdapter.
+	return msg
 }
 
 // getToolCategory returns the category for a tool (for UI icons)
 func getToolCategory(toolName string) string {
 	switch toolName {
-	case "Search", "Brave_Search":
+	case "Search", "Brave_Search", "Search_Documents":
 		return "search"
 	case "Execute_TypeScript":
 		return "code"
@@ -882,6 +1046,8 @@ func getToolCategory(toolName string) string {
 		return "skills"
 	case "Browser_Alert", "Browser_Prompt", "Browser_Navigate", "Sandbox_Run", "Confirm_With_User":
 		return "browser"
+	case common_tools.RememberToolName, common_tools.RecallToolName, common_tools.ForgetToolName:
+		return "memory"
 	default:
 		if strings.Contains(toolName, "Workflow") {
 			return "workflow"
@@ -901,6 +1067,14 @@ func getToolStartLabel(toolName string, args map[string]interface{}) string {
 			return fmt.Sprintf("Searching: \"%s\"", query)
 		}
 		return "Searching the web"
+	case "Search_Documents":
+		if query, ok := args["query"].(string); ok {
+			if len(query) > 50 {
+				query = query[:50] + "..."
+			}
+			return fmt.Sprintf("Searching documents: \"%s\"", query)
+		}
+		return "Searching documents"
 	case "Execute_TypeScript":
 		return "Executing code"
 	case "Generate_Image":
@@ -919,6 +1093,12 @@ func getToolStartLabel(toolName string, args map[string]interface{}) string {
 		return "Navigating"
 	case "Confirm_With_User":
 		return "Waiting for confirmation"
+	case common_tools.RememberToolName:
+		return "Saving to memory"
+	case common_tools.RecallToolName:
+		return "Searching memory"
+	case common_tools.ForgetToolName:
+		return "Forgetting a memory"
 	default:
 		// Convert tool name to readable format
 		readable := strings.ReplaceAll(toolName, "_", " ")
@@ -967,6 +1147,8 @@ func getToolEndLabel(toolName string, args map[string]interface{}) string {
 			return fmt.Sprintf("Searched: \"%s\"", query)
 		}
 		return "Searched the web"
+	case "Search_Documents":
+		return "Searched documents"
 	case "Execute_TypeScript":
 		return "Executed code"
 	case "Generate_Image":
@@ -985,14 +1167,34 @@ func getToolEndLabel(toolName string, args map[string]interface{}) string {
 		return "Navigated"
 	case "Confirm_With_User":
 		return "User responded"
+	case common_tools.RememberToolName:
+		return "Saved to memory"
+	case common_tools.RecallToolName:
+		return "Searched memory"
+	case common_tools.ForgetToolName:
+		return "Forgot a memory"
 	default:
 		readable := strings.ReplaceAll(toolName, "_", " ")
 		return fmt.Sprintf("Ran %s", readable)
 	}
 }
 
+// executeMemoryTool runs Remember, Recall or Forget against the session's (user-scoped) memory
+func (as *AgentSession) executeMemoryTool(ctx context.Context, fc functionCallInfo) (string, error) {
+	if as.Memory == nil {
+		return `{"error": "Long-term memory is not configured for this session."}`, nil
+	}
+
+	traceEmitter := as.newTraceEmitter(ctx, fc.ID)
+
+	tools := common_tools.NewMemoryTools(as.Memory, as.UserID).
+		WithSessionID(as.SessionID).
+		WithTraceEmitter(traceEmitter)
+	return tools.Execute(fc.Name, fc.Args)
+}
+
 // executeTypeScriptWithTracing executes TypeScript code with real-time trace streaming
-func (as *AgentSession) executeTypeScriptWithTracing(fc functionCallInfo) (string, error) {
+func (as *AgentSession) executeTypeScriptWithTracing(ctx context.Context, fc functionCallInfo) (string, error) {
 	// Extract the code argument
 	code, ok := fc.Args["code"].(string)
 	if !ok {
@@ -1011,16 +1213,7 @@ func (as *AgentSession) executeTypeScriptWithTracing(fc functionCallInfo) (strin
 
 	// Create a trace emitter that sends traces over WebSocket and saves to DB
 	// The trace emitter is linked to this specific tool call via fc.ID
-	traceEmitter := &wsTraceEmitterAdapter{
-		emitter: &WebSocketTraceEmitter{
-			Writer:     as.Writer,
-			ToolCallID: fc.ID,
-		},
-		traceStore:     as.TraceStore,
-		conversationID: as.SessionID,
-		toolCallID:     fc.ID,
-		logger:         as.Logger,
-	}
+	traceEmitter := as.newTraceEmitter(ctx, fc.ID)
 
 	// Create a frontend action handler for navigate/alert actions from TypeScript
 	// Uses a dedicated ResponseWaiter for frontend action responses
@@ -1043,7 +1236,26 @@ type wsTraceEmitterAdapter struct {
 	traceStore     stores.TraceStore
 	conversationID string
 	toolCallID     string
-	logger         *log.Logger
+	spanTraceID    string // OpenTelemetry IDs of the tool call span, saved with each trace
+	spanID         string
+	logger         *slog.Logger
+}
+
+// newTraceEmitter returns a trace emitter for a tool call that streams traces to the client
+// and saves them, linked to the tool call's telemetry span in ctx
+func (as *AgentSession) newTraceEmitter(ctx context.Context, toolCallID string) *wsTraceEmitterAdapter {
+	adapter := &wsTraceEmitterAdapter{
+		emitter: &WebSocketTraceEmitter{
+			Writer:     as.Writer,
+			ToolCallID: toolCallID,
+		},
+		traceStore:     as.TraceStore,
+		conversationID: as.SessionID,
+		toolCallID:     toolCallID,
+		logger:         as.Logger,
+	}
+	adapter.spanTraceID, adapter.spanID = telemetry.SpanIDs(ctx)
+	return adapter
 }
 
 func (a *wsTraceEmitterAdapter) EmitTrace(trace common_tools.TraceEvent) error {
@@ -1075,11 +1287,13 @@ func (a *wsTraceEmitterAdapter) EmitTrace(trace common_tools.TraceEvent) error {
 			Details:        trace.Details,
 			Timestamp:      trace.Timestamp,
 			DurationMS:     trace.DurationMS,
+			SpanTraceID:    a.spanTraceID,
+			SpanID:         a.spanID,
 		}
 		// Save async to avoid blocking
 		go func() {
 			if saveErr := a.traceStore.SaveTrace(dbTrace); saveErr != nil && a.logger != nil {
-				a.logger.Printf("Warning: Failed to save TypeScript trace to database: %v", saveErr)
+				a.logger.Warn("Failed to save TypeScript trace", logging.KeyError, saveErr)
 			}
 		}()
 	}
@@ -1089,6 +1303,11 @@ func (a *wsTraceEmitterAdapter) EmitTrace(trace common_tools.TraceEvent) error {
 
 // sendToolResult sends a tool result to the WebSocket client
 func (as *AgentSession) sendToolResult(fc functionCallInfo, toolResultJSON string) error {
+	return as.Writer.WriteResponse(newToolResultMessage(fc.ID, fc.Name, toolResultJSON))
+}
+
+// newToolResultMessage builds the tool_result message for a tool's output
+func newToolResultMessage(functionID, functionName, toolResultJSON string) WebSocketToolResultMessage {
 	var resultData map[string]interface{}
 	if err := json.Unmarshal([]byte(toolResultJSON), &resultData); err != nil {
 		// Not JSON - wrap plain text output in a structure
@@ -1096,20 +1315,18 @@ func (as *AgentSession) sendToolResult(fc functionCallInfo, toolResultJSON strin
 		resultData = map[string]interface{}{"output": toolResultJSON}
 	}
 
-	toolMsg := WebSocketToolResultMessage{
-		Type:         "tool_result",
-		FunctionName: fc.Name,
-		FunctionID:   fc.ID,
+	return WebSocketToolResultMessage{
+		Type:         EventToolResult,
+		FunctionName: functionName,
+		FunctionID:   functionID,
 		Result:       resultData,
 		ResultJSON:   toolResultJSON,
 	}
-
-	return as.Writer.WriteResponse(toolMsg)
 }
 
 // sendError sends an error message and returns an AgentError
 func (as *AgentSession) sendError(message string, fatal bool) error {
-	as.Logger.Printf("Error: %s (fatal: %v)", message, fatal)
+	as.Logger.Error(message, "fatal", fatal)
 	as.Writer.WriteError(message)
 	return &AgentError{Message: message, Fatal: fatal}
 }
@@ -1117,22 +1334,22 @@ func (as *AgentSession) sendError(message string, fatal bool) error {
 // saveToMemoryAsync saves content to memory asynchronously (fire-and-forget)
 func (as *AgentSession) saveToMemoryAsync(content string, role string) {
 	if as.Memory == nil {
-		as.Logger.Printf("[SESSION-MEMORY] Memory is nil, skipping save for role=%s", role)
+		return
+	}
+	if _, ok := as.Memory.(TurnMemory); ok {
+		// Whole turns are handed over in processTurnMemoryAsync instead
 		return
 	}
 
-	as.Logger.Printf("[SESSION-MEMORY] Queueing async memory save: role=%s contentLen=%d preview='%.200s'", role, len(content), content)
+	logger := as.Logger.With(logging.KeyComponent, "memory", "role", role)
 
 	go func() {
 		contextText := as.buildMemoryContext()
 		if contextText != "" {
-			as.Logger.Printf("[SESSION-MEMORY] Using conversation context for memory (len=%d): '%.300s'", len(contextText), contextText)
 			content = contextText
-		} else {
-			as.Logger.Printf("[SESSION-MEMORY] No conversation context, using raw content")
 		}
 		if content == "" {
-			as.Logger.Printf("[SESSION-MEMORY] SKIPPED: content is empty after context build")
+			logger.Debug("Skipping memory save of empty content")
 			return
 		}
 
@@ -1141,11 +1358,52 @@ func (as *AgentSession) saveToMemoryAsync(content string, role string) {
 			"role":       role,
 			"timestamp":  time.Now().Format(time.RFC3339),
 		}
-		as.Logger.Printf("[SESSION-MEMORY] Calling AddMemory: role=%s contentLen=%d", role, len(content))
+		if as.TenantID != "" {
+			metadata["tenant_id"] = as.TenantID
+		}
+		if as.UserID != "" {
+			metadata["user_id"] = as.UserID
+		}
 		if err := as.Memory.AddMemory(content, metadata); err != nil {
-			as.Logger.Printf("[SESSION-MEMORY] FAILED to save memory: %v", err)
+			logger.Error("Failed to save memory", logging.KeyError, err)
 		} else {
-			as.Logger.Printf("[SESSION-MEMORY] SUCCESS: saved memory for role=%s", role)
+			logger.Debug("Saved memory", "content_length", len(content))
+		}
+	}()
+}
+
+// processTurnMemoryAsync hands the completed turn to a TurnMemory (fire-and-forget)
+func (as *AgentSession) processTurnMemoryAsync() {
+	turnMemory, ok := as.Memory.(TurnMemory)
+	if !ok || as.Store == nil {
+		return
+	}
+
+	go func() {
+		history, err := as.Store.FetchHistory(as.SessionID, 0)
+		if err != nil {
+			as.Logger.Error("Failed to fetch history for turn memory", logging.KeyComponent, "memory", logging.KeyError, err)
+			return
+		}
+		start := -1
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Type == "user_message" {
+				start = i
+				break
+			}
+		}
+		if start == -1 {
+			return
+		}
+
+		turn := MemoryTurn{
+			SessionID: as.SessionID,
+			UserID:    as.UserID,
+			TenantID:  as.TenantID,
+			Messages:  history[start:],
+		}
+		if err := turnMemory.ProcessTurn(turn); err != nil {
+			as.Logger.Error("Failed to process turn memory", logging.KeyComponent, "memory", logging.KeyError, err)
 		}
 	}()
 }
@@ -1243,6 +1501,36 @@ func (as *AgentSession) retrieveMemories(queryText string, mode string) ([]strin
 	return as.Memory.RetrieveMemories(queryText, limit)
 }
 
+// withMemoryContext returns the request with relevant memories prepended to the user message.
+// The stored message is not changed; memories only reach the model for this call.
+func (as *AgentSession) withMemoryContext(req models.Model_Request, mode string) models.Model_Request {
+	if as.Memory == nil || req.User_Message == nil {
+		return req
+	}
+
+	memories, err := as.retrieveMemories(as.extractTextFromMessage(req.User_Message), mode)
+	if err != nil {
+		as.Logger.Error("Failed to retrieve memories", logging.KeyComponent, "memory", logging.KeyError, err)
+		return req
+	}
+	if len(memories) == 0 {
+		return req
+	}
+
+	var b strings.Builder
+	b.WriteString("Relevant memories from earlier conversations (use only if helpful):\n")
+	for _, memory := range memories {
+		b.WriteString("- ")
+		b.WriteString(strings.ReplaceAll(strings.TrimSpace(memory), "\n", " "))
+		b.WriteString("\n")
+	}
+
+	userMsg := *req.User_Message
+	userMsg.Content.Parts = append([]models.User_Part{{Text: b.String()}}, userMsg.Content.Parts...)
+	req.User_Message = &userMsg
+	return req
+}
+
 // extractTextFromMessage extracts text content from a user message
 func (as *AgentSession) extractTextFromMessage(userMsg *models.User_Message) string {
 	if userMsg == nil || userMsg.Content.Parts == nil {
@@ -0,0 +1,98 @@
+package stores
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrConversationNotFound is returned when a conversation does not exist for the store's tenant
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationManager is implemented by stores that can look up, rename and delete
+// single conversations. All operations are scoped to the store's tenant.
+type ConversationManager interface {
+	// GetConversation returns a conversation's details
+	GetConversation(convoID string) (*ConversationInfo, error)
+
+	// RenameConversation sets a conversation's title
+	RenameConversation(convoID, title string) error
+
+	// DeleteConversation permanently removes a conversation with its messages and feedback
+	DeleteConversation(convoID string) error
+}
+
+// getConversation loads a conversation with its message count computed from the messages table
+func getConversation(db *gorm.DB, tenantID, convoID string) (*ConversationInfo, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	var convs []Conversation
+	if err := db.Where("conversation_id = ? AND tenant_id = ?", convoID, tenantID).Limit(1).Find(&convs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch conversation: %w", err)
+	}
+	if len(convs) == 0 {
+		return nil, fmt.Errorf("conversation %s: %w", convoID, ErrConversationNotFound)
+	}
+
+	var count int64
+	if err := db.Model(&Message{}).Where("conversation_id = ? AND tenant_id = ?", convoID, tenantID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	c := convs[0]
+	return &ConversationInfo{
+		ConversationID: c.ConversationID,
+		TenantID:       c.TenantID,
+		UserID:         c.UserID,
+		Title:          c.Title,
+		MessageCount:   int(count),
+		CreatedAt:      c.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      c.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// renameConversation updates a conversation's title
+func renameConversation(db *gorm.DB, tenantID, convoID, title string) error {
+	if db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	result := db.Model(&Conversation{}).
+		Where("conversation_id = ? AND tenant_id = ?", convoID, tenantID).
+		Update("title", title)
+	if result.Error != nil {
+		return fmt.Errorf("failed to rename conversation: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("conversation %s: %w", convoID, ErrConversationNotFound)
+	}
+	return nil
+}
+
+// deleteConversation hard-deletes a conversation, its messages and its feedback in one transaction,
+// so the conversation ID can be reused
+func deleteConversation(db *gorm.DB, tenantID, convoID string) error {
+	if db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("conversation_id = ? AND tenant_id = ?", convoID, tenantID).Delete(&Conversation{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete conversation: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("conversation %s: %w", convoID, ErrConversationNotFound)
+		}
+		if err := tx.Unscoped().Where("conversation_id = ? AND tenant_id = ?", convoID, tenantID).Delete(&Message{}).Error; err != nil {
+			return fmt.Errorf("failed to delete messages: %w", err)
+		}
+		if err := tx.Where("conversation_id = ? AND tenant_id = ?", convoID, tenantID).Delete(&MessageFeedback{}).Error; err != nil {
+			return fmt.Errorf("failed to delete feedback: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSQLiteStore_ConversationManager(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	seedConversation(t, store, "conv-1")
+	if err := store.SaveFeedback(&MessageFeedback{ConversationID: "conv-1", Sequence: 2, UserID: "alice", Rating: RatingPositive}); err != nil {
+		t.Fatalf("SaveFeedback: %v", err)
+	}
+
+	if err := store.RenameConversation("conv-1", "Greetings"); err != nil {
+		t.Fatalf("RenameConversation: %v", err)
+	}
+	info, err := store.GetConversation("conv-1")
+	if err != nil {
+		t.Fatalf("GetConversation: %v", err)
+	}
+	if info.Title != "Greetings" || info.UserID != "alice" || info.MessageCount != 2 {
+		t.Errorf("Unexpected conversation %+v", info)
+	}
+
+	if _, err := store.ForTenant("acme").(ConversationManager).GetConversation("conv-1"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Expected ErrConversationNotFound from another tenant, got %v", err)
+	}
+	if err := store.RenameConversation("missing", "x"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Expected ErrConversationNotFound renaming a missing conversation, got %v", err)
+	}
+
+	if err := store.DeleteConversation("conv-1"); err != nil {
+		t.Fatalf("DeleteConversation: %v", err)
+	}
+	if _, err := store.GetConversation("conv-1"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Expected ErrConversationNotFound after delete, got %v", err)
+	}
+	if history, _ := store.FetchHistory("conv-1", 0); len(history) != 0 {
+		t.Errorf("Expected messages to be deleted, got %d", len(history))
+	}
+	if feedback, _ := store.GetFeedbackForConversation("conv-1"); len(feedback) != 0 {
+		t.Errorf("Expected feedback to be deleted, got %d", len(feedback))
+	}
+
+	// The ID can be reused once deleted
+	seedConversation(t, store, "conv-1")
+	if info, err := store.GetConversation("conv-1"); err != nil || info.MessageCount != 2 {
+		t.Errorf("Expected recreated conversation with 2 messages, got %+v (%v)", info, err)
+	}
+}
@@ -0,0 +1,443 @@
+package stores
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Desarso/godantic/logging"
+	"gorm.io/gorm"
+)
+
+// encryptedFieldVersion is written into every encrypted envelope so the format can evolve.
+// Version 2 authenticates the field's binding (see FieldBinding) with the ciphertext.
+const encryptedFieldVersion = "v2"
+
+// unboundFieldVersion envelopes were written before fields were bound to their row. They are
+// still read, and rewritten bound to their row like values under an older key.
+const unboundFieldVersion = "v1"
+
+// maxCachedDataKeys bounds the unwrapped data key cache kept by FieldEncryptor.
+const maxCachedDataKeys = 1024
+
+// KeyProvider supplies key-encryption keys (KEKs) for envelope encryption.
+// Every stored value gets its own random data key; the provider only wraps and unwraps
+// that data key, so rotating the current key never requires touching existing rows up front.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key used to wrap new data keys.
+	CurrentKeyID() string
+	// WrapKey encrypts a data key with the key identified by keyID.
+	WrapKey(keyID string, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key previously wrapped with keyID.
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// StaticKeyProvider wraps data keys with AES-256-GCM using a fixed set of in-memory keys.
+// It backs both the local keyfile and the env-derived providers.
+type StaticKeyProvider struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// NewStaticKeyProvider creates a provider from raw 32-byte keys.
+// currentID selects the key used for new writes; the remaining keys are kept for decryption only.
+func NewStaticKeyProvider(currentID string, keys map[string][]byte) (*StaticKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one encryption key is required")
+	}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %q must be 32 bytes, got %d", id, len(key))
+		}
+	}
+	if _, ok := keys[currentID]; !ok {
+		return nil, fmt.Errorf("current encryption key %q not found", currentID)
+	}
+	return &StaticKeyProvider{currentID: currentID, keys: keys}, nil
+}
+
+// CurrentKeyID returns the ID of the key used for new writes
+func (p *StaticKeyProvider) CurrentKeyID() string {
+	return p.currentID
+}
+
+// WrapKey encrypts a data key with the named key
+func (p *StaticKeyProvider) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+	return sealAESGCM(key, dataKey, nil)
+}
+
+// UnwrapKey decrypts a data key with the named key
+func (p *StaticKeyProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+	return openAESGCM(key, wrapped, nil)
+}
+
+// keyFile is the on-disk format read by NewLocalKeyFileProvider:
+//
+//	{"current": "2025-01", "keys": {"2024-06": "<base64>", "2025-01": "<base64>"}}
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// NewLocalKeyFileProvider loads base64-encoded 32-byte keys from a JSON keyfile.
+// Rotate by adding a new key and pointing "current" at it; old keys stay readable.
+func NewLocalKeyFileProvider(path string) (*StaticKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyfile: %w", err)
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("failed to parse keyfile: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(kf.Keys))
+	for id, encoded := range kf.Keys {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	return NewStaticKeyProvider(kf.Current, keys)
+}
+
+// NewEnvKeyProvider derives keys from secrets held in environment variables.
+// The first variable is the current key; any further variables hold previous secrets
+// that are still needed to read rows written before a rotation.
+// Key IDs are fingerprints of the derived keys, so they never reveal the secret.
+func NewEnvKeyProvider(envVar string, previousEnvVars ...string) (*StaticKeyProvider, error) {
+	keys := make(map[string][]byte)
+	currentID := ""
+
+	for i, name := range append([]string{envVar}, previousEnvVars...) {
+		secret := os.Getenv(name)
+		if secret == "" {
+			if i == 0 {
+				return nil, fmt.Errorf("environment variable %s is not set", name)
+			}
+			continue
+		}
+		key, err := hkdf.Key(sha256.New, []byte(secret), nil, "godantic field encryption", 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key from %s: %w", name, err)
+		}
+		sum := sha256.Sum256(key)
+		id := "env-" + hex.EncodeToString(sum[:4])
+		keys[id] = key
+		if i == 0 {
+			currentID = id
+		}
+	}
+
+	return NewStaticKeyProvider(currentID, keys)
+}
+
+// KMSClient is the minimal surface needed from an external key management service
+// (AWS KMS, GCP Cloud KMS, Vault transit, ...). Adapters live in application code.
+type KMSClient interface {
+	Encrypt(keyID string, plaintext []byte) ([]byte, error)
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// KMSKeyProvider wraps data keys using a KMS. Rotate by changing KeyID;
+// rows written under the old key are unwrapped with the key ID recorded in them.
+type KMSKeyProvider struct {
+	Client KMSClient
+	KeyID  string
+}
+
+// CurrentKeyID returns the KMS key used for new writes
+func (p *KMSKeyProvider) CurrentKeyID() string {
+	return p.KeyID
+}
+
+// WrapKey encrypts a data key with the KMS
+func (p *KMSKeyProvider) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	return p.Client.Encrypt(keyID, dataKey)
+}
+
+// UnwrapKey decrypts a data key with the KMS
+func (p *KMSKeyProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	return p.Client.Decrypt(keyID, wrapped)
+}
+
+// encryptedEnvelope is the stored form of an encrypted field.
+// It is itself valid JSON so it fits the json-typed PartsJSON column on PostgreSQL.
+type encryptedEnvelope struct {
+	Version    string `json:"_enc"`
+	KeyID      string `json:"kid"`
+	DataKey    string `json:"dek"`
+	Ciphertext string `json:"ct"`
+}
+
+// FieldEncryptor encrypts individual column values with per-value data keys.
+type FieldEncryptor struct {
+	provider KeyProvider
+
+	cacheMu  sync.Mutex
+	keyCache map[string][]byte
+}
+
+// NewFieldEncryptor creates an encryptor backed by the given key provider
+func NewFieldEncryptor(provider KeyProvider) *FieldEncryptor {
+	return &FieldEncryptor{
+		provider: provider,
+		keyCache: make(map[string][]byte),
+	}
+}
+
+// IsEncryptedField reports whether a stored value is an encrypted envelope
+func IsEncryptedField(value string) bool {
+	return strings.HasPrefix(value, `{"_enc":`)
+}
+
+// FieldBinding identifies the column of a row an encrypted value is stored in. Passed to Encrypt
+// and Decrypt, it is authenticated with the ciphertext, so a value copied into another row or
+// column fails to decrypt.
+func FieldBinding(table, column string, rowID uint) string {
+	return fmt.Sprintf("%s.%s:%d", table, column, rowID)
+}
+
+// Encrypt seals a plaintext value under a fresh data key wrapped with the current key.
+// binding (see FieldBinding) must be passed again to decrypt the value.
+func (e *FieldEncryptor) Encrypt(plaintext, binding string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	keyID := e.provider.CurrentKeyID()
+	wrapped, err := e.provider.WrapKey(keyID, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	ciphertext, err := sealAESGCM(dataKey, []byte(plaintext), []byte(binding))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt field: %w", err)
+	}
+
+	envelope, err := json.Marshal(encryptedEnvelope{
+		Version:    encryptedFieldVersion,
+		KeyID:      keyID,
+		DataKey:    base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(envelope), nil
+}
+
+// Decrypt opens a stored value encrypted with the same binding. Plaintext values (written
+// before encryption was enabled) are returned unchanged. stale is true when the value should
+// be rewritten: it is plaintext, was wrapped with an older key, or is not bound to its row.
+func (e *FieldEncryptor) Decrypt(stored, binding string) (plaintext string, stale bool, err error) {
+	if !IsEncryptedField(stored) {
+		return stored, stored != "", nil
+	}
+
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal([]byte(stored), &envelope); err != nil {
+		return "", false, fmt.Errorf("failed to parse encrypted field: %w", err)
+	}
+	var additionalData []byte
+	switch envelope.Version {
+	case encryptedFieldVersion:
+		additionalData = []byte(binding)
+	case unboundFieldVersion:
+	default:
+		return "", false, fmt.Errorf("unsupported encrypted field version %q", envelope.Version)
+	}
+
+	dataKey, err := e.unwrapDataKey(envelope.KeyID, envelope.DataKey)
+	if err != nil {
+		return "", false, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	data, err := openAESGCM(dataKey, ciphertext, additionalData)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt field: %w", err)
+	}
+
+	stale = envelope.KeyID != e.provider.CurrentKeyID() || (envelope.Version == unboundFieldVersion && binding != "")
+	return string(data), stale, nil
+}
+
+// unwrapDataKey unwraps a data key, caching the result so repeated history reads
+// don't hit a remote KMS for every message.
+func (e *FieldEncryptor) unwrapDataKey(keyID, wrappedB64 string) ([]byte, error) {
+	cacheKey := keyID + ":" + wrappedB64
+
+	e.cacheMu.Lock()
+	if key, ok := e.keyCache[cacheKey]; ok {
+		e.cacheMu.Unlock()
+		return key, nil
+	}
+	e.cacheMu.Unlock()
+
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedB64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data key: %w", err)
+	}
+	key, err := e.provider.UnwrapKey(keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with key %q: %w", keyID, err)
+	}
+
+	e.cacheMu.Lock()
+	if len(e.keyCache) >= maxCachedDataKeys {
+		e.keyCache = make(map[string][]byte)
+	}
+	e.keyCache[cacheKey] = key
+	e.cacheMu.Unlock()
+
+	return key, nil
+}
+
+// EncryptionConfigurable is implemented by stores that support field-level encryption
+type EncryptionConfigurable interface {
+	SetEncryptor(encryptor *FieldEncryptor)
+}
+
+func sealAESGCM(key, plaintext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openAESGCM(key, sealed, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func messageBinding(id uint) string {
+	return FieldBinding("messages", "parts_json", id)
+}
+
+func traceBinding(id uint) string {
+	return FieldBinding("execution_traces", "details_json", id)
+}
+
+// encryptMessageParts stores msg's PartsJSON encrypted and bound to the row, which must have
+// been created (inside tx) so that it has an ID
+func encryptMessageParts(tx *gorm.DB, encryptor *FieldEncryptor, msg *Message, partsJSON string) error {
+	encrypted, err := encryptor.Encrypt(partsJSON, messageBinding(msg.ID))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt parts for database: %w", err)
+	}
+	if err := tx.Model(&Message{}).Where("id = ?", msg.ID).UpdateColumn("parts_json", encrypted).Error; err != nil {
+		return fmt.Errorf("failed to store encrypted parts: %w", err)
+	}
+	msg.PartsJSON = encrypted
+	return nil
+}
+
+// decryptMessages decrypts PartsJSON in place. Rows that are still plaintext or were
+// written under an older key are re-encrypted with the current key as they are read.
+// A row that cannot be decrypted (e.g. its key is gone) fails the whole read.
+func decryptMessages(db *gorm.DB, encryptor *FieldEncryptor, logger *slog.Logger, msgs []Message) error {
+	if encryptor == nil {
+		return nil
+	}
+
+	for i := range msgs {
+		plaintext, stale, err := encryptor.Decrypt(msgs[i].PartsJSON, messageBinding(msgs[i].ID))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt message %d of conversation %s: %w", msgs[i].ID, msgs[i].ConversationID, err)
+		}
+		msgs[i].PartsJSON = plaintext
+
+		if stale {
+			reencrypted, err := encryptor.Encrypt(plaintext, messageBinding(msgs[i].ID))
+			if err != nil {
+				logger.Warn("Failed to re-encrypt message", "message_id", msgs[i].ID, logging.KeyError, err)
+				continue
+			}
+			if err := db.Model(&Message{}).Where("id = ?", msgs[i].ID).UpdateColumn("parts_json", reencrypted).Error; err != nil {
+				logger.Warn("Failed to store re-encrypted message", "message_id", msgs[i].ID, logging.KeyError, err)
+			}
+		}
+	}
+	return nil
+}
+
+// decryptTraces decrypts DetailsJSON into Details, re-encrypting stale rows like decryptMessages.
+// A trace that cannot be decrypted fails the whole read.
+func decryptTraces(db *gorm.DB, encryptor *FieldEncryptor, logger *slog.Logger, traces []*ExecutionTrace) error {
+	if encryptor == nil {
+		return nil
+	}
+
+	for _, trace := range traces {
+		if trace.DetailsJSON == "" {
+			continue
+		}
+		plaintext, stale, err := encryptor.Decrypt(trace.DetailsJSON, traceBinding(trace.ID))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt details of trace %d: %w", trace.ID, err)
+		}
+		if err := json.Unmarshal([]byte(plaintext), &trace.Details); err != nil {
+			return fmt.Errorf("failed to unmarshal details of trace %d: %w", trace.ID, err)
+		}
+		trace.DetailsJSON = plaintext
+
+		if stale {
+			reencrypted, err := encryptor.Encrypt(plaintext, traceBinding(trace.ID))
+			if err != nil {
+				logger.Warn("Failed to re-encrypt trace", "trace_id", trace.ID, logging.KeyError, err)
+				continue
+			}
+			if err := db.Model(&ExecutionTrace{}).Where("id = ?", trace.ID).UpdateColumn("details_json", reencrypted).Error; err != nil {
+				logger.Warn("Failed to store re-encrypted trace", "trace_id", trace.ID, logging.KeyError, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package stores
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestFieldEncryptor_RoundTrip(t *testing.T) {
+	provider, err := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatalf("NewStaticKeyProvider: %v", err)
+	}
+	enc := NewFieldEncryptor(provider)
+
+	plaintext := `[{"text":"my card number is 4111"}]`
+	binding := FieldBinding("messages", "parts_json", 1)
+	sealed, err := enc.Encrypt(plaintext, binding)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if strings.Contains(sealed, "4111") {
+		t.Errorf("Encrypted value leaks plaintext: %s", sealed)
+	}
+	if !IsEncryptedField(sealed) {
+		t.Errorf("Expected sealed value to be detected as encrypted")
+	}
+	if !json.Valid([]byte(sealed)) {
+		t.Errorf("Encrypted envelope must be valid JSON for json columns")
+	}
+
+	opened, stale, err := enc.Decrypt(sealed, binding)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if opened != plaintext {
+		t.Errorf("Expected %q, got %q", plaintext, opened)
+	}
+	if stale {
+		t.Errorf("Value sealed with the current key should not be stale")
+	}
+
+	// The value is bound to its row and column
+	for _, other := range []string{FieldBinding("messages", "parts_json", 2), FieldBinding("execution_traces", "details_json", 1)} {
+		if _, _, err := enc.Decrypt(sealed, other); err == nil {
+			t.Errorf("Expected decrypting with binding %q to fail", other)
+		}
+	}
+}
+
+func TestFieldEncryptor_UnboundIsStale(t *testing.T) {
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(1)})
+	enc := NewFieldEncryptor(provider)
+
+	// An envelope written before values were bound to their row
+	sealed, _ := enc.Encrypt("legacy", "")
+	var envelope encryptedEnvelope
+	json.Unmarshal([]byte(sealed), &envelope)
+	envelope.Version = unboundFieldVersion
+	legacy, _ := json.Marshal(envelope)
+
+	opened, stale, err := enc.Decrypt(string(legacy), FieldBinding("messages", "parts_json", 7))
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if opened != "legacy" || !stale {
+		t.Errorf("Expected the unbound value returned and marked stale, got %q stale=%v", opened, stale)
+	}
+}
+
+func TestFieldEncryptor_PlaintextIsStale(t *testing.T) {
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(1)})
+	enc := NewFieldEncryptor(provider)
+
+	opened, stale, err := enc.Decrypt(`[{"text":"legacy"}]`, "")
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if opened != `[{"text":"legacy"}]` || !stale {
+		t.Errorf("Expected legacy plaintext returned as-is and marked stale, got %q stale=%v", opened, stale)
+	}
+}
+
+func TestLocalKeyFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := `{"current":"b","keys":{"a":"` + base64.StdEncoding.EncodeToString(testKey(1)) +
+		`","b":"` + base64.StdEncoding.EncodeToString(testKey(2)) + `"}}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewLocalKeyFileProvider(path)
+	if err != nil {
+		t.Fatalf("NewLocalKeyFileProvider: %v", err)
+	}
+	if provider.CurrentKeyID() != "b" {
+		t.Errorf("Expected current key b, got %s", provider.CurrentKeyID())
+	}
+}
+
+func TestEnvKeyProvider(t *testing.T) {
+	t.Setenv("TEST_ENC_KEY", "new secret")
+	t.Setenv("TEST_ENC_KEY_OLD", "old secret")
+
+	oldOnly, err := NewEnvKeyProvider("TEST_ENC_KEY_OLD")
+	if err != nil {
+		t.Fatalf("NewEnvKeyProvider: %v", err)
+	}
+	sealed, _ := NewFieldEncryptor(oldOnly).Encrypt("hello", "")
+
+	rotated, err := NewEnvKeyProvider("TEST_ENC_KEY", "TEST_ENC_KEY_OLD")
+	if err != nil {
+		t.Fatalf("NewEnvKeyProvider: %v", err)
+	}
+	opened, stale, err := NewFieldEncryptor(rotated).Decrypt(sealed, "")
+	if err != nil {
+		t.Fatalf("Decrypt with previous key: %v", err)
+	}
+	if opened != "hello" || !stale {
+		t.Errorf("Expected hello marked stale after rotation, got %q stale=%v", opened, stale)
+	}
+
+	if _, err := NewEnvKeyProvider("TEST_ENC_KEY_MISSING"); err == nil {
+		t.Errorf("Expected error for unset env var")
+	}
+}
+
+type fakeKMS struct {
+	calls int
+}
+
+func (k *fakeKMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	k.calls++
+	return append([]byte(keyID+":"), plaintext...), nil
+}
+
+func (k *fakeKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	k.calls++
+	return bytes.TrimPrefix(ciphertext, []byte(keyID+":")), nil
+}
+
+func TestKMSKeyProvider_CachesDataKeys(t *testing.T) {
+	kms := &fakeKMS{}
+	enc := NewFieldEncryptor(&KMSKeyProvider{Client: kms, KeyID: "projects/x/keys/1"})
+
+	sealed, err := enc.Encrypt("secret", "")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if opened, _, err := enc.Decrypt(sealed, ""); err != nil || opened != "secret" {
+			t.Fatalf("Decrypt: %q %v", opened, err)
+		}
+	}
+	if kms.calls != 2 {
+		t.Errorf("Expected 1 wrap + 1 unwrap KMS call, got %d", kms.calls)
+	}
+}
+
+func TestSQLiteStore_EncryptsPartsAndRotatesLazily(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "enc.sqlite")
+	oldProvider, _ := NewStaticKeyProvider("old", map[string][]byte{"old": testKey(1)})
+
+	config := NewStoreConfig("sqlite", dbPath).WithEncryption(oldProvider)
+	store, err := NewSQLiteStore(config)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.SaveMessage("conv-1", "user", "user_message", []map[string]string{{"text": "top secret"}}, ""); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+
+	var raw Message
+	store.db.Where("conversation_id = ?", "conv-1").First(&raw)
+	if strings.Contains(raw.PartsJSON, "top secret") {
+		t.Fatalf("PartsJSON stored in plaintext: %s", raw.PartsJSON)
+	}
+
+	// Rotate: new current key, old key kept for reads
+	rotated, _ := NewStaticKeyProvider("new", map[string][]byte{"old": testKey(1), "new": testKey(2)})
+	store.SetEncryptor(NewFieldEncryptor(rotated))
+
+	history, err := store.FetchHistory("conv-1", 0)
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	if len(history) != 1 || !strings.Contains(history[0].PartsJSON, "top secret") {
+		t.Fatalf("Expected decrypted history, got %+v", history)
+	}
+
+	store.db.Where("conversation_id = ?", "conv-1").First(&raw)
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal([]byte(raw.PartsJSON), &envelope); err != nil {
+		t.Fatalf("Stored value is not an envelope: %v", err)
+	}
+	if envelope.KeyID != "new" {
+		t.Errorf("Expected row re-encrypted under new key, got %s", envelope.KeyID)
+	}
+}
+
+func TestGORMTraceStore_EncryptsDetails(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "traces.sqlite")
+	store, err := NewSQLiteStoreSimple(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	defer store.Close()
+
+	traceStore, err := NewGORMTraceStore(store.db)
+	if err != nil {
+		t.Fatalf("NewGORMTraceStore: %v", err)
+	}
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(3)})
+	traceStore.SetEncryptor(NewFieldEncryptor(provider))
+
+	trace := &ExecutionTrace{
+		ConversationID: "conv-1",
+		ToolCallID:     "call-1",
+		TraceID:        "t1",
+		Status:         "end",
+		Label:          "Fetched",
+		Details:        map[string]any{"url": "https://internal.example"},
+		Timestamp:      1,
+	}
+	if err := traceStore.SaveTrace(trace); err != nil {
+		t.Fatalf("SaveTrace: %v", err)
+	}
+
+	var raw ExecutionTrace
+	store.db.Model(&ExecutionTrace{}).Select("details_json").First(&raw)
+	if strings.Contains(raw.DetailsJSON, "internal.example") {
+		t.Fatalf("DetailsJSON stored in plaintext: %s", raw.DetailsJSON)
+	}
+
+	traces, err := traceStore.GetTracesByConversation("conv-1")
+	if err != nil {
+		t.Fatalf("GetTracesByConversation: %v", err)
+	}
+	if len(traces) != 1 || traces[0].Details["url"] != "https://internal.example" {
+		t.Errorf("Expected decrypted details, got %+v", traces)
+	}
+}
+
+func TestSQLiteStore_FailsOnUndecryptableMessage(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "enc.sqlite")
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(1)})
+	store, err := NewSQLiteStore(NewStoreConfig("sqlite", dbPath).WithEncryption(provider))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.SaveMessage("conv-1", "user", "user_message", []map[string]string{{"text": "top secret"}}, ""); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+
+	// The key the row was written under is gone
+	other, _ := NewStaticKeyProvider("k2", map[string][]byte{"k2": testKey(2)})
+	store.SetEncryptor(NewFieldEncryptor(other))
+
+	if history, err := store.FetchHistory("conv-1", 0); err == nil {
+		t.Errorf("Expected FetchHistory to fail, got %+v", history)
+	}
+	if msgs, err := store.FetchRawHistory("conv-1"); err == nil {
+		t.Errorf("Expected FetchRawHistory to fail, got %+v", msgs)
+	}
+}
+
+func TestGORMTraceStore_SaveTracesReturnsIDs(t *testing.T) {
+	store, err := NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "traces.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	defer store.Close()
+
+	traceStore, err := NewGORMTraceStore(store.db)
+	if err != nil {
+		t.Fatalf("NewGORMTraceStore: %v", err)
+	}
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(3)})
+	traceStore.SetEncryptor(NewFieldEncryptor(provider))
+
+	traces := []*ExecutionTrace{
+		{ConversationID: "conv-1", ToolCallID: "call-1", TraceID: "t1", Status: "start", Label: "Fetching", Details: map[string]any{"url": "a"}, Timestamp: 1},
+		{ConversationID: "conv-1", ToolCallID: "call-1", TraceID: "t1", Status: "end", Label: "Fetched", Timestamp: 2},
+	}
+	if err := traceStore.SaveTraces(traces); err != nil {
+		t.Fatalf("SaveTraces: %v", err)
+	}
+	for i, trace := range traces {
+		if trace.ID == 0 || trace.CreatedAt.IsZero() {
+			t.Errorf("Expected trace %d to get its generated ID and timestamp, got %+v", i, trace)
+		}
+		if trace.DetailsJSON != "" {
+			t.Errorf("Expected trace %d to keep its plaintext fields, got DetailsJSON %q", i, trace.DetailsJSON)
+		}
+	}
+	if traces[0].ID == traces[1].ID {
+		t.Errorf("Expected distinct IDs, got %d twice", traces[0].ID)
+	}
+}
+
+func TestSQLiteStore_RejectsSwappedCiphertext(t *testing.T) {
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(1)})
+	store, err := NewSQLiteStore(NewStoreConfig("sqlite", filepath.Join(t.TempDir(), "enc.sqlite")).WithEncryption(provider))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	for _, conv := range []string{"conv-alice", "conv-bob"} {
+		if err := store.SaveMessage(conv, "user", "user_message", []map[string]string{{"text": conv}}, ""); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+	}
+	if history, err := store.FetchHistory("conv-bob", 0); err != nil || !strings.Contains(history[0].PartsJSON, "conv-bob") {
+		t.Fatalf("Expected bob's message decrypted, got %+v, %v", history, err)
+	}
+
+	// Copy alice's ciphertext into bob's row
+	var alice Message
+	store.db.Where("conversation_id = ?", "conv-alice").First(&alice)
+	store.db.Model(&Message{}).Where("conversation_id = ?", "conv-bob").UpdateColumn("parts_json", alice.PartsJSON)
+
+	if history, err := store.FetchHistory("conv-bob", 0); err == nil {
+		t.Errorf("Expected the swapped row to fail to decrypt, got %+v", history)
+	}
+}
+
+func TestGORMTraceStore_FailsOnUndecryptableDetails(t *testing.T) {
+	store, err := NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "traces.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	defer store.Close()
+
+	traceStore, err := NewGORMTraceStore(store.db)
+	if err != nil {
+		t.Fatalf("NewGORMTraceStore: %v", err)
+	}
+	provider, _ := NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey(3)})
+	traceStore.SetEncryptor(NewFieldEncryptor(provider))
+	trace := &ExecutionTrace{ConversationID: "conv-1", ToolCallID: "call-1", TraceID: "t1", Status: "end", Label: "Fetched", Details: map[string]any{"url": "a"}, Timestamp: 1}
+	if err := traceStore.SaveTrace(trace); err != nil {
+		t.Fatalf("SaveTrace: %v", err)
+	}
+
+	other, _ := NewStaticKeyProvider("k2", map[string][]byte{"k2": testKey(4)})
+	traceStore.SetEncryptor(NewFieldEncryptor(other))
+	if traces, err := traceStore.GetTracesByConversation("conv-1"); err == nil {
+		t.Errorf("Expected GetTracesByConversation to fail, got %+v", traces)
+	}
+	if traces, err := traceStore.GetTracesByToolCall("call-1"); err == nil {
+		t.Errorf("Expected GetTracesByToolCall to fail, got %+v", traces)
+	}
+}
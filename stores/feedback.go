@@ -0,0 +1,233 @@
+package stores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMessageNotFound is returned when feedback refers to a message that does not exist
+var ErrMessageNotFound = errors.New("message not found")
+
+// Feedback ratings for thumbs up/down. Other scales (e.g. 1-5 stars) may be stored as-is.
+const (
+	RatingNegative = -1
+	RatingNeutral  = 0
+	RatingPositive = 1
+)
+
+// MessageFeedback is a user's rating of a message, identified by ConversationID + Sequence.
+// Each user has at most one feedback entry per message; saving again replaces it.
+type MessageFeedback struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ConversationID string    `gorm:"uniqueIndex:idx_feedback_message_user;not null" json:"conversation_id"`
+	Sequence       int       `gorm:"uniqueIndex:idx_feedback_message_user;not null" json:"sequence"`
+	UserID         string    `gorm:"uniqueIndex:idx_feedback_message_user;not null;default:''" json:"user_id"`
+	TenantID       string    `gorm:"index;not null;default:''" json:"tenant_id,omitempty"`
+	Rating         int       `gorm:"index;not null" json:"rating"` // RatingPositive, RatingNegative, ...
+	Comment        string    `gorm:"type:text" json:"comment,omitempty"`
+	TagsJSON       string    `gorm:"type:text" json:"-"`              // Stored as JSON string
+	Tags           []string  `gorm:"-" json:"tags,omitempty"`         // Not stored, computed from TagsJSON
+	MessageRole    string    `gorm:"-" json:"message_role,omitempty"` // Filled on read from the rated message
+}
+
+// TableName keeps the table name stable and descriptive
+func (MessageFeedback) TableName() string {
+	return "message_feedback"
+}
+
+// BeforeSave marshals Tags to TagsJSON
+func (f *MessageFeedback) BeforeSave(tx *gorm.DB) error {
+	if f.Tags == nil {
+		f.TagsJSON = ""
+		return nil
+	}
+	data, err := json.Marshal(f.Tags)
+	if err != nil {
+		return err
+	}
+	f.TagsJSON = string(data)
+	return nil
+}
+
+// AfterFind unmarshals TagsJSON to Tags
+func (f *MessageFeedback) AfterFind(tx *gorm.DB) error {
+	if f.TagsJSON != "" {
+		return json.Unmarshal([]byte(f.TagsJSON), &f.Tags)
+	}
+	return nil
+}
+
+// HasAnyTag reports whether the feedback carries at least one of the given tags
+func (f *MessageFeedback) HasAnyTag(tags []string) bool {
+	for _, want := range tags {
+		for _, have := range f.Tags {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FeedbackFilter narrows feedback queries. Zero values are ignored.
+type FeedbackFilter struct {
+	ConversationID string
+	UserID         string
+	MinRating      *int
+	MaxRating      *int
+	Tags           []string // Matches feedback carrying any of these tags
+	Since          time.Time
+	Until          time.Time
+	Limit          int
+}
+
+// FeedbackStore persists user feedback on messages
+type FeedbackStore interface {
+	// SaveFeedback creates or replaces the user's feedback for a message
+	SaveFeedback(feedback *MessageFeedback) error
+
+	// GetFeedbackForConversation returns all feedback for a conversation ordered by sequence
+	GetFeedbackForConversation(conversationID string) ([]*MessageFeedback, error)
+
+	// QueryFeedback returns feedback matching the filter, newest first
+	QueryFeedback(filter FeedbackFilter) ([]*MessageFeedback, error)
+
+	// DeleteFeedback removes a user's feedback for a message
+	DeleteFeedback(conversationID string, sequence int, userID string) error
+}
+
+// saveFeedback upserts feedback after checking the rated message exists for the tenant
+func saveFeedback(db *gorm.DB, tenantID string, feedback *MessageFeedback) error {
+	if db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	var count int64
+	if err := db.Model(&Message{}).
+		Where("conversation_id = ? AND sequence = ? AND tenant_id = ?", feedback.ConversationID, feedback.Sequence, tenantID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to look up message: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("conversation %s sequence %d: %w", feedback.ConversationID, feedback.Sequence, ErrMessageNotFound)
+	}
+
+	feedback.TenantID = tenantID
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "conversation_id"}, {Name: "sequence"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "comment", "tags_json", "updated_at"}),
+	}).Create(feedback).Error
+	if err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return nil
+}
+
+// queryFeedback runs a filtered feedback query scoped to the tenant
+func queryFeedback(db *gorm.DB, tenantID string, filter FeedbackFilter) ([]*MessageFeedback, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	query := db.Where("tenant_id = ?", tenantID)
+	if filter.ConversationID != "" {
+		query = query.Where("conversation_id = ?", filter.ConversationID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.MinRating != nil {
+		query = query.Where("rating >= ?", *filter.MinRating)
+	}
+	if filter.MaxRating != nil {
+		query = query.Where("rating <= ?", *filter.MaxRating)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	if filter.ConversationID != "" {
+		query = query.Order("sequence ASC")
+	} else {
+		query = query.Order("created_at DESC")
+	}
+	// Tags are stored as JSON, so tag filtering happens after the query
+	if filter.Limit > 0 && len(filter.Tags) == 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var feedback []*MessageFeedback
+	if err := query.Find(&feedback).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch feedback: %w", err)
+	}
+
+	if len(filter.Tags) > 0 {
+		filtered := feedback[:0]
+		for _, f := range feedback {
+			if f.HasAnyTag(filter.Tags) {
+				filtered = append(filtered, f)
+			}
+		}
+		feedback = filtered
+		if filter.Limit > 0 && len(feedback) > filter.Limit {
+			feedback = feedback[:filter.Limit]
+		}
+	}
+
+	fillMessageRoles(db, tenantID, feedback)
+	return feedback, nil
+}
+
+// fillMessageRoles annotates feedback with the role of the rated message
+func fillMessageRoles(db *gorm.DB, tenantID string, feedback []*MessageFeedback) {
+	if len(feedback) == 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	conversationIDs := make([]string, 0)
+	for _, f := range feedback {
+		if !seen[f.ConversationID] {
+			seen[f.ConversationID] = true
+			conversationIDs = append(conversationIDs, f.ConversationID)
+		}
+	}
+
+	var rows []struct {
+		ConversationID string
+		Sequence       int
+		Role           string
+	}
+	if err := db.Model(&Message{}).
+		Select("conversation_id, sequence, role").
+		Where("conversation_id IN ? AND tenant_id = ?", conversationIDs, tenantID).
+		Scan(&rows).Error; err != nil {
+		return
+	}
+
+	roles := make(map[string]string, len(rows))
+	for _, row := range rows {
+		roles[fmt.Sprintf("%s#%d", row.ConversationID, row.Sequence)] = row.Role
+	}
+	for _, f := range feedback {
+		f.MessageRole = roles[fmt.Sprintf("%s#%d", f.ConversationID, f.Sequence)]
+	}
+}
+
+// deleteFeedback removes one user's feedback for a message
+func deleteFeedback(db *gorm.DB, tenantID, conversationID string, sequence int, userID string) error {
+	if db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	return db.Where("conversation_id = ? AND sequence = ? AND user_id = ? AND tenant_id = ?", conversationID, sequence, userID, tenantID).
+		Delete(&MessageFeedback{}).Error
+}
@@ -0,0 +1,118 @@
+package stores
+
+import (
+	"errors"
+	"testing"
+)
+
+func seedConversation(t *testing.T, store MessageStore, conversationID string) {
+	t.Helper()
+	if err := store.SaveMessageWithUser(conversationID, "alice", "user", "user_message", []map[string]string{{"text": "hi"}}, ""); err != nil {
+		t.Fatalf("SaveMessageWithUser: %v", err)
+	}
+	if err := store.SaveMessageWithUser(conversationID, "alice", "model", "model_message", []map[string]string{{"text": "hello"}}, ""); err != nil {
+		t.Fatalf("SaveMessageWithUser: %v", err)
+	}
+}
+
+func TestSQLiteStore_SaveFeedbackUpserts(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	seedConversation(t, store, "conv-1")
+
+	if err := store.SaveFeedback(&MessageFeedback{ConversationID: "conv-1", Sequence: 2, UserID: "alice", Rating: RatingNegative, Tags: []string{"wrong"}}); err != nil {
+		t.Fatalf("SaveFeedback: %v", err)
+	}
+	if err := store.SaveFeedback(&MessageFeedback{ConversationID: "conv-1", Sequence: 2, UserID: "alice", Rating: RatingPositive, Comment: "fixed"}); err != nil {
+		t.Fatalf("SaveFeedback again: %v", err)
+	}
+
+	feedback, err := store.GetFeedbackForConversation("conv-1")
+	if err != nil {
+		t.Fatalf("GetFeedbackForConversation: %v", err)
+	}
+	if len(feedback) != 1 {
+		t.Fatalf("Expected 1 feedback entry after upsert, got %d", len(feedback))
+	}
+	got := feedback[0]
+	if got.Rating != RatingPositive || got.Comment != "fixed" || len(got.Tags) != 0 {
+		t.Errorf("Expected replaced feedback, got %+v", got)
+	}
+	if got.MessageRole != "model" {
+		t.Errorf("Expected message role model, got %q", got.MessageRole)
+	}
+
+	err = store.SaveFeedback(&MessageFeedback{ConversationID: "conv-1", Sequence: 99, UserID: "alice", Rating: RatingPositive})
+	if !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+
+	if err := store.DeleteFeedback("conv-1", 2, "alice"); err != nil {
+		t.Fatalf("DeleteFeedback: %v", err)
+	}
+	if feedback, _ := store.GetFeedbackForConversation("conv-1"); len(feedback) != 0 {
+		t.Errorf("Expected no feedback after delete, got %d", len(feedback))
+	}
+}
+
+func TestSQLiteStore_QueryFeedback(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	seedConversation(t, store, "conv-1")
+	seedConversation(t, store, "conv-2")
+
+	entries := []*MessageFeedback{
+		{ConversationID: "conv-1", Sequence: 2, UserID: "alice", Rating: RatingPositive, Tags: []string{"helpful"}},
+		{ConversationID: "conv-2", Sequence: 2, UserID: "alice", Rating: RatingNegative, Tags: []string{"hallucination", "wrong"}},
+		{ConversationID: "conv-2", Sequence: 2, UserID: "bob", Rating: RatingNegative},
+	}
+	for _, f := range entries {
+		if err := store.SaveFeedback(f); err != nil {
+			t.Fatalf("SaveFeedback: %v", err)
+		}
+	}
+
+	negative := RatingNegative
+	feedback, err := store.QueryFeedback(FeedbackFilter{MaxRating: &negative})
+	if err != nil {
+		t.Fatalf("QueryFeedback: %v", err)
+	}
+	if len(feedback) != 2 {
+		t.Errorf("Expected 2 negative entries, got %d", len(feedback))
+	}
+
+	feedback, _ = store.QueryFeedback(FeedbackFilter{Tags: []string{"wrong", "missing"}})
+	if len(feedback) != 1 || feedback[0].ConversationID != "conv-2" || feedback[0].UserID != "alice" {
+		t.Errorf("Expected tag filter to match alice's conv-2 feedback, got %+v", feedback)
+	}
+
+	feedback, _ = store.QueryFeedback(FeedbackFilter{UserID: "bob"})
+	if len(feedback) != 1 {
+		t.Errorf("Expected 1 entry for bob, got %d", len(feedback))
+	}
+
+	feedback, _ = store.QueryFeedback(FeedbackFilter{Limit: 1})
+	if len(feedback) != 1 {
+		t.Errorf("Expected limit to cap results at 1, got %d", len(feedback))
+	}
+}
+
+func TestSQLiteStore_FeedbackTenantIsolation(t *testing.T) {
+	base := newTestSQLiteStore(t)
+	acme := base.ForTenant("acme").(*SQLiteStore)
+	globex := base.ForTenant("globex").(*SQLiteStore)
+	seedConversation(t, acme, "conv-a")
+
+	err := globex.SaveFeedback(&MessageFeedback{ConversationID: "conv-a", Sequence: 2, UserID: "alice", Rating: RatingPositive})
+	if !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound rating another tenant's message, got %v", err)
+	}
+
+	if err := acme.SaveFeedback(&MessageFeedback{ConversationID: "conv-a", Sequence: 2, UserID: "alice", Rating: RatingPositive}); err != nil {
+		t.Fatalf("SaveFeedback: %v", err)
+	}
+	if feedback, _ := globex.QueryFeedback(FeedbackFilter{}); len(feedback) != 0 {
+		t.Errorf("Expected globex to see no feedback, got %d", len(feedback))
+	}
+	if feedback, _ := acme.QueryFeedback(FeedbackFilter{}); len(feedback) != 1 || feedback[0].TenantID != "acme" {
+		t.Errorf("Expected acme feedback, got %+v", feedback)
+	}
+}
@@ -1,7 +1,9 @@
 package stores
 
 import (
-	"log"
+	"log/slog"
+
+	"github.com/Desarso/godantic/logging"
 )
 
 // SanitizeHistory ensures the message history has valid turn structure for LLM APIs.
@@ -18,6 +20,11 @@ import (
 // - Every function_call has a matching function_response after it
 // - No orphaned function_responses without preceding function_calls
 func SanitizeHistory(msgs []Message) []Message {
+	return sanitizeHistory(logging.Default(), msgs)
+}
+
+// sanitizeHistory is SanitizeHistory logging repairs to logger
+func sanitizeHistory(logger *slog.Logger, msgs []Message) []Message {
 	if len(msgs) == 0 {
 		return msgs
 	}
@@ -30,25 +37,25 @@ func SanitizeHistory(msgs []Message) []Message {
 		// to preserve at least some context
 		for i := len(msgs) - 1; i >= 0; i-- {
 			if msgs[i].Type == "user_message" {
-				log.Printf("[HISTORY_SANITIZER] No valid start, but found user_message at index %d, using as fallback", i)
+				logger.Warn("History has no valid start, keeping the last user message", "index", i)
 				return []Message{msgs[i]}
 			}
 		}
-		log.Printf("[HISTORY_SANITIZER] No valid starting point found, returning empty history")
+		logger.Warn("History has no valid start, returning empty history")
 		return []Message{}
 	}
 
 	if startIdx > 0 {
-		log.Printf("[HISTORY_SANITIZER] Skipping first %d messages to find valid start (was type: %s)", startIdx, msgs[0].Type)
+		logger.Debug("Skipping messages before a valid history start", "skipped", startIdx, "first_type", msgs[0].Type)
 		msgs = msgs[startIdx:]
 	}
 
 	// Step 2: Validate and fix tool call cycles
 	// Walk through and ensure every function_call has a matching function_response
-	sanitized := sanitizeToolCycles(msgs)
+	sanitized := sanitizeToolCycles(logger, msgs)
 
 	if len(sanitized) != len(msgs) {
-		log.Printf("[HISTORY_SANITIZER] Removed %d messages with broken tool cycles", len(msgs)-len(sanitized))
+		logger.Warn("Removed messages with broken tool cycles", "removed", len(msgs)-len(sanitized))
 	}
 
 	return sanitized
@@ -82,7 +89,7 @@ func findValidStartIndex(msgs []Message) int {
 // sanitizeToolCycles walks through messages and ensures tool call cycles are complete.
 // If a function_call doesn't have a matching function_response, we remove the incomplete cycle.
 // If a function_response appears without a preceding function_call, we remove it.
-func sanitizeToolCycles(msgs []Message) []Message {
+func sanitizeToolCycles(logger *slog.Logger, msgs []Message) []Message {
 	if len(msgs) == 0 {
 		return msgs
 	}
@@ -120,13 +127,13 @@ func sanitizeToolCycles(msgs []Message) []Message {
 				if nextIdx >= len(msgs) {
 					// Trailing function_calls at end of history - keep them
 					// The response will come in the current Model_Request.Tool_Results
-					log.Printf("[HISTORY_SANITIZER] Keeping trailing function_call(s) at end of history (index %d-%d) - response expected in current turn", cycleStart, nextIdx-1)
+					logger.Debug("Keeping trailing function calls awaiting a response", "from", cycleStart, "to", nextIdx-1)
 					result = append(result, cycleMessages...)
 					i = nextIdx
 				} else {
 					// Incomplete cycle in the middle of history - this shouldn't happen in normal operation
 					// Skip these orphaned function_calls
-					log.Printf("[HISTORY_SANITIZER] Removing incomplete tool cycle in middle of history at index %d (function_call without response)", cycleStart)
+					logger.Warn("Removing function call without a response", "index", cycleStart)
 					i = nextIdx
 				}
 			}
@@ -134,12 +141,12 @@ func sanitizeToolCycles(msgs []Message) []Message {
 		case "function_response":
 			// Orphaned function_response without preceding function_call
 			// This shouldn't happen if we started correctly, but handle it
-			log.Printf("[HISTORY_SANITIZER] Removing orphaned function_response at index %d", i)
+			logger.Warn("Removing orphaned function response", "index", i)
 			i++
 
 		default:
 			// Unknown message type - include it but log
-			log.Printf("[HISTORY_SANITIZER] Unknown message type '%s' at index %d, including anyway", msg.Type, i)
+			logger.Warn("Keeping message of unknown type", "type", msg.Type, "index", i)
 			result = append(result, msg)
 			i++
 		}
@@ -1,6 +1,8 @@
 package stores
 
 import (
+	"log/slog"
+
 	"gorm.io/gorm"
 )
 
@@ -9,6 +11,7 @@ import (
 type Message struct {
 	gorm.Model
 	ConversationID string `gorm:"index;not null"`
+	TenantID       string `gorm:"index;not null;default:''"` // Empty for the default tenant
 	Sequence       int    `gorm:"not null"`
 	Role           string `gorm:"not null"` // "user", "model"
 	Type           string `gorm:"not null"` // "user_message", "model_message", "function_call", "function_response"
@@ -17,6 +20,9 @@ type Message struct {
 	// PartsJSON stores the JSON marshaled array of content parts for this turn.
 	// This could be []models.User_Part or []models.Model_Part depending on the Role/Type.
 	PartsJSON string `gorm:"type:json"`
+	// MetadataJSON stores generation metadata for model messages (provider, model, latency, usage, ...).
+	// Empty for user messages and for rows written before metadata was recorded.
+	MetadataJSON string `gorm:"type:text"`
 }
 
 // Conversation holds metadata for a chat conversation
@@ -24,6 +30,7 @@ type Message struct {
 type Conversation struct {
 	gorm.Model
 	ConversationID string    `gorm:"uniqueIndex;not null"`
+	TenantID       string    `gorm:"index;not null;default:''"` // Empty for the default tenant
 	UserID         string    `gorm:"index;not null"`
 	Title          string    `gorm:"type:text"` // Conversation title (migrated from old system or AI-generated)
 	MessageCount   int       `gorm:"default:0"`
@@ -32,12 +39,13 @@ type Conversation struct {
 
 // ConversationInfo holds basic conversation metadata for listing
 type ConversationInfo struct {
-	ConversationID string
-	UserID         string
-	Title          string
-	MessageCount   int
-	CreatedAt      string
-	UpdatedAt      string
+	ConversationID string `json:"conversation_id"`
+	TenantID       string `json:"tenant_id,omitempty"`
+	UserID         string `json:"user_id"`
+	Title          string `json:"title"`
+	MessageCount   int    `json:"message_count"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
 }
 
 // MessageStore interface for abstracting database operations
@@ -60,11 +68,30 @@ type MessageStore interface {
 	Ping() error
 }
 
+// MessageMetadataStore is implemented by stores that can persist generation metadata
+// alongside a message. metadata is marshalled to JSON (typically *models.MessageMetadata).
+type MessageMetadataStore interface {
+	SaveMessageWithMetadata(sessionID, userID, role, messageType string, parts interface{}, functionID string, metadata interface{}) error
+}
+
+// RawHistoryStore is implemented by stores that can return a conversation exactly as stored,
+// without the SanitizeHistory repairs FetchHistory applies. Tools that report or drop
+// corrupted history (e.g. dataset export) need the unrepaired messages.
+type RawHistoryStore interface {
+	FetchRawHistory(sessionID string) ([]Message, error)
+}
+
+// LoggerConfigurable is implemented by stores that accept a logger for their warnings
+type LoggerConfigurable interface {
+	SetLogger(logger *slog.Logger)
+}
+
 // StoreConfig holds configuration for database stores
 type StoreConfig struct {
 	Type       string            `json:"type"`       // "sqlite", "postgres", "mysql", etc.
 	Connection string            `json:"connection"` // connection string
 	Options    map[string]string `json:"options"`    // additional options
+	Encryptor  *FieldEncryptor   `json:"-"`          // Optional: field-level encryption for message parts
 }
 
 // NewStoreConfig creates a new store configuration
@@ -81,3 +108,9 @@ func (c *StoreConfig) WithOption(key, value string) *StoreConfig {
 	c.Options[key] = value
 	return c
 }
+
+// WithEncryption enables field-level envelope encryption using the given key provider
+func (c *StoreConfig) WithEncryption(provider KeyProvider) *StoreConfig {
+	c.Encryptor = NewFieldEncryptor(provider)
+	return c
+}
@@ -0,0 +1,30 @@
+package stores
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteStore_LogsToConfiguredLogger(t *testing.T) {
+	var out bytes.Buffer
+	store := newTestSQLiteStore(t)
+	store.SetLogger(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	parts := []map[string]string{{"text": "hi"}}
+	if err := store.SaveMessage("conv-1", "user", "user_message", parts, ""); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+	if err := store.SaveMessage("conv-1", "user", "function_response", parts, "call-1"); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+	if _, err := store.FetchHistory("conv-1", 0); err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+
+	logged := out.String()
+	if !strings.Contains(logged, `"msg":"Removing orphaned function response"`) || !strings.Contains(logged, `"component":"sqlite_store"`) {
+		t.Errorf("Expected the sanitizer warning on the store's logger, got %s", logged)
+	}
+}
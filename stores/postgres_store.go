@@ -3,16 +3,20 @@ package stores
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 
+	"github.com/Desarso/godantic/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 // PostgresStore implements MessageStore for PostgreSQL databases
 type PostgresStore struct {
-	db  *gorm.DB
-	dsn string
+	db        *gorm.DB
+	dsn       string
+	encryptor *FieldEncryptor
+	tenantID  string
+	logger    *slog.Logger
 }
 
 // NewPostgresStore creates a new PostgreSQL store
@@ -22,7 +26,8 @@ func NewPostgresStore(config *StoreConfig) (*PostgresStore, error) {
 	}
 
 	store := &PostgresStore{
-		dsn: config.Connection,
+		dsn:       config.Connection,
+		encryptor: config.Encryptor,
 	}
 
 	if err := store.Connect(); err != nil {
@@ -45,16 +50,52 @@ func (s *PostgresStore) Connect() error {
 		return fmt.Errorf("failed to connect to PostgreSQL database: %w", err)
 	}
 
+	if err := db.Use(tracingPlugin{}); err != nil {
+		return fmt.Errorf("failed to register telemetry plugin: %w", err)
+	}
 	s.db = db
 
 	// Auto-migrate the schema
-	if err := s.db.AutoMigrate(&Conversation{}, &Message{}); err != nil {
+	if err := s.db.AutoMigrate(&Conversation{}, &Message{}, &MessageFeedback{}); err != nil {
 		return fmt.Errorf("failed to migrate database schema: %w", err)
 	}
 
 	return nil
 }
 
+// SetEncryptor enables field-level encryption for message parts.
+// Existing plaintext rows stay readable and are encrypted the next time they are fetched.
+func (s *PostgresStore) SetEncryptor(encryptor *FieldEncryptor) {
+	s.encryptor = encryptor
+}
+
+// SetLogger sets the logger for warnings about stored data; nil uses logging.Default()
+func (s *PostgresStore) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+func (s *PostgresStore) log() *slog.Logger {
+	return logging.Or(s.logger).With(logging.KeyComponent, "postgres_store")
+}
+
+// ForTenant returns a view of the store restricted to tenantID.
+// The view shares the database connection; closing either closes both.
+func (s *PostgresStore) ForTenant(tenantID string) MessageStore {
+	scoped := *s
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// DB returns the underlying connection so other GORM-backed stores (traces, memories) can share it
+func (s *PostgresStore) DB() *gorm.DB {
+	return s.db
+}
+
+// TenantID returns the tenant this store is scoped to ("" for the default tenant)
+func (s *PostgresStore) TenantID() string {
+	return s.tenantID
+}
+
 // Close closes the database connection
 func (s *PostgresStore) Close() error {
 	if s.db != nil {
@@ -88,18 +129,26 @@ func (s *PostgresStore) SaveMessage(sessionID, role, messageType string, parts i
 
 // SaveMessageWithUser saves a message to the database with user association
 func (s *PostgresStore) SaveMessageWithUser(sessionID, userID, role, messageType string, parts interface{}, functionID string) error {
+	return s.SaveMessageWithMetadata(sessionID, userID, role, messageType, parts, functionID, nil)
+}
+
+// SaveMessageWithMetadata saves a message together with its generation metadata (nil for none)
+func (s *PostgresStore) SaveMessageWithMetadata(sessionID, userID, role, messageType string, parts interface{}, functionID string, metadata interface{}) error {
 	if s.db == nil {
 		return fmt.Errorf("database connection is nil")
 	}
 
 	// Ensure conversation record exists (create if first message)
-	var convCount int64
-	if err := s.db.Model(&Conversation{}).Where("conversation_id = ?", sessionID).Count(&convCount).Error; err != nil {
-		log.Printf("Warning: Error checking for conversation %s: %v", sessionID, err)
-	} else if convCount == 0 {
+	var owners []string
+	if err := s.db.Model(&Conversation{}).Where("conversation_id = ?", sessionID).Pluck("tenant_id", &owners).Error; err != nil {
+		return fmt.Errorf("failed to check conversation owner: %w", err)
+	} else if len(owners) > 0 && owners[0] != s.tenantID {
+		return fmt.Errorf("cannot save message to conversation %s: %w", sessionID, ErrTenantMismatch)
+	} else if len(owners) == 0 {
 		// Conversation doesn't exist, create it with user ID
+		// Without it the message would have no conversation to carry its tenant
 		if err := s.CreateConversation(sessionID, userID); err != nil {
-			log.Printf("Warning: Failed to create conversation record for %s: %v", sessionID, err)
+			return fmt.Errorf("failed to create conversation %s: %w", sessionID, err)
 		}
 	}
 
@@ -113,31 +162,53 @@ func (s *PostgresStore) SaveMessageWithUser(sessionID, userID, role, messageType
 	// Marshal the provided parts into JSON
 	partsJSONBytes, err := json.Marshal(parts)
 	if err != nil {
-		log.Printf("Error marshalling parts for DB storage (ConvID: %s): %v", sessionID, err)
 		return fmt.Errorf("failed to marshal parts for database: %w", err)
 	}
 	partsJSONStr := string(partsJSONBytes)
 
 	// Ensure partsJSONStr is not empty or just "null"
 	if parts == nil || partsJSONStr == "null" || partsJSONStr == "[]" {
-		log.Printf("Warning: Saving message with empty/null parts for ConvID: %s, Role: %s, Type: %s", sessionID, role, messageType)
+		s.log().Debug("Saving message with empty parts", "conversation_id", sessionID, "role", role, "type", messageType)
 		partsJSONStr = "{}" // Save as empty JSON object
 	}
 
+	metadataJSONStr := ""
+	if metadata != nil {
+		metadataJSONBytes, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message metadata: %w", err)
+		}
+		if string(metadataJSONBytes) != "null" {
+			metadataJSONStr = string(metadataJSONBytes)
+		}
+	}
+
 	msg := Message{
 		ConversationID: sessionID,
+		TenantID:       s.tenantID,
 		Sequence:       seq,
 		Role:           role,
 		Type:           messageType,
 		PartsJSON:      partsJSONStr,
+		MetadataJSON:   metadataJSONStr,
 		FunctionID:     functionID,
 	}
+	if s.encryptor != nil {
+		// Encrypted once the row has an ID to bind the ciphertext to
+		msg.PartsJSON = "{}"
+	}
 
 	tx := s.db.Begin()
 	if err := tx.Create(&msg).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to create message record: %w", err)
 	}
+	if s.encryptor != nil {
+		if err := encryptMessageParts(tx, s.encryptor, &msg, partsJSONStr); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
 	if err := tx.Model(&Conversation{}).Where("conversation_id = ?", sessionID).Update("message_count", seq).Error; err != nil {
 		tx.Rollback()
@@ -156,12 +227,12 @@ func (s *PostgresStore) FetchHistory(sessionID string, limit int) ([]Message, er
 	}
 
 	var msgs []Message
-	query := s.db.Where("conversation_id = ?", sessionID).Order("sequence ASC")
+	query := s.db.Where("conversation_id = ? AND tenant_id = ?", sessionID, s.tenantID).Order("sequence ASC")
 
 	if limit > 0 {
 		// Get total count first
 		var count int64
-		if err := s.db.Model(&Message{}).Where("conversation_id = ?", sessionID).Count(&count).Error; err != nil {
+		if err := s.db.Model(&Message{}).Where("conversation_id = ? AND tenant_id = ?", sessionID, s.tenantID).Count(&count).Error; err != nil {
 			return nil, fmt.Errorf("failed to count messages: %w", err)
 		}
 
@@ -187,20 +258,42 @@ func (s *PostgresStore) FetchHistory(sessionID string, limit int) ([]Message, er
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
+	// Decrypt parts (no-op when encryption is disabled)
+	if err := decryptMessages(s.db, s.encryptor, s.log(), msgs); err != nil {
+		return nil, err
+	}
+
 	// Sanitize history to ensure valid turn structure
 	// This handles truncation breaking tool cycles and corrupted history
-	msgs = SanitizeHistory(msgs)
+	msgs = sanitizeHistory(s.log(), msgs)
 
 	// If we fetched extra and now have more than limit, trim to limit
 	if limit > 0 && len(msgs) > limit {
 		msgs = msgs[len(msgs)-limit:]
 		// Re-sanitize after trimming to ensure we still have valid start
-		msgs = SanitizeHistory(msgs)
+		msgs = sanitizeHistory(s.log(), msgs)
 	}
 
 	return msgs, nil
 }
 
+// FetchRawHistory returns all messages of a conversation in sequence order without sanitizing them
+func (s *PostgresStore) FetchRawHistory(sessionID string) ([]Message, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	var msgs []Message
+	if err := s.db.Where("conversation_id = ? AND tenant_id = ?", sessionID, s.tenantID).Order("sequence ASC").Find(&msgs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+
+	if err := decryptMessages(s.db, s.encryptor, s.log(), msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
 // CreateConversation creates a new conversation record
 func (s *PostgresStore) CreateConversation(convoID, userID string) error {
 	if s.db == nil {
@@ -209,6 +302,7 @@ func (s *PostgresStore) CreateConversation(convoID, userID string) error {
 
 	conv := Conversation{
 		ConversationID: convoID,
+		TenantID:       s.tenantID,
 		UserID:         userID,
 		MessageCount:   0,
 	}
@@ -216,14 +310,14 @@ func (s *PostgresStore) CreateConversation(convoID, userID string) error {
 	return s.db.Create(&conv).Error
 }
 
-// ListConversations returns all conversation IDs
+// ListConversations returns all conversation IDs for the store's tenant
 func (s *PostgresStore) ListConversations() ([]string, error) {
 	if s.db == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
 
 	var convs []Conversation
-	if err := s.db.Find(&convs).Error; err != nil {
+	if err := s.db.Where("tenant_id = ?", s.tenantID).Find(&convs).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch conversations: %w", err)
 	}
 
@@ -251,7 +345,7 @@ func (s *PostgresStore) ListConversationsForUser(userID string) ([]ConversationI
 	var convs []ConvWithCount
 	err := s.db.Model(&Conversation{}).
 		Select("conversations.*, (SELECT COUNT(*) FROM messages WHERE messages.conversation_id = conversations.conversation_id) as computed_message_count").
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND tenant_id = ?", userID, s.tenantID).
 		Order("updated_at DESC").
 		Find(&convs).Error
 
@@ -263,6 +357,7 @@ func (s *PostgresStore) ListConversationsForUser(userID string) ([]ConversationI
 	for i, c := range convs {
 		result[i] = ConversationInfo{
 			ConversationID: c.ConversationID,
+			TenantID:       c.TenantID,
 			UserID:         c.UserID,
 			Title:          c.Title,
 			MessageCount:   c.ComputedMessageCount, // Use computed count, not stored
@@ -273,3 +368,38 @@ func (s *PostgresStore) ListConversationsForUser(userID string) ([]ConversationI
 
 	return result, nil
 }
+
+// SaveFeedback creates or replaces a user's feedback for a message in this store's tenant
+func (s *PostgresStore) SaveFeedback(feedback *MessageFeedback) error {
+	return saveFeedback(s.db, s.tenantID, feedback)
+}
+
+// GetFeedbackForConversation returns all feedback for a conversation ordered by sequence
+func (s *PostgresStore) GetFeedbackForConversation(conversationID string) ([]*MessageFeedback, error) {
+	return queryFeedback(s.db, s.tenantID, FeedbackFilter{ConversationID: conversationID})
+}
+
+// QueryFeedback returns feedback matching the filter, newest first
+func (s *PostgresStore) QueryFeedback(filter FeedbackFilter) ([]*MessageFeedback, error) {
+	return queryFeedback(s.db, s.tenantID, filter)
+}
+
+// DeleteFeedback removes a user's feedback for a message
+func (s *PostgresStore) DeleteFeedback(conversationID string, sequence int, userID string) error {
+	return deleteFeedback(s.db, s.tenantID, conversationID, sequence, userID)
+}
+
+// GetConversation returns a conversation's details, or ErrConversationNotFound
+func (s *PostgresStore) GetConversation(convoID string) (*ConversationInfo, error) {
+	return getConversation(s.db, s.tenantID, convoID)
+}
+
+// RenameConversation sets a conversation's title
+func (s *PostgresStore) RenameConversation(convoID, title string) error {
+	return renameConversation(s.db, s.tenantID, convoID, title)
+}
+
+// DeleteConversation permanently removes a conversation with its messages and feedback
+func (s *PostgresStore) DeleteConversation(convoID string) error {
+	return deleteConversation(s.db, s.tenantID, convoID)
+}
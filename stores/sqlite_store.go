@@ -3,16 +3,20 @@ package stores
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 
+	"github.com/Desarso/godantic/logging"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // SQLiteStore implements MessageStore for SQLite databases
 type SQLiteStore struct {
-	db   *gorm.DB
-	path string
+	db        *gorm.DB
+	path      string
+	encryptor *FieldEncryptor
+	tenantID  string
+	logger    *slog.Logger
 }
 
 // NewSQLiteStore creates a new SQLite store
@@ -22,7 +26,8 @@ func NewSQLiteStore(config *StoreConfig) (*SQLiteStore, error) {
 	}
 
 	store := &SQLiteStore{
-		path: config.Connection,
+		path:      config.Connection,
+		encryptor: config.Encryptor,
 	}
 
 	if err := store.Connect(); err != nil {
@@ -45,16 +50,52 @@ func (s *SQLiteStore) Connect() error {
 		return fmt.Errorf("failed to connect to SQLite database: %w", err)
 	}
 
+	if err := db.Use(tracingPlugin{}); err != nil {
+		return fmt.Errorf("failed to register telemetry plugin: %w", err)
+	}
 	s.db = db
 
 	// Auto-migrate the schema
-	if err := s.db.AutoMigrate(&Conversation{}, &Message{}); err != nil {
+	if err := s.db.AutoMigrate(&Conversation{}, &Message{}, &MessageFeedback{}); err != nil {
 		return fmt.Errorf("failed to migrate database schema: %w", err)
 	}
 
 	return nil
 }
 
+// SetEncryptor enables field-level encryption for message parts.
+// Existing plaintext rows stay readable and are encrypted the next time they are fetched.
+func (s *SQLiteStore) SetEncryptor(encryptor *FieldEncryptor) {
+	s.encryptor = encryptor
+}
+
+// SetLogger sets the logger for warnings about stored data; nil uses logging.Default()
+func (s *SQLiteStore) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+func (s *SQLiteStore) log() *slog.Logger {
+	return logging.Or(s.logger).With(logging.KeyComponent, "sqlite_store")
+}
+
+// ForTenant returns a view of the store restricted to tenantID.
+// The view shares the database connection; closing either closes both.
+func (s *SQLiteStore) ForTenant(tenantID string) MessageStore {
+	scoped := *s
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// DB returns the underlying connection so other GORM-backed stores (traces, memories) can share it
+func (s *SQLiteStore) DB() *gorm.DB {
+	return s.db
+}
+
+// TenantID returns the tenant this store is scoped to ("" for the default tenant)
+func (s *SQLiteStore) TenantID() string {
+	return s.tenantID
+}
+
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
 	if s.db != nil {
@@ -88,23 +129,32 @@ func (s *SQLiteStore) SaveMessage(sessionID, role, messageType string, parts int
 
 // SaveMessageWithUser saves a message to the database with user association
 func (s *SQLiteStore) SaveMessageWithUser(sessionID, userID, role, messageType string, parts interface{}, functionID string) error {
+	return s.SaveMessageWithMetadata(sessionID, userID, role, messageType, parts, functionID, nil)
+}
+
+// SaveMessageWithMetadata saves a message together with its generation metadata (nil for none)
+func (s *SQLiteStore) SaveMessageWithMetadata(sessionID, userID, role, messageType string, parts interface{}, functionID string, metadata interface{}) error {
 	if s.db == nil {
 		return fmt.Errorf("database connection is nil")
 	}
 
 	// Ensure conversation record exists (create if first message)
-	// Use Count() to check existence without triggering "record not found" error logs
-	var count int64
-	if err := s.db.Model(&Conversation{}).Where("conversation_id = ?", sessionID).Count(&count).Error; err != nil {
-		log.Printf("Warning: Error checking for conversation %s: %v", sessionID, err)
-	} else if count == 0 {
+	// Use Pluck() to check existence without triggering "record not found" error logs
+	var owners []string
+	if err := s.db.Model(&Conversation{}).Where("conversation_id = ?", sessionID).Pluck("tenant_id", &owners).Error; err != nil {
+		return fmt.Errorf("failed to check conversation owner: %w", err)
+	} else if len(owners) > 0 && owners[0] != s.tenantID {
+		return fmt.Errorf("cannot save message to conversation %s: %w", sessionID, ErrTenantMismatch)
+	} else if len(owners) == 0 {
 		// Conversation doesn't exist, create it with user ID
+		// Without it the message would have no conversation to carry its tenant
 		if err := s.CreateConversation(sessionID, userID); err != nil {
-			log.Printf("Warning: Failed to create conversation record for %s: %v", sessionID, err)
+			return fmt.Errorf("failed to create conversation %s: %w", sessionID, err)
 		}
 	}
 
-	// Reuse count variable to get message sequence number
+	// Get message sequence number
+	var count int64
 	if err := s.db.Model(&Message{}).Where("conversation_id = ?", sessionID).Count(&count).Error; err != nil {
 		return fmt.Errorf("failed to count existing messages: %w", err)
 	}
@@ -114,31 +164,53 @@ func (s *SQLiteStore) SaveMessageWithUser(sessionID, userID, role, messageType s
 	// Marshal the provided parts into JSON
 	partsJSONBytes, err := json.Marshal(parts)
 	if err != nil {
-		log.Printf("Error marshalling parts for DB storage (ConvID: %s): %v", sessionID, err)
 		return fmt.Errorf("failed to marshal parts for database: %w", err)
 	}
 	partsJSONStr := string(partsJSONBytes)
 
 	// Ensure partsJSONStr is not empty or just "null"
 	if parts == nil || partsJSONStr == "null" || partsJSONStr == "[]" {
-		log.Printf("Warning: Saving message with empty/null parts for ConvID: %s, Role: %s, Type: %s", sessionID, role, messageType)
+		s.log().Debug("Saving message with empty parts", "conversation_id", sessionID, "role", role, "type", messageType)
 		partsJSONStr = "{}" // Save as empty JSON object
 	}
 
+	metadataJSONStr := ""
+	if metadata != nil {
+		metadataJSONBytes, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message metadata: %w", err)
+		}
+		if string(metadataJSONBytes) != "null" {
+			metadataJSONStr = string(metadataJSONBytes)
+		}
+	}
+
 	msg := Message{
 		ConversationID: sessionID,
+		TenantID:       s.tenantID,
 		Sequence:       seq,
 		Role:           role,
 		Type:           messageType,
 		PartsJSON:      partsJSONStr,
+		MetadataJSON:   metadataJSONStr,
 		FunctionID:     functionID,
 	}
+	if s.encryptor != nil {
+		// Encrypted once the row has an ID to bind the ciphertext to
+		msg.PartsJSON = "{}"
+	}
 
 	tx := s.db.Begin()
 	if err := tx.Create(&msg).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to create message record: %w", err)
 	}
+	if s.encryptor != nil {
+		if err := encryptMessageParts(tx, s.encryptor, &msg, partsJSONStr); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
 	if err := tx.Model(&Conversation{}).Where("conversation_id = ?", sessionID).Update("message_count", seq).Error; err != nil {
 		tx.Rollback()
@@ -157,12 +229,12 @@ func (s *SQLiteStore) FetchHistory(sessionID string, limit int) ([]Message, erro
 	}
 
 	var msgs []Message
-	query := s.db.Where("conversation_id = ?", sessionID).Order("sequence ASC")
+	query := s.db.Where("conversation_id = ? AND tenant_id = ?", sessionID, s.tenantID).Order("sequence ASC")
 
 	if limit > 0 {
 		// Get total count first
 		var count int64
-		if err := s.db.Model(&Message{}).Where("conversation_id = ?", sessionID).Count(&count).Error; err != nil {
+		if err := s.db.Model(&Message{}).Where("conversation_id = ? AND tenant_id = ?", sessionID, s.tenantID).Count(&count).Error; err != nil {
 			return nil, fmt.Errorf("failed to count messages: %w", err)
 		}
 
@@ -188,17 +260,39 @@ func (s *SQLiteStore) FetchHistory(sessionID string, limit int) ([]Message, erro
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
+	// Decrypt parts (no-op when encryption is disabled)
+	if err := decryptMessages(s.db, s.encryptor, s.log(), msgs); err != nil {
+		return nil, err
+	}
+
 	// Sanitize history to ensure valid turn structure
 	// This handles truncation breaking tool cycles and corrupted history
-	msgs = SanitizeHistory(msgs)
+	msgs = sanitizeHistory(s.log(), msgs)
 
 	// If we fetched extra and now have more than limit, trim to limit
 	if limit > 0 && len(msgs) > limit {
 		msgs = msgs[len(msgs)-limit:]
 		// Re-sanitize after trimming to ensure we still have valid start
-		msgs = SanitizeHistory(msgs)
+		msgs = sanitizeHistory(s.log(), msgs)
+	}
+
+	return msgs, nil
+}
+
+// FetchRawHistory returns all messages of a conversation in sequence order without sanitizing them
+func (s *SQLiteStore) FetchRawHistory(sessionID string) ([]Message, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
 	}
 
+	var msgs []Message
+	if err := s.db.Where("conversation_id = ? AND tenant_id = ?", sessionID, s.tenantID).Order("sequence ASC").Find(&msgs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+
+	if err := decryptMessages(s.db, s.encryptor, s.log(), msgs); err != nil {
+		return nil, err
+	}
 	return msgs, nil
 }
 
@@ -210,6 +304,7 @@ func (s *SQLiteStore) CreateConversation(convoID, userID string) error {
 
 	conv := Conversation{
 		ConversationID: convoID,
+		TenantID:       s.tenantID,
 		UserID:         userID,
 		MessageCount:   0,
 	}
@@ -217,14 +312,14 @@ func (s *SQLiteStore) CreateConversation(convoID, userID string) error {
 	return s.db.Create(&conv).Error
 }
 
-// ListConversations returns all conversation IDs
+// ListConversations returns all conversation IDs for the store's tenant
 func (s *SQLiteStore) ListConversations() ([]string, error) {
 	if s.db == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
 
 	var convs []Conversation
-	if err := s.db.Find(&convs).Error; err != nil {
+	if err := s.db.Where("tenant_id = ?", s.tenantID).Find(&convs).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch conversations: %w", err)
 	}
 
@@ -252,7 +347,7 @@ func (s *SQLiteStore) ListConversationsForUser(userID string) ([]ConversationInf
 	var convs []ConvWithCount
 	err := s.db.Model(&Conversation{}).
 		Select("conversations.*, (SELECT COUNT(*) FROM messages WHERE messages.conversation_id = conversations.conversation_id) as computed_message_count").
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND tenant_id = ?", userID, s.tenantID).
 		Order("updated_at DESC").
 		Find(&convs).Error
 
@@ -264,6 +359,7 @@ func (s *SQLiteStore) ListConversationsForUser(userID string) ([]ConversationInf
 	for i, c := range convs {
 		result[i] = ConversationInfo{
 			ConversationID: c.ConversationID,
+			TenantID:       c.TenantID,
 			UserID:         c.UserID,
 			Title:          c.Title,
 			MessageCount:   c.ComputedMessageCount, // Use computed count, not stored
@@ -274,3 +370,38 @@ func (s *SQLiteStore) ListConversationsForUser(userID string) ([]ConversationInf
 
 	return result, nil
 }
+
+// SaveFeedback creates or replaces a user's feedback for a message in this store's tenant
+func (s *SQLiteStore) SaveFeedback(feedback *MessageFeedback) error {
+	return saveFeedback(s.db, s.tenantID, feedback)
+}
+
+// GetFeedbackForConversation returns all feedback for a conversation ordered by sequence
+func (s *SQLiteStore) GetFeedbackForConversation(conversationID string) ([]*MessageFeedback, error) {
+	return queryFeedback(s.db, s.tenantID, FeedbackFilter{ConversationID: conversationID})
+}
+
+// QueryFeedback returns feedback matching the filter, newest first
+func (s *SQLiteStore) QueryFeedback(filter FeedbackFilter) ([]*MessageFeedback, error) {
+	return queryFeedback(s.db, s.tenantID, filter)
+}
+
+// DeleteFeedback removes a user's feedback for a message
+func (s *SQLiteStore) DeleteFeedback(conversationID string, sequence int, userID string) error {
+	return deleteFeedback(s.db, s.tenantID, conversationID, sequence, userID)
+}
+
+// GetConversation returns a conversation's details, or ErrConversationNotFound
+func (s *SQLiteStore) GetConversation(convoID string) (*ConversationInfo, error) {
+	return getConversation(s.db, s.tenantID, convoID)
+}
+
+// RenameConversation sets a conversation's title
+func (s *SQLiteStore) RenameConversation(convoID, title string) error {
+	return renameConversation(s.db, s.tenantID, convoID, title)
+}
+
+// DeleteConversation permanently removes a conversation with its messages and feedback
+func (s *SQLiteStore) DeleteConversation(convoID string) error {
+	return deleteConversation(s.db, s.tenantID, convoID)
+}
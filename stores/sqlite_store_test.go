@@ -0,0 +1,57 @@
+package stores
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSQLiteStore_SaveMessageWithMetadata(t *testing.T) {
+	store := newTestSQLiteStore(t)
+
+	if err := store.SaveMessage("conv-1", "user", "user_message", []map[string]string{{"text": "hi"}}, ""); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+	metadata := map[string]interface{}{
+		"provider":      "anthropic",
+		"model":         "claude-sonnet-4-20250514",
+		"latency_ms":    1234,
+		"finish_reason": "end_turn",
+	}
+	if err := store.SaveMessageWithMetadata("conv-1", "", "model", "model_message", []map[string]string{{"text": "hello"}}, "", metadata); err != nil {
+		t.Fatalf("SaveMessageWithMetadata: %v", err)
+	}
+
+	history, err := store.FetchHistory("conv-1", 0)
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(history))
+	}
+	if history[0].MetadataJSON != "" {
+		t.Errorf("Expected no metadata on user message, got %s", history[0].MetadataJSON)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(history[1].MetadataJSON), &got); err != nil {
+		t.Fatalf("Invalid metadata JSON %q: %v", history[1].MetadataJSON, err)
+	}
+	if got["provider"] != "anthropic" || got["finish_reason"] != "end_turn" {
+		t.Errorf("Unexpected metadata: %v", got)
+	}
+}
+
+func TestSQLiteStore_NilMetadataPointerStoresNothing(t *testing.T) {
+	store := newTestSQLiteStore(t)
+
+	var metadata *struct{ Model string }
+	if err := store.SaveMessageWithMetadata("conv-1", "", "model", "model_message", []map[string]string{{"text": "hello"}}, "", metadata); err != nil {
+		t.Fatalf("SaveMessageWithMetadata: %v", err)
+	}
+
+	var msg Message
+	store.db.Where("conversation_id = ?", "conv-1").First(&msg)
+	if msg.MetadataJSON != "" {
+		t.Errorf("Expected empty metadata for nil pointer, got %q", msg.MetadataJSON)
+	}
+}
@@ -0,0 +1,68 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/Desarso/godantic/telemetry"
+	"gorm.io/gorm"
+)
+
+const storeSpanKey = "godantic:span"
+
+// tracingPlugin is a GORM plugin that records a telemetry span and duration for every statement
+type tracingPlugin struct{}
+
+// Name implements gorm.Plugin
+func (tracingPlugin) Name() string {
+	return "godantic:telemetry"
+}
+
+// Initialize registers callbacks around GORM's create, query, update, delete, row and raw processors
+func (tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("godantic:before_create", startStoreSpan("INSERT")),
+		cb.Create().After("gorm:create").Register("godantic:after_create", endStoreSpan),
+		cb.Query().Before("gorm:query").Register("godantic:before_query", startStoreSpan("SELECT")),
+		cb.Query().After("gorm:query").Register("godantic:after_query", endStoreSpan),
+		cb.Update().Before("gorm:update").Register("godantic:before_update", startStoreSpan("UPDATE")),
+		cb.Update().After("gorm:update").Register("godantic:after_update", endStoreSpan),
+		cb.Delete().Before("gorm:delete").Register("godantic:before_delete", startStoreSpan("DELETE")),
+		cb.Delete().After("gorm:delete").Register("godantic:after_delete", endStoreSpan),
+		cb.Row().Before("gorm:row").Register("godantic:before_row", startStoreSpan("SELECT")),
+		cb.Row().After("gorm:row").Register("godantic:after_row", endStoreSpan),
+		cb.Raw().Before("gorm:raw").Register("godantic:before_raw", startStoreSpan("RAW")),
+		cb.Raw().After("gorm:raw").Register("godantic:after_raw", endStoreSpan),
+	)
+}
+
+func startStoreSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		system := db.Dialector.Name()
+		if system == "postgres" {
+			system = "postgresql"
+		}
+		ctx, span := telemetry.StartStoreOperation(db.Statement.Context, system, operation, db.Statement.Table)
+		db.Statement.Context = ctx
+		db.InstanceSet(storeSpanKey, span)
+	}
+}
+
+func endStoreSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(storeSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(*telemetry.StoreSpan)
+	if !ok {
+		return
+	}
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	span.End(err)
+}
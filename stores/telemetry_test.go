@@ -0,0 +1,32 @@
+package stores
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSQLiteStore_RecordsStoreSpans(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	defer otel.SetTracerProvider(previous)
+
+	store := newTestSQLiteStore(t)
+	if err := store.SaveMessage("conv-1", "user", "user_message", []map[string]string{{"text": "hi"}}, ""); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+	if _, err := store.FetchHistory("conv-1", 0); err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, span := range spans.Ended() {
+		names[span.Name()] = true
+	}
+	if !names["INSERT messages"] || !names["SELECT messages"] {
+		t.Errorf("Expected INSERT and SELECT spans on messages, got %v", names)
+	}
+}
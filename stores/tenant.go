@@ -0,0 +1,49 @@
+package stores
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTenantMismatch is returned when a conversation is accessed through a store
+// scoped to a different tenant than the one that owns it
+var ErrTenantMismatch = errors.New("conversation belongs to a different tenant")
+
+// TenantScopedStore is implemented by message stores that can be narrowed to a single tenant.
+// The returned store shares the underlying connection; every read and write it performs
+// is restricted to rows belonging to tenantID.
+type TenantScopedStore interface {
+	ForTenant(tenantID string) MessageStore
+}
+
+// TenantScopedTraceStore is implemented by trace stores that can be narrowed to a single tenant
+type TenantScopedTraceStore interface {
+	ForTenant(tenantID string) TraceStore
+}
+
+// ScopeStoreToTenant returns a view of store restricted to tenantID.
+// An empty tenantID returns the store unchanged (the default tenant).
+// Stores that cannot be scoped return an error rather than silently sharing data.
+func ScopeStoreToTenant(store MessageStore, tenantID string) (MessageStore, error) {
+	if store == nil || tenantID == "" {
+		return store, nil
+	}
+	scoped, ok := store.(TenantScopedStore)
+	if !ok {
+		return nil, fmt.Errorf("message store %T does not support tenant scoping", store)
+	}
+	return scoped.ForTenant(tenantID), nil
+}
+
+// ScopeTraceStoreToTenant returns a view of traceStore restricted to tenantID.
+// An empty tenantID returns the store unchanged (the default tenant).
+func ScopeTraceStoreToTenant(traceStore TraceStore, tenantID string) (TraceStore, error) {
+	if traceStore == nil || tenantID == "" {
+		return traceStore, nil
+	}
+	scoped, ok := traceStore.(TenantScopedTraceStore)
+	if !ok {
+		return nil, fmt.Errorf("trace store %T does not support tenant scoping", traceStore)
+	}
+	return scoped.ForTenant(tenantID), nil
+}
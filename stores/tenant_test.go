@@ -0,0 +1,124 @@
+package stores
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStoreSimple(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreSimple: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStore_TenantIsolation(t *testing.T) {
+	base := newTestSQLiteStore(t)
+	acme := base.ForTenant("acme")
+	globex := base.ForTenant("globex")
+
+	parts := []map[string]string{{"text": "hello"}}
+	if err := acme.SaveMessageWithUser("conv-a", "alice", "user", "user_message", parts, ""); err != nil {
+		t.Fatalf("SaveMessageWithUser acme: %v", err)
+	}
+	if err := globex.SaveMessageWithUser("conv-g", "alice", "user", "user_message", parts, ""); err != nil {
+		t.Fatalf("SaveMessageWithUser globex: %v", err)
+	}
+	if err := base.SaveMessage("conv-default", "user", "user_message", parts, ""); err != nil {
+		t.Fatalf("SaveMessage default: %v", err)
+	}
+
+	ids, err := acme.ListConversations()
+	if err != nil {
+		t.Fatalf("ListConversations: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "conv-a" {
+		t.Errorf("Expected acme to see only conv-a, got %v", ids)
+	}
+
+	ids, _ = base.ListConversations()
+	if len(ids) != 1 || ids[0] != "conv-default" {
+		t.Errorf("Expected default tenant to see only conv-default, got %v", ids)
+	}
+
+	infos, err := globex.ListConversationsForUser("alice")
+	if err != nil {
+		t.Fatalf("ListConversationsForUser: %v", err)
+	}
+	if len(infos) != 1 || infos[0].ConversationID != "conv-g" || infos[0].TenantID != "globex" {
+		t.Errorf("Expected globex to see only its conversation for alice, got %+v", infos)
+	}
+
+	history, err := globex.FetchHistory("conv-a", 0)
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected no cross-tenant history, got %d messages", len(history))
+	}
+
+	err = globex.SaveMessage("conv-a", "user", "user_message", parts, "")
+	if !errors.Is(err, ErrTenantMismatch) {
+		t.Errorf("Expected ErrTenantMismatch writing to another tenant's conversation, got %v", err)
+	}
+}
+
+func TestSQLiteStore_SaveFailsWithoutConversation(t *testing.T) {
+	base := newTestSQLiteStore(t)
+	store := base.ForTenant("acme")
+	if err := base.db.Exec(`CREATE TRIGGER no_conversations BEFORE INSERT ON conversations BEGIN SELECT RAISE(FAIL, 'read only'); END`).Error; err != nil {
+		t.Fatalf("Creating trigger: %v", err)
+	}
+
+	if err := store.SaveMessage("conv-a", "user", "user_message", []map[string]string{{"text": "hello"}}, ""); err == nil {
+		t.Fatal("Expected SaveMessage to fail when the conversation cannot be created")
+	}
+	var count int64
+	base.db.Model(&Message{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no orphan message, got %d", count)
+	}
+}
+
+func TestGORMTraceStore_TenantIsolation(t *testing.T) {
+	base := newTestSQLiteStore(t)
+	traceStore, err := NewGORMTraceStore(base.db)
+	if err != nil {
+		t.Fatalf("NewGORMTraceStore: %v", err)
+	}
+	acme := traceStore.ForTenant("acme")
+	globex := traceStore.ForTenant("globex")
+
+	trace := &ExecutionTrace{ConversationID: "conv-1", ToolCallID: "call-1", TraceID: "t1", Status: "end", Label: "Done", Timestamp: 1}
+	if err := acme.SaveTrace(trace); err != nil {
+		t.Fatalf("SaveTrace: %v", err)
+	}
+
+	if traces, _ := globex.GetTracesByToolCall("call-1"); len(traces) != 0 {
+		t.Errorf("Expected globex to see no traces, got %d", len(traces))
+	}
+	if err := globex.DeleteTracesByConversation("conv-1"); err != nil {
+		t.Fatalf("DeleteTracesByConversation: %v", err)
+	}
+	traces, _ := acme.GetTracesByConversation("conv-1")
+	if len(traces) != 1 || traces[0].TenantID != "acme" {
+		t.Errorf("Expected acme trace to survive another tenant's delete, got %+v", traces)
+	}
+}
+
+type unscopedStore struct{ MessageStore }
+
+func TestScopeStoreToTenant(t *testing.T) {
+	base := newTestSQLiteStore(t)
+
+	if store, err := ScopeStoreToTenant(base, ""); err != nil || store != MessageStore(base) {
+		t.Errorf("Expected default tenant to return the store unchanged, got %v %v", store, err)
+	}
+	if _, err := ScopeStoreToTenant(unscopedStore{base}, "acme"); err == nil {
+		t.Errorf("Expected error scoping a store without tenant support")
+	}
+}
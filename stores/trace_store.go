@@ -3,8 +3,10 @@ package stores
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/Desarso/godantic/logging"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +16,7 @@ type ExecutionTrace struct {
 	ID             uint           `gorm:"primarykey" json:"-"`
 	CreatedAt      time.Time      `json:"-"`
 	ConversationID string         `gorm:"index:idx_trace_conv;not null" json:"conversation_id"`
+	TenantID       string         `gorm:"index;not null;default:''" json:"tenant_id,omitempty"`
 	ToolCallID     string         `gorm:"index:idx_trace_conv;index:idx_trace_tool;not null" json:"tool_call_id"`
 	TraceID        string         `gorm:"not null" json:"trace_id"`
 	ParentID       string         `json:"parent_id,omitempty"`
@@ -25,6 +28,8 @@ type ExecutionTrace struct {
 	Details        map[string]any `gorm:"-" json:"details,omitempty"` // Not stored, computed from DetailsJSON
 	Timestamp      int64          `gorm:"not null" json:"timestamp"`
 	DurationMS     int64          `json:"duration_ms,omitempty"`
+	SpanTraceID    string         `json:"span_trace_id,omitempty"` // OpenTelemetry trace of the tool call span, if one was recording
+	SpanID         string         `json:"span_id,omitempty"`       // OpenTelemetry span ID of the tool call span
 }
 
 // BeforeSave marshals Details to DetailsJSON
@@ -40,8 +45,9 @@ func (t *ExecutionTrace) BeforeSave(tx *gorm.DB) error {
 }
 
 // AfterFind unmarshals DetailsJSON to Details
+// Encrypted details are left for the trace store to decrypt.
 func (t *ExecutionTrace) AfterFind(tx *gorm.DB) error {
-	if t.DetailsJSON != "" {
+	if t.DetailsJSON != "" && !IsEncryptedField(t.DetailsJSON) {
 		return json.Unmarshal([]byte(t.DetailsJSON), &t.Details)
 	}
 	return nil
@@ -67,7 +73,10 @@ type TraceStore interface {
 
 // SQLiteTraceStore implements TraceStore for SQLite/PostgreSQL via GORM
 type GORMTraceStore struct {
-	db *gorm.DB
+	db        *gorm.DB
+	encryptor *FieldEncryptor
+	tenantID  string
+	logger    *slog.Logger
 }
 
 // NewGORMTraceStore creates a trace store from an existing GORM database connection
@@ -84,12 +93,37 @@ func NewGORMTraceStore(db *gorm.DB) (*GORMTraceStore, error) {
 	return &GORMTraceStore{db: db}, nil
 }
 
+// SetEncryptor enables field-level encryption for trace details
+func (s *GORMTraceStore) SetEncryptor(encryptor *FieldEncryptor) {
+	s.encryptor = encryptor
+}
+
+// SetLogger sets the logger for warnings about stored traces; nil uses logging.Default()
+func (s *GORMTraceStore) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+func (s *GORMTraceStore) log() *slog.Logger {
+	return logging.Or(s.logger).With(logging.KeyComponent, "trace_store")
+}
+
+// ForTenant returns a view of the trace store restricted to tenantID
+func (s *GORMTraceStore) ForTenant(tenantID string) TraceStore {
+	scoped := *s
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
 // SaveTrace saves a single trace event
 func (s *GORMTraceStore) SaveTrace(trace *ExecutionTrace) error {
 	if s.db == nil {
 		return fmt.Errorf("database connection is nil")
 	}
-	return s.db.Create(trace).Error
+	trace.TenantID = s.tenantID
+	if s.encryptor == nil {
+		return s.db.Create(trace).Error
+	}
+	return s.createEncrypted([]*ExecutionTrace{trace})
 }
 
 // SaveTraces saves multiple trace events in a batch
@@ -100,7 +134,59 @@ func (s *GORMTraceStore) SaveTraces(traces []*ExecutionTrace) error {
 	if len(traces) == 0 {
 		return nil
 	}
-	return s.db.CreateInBatches(traces, 100).Error
+	for _, trace := range traces {
+		trace.TenantID = s.tenantID
+	}
+	if s.encryptor == nil {
+		return s.db.CreateInBatches(traces, 100).Error
+	}
+	return s.createEncrypted(traces)
+}
+
+// createEncrypted inserts copies of the traces without their details, then stores the details
+// encrypted and bound to each new row. The traces get the generated IDs, as with
+// encryption disabled, and keep their plaintext fields.
+func (s *GORMTraceStore) createEncrypted(traces []*ExecutionTrace) error {
+	stored := make([]*ExecutionTrace, len(traces))
+	details := make([]string, len(traces))
+	for i, trace := range traces {
+		row := *trace
+		if trace.Details != nil {
+			data, err := json.Marshal(trace.Details)
+			if err != nil {
+				return fmt.Errorf("failed to marshal trace details: %w", err)
+			}
+			details[i] = string(data)
+			row.Details, row.DetailsJSON = nil, ""
+		}
+		stored[i] = &row
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(stored, 100).Error; err != nil {
+			return err
+		}
+		for i, row := range stored {
+			if details[i] == "" {
+				continue
+			}
+			encrypted, err := s.encryptor.Encrypt(details[i], traceBinding(row.ID))
+			if err != nil {
+				return fmt.Errorf("failed to encrypt trace details: %w", err)
+			}
+			if err := tx.Model(&ExecutionTrace{}).Where("id = ?", row.ID).UpdateColumn("details_json", encrypted).Error; err != nil {
+				return fmt.Errorf("failed to store encrypted trace details: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, trace := range traces {
+		trace.ID, trace.CreatedAt = stored[i].ID, stored[i].CreatedAt
+	}
+	return nil
 }
 
 // GetTracesByConversation retrieves all traces for a conversation, ordered by timestamp
@@ -110,11 +196,17 @@ func (s *GORMTraceStore) GetTracesByConversation(conversationID string) ([]*Exec
 	}
 
 	var traces []*ExecutionTrace
-	err := s.db.Where("conversation_id = ?", conversationID).
+	err := s.db.Where("conversation_id = ? AND tenant_id = ?", conversationID, s.tenantID).
 		Order("timestamp ASC").
 		Find(&traces).Error
+	if err != nil {
+		return nil, err
+	}
 
-	return traces, err
+	if err := decryptTraces(s.db, s.encryptor, s.log(), traces); err != nil {
+		return nil, err
+	}
+	return traces, nil
 }
 
 // GetTracesByToolCall retrieves all traces for a specific tool call
@@ -124,11 +216,17 @@ func (s *GORMTraceStore) GetTracesByToolCall(toolCallID string) ([]*ExecutionTra
 	}
 
 	var traces []*ExecutionTrace
-	err := s.db.Where("tool_call_id = ?", toolCallID).
+	err := s.db.Where("tool_call_id = ? AND tenant_id = ?", toolCallID, s.tenantID).
 		Order("timestamp ASC").
 		Find(&traces).Error
+	if err != nil {
+		return nil, err
+	}
 
-	return traces, err
+	if err := decryptTraces(s.db, s.encryptor, s.log(), traces); err != nil {
+		return nil, err
+	}
+	return traces, nil
 }
 
 // DeleteTracesByConversation removes all traces for a conversation
@@ -136,5 +234,5 @@ func (s *GORMTraceStore) DeleteTracesByConversation(conversationID string) error
 	if s.db == nil {
 		return fmt.Errorf("database connection is nil")
 	}
-	return s.db.Where("conversation_id = ?", conversationID).Delete(&ExecutionTrace{}).Error
+	return s.db.Where("conversation_id = ? AND tenant_id = ?", conversationID, s.tenantID).Delete(&ExecutionTrace{}).Error
 }
@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// MetricInterval is how often Setup exports metrics; they are also exported on shutdown
+var MetricInterval = 30 * time.Second
+
+// Setup installs global tracer and meter providers that write spans and metrics to w as
+// JSON lines, for local verification. The returned shutdown function flushes both and
+// must be called before exit.
+func Setup(serviceName string, w io.Writer) (shutdown func(context.Context) error, err error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build telemetry resource: %w", err)
+	}
+
+	spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create span exporter: %w", err)
+	}
+	metricExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(spanExporter),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(MetricInterval))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+// SetupFile is Setup writing to a file, which is appended to and closed on shutdown
+func SetupFile(serviceName, path string) (shutdown func(context.Context) error, err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open telemetry file: %w", err)
+	}
+	shutdownProviders, err := Setup(serviceName, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return errors.Join(shutdownProviders(ctx), file.Close())
+	}, nil
+}
@@ -0,0 +1,315 @@
+// Package telemetry records OpenTelemetry spans and metrics for agent turns, model
+// requests, tool calls and store operations.
+//
+// Instrumentation goes through the global tracer and meter providers, so it costs
+// nothing until an application installs an SDK: either its own (e.g. an OTLP exporter)
+// or the JSON exporter set up by Setup and SetupFile. Model spans and metrics follow the
+// OpenTelemetry GenAI semantic conventions.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Desarso/godantic"
+
+// Attribute keys. The gen_ai, db and error keys are from the OpenTelemetry semantic conventions.
+const (
+	AttrOperationName  = "gen_ai.operation.name"
+	AttrProviderName   = "gen_ai.provider.name"
+	AttrRequestModel   = "gen_ai.request.model"
+	AttrResponseFinish = "gen_ai.response.finish_reasons"
+	AttrInputTokens    = "gen_ai.usage.input_tokens"
+	AttrOutputTokens   = "gen_ai.usage.output_tokens"
+	AttrCacheRead      = "gen_ai.usage.cache_read.input_tokens"
+	AttrCacheCreation  = "gen_ai.usage.cache_creation.input_tokens"
+	AttrTokenType      = "gen_ai.token.type"
+	AttrConversationID = "gen_ai.conversation.id"
+	AttrToolName       = "gen_ai.tool.name"
+	AttrToolCallID     = "gen_ai.tool.call.id"
+	AttrDBSystem       = "db.system.name"
+	AttrDBOperation    = "db.operation.name"
+	AttrDBCollection   = "db.collection.name"
+	AttrErrorType      = "error.type"
+	AttrUserID         = "godantic.user.id"
+	AttrStream         = "godantic.stream"
+	AttrCacheResult    = "godantic.prompt_cache.result"
+)
+
+const (
+	operationChat        = "chat"
+	operationExecuteTool = "execute_tool"
+	operationInvokeAgent = "invoke_agent"
+	errorTypeOther       = "_OTHER"
+	errorTypeCancelled   = "cancelled"
+	errorTypeTimeout     = "timeout"
+	cacheResultHit       = "hit"
+	cacheResultWrite     = "write"
+	tokenTypeCacheRead   = "cache_read"
+	tokenTypeCacheWrite  = "cache_creation"
+)
+
+// instrumentSet holds the package's metric instruments
+type instrumentSet struct {
+	operationDuration metric.Float64Histogram
+	timeToFirstChunk  metric.Float64Histogram
+	tokenUsage        metric.Int64Histogram
+	turnDuration      metric.Float64Histogram
+	toolDuration      metric.Float64Histogram
+	storeDuration     metric.Float64Histogram
+	cacheRequests     metric.Int64Counter
+	cacheTokens       metric.Int64Counter
+}
+
+var (
+	instrumentsMu       sync.Mutex
+	instruments         *instrumentSet
+	instrumentsProvider metric.MeterProvider
+)
+
+// durationBuckets suit calls that take from milliseconds to minutes (the GenAI convention's advice)
+var durationBuckets = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+
+// meter returns the instruments of the global meter provider, creating them when it changes.
+// Instruments created before an SDK is installed forward to it once it is.
+func meter() *instrumentSet {
+	instrumentsMu.Lock()
+	defer instrumentsMu.Unlock()
+	if provider := otel.GetMeterProvider(); instruments == nil || provider != instrumentsProvider {
+		instruments, instrumentsProvider = newInstruments(provider.Meter(instrumentationName)), provider
+	}
+	return instruments
+}
+
+func newInstruments(m metric.Meter) *instrumentSet {
+	seconds := metric.WithExplicitBucketBoundaries(durationBuckets...)
+	set := &instrumentSet{}
+	set.operationDuration, _ = m.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of model requests"), seconds)
+	set.timeToFirstChunk, _ = m.Float64Histogram("gen_ai.client.operation.time_to_first_chunk",
+		metric.WithUnit("s"), metric.WithDescription("Time until the first chunk of a streamed model response"), seconds)
+	set.tokenUsage, _ = m.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithUnit("{token}"), metric.WithDescription("Input and output tokens per model request"))
+	set.turnDuration, _ = m.Float64Histogram("godantic.turn.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of agent turns, including their tool loop"), seconds)
+	set.toolDuration, _ = m.Float64Histogram("godantic.tool.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of tool calls; calls that failed carry error.type"), seconds)
+	set.storeDuration, _ = m.Float64Histogram("db.client.operation.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of store operations"), seconds)
+	set.cacheRequests, _ = m.Int64Counter("godantic.prompt_cache.requests",
+		metric.WithUnit("{request}"), metric.WithDescription("Model requests that read from (hit) or only wrote to the prompt cache"))
+	set.cacheTokens, _ = m.Int64Counter("godantic.prompt_cache.tokens",
+		metric.WithUnit("{token}"), metric.WithDescription("Input tokens read from or written to the prompt cache"))
+	return set
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// TurnSpan covers one agent turn: a user request and the model calls and tool calls it leads to
+type TurnSpan struct {
+	span  trace.Span
+	start time.Time
+}
+
+// StartTurn starts a turn span; pass the returned context to the turn's model and tool calls
+func StartTurn(ctx context.Context, conversationID, userID string) (context.Context, *TurnSpan) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrOperationName, operationInvokeAgent),
+		attribute.String(AttrConversationID, conversationID),
+	}
+	if userID != "" {
+		attrs = append(attrs, attribute.String(AttrUserID, userID))
+	}
+	ctx, span := tracer().Start(ctx, operationInvokeAgent, trace.WithAttributes(attrs...))
+	return ctx, &TurnSpan{span: span, start: time.Now()}
+}
+
+// End ends the turn, marking it failed if err is set
+func (t *TurnSpan) End(err error) {
+	attrs := endSpan(t.span, err)
+	meter().turnDuration.Record(context.Background(), time.Since(t.start).Seconds(), metric.WithAttributes(attrs...))
+}
+
+// ModelSpan covers one model request
+type ModelSpan struct {
+	span       trace.Span
+	ctx        context.Context
+	start      time.Time
+	firstChunk time.Time
+	attrs      []attribute.KeyValue
+	once       sync.Once
+}
+
+// ModelResult is the outcome of a model request
+type ModelResult struct {
+	FinishReason     string
+	InputTokens      int // Including cached tokens
+	OutputTokens     int
+	CacheReadTokens  int
+	CacheWriteTokens int
+	Err              error
+}
+
+// StartModelCall starts a model request span
+func StartModelCall(ctx context.Context, provider, model string, stream bool) (context.Context, *ModelSpan) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrOperationName, operationChat),
+		attribute.String(AttrProviderName, provider),
+		attribute.String(AttrRequestModel, model),
+	}
+	name := operationChat
+	if model != "" {
+		name += " " + model
+	}
+	ctx, span := tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, attribute.Bool(AttrStream, stream))...))
+	return ctx, &ModelSpan{span: span, ctx: ctx, start: time.Now(), attrs: attrs}
+}
+
+// FirstChunk records the time to the first streamed chunk; later calls are ignored
+func (s *ModelSpan) FirstChunk() {
+	if !s.firstChunk.IsZero() {
+		return
+	}
+	s.firstChunk = time.Now()
+	s.span.AddEvent("gen_ai.first_chunk")
+	meter().timeToFirstChunk.Record(s.ctx, s.firstChunk.Sub(s.start).Seconds(), metric.WithAttributes(s.attrs...))
+}
+
+// End ends the request span and records its duration and token usage. Only the first call counts.
+func (s *ModelSpan) End(result ModelResult) {
+	s.once.Do(func() {
+		if result.FinishReason != "" {
+			s.span.SetAttributes(attribute.StringSlice(AttrResponseFinish, []string{result.FinishReason}))
+		}
+		if result.InputTokens > 0 || result.OutputTokens > 0 {
+			s.span.SetAttributes(
+				attribute.Int(AttrInputTokens, result.InputTokens),
+				attribute.Int(AttrOutputTokens, result.OutputTokens),
+			)
+			tokens := meter().tokenUsage
+			tokens.Record(s.ctx, int64(result.InputTokens), metric.WithAttributes(append(s.attrs, attribute.String(AttrTokenType, "input"))...))
+			tokens.Record(s.ctx, int64(result.OutputTokens), metric.WithAttributes(append(s.attrs, attribute.String(AttrTokenType, "output"))...))
+		}
+		s.recordCache(result)
+		attrs := append(s.attrs, endSpan(s.span, result.Err)...)
+		meter().operationDuration.Record(s.ctx, time.Since(s.start).Seconds(), metric.WithAttributes(attrs...))
+	})
+}
+
+// recordCache records prompt cache reads and writes; requests that touched no cache are not counted
+func (s *ModelSpan) recordCache(result ModelResult) {
+	if result.CacheReadTokens == 0 && result.CacheWriteTokens == 0 {
+		return
+	}
+	s.span.SetAttributes(
+		attribute.Int(AttrCacheRead, result.CacheReadTokens),
+		attribute.Int(AttrCacheCreation, result.CacheWriteTokens),
+	)
+	outcome := cacheResultWrite
+	if result.CacheReadTokens > 0 {
+		outcome = cacheResultHit
+	}
+	instruments := meter()
+	instruments.cacheRequests.Add(s.ctx, 1, metric.WithAttributes(append(s.attrs, attribute.String(AttrCacheResult, outcome))...))
+	instruments.cacheTokens.Add(s.ctx, int64(result.CacheReadTokens), metric.WithAttributes(append(s.attrs, attribute.String(AttrTokenType, tokenTypeCacheRead))...))
+	instruments.cacheTokens.Add(s.ctx, int64(result.CacheWriteTokens), metric.WithAttributes(append(s.attrs, attribute.String(AttrTokenType, tokenTypeCacheWrite))...))
+}
+
+// ToolSpan covers one tool call
+type ToolSpan struct {
+	span  trace.Span
+	ctx   context.Context
+	start time.Time
+	name  string
+}
+
+// StartToolCall starts a tool call span; traces the tool emits can carry the returned context's span IDs
+func StartToolCall(ctx context.Context, name, callID string) (context.Context, *ToolSpan) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrOperationName, operationExecuteTool),
+		attribute.String(AttrToolName, name),
+	}
+	if callID != "" {
+		attrs = append(attrs, attribute.String(AttrToolCallID, callID))
+	}
+	ctx, span := tracer().Start(ctx, operationExecuteTool+" "+name, trace.WithAttributes(attrs...))
+	return ctx, &ToolSpan{span: span, ctx: ctx, start: time.Now(), name: name}
+}
+
+// End ends the tool call, marking it failed if err is set
+func (s *ToolSpan) End(err error) {
+	attrs := append([]attribute.KeyValue{attribute.String(AttrToolName, s.name)}, endSpan(s.span, err)...)
+	meter().toolDuration.Record(s.ctx, time.Since(s.start).Seconds(), metric.WithAttributes(attrs...))
+}
+
+// StoreSpan covers one store operation
+type StoreSpan struct {
+	span  trace.Span
+	ctx   context.Context
+	start time.Time
+	attrs []attribute.KeyValue
+}
+
+// StartStoreOperation starts a store operation span. system is the database (sqlite,
+// postgresql), operation the statement kind and collection the table.
+func StartStoreOperation(ctx context.Context, system, operation, collection string) (context.Context, *StoreSpan) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrDBSystem, system),
+		attribute.String(AttrDBOperation, operation),
+	}
+	name := operation
+	if collection != "" {
+		attrs = append(attrs, attribute.String(AttrDBCollection, collection))
+		name += " " + collection
+	}
+	ctx, span := tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx, &StoreSpan{span: span, ctx: ctx, start: time.Now(), attrs: attrs}
+}
+
+// End ends the store operation, marking it failed if err is set
+func (s *StoreSpan) End(err error) {
+	attrs := append(s.attrs, endSpan(s.span, err)...)
+	meter().storeDuration.Record(s.ctx, time.Since(s.start).Seconds(), metric.WithAttributes(attrs...))
+}
+
+// SpanIDs returns the hex trace and span IDs of the span in ctx, or empty strings when there is none
+func SpanIDs(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
+
+// endSpan records err on the span and ends it, returning the error.type attribute for metrics
+func endSpan(span trace.Span, err error) []attribute.KeyValue {
+	defer span.End()
+	if err == nil {
+		return nil
+	}
+	errorType := errorTypeOther
+	switch {
+	case errors.Is(err, context.Canceled):
+		errorType = errorTypeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		errorType = errorTypeTimeout
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	attr := attribute.String(AttrErrorType, errorType)
+	span.SetAttributes(attr)
+	return []attribute.KeyValue{attr}
+}
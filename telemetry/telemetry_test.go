@@ -0,0 +1,188 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// installTestProviders routes spans and metrics to in-memory readers for the test
+func installTestProviders(t *testing.T) (*tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	spans := tracetest.NewSpanRecorder()
+	metrics := sdkmetric.NewManualReader()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(metrics))
+
+	previousTracer, previousMeter := otel.GetTracerProvider(), otel.GetMeterProvider()
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousTracer)
+		otel.SetMeterProvider(previousMeter)
+	})
+	return spans, metrics
+}
+
+func attr(attrs []attribute.KeyValue, key string) attribute.Value {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTurnModelAndToolSpans(t *testing.T) {
+	spans, metrics := installTestProviders(t)
+
+	ctx, turn := StartTurn(context.Background(), "conv-1", "user-1")
+	_, model := StartModelCall(ctx, "anthropic", "claude-sonnet-4", true)
+	model.FirstChunk()
+	model.End(ModelResult{FinishReason: "end_turn", InputTokens: 120, OutputTokens: 30})
+	model.End(ModelResult{Err: errors.New("ignored")})
+	toolCtx, tool := StartToolCall(ctx, "Get_Weather", "call-1")
+	traceID, spanID := SpanIDs(toolCtx)
+	tool.End(errors.New("no such city"))
+	turn.End(nil)
+
+	ended := spans.Ended()
+	if len(ended) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(ended))
+	}
+	chat, execute, invoke := ended[0], ended[1], ended[2]
+	if invoke.Name() != "invoke_agent" || attr(invoke.Attributes(), AttrConversationID).AsString() != "conv-1" {
+		t.Errorf("Unexpected turn span %s %v", invoke.Name(), invoke.Attributes())
+	}
+	if chat.Name() != "chat claude-sonnet-4" || chat.Parent().SpanID() != invoke.SpanContext().SpanID() {
+		t.Errorf("Expected the chat span under the turn, got %s with parent %s", chat.Name(), chat.Parent().SpanID())
+	}
+	if attr(chat.Attributes(), AttrInputTokens).AsInt64() != 120 || attr(chat.Attributes(), AttrResponseFinish).AsStringSlice()[0] != "end_turn" {
+		t.Errorf("Unexpected chat attributes %v", chat.Attributes())
+	}
+	if chat.Status().Code == codes.Error {
+		t.Errorf("Expected only the first End to count, got status %v", chat.Status())
+	}
+	if execute.Status().Code != codes.Error || attr(execute.Attributes(), AttrErrorType).AsString() != errorTypeOther {
+		t.Errorf("Expected a failed tool span, got %v %v", execute.Status(), execute.Attributes())
+	}
+	if traceID != execute.SpanContext().TraceID().String() || spanID != execute.SpanContext().SpanID().String() {
+		t.Errorf("SpanIDs returned %s/%s, want the tool span", traceID, spanID)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := metrics.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	counts := map[string]uint64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch hist := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, point := range hist.DataPoints {
+					counts[m.Name] += point.Count
+				}
+			case metricdata.Histogram[int64]:
+				for _, point := range hist.DataPoints {
+					counts[m.Name] += point.Count
+				}
+			}
+		}
+	}
+	for name, want := range map[string]uint64{
+		"gen_ai.client.operation.duration":            1,
+		"gen_ai.client.operation.time_to_first_chunk": 1,
+		"gen_ai.client.token.usage":                   2,
+		"godantic.tool.duration":                      1,
+		"godantic.turn.duration":                      1,
+	} {
+		if counts[name] != want {
+			t.Errorf("Expected %d %s points, got %d", want, name, counts[name])
+		}
+	}
+}
+
+func TestPromptCacheMetrics(t *testing.T) {
+	spans, metrics := installTestProviders(t)
+
+	_, hit := StartModelCall(context.Background(), "anthropic", "claude-sonnet-4", false)
+	hit.End(ModelResult{InputTokens: 1200, OutputTokens: 10, CacheReadTokens: 1000, CacheWriteTokens: 150})
+	_, write := StartModelCall(context.Background(), "anthropic", "claude-sonnet-4", false)
+	write.End(ModelResult{InputTokens: 1200, OutputTokens: 10, CacheWriteTokens: 1100})
+	_, uncached := StartModelCall(context.Background(), "gemini", "gemini-2.0-flash", false)
+	uncached.End(ModelResult{InputTokens: 50, OutputTokens: 10})
+
+	if got := attr(spans.Ended()[0].Attributes(), AttrCacheRead).AsInt64(); got != 1000 {
+		t.Errorf("Expected 1000 cache read tokens on the span, got %d", got)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := metrics.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	sums := map[string]int64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, point := range sum.DataPoints {
+					for _, key := range []string{AttrCacheResult, AttrTokenType} {
+						if value, ok := point.Attributes.Value(attribute.Key(key)); ok {
+							sums[m.Name+"/"+value.AsString()] += point.Value
+						}
+					}
+				}
+			}
+		}
+	}
+	for name, want := range map[string]int64{
+		"godantic.prompt_cache.requests/hit":          1,
+		"godantic.prompt_cache.requests/write":        1,
+		"godantic.prompt_cache.tokens/cache_read":     1000,
+		"godantic.prompt_cache.tokens/cache_creation": 1250,
+	} {
+		if sums[name] != want {
+			t.Errorf("Expected %s = %d, got %d", name, want, sums[name])
+		}
+	}
+}
+
+func TestSpanIDsWithoutSpan(t *testing.T) {
+	if traceID, spanID := SpanIDs(context.Background()); traceID != "" || spanID != "" {
+		t.Errorf("Expected no IDs, got %q/%q", traceID, spanID)
+	}
+}
+
+func TestSetupWritesJSON(t *testing.T) {
+	previousTracer, previousMeter := otel.GetTracerProvider(), otel.GetMeterProvider()
+	defer func() {
+		otel.SetTracerProvider(previousTracer)
+		otel.SetMeterProvider(previousMeter)
+	}()
+
+	var out bytes.Buffer
+	shutdown, err := Setup("godantic-test", &out)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	_, tool := StartToolCall(context.Background(), "Echo", "")
+	tool.End(nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	written := out.String()
+	for _, want := range []string{`"Name":"execute_tool Echo"`, `"Value":"godantic-test"`, `"Name":"godantic.tool.duration"`} {
+		if !strings.Contains(written, want) {
+			t.Errorf("Expected %s in the exported JSON:\n%s", want, written)
+		}
+	}
+}
@@ -1,6 +1,6 @@
 package godantic
 
-import "log"
+import "github.com/Desarso/godantic/logging"
 
 // List of tools that don't require explicit user approval
 var autoApprovedTools = map[string]bool{
@@ -14,7 +14,7 @@ func Tool_Approver(tool_name string, tool_args map[string]interface{}) (bool, er
 
 	// TODO: Implement actual approval logic, potentially involving WebSocket communication.
 	// For now, auto-approve all tools for testing/development.
-	log.Printf("Auto-approving tool: %s", tool_name)
+	logging.Default().Debug("Auto-approving tool", logging.KeyTool, tool_name)
 	return true, nil
 
 	// // Check if the tool_name is in the list of automatically approved tools